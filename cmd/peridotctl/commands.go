@@ -0,0 +1,957 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/internal/controllerrpc"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// subcommands maps the names of a command's subcommands to their functions.
+type subcommands map[string]func(cl *client, args []string) error
+
+// dispatch runs the subcommand named by the first argument.
+func dispatch(cl *client, args []string, subs subcommands, names string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing subcommand; must be one of %s", names)
+	}
+	sub, ok := subs[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown subcommand %q; must be one of %s", args[0], names)
+	}
+	return sub(cl, args[1:])
+}
+
+// parseArgs parses flags for a subcommand, allowing them to appear before,
+// after or in between its positional arguments. It returns the positional
+// arguments, and an error if there are not exactly nArgs of them (or at
+// least nArgs, if variadic is true).
+func parseArgs(fs *flag.FlagSet, args []string, nArgs int, variadic bool) ([]string, error) {
+	positional := []string{}
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) < nArgs || (!variadic && len(positional) > nArgs) {
+		return nil, fmt.Errorf("expected %d argument(s), got %d", nArgs, len(positional))
+	}
+	return positional, nil
+}
+
+// parseID parses a Job or JobSet ID.
+func parseID(s string) (uint64, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid ID %q; must be a positive integer", s)
+	}
+	return id, nil
+}
+
+// kvList is a repeatable flag collecting key=value pairs.
+type kvList []*pbc.AgentConfig_AgentKV
+
+func (kvs *kvList) String() string {
+	strs := []string{}
+	for _, kv := range *kvs {
+		strs = append(strs, kv.Key+"="+kv.Value)
+	}
+	return strings.Join(strs, ",")
+}
+
+func (kvs *kvList) Set(s string) error {
+	key, value, err := splitKV(s)
+	if err != nil {
+		return err
+	}
+	*kvs = append(*kvs, &pbc.AgentConfig_AgentKV{Key: key, Value: value})
+	return nil
+}
+
+// splitKV splits a key=value argument.
+func splitKV(s string) (string, string, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid configuration %q; must be key=value", s)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// loadConfigFile reads a YAML file in the controller configuration file
+// format, for adding the agents or templates that it defines.
+func loadConfigFile(path string) (*controller.Config, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no file given; use -f")
+	}
+	return controller.LoadConfigFile(path)
+}
+
+// ===== Controller startup and status =====
+
+func runStart(cl *client, args []string) error {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.Start(ctx, &pbc.StartReq{})
+	if err != nil {
+		return err
+	}
+	if !resp.Starting {
+		return fmt.Errorf("controller not starting: %s", resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Println("controller starting")
+	return nil
+}
+
+func runStop(cl *client, args []string) error {
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	drain := fs.Bool("drain", false, "let active Jobs finish before stopping")
+	drainTimeout := fs.Int64("drain-timeout", 60, "if draining, seconds to wait before cancelling remaining Jobs")
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	// draining can take up to the drain timeout, so allow for that on
+	// top of the usual request timeout
+	timeout := cl.timeout
+	if *drain {
+		timeout += time.Duration(*drainTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := cl.c.Stop(ctx, &pbc.StopReq{Drain: *drain, DrainTimeoutSeconds: *drainTimeout})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Println("controller stopped")
+	if len(resp.InterruptedJobIDs) > 0 {
+		fmt.Printf("interrupted jobs: %s\n", formatIDs(resp.InterruptedJobIDs))
+	}
+	if len(resp.InterruptedJobSetIDs) > 0 {
+		fmt.Printf("interrupted jobsets: %s\n", formatIDs(resp.InterruptedJobSetIDs))
+	}
+	return nil
+}
+
+func runStatus(cl *client, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetStatus(ctx, &pbc.GetStatusReq{})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printStatus(resp)
+	return nil
+}
+
+// ===== Agents =====
+
+func runAgent(cl *client, args []string) error {
+	return dispatch(cl, args, subcommands{
+		"add":    runAgentAdd,
+		"update": runAgentUpdate,
+		"remove": runAgentRemove,
+		"get":    runAgentGet,
+		"list":   runAgentList,
+	}, "add, update, remove, get, list")
+}
+
+// parseAgentConfigs returns the agent configurations for the add and
+// update subcommands, either from a YAML file or from flags.
+func parseAgentConfigs(name string, args []string) ([]*pbc.AgentConfig, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("f", "", "YAML file with agents, in the controller configuration file format")
+	agentName := fs.String("name", "", "agent name")
+	url := fs.String("url", "", "agent URL")
+	port := fs.Uint("port", 0, "agent port")
+	agentType := fs.String("type", "", "agent type")
+	maxJobs := fs.Uint("max-jobs", 0, "maximum number of Jobs the agent may run at once; 0 means no limit")
+	jobTimeout := fs.Duration("job-timeout", 0, "default timeout for the agent's Jobs, e.g. 30m; 0 means no timeout")
+	resourceClass := fs.String("resource-class", "", "resource class of the agent's Jobs, which must be configured on the Controller")
+	kvs := kvList{}
+	fs.Var(&kvs, "kv", "agent-specific key=value pair; may be repeated")
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if *agentName != "" {
+			return nil, fmt.Errorf("can't use both -f and -name")
+		}
+		cfg, err := loadConfigFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		if len(cfg.Agents) == 0 {
+			return nil, fmt.Errorf("no agents defined in %s", *path)
+		}
+		return cfg.Agents, nil
+	}
+
+	if *agentName == "" {
+		return nil, fmt.Errorf("must give either -f or -name")
+	}
+	if *jobTimeout < 0 {
+		return nil, fmt.Errorf("-job-timeout can't be negative")
+	}
+	return []*pbc.AgentConfig{{
+		Name:              *agentName,
+		Url:               *url,
+		Port:              uint32(*port),
+		Type:              *agentType,
+		Kvs:               kvs,
+		MaxConcurrentJobs: uint32(*maxJobs),
+		JobTimeoutMillis:  int64(*jobTimeout / time.Millisecond),
+		ResourceClass:     *resourceClass,
+	}}, nil
+}
+
+func runAgentAdd(cl *client, args []string) error {
+	cfgs, err := parseAgentConfigs("agent add", args)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range cfgs {
+		ctx, cancel := cl.ctx()
+		resp, err := cl.c.AddAgent(ctx, &pbc.AddAgentReq{Cfg: cfg})
+		cancel()
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("couldn't add agent %s: %s", cfg.Name, resp.ErrorMsg)
+		}
+		if cl.json {
+			if err := printJSON(resp); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("added agent %s\n", cfg.Name)
+	}
+	return nil
+}
+
+func runAgentUpdate(cl *client, args []string) error {
+	cfgs, err := parseAgentConfigs("agent update", args)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range cfgs {
+		ctx, cancel := cl.ctx()
+		resp, err := cl.c.UpdateAgent(ctx, &pbc.UpdateAgentReq{Cfg: cfg})
+		cancel()
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("couldn't update agent %s: %s", cfg.Name, resp.ErrorMsg)
+		}
+		if cl.json {
+			if err := printJSON(resp); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("updated agent %s\n", cfg.Name)
+	}
+	return nil
+}
+
+func runAgentRemove(cl *client, args []string) error {
+	fs := flag.NewFlagSet("agent remove", flag.ContinueOnError)
+	force := fs.Bool("force", false, "remove the agent even if it has active Jobs")
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.RemoveAgent(ctx, &pbc.RemoveAgentReq{Name: pos[0], Force: *force})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't remove agent %s: %s", pos[0], resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("removed agent %s\n", pos[0])
+	return nil
+}
+
+func runAgentGet(cl *client, args []string) error {
+	fs := flag.NewFlagSet("agent get", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetAgent(ctx, &pbc.GetAgentReq{Name: pos[0]})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printAgents([]*pbc.AgentConfig{resp.Cfg})
+	return nil
+}
+
+func runAgentList(cl *client, args []string) error {
+	fs := flag.NewFlagSet("agent list", flag.ContinueOnError)
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetAllAgents(ctx, &pbc.GetAllAgentsReq{})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printAgents(resp.Cfgs)
+	return nil
+}
+
+// ===== JobSetTemplates =====
+
+func runTemplate(cl *client, args []string) error {
+	return dispatch(cl, args, subcommands{
+		"add":  runTemplateAdd,
+		"get":  runTemplateGet,
+		"list": runTemplateList,
+	}, "add, get, list")
+}
+
+func runTemplateAdd(cl *client, args []string) error {
+	fs := flag.NewFlagSet("template add", flag.ContinueOnError)
+	path := fs.String("f", "", "YAML file with templates, in the controller configuration file format")
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigFile(*path)
+	if err != nil {
+		return err
+	}
+	if len(cfg.JobSetTemplates) == 0 {
+		return fmt.Errorf("no templates defined in %s", *path)
+	}
+
+	// templates are added in the order they appear in the file, so a
+	// template should come after any others that it refers to
+	for _, jst := range cfg.JobSetTemplates {
+		req := &pbc.AddJobSetTemplateReq{Jst: &pbc.JobSetTemplate{
+			Name:  jst.Name,
+			Steps: controllerrpc.CreateProtoStepsFromStepTemplate(jst.Steps),
+		}}
+		ctx, cancel := cl.ctx()
+		resp, err := cl.c.AddJobSetTemplate(ctx, req)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if !resp.Success {
+			return fmt.Errorf("couldn't add template %s: %s", jst.Name, resp.ErrorMsg)
+		}
+		if cl.json {
+			if err := printJSON(resp); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("added template %s\n", jst.Name)
+	}
+	return nil
+}
+
+func runTemplateGet(cl *client, args []string) error {
+	fs := flag.NewFlagSet("template get", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetJobSetTemplate(ctx, &pbc.GetJobSetTemplateReq{Name: pos[0]})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printTemplate(resp.Jst)
+	return nil
+}
+
+func runTemplateList(cl *client, args []string) error {
+	fs := flag.NewFlagSet("template list", flag.ContinueOnError)
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetAllJobSetTemplates(ctx, &pbc.GetAllJobSetTemplatesReq{})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printTemplates(resp.Jsts)
+	return nil
+}
+
+// ===== JobSets =====
+
+func runJobSet(cl *client, args []string) error {
+	return dispatch(cl, args, subcommands{
+		"start":    runJobSetStart,
+		"get":      runJobSetGet,
+		"list":     runJobSetList,
+		"cancel":   runJobSetCancel,
+		"priority": runJobSetPriority,
+	}, "start, get, list, cancel, priority")
+}
+
+func runJobSetStart(cl *client, args []string) error {
+	fs := flag.NewFlagSet("jobset start", flag.ContinueOnError)
+	wait := fs.Bool("wait", false, "wait for the JobSet to stop, then show its details")
+	pollInterval := fs.Duration("poll-interval", 2*time.Second, "how often to check the JobSet's status when waiting")
+	priority := fs.Int("priority", 0, "scheduling priority; steps from higher-priority JobSets start first")
+	tenant := fs.String("tenant", "", "tenant that owns the JobSet, for sharing Job slots fairly")
+	pos, err := parseArgs(fs, args, 1, true)
+	if err != nil {
+		return err
+	}
+
+	req := &pbc.StartJobSetReq{JstName: pos[0], Priority: int32(*priority), Tenant: *tenant}
+	for _, arg := range pos[1:] {
+		key, value, err := splitKV(arg)
+		if err != nil {
+			return err
+		}
+		req.Cfgs = append(req.Cfgs, &pbc.JobSetConfig{Key: key, Value: value})
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.StartJobSet(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't start jobset: %s", resp.ErrorMsg)
+	}
+	if *wait {
+		return waitForJobSet(cl, resp.JobSetID, *pollInterval)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("started jobset %d\n", resp.JobSetID)
+	return nil
+}
+
+func runJobSetGet(cl *client, args []string) error {
+	fs := flag.NewFlagSet("jobset get", flag.ContinueOnError)
+	wait := fs.Bool("wait", false, "wait for the JobSet to stop, then show its details")
+	pollInterval := fs.Duration("poll-interval", 2*time.Second, "how often to check the JobSet's status when waiting")
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+	jobSetID, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	if *wait {
+		return waitForJobSet(cl, jobSetID, *pollInterval)
+	}
+
+	resp, err := getJobSet(cl, jobSetID)
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printJobSet(resp.JobSet)
+	return nil
+}
+
+// getJobSet requests the details for one JobSet.
+func getJobSet(cl *client, jobSetID uint64) (*pbc.GetJobSetResp, error) {
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetJobSet(ctx, &pbc.GetJobSetReq{JobSetID: jobSetID})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.ErrorMsg)
+	}
+	return resp, nil
+}
+
+// waitForJobSet polls GetJobSet until the JobSet has stopped, then prints
+// its details. It returns an error if the JobSet stopped with an error or
+// was cancelled.
+func waitForJobSet(cl *client, jobSetID uint64, pollInterval time.Duration) error {
+	for {
+		resp, err := getJobSet(cl, jobSetID)
+		if err != nil {
+			return err
+		}
+
+		st := resp.JobSet.St
+		if st.RunStatus == pbs.Status_STOPPED {
+			if cl.json {
+				err = printJSON(resp)
+			} else {
+				printJobSet(resp.JobSet)
+			}
+			if err != nil {
+				return err
+			}
+			if st.Cancelled {
+				return fmt.Errorf("jobset %d was cancelled", jobSetID)
+			}
+			if st.HealthStatus == pbs.Health_ERROR {
+				return fmt.Errorf("jobset %d stopped with errors", jobSetID)
+			}
+			return nil
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+func runJobSetList(cl *client, args []string) error {
+	fs := flag.NewFlagSet("jobset list", flag.ContinueOnError)
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetAllJobSets(ctx, &pbc.GetAllJobSetsReq{})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printJobSets(resp.JobSets)
+	return nil
+}
+
+func runJobSetCancel(cl *client, args []string) error {
+	fs := flag.NewFlagSet("jobset cancel", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+	jobSetID, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.CancelJobSet(ctx, &pbc.CancelJobSetReq{JobSetID: jobSetID})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't cancel jobset: %s", resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("cancelled jobset %d\n", jobSetID)
+	return nil
+}
+
+func runJobSetPriority(cl *client, args []string) error {
+	fs := flag.NewFlagSet("jobset priority", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, 2, false)
+	if err != nil {
+		return err
+	}
+	jobSetID, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+	priority, err := strconv.ParseInt(pos[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid priority %q", pos[1])
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.SetJobSetPriority(ctx, &pbc.SetJobSetPriorityReq{JobSetID: jobSetID, Priority: int32(priority)})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't set jobset priority: %s", resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("set priority of jobset %d to %d\n", jobSetID, priority)
+	return nil
+}
+
+// ===== Jobs =====
+
+func runJob(cl *client, args []string) error {
+	return dispatch(cl, args, subcommands{
+		"get":    runJobGet,
+		"list":   runJobList,
+		"cancel": runJobCancel,
+	}, "get, list, cancel")
+}
+
+func runJobGet(cl *client, args []string) error {
+	fs := flag.NewFlagSet("job get", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+	jobID, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetJob(ctx, &pbc.GetJobReq{JobID: jobID})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printJob(resp.Job)
+	return nil
+}
+
+func runJobList(cl *client, args []string) error {
+	fs := flag.NewFlagSet("job list", flag.ContinueOnError)
+	jobSetIDStr := fs.String("jobset", "", "only list Jobs in the JobSet with this ID")
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+
+	if *jobSetIDStr != "" {
+		jobSetID, err := parseID(*jobSetIDStr)
+		if err != nil {
+			return err
+		}
+		resp, err := cl.c.GetAllJobsForJobSet(ctx, &pbc.GetAllJobsForJobSetReq{JobSetID: jobSetID})
+		if err != nil {
+			return err
+		}
+		if cl.json {
+			return printJSON(resp)
+		}
+		printJobs(resp.Jobs)
+		return nil
+	}
+
+	resp, err := cl.c.GetAllJobs(ctx, &pbc.GetAllJobsReq{})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printJobs(resp.Jobs)
+	return nil
+}
+
+func runJobCancel(cl *client, args []string) error {
+	fs := flag.NewFlagSet("job cancel", flag.ContinueOnError)
+	skip := fs.Bool("skip", false, "treat the Job's step as skipped, so that later steps proceed, rather than as failed")
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+	jobID, err := parseID(pos[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.CancelJob(ctx, &pbc.CancelJobReq{JobID: jobID, SkipStep: *skip})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't cancel job: %s", resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("cancelled job %d\n", jobID)
+	return nil
+}
+
+// ===== Tenants =====
+
+func runTenant(cl *client, args []string) error {
+	return dispatch(cl, args, subcommands{
+		"set":  runTenantSet,
+		"list": runTenantList,
+	}, "set, list")
+}
+
+func runTenantSet(cl *client, args []string) error {
+	fs := flag.NewFlagSet("tenant set", flag.ContinueOnError)
+	weight := fs.Uint("weight", 1, "relative share of the running Job slots")
+	minJobs := fs.Uint("min-jobs", 0, "number of running Jobs guaranteed to the tenant")
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	cfg := &pbc.TenantConfig{Name: pos[0], Weight: uint32(*weight), MinJobs: uint32(*minJobs)}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.SetTenant(ctx, &pbc.SetTenantReq{Cfg: cfg})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't set tenant: %s", resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("set tenant %s\n", cfg.Name)
+	return nil
+}
+
+func runTenantList(cl *client, args []string) error {
+	fs := flag.NewFlagSet("tenant list", flag.ContinueOnError)
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetAllTenants(ctx, &pbc.GetAllTenantsReq{})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printTenants(resp.Tenants)
+	return nil
+}
+
+func runSchedule(cl *client, args []string) error {
+	return dispatch(cl, args, subcommands{
+		"add":    runScheduleAdd,
+		"list":   runScheduleList,
+		"pause":  runSchedulePause,
+		"resume": runScheduleResume,
+		"delete": runScheduleDelete,
+	}, "add, list, pause, resume, delete")
+}
+
+// overlapPolicies maps the -overlap flag's values to overlap policies.
+var overlapPolicies = map[string]pbc.OverlapPolicy{
+	"skip":  pbc.OverlapPolicy_OVERLAP_SKIP,
+	"queue": pbc.OverlapPolicy_OVERLAP_QUEUE,
+	"allow": pbc.OverlapPolicy_OVERLAP_ALLOW,
+}
+
+func runScheduleAdd(cl *client, args []string) error {
+	fs := flag.NewFlagSet("schedule add", flag.ContinueOnError)
+	priority := fs.Int("priority", 0, "scheduling priority of each JobSet started")
+	tenant := fs.String("tenant", "", "tenant that owns each JobSet started")
+	overlap := fs.String("overlap", "skip", "what to do if the last run is still going: skip, queue or allow")
+	pos, err := parseArgs(fs, args, 3, true)
+	if err != nil {
+		return err
+	}
+	policy, ok := overlapPolicies[*overlap]
+	if !ok {
+		return fmt.Errorf("invalid overlap policy %q; must be skip, queue or allow", *overlap)
+	}
+
+	cfg := &pbc.ScheduleConfig{
+		Name:     pos[0],
+		Cron:     pos[1],
+		JstName:  pos[2],
+		Priority: int32(*priority),
+		Tenant:   *tenant,
+		Overlap:  policy,
+	}
+	for _, arg := range pos[3:] {
+		key, value, err := splitKV(arg)
+		if err != nil {
+			return err
+		}
+		cfg.Cfgs = append(cfg.Cfgs, &pbc.JobSetConfig{Key: key, Value: value})
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.AddSchedule(ctx, &pbc.AddScheduleReq{Cfg: cfg})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't add schedule: %s", resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("added schedule %s\n", cfg.Name)
+	return nil
+}
+
+func runScheduleList(cl *client, args []string) error {
+	fs := flag.NewFlagSet("schedule list", flag.ContinueOnError)
+	if _, err := parseArgs(fs, args, 0, false); err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.GetAllSchedules(ctx, &pbc.GetAllSchedulesReq{})
+	if err != nil {
+		return err
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	printSchedules(resp.Schedules)
+	return nil
+}
+
+func runSchedulePause(cl *client, args []string) error {
+	return setSchedulePaused(cl, "schedule pause", args, true)
+}
+
+func runScheduleResume(cl *client, args []string) error {
+	return setSchedulePaused(cl, "schedule resume", args, false)
+}
+
+// setSchedulePaused pauses or resumes the schedule named in args.
+func setSchedulePaused(cl *client, name string, args []string, paused bool) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.PauseSchedule(ctx, &pbc.PauseScheduleReq{Name: pos[0], Paused: paused})
+	if err != nil {
+		return err
+	}
+	verb := "resume"
+	if paused {
+		verb = "pause"
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't %s schedule: %s", verb, resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("%sd schedule %s\n", verb, pos[0])
+	return nil
+}
+
+func runScheduleDelete(cl *client, args []string) error {
+	fs := flag.NewFlagSet("schedule delete", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := cl.ctx()
+	defer cancel()
+	resp, err := cl.c.DeleteSchedule(ctx, &pbc.DeleteScheduleReq{Name: pos[0]})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("couldn't delete schedule: %s", resp.ErrorMsg)
+	}
+	if cl.json {
+		return printJSON(resp)
+	}
+	fmt.Printf("deleted schedule %s\n", pos[0])
+	return nil
+}
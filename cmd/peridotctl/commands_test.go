@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		nArgs      int
+		variadic   bool
+		positional []string
+		skip       bool
+		kvs        string
+		err        string
+	}{
+		{name: "positionals only", args: []string{"a", "b"}, nArgs: 2, positional: []string{"a", "b"}},
+		{name: "flags first", args: []string{"-skip", "-kv", "k=v", "a"}, nArgs: 1, positional: []string{"a"}, skip: true, kvs: "k=v"},
+		{name: "flags last", args: []string{"a", "-skip"}, nArgs: 1, positional: []string{"a"}, skip: true},
+		{name: "flags in between", args: []string{"a", "-kv", "k=v", "b", "-kv", "x=y", "c"}, nArgs: 1, variadic: true, positional: []string{"a", "b", "c"}, kvs: "k=v,x=y"},
+		{name: "too few", args: []string{"-skip"}, nArgs: 1, err: "expected 1 argument(s), got 0"},
+		{name: "too many", args: []string{"a", "b"}, nArgs: 1, err: "expected 1 argument(s), got 2"},
+		{name: "unknown flag", args: []string{"a", "-bogus"}, nArgs: 1, err: "flag provided but not defined: -bogus"},
+		{name: "invalid kv", args: []string{"-kv", "=v", "a"}, nArgs: 1, err: `invalid configuration "=v"`},
+	}
+	for _, tc := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		skip := fs.Bool("skip", false, "")
+		kvs := kvList{}
+		fs.Var(&kvs, "kv", "")
+
+		positional, err := parseArgs(fs, tc.args, tc.nArgs, tc.variadic)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(positional, tc.positional) || *skip != tc.skip || kvs.String() != tc.kvs {
+			t.Errorf("%s: expected %v, skip %v and kvs %q, got %v, %v and %q", tc.name, tc.positional, tc.skip, tc.kvs, positional, *skip, kvs.String())
+		}
+	}
+}
+
+func TestSplitKV(t *testing.T) {
+	tests := []struct {
+		s     string
+		key   string
+		value string
+		ok    bool
+	}{
+		{"k=v", "k", "v", true},
+		{"k=", "k", "", true},
+		{"k=a=b", "k", "a=b", true},
+		{"=v", "", "", false},
+		{"k", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tc := range tests {
+		key, value, err := splitKV(tc.s)
+		if (err == nil) != tc.ok || key != tc.key || value != tc.value {
+			t.Errorf("%q: expected %q, %q and ok %v, got %q, %q and %v", tc.s, tc.key, tc.value, tc.ok, key, value, err)
+		}
+	}
+}
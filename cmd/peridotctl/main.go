@@ -0,0 +1,115 @@
+// Command peridotctl is a command-line client for the peridot Controller's
+// gRPC service. It covers each of the Controller's RPCs, and can print
+// results either as tables or as JSON.
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	"google.golang.org/grpc"
+)
+
+// defaultAddress is the Controller address used if none is configured.
+const defaultAddress = "localhost:8900"
+
+// command is one peridotctl subcommand. run is called with the remaining
+// command-line arguments after the subcommand's name.
+type command struct {
+	name  string
+	usage string
+	run   func(cl *client, args []string) error
+}
+
+// commands lists each subcommand, in the order shown in the usage message.
+var commands = []*command{
+	{"start", "start the Controller", runStart},
+	{"stop", "stop the Controller [-drain] [-drain-timeout SECONDS]", runStop},
+	{"status", "show the Controller's status", runStatus},
+	{"agent", "manage Agents: add, update, remove, get, list", runAgent},
+	{"template", "manage JobSetTemplates: add, get, list", runTemplate},
+	{"jobset", "manage JobSets: start, get, list, cancel, priority", runJobSet},
+	{"job", "manage Jobs: get, list, cancel", runJob},
+	{"tenant", "manage tenants: set, list", runTenant},
+	{"schedule", "manage schedules: add, list, pause, resume, delete", runSchedule},
+}
+
+// client holds the connection to the Controller and the output settings
+// that apply to every subcommand.
+type client struct {
+	c       pbc.ControllerClient
+	timeout time.Duration
+	json    bool
+}
+
+// ctx returns a context for a single RPC call.
+func (cl *client) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), cl.timeout)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: peridotctl [flags] <command> [args]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	address := defaultAddress
+	if v, ok := os.LookupEnv("PERIDOT_ADDRESS"); ok {
+		address = v
+	}
+	flag.StringVar(&address, "addr", address, "address of the Controller (env PERIDOT_ADDRESS)")
+	output := flag.String("o", "table", "output format: table or json")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout for each request to the Controller")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "peridotctl: invalid output format %q; must be table or json\n", *output)
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == flag.Arg(0) {
+			cmd = c
+			break
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "peridotctl: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "peridotctl: couldn't connect to controller at %s: %v\n", address, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	cl := &client{
+		c:       pbc.NewControllerClient(conn),
+		timeout: *timeout,
+		json:    *output == "json",
+	}
+	err = cmd.run(cl, flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "peridotctl %s: %v\n", cmd.name, err)
+		conn.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,440 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// printJSON prints a response message as JSON, using the field names
+// from the .proto definitions.
+func printJSON(msg proto.Message) error {
+	m := jsonpb.Marshaler{OrigName: true, EmitDefaults: true, Indent: "  "}
+	s, err := m.MarshalToString(msg)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal response to JSON: %v", err)
+	}
+	fmt.Println(s)
+	return nil
+}
+
+// newTable returns a tabwriter for printing aligned columns to stdout.
+// The caller must call Flush when done.
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+}
+
+// formatTime formats a Unix time, or returns "-" if it is unset.
+func formatTime(t int64) string {
+	if t <= 0 {
+		return "-"
+	}
+	return time.Unix(t, 0).Format("2006-01-02 15:04:05")
+}
+
+// formatTimeout formats a timeout in milliseconds, or returns "-" if it
+// is unset.
+func formatTimeout(millis int64) string {
+	if millis <= 0 {
+		return "-"
+	}
+	return (time.Duration(millis) * time.Millisecond).String()
+}
+
+// formatIDs formats a list of IDs as a comma-separated string.
+func formatIDs(ids []uint64) string {
+	strs := []string{}
+	for _, id := range ids {
+		strs = append(strs, fmt.Sprintf("%d", id))
+	}
+	return strings.Join(strs, ", ")
+}
+
+// printMessages prints any output and error messages, indented.
+func printMessages(outputMsgs string, errorMsgs string) {
+	if outputMsgs != "" {
+		fmt.Printf("output:\n  %s\n", strings.ReplaceAll(strings.TrimSpace(outputMsgs), "\n", "\n  "))
+	}
+	if errorMsgs != "" {
+		fmt.Printf("errors:\n  %s\n", strings.ReplaceAll(strings.TrimSpace(errorMsgs), "\n", "\n  "))
+	}
+}
+
+func printStatus(resp *pbc.GetStatusResp) {
+	tw := newTable()
+	fmt.Fprintf(tw, "run status:\t%s\n", resp.RunStatus)
+	fmt.Fprintf(tw, "health:\t%s\n", resp.HealthStatus)
+	tw.Flush()
+	printMessages(resp.OutputMsg, resp.ErrorMsg)
+}
+
+func printAgents(cfgs []*pbc.AgentConfig) {
+	sort.Slice(cfgs, func(i, j int) bool { return cfgs[i].Name < cfgs[j].Name })
+
+	tw := newTable()
+	fmt.Fprintf(tw, "NAME\tURL\tPORT\tTYPE\tMAX JOBS\tJOB TIMEOUT\tCLASS\tKVS\n")
+	for _, cfg := range cfgs {
+		kvs := []string{}
+		for _, kv := range cfg.Kvs {
+			kvs = append(kvs, kv.Key+"="+kv.Value)
+		}
+		maxJobs := "-"
+		if cfg.MaxConcurrentJobs > 0 {
+			maxJobs = fmt.Sprintf("%d", cfg.MaxConcurrentJobs)
+		}
+		class := "-"
+		if cfg.ResourceClass != "" {
+			class = cfg.ResourceClass
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", cfg.Name, cfg.Url, cfg.Port, cfg.Type, maxJobs,
+			formatTimeout(cfg.JobTimeoutMillis), class, strings.Join(kvs, ","))
+	}
+	tw.Flush()
+}
+
+// formatStepTemplates summarizes template steps on a single line.
+func formatStepTemplates(steps []*pbc.StepTemplate) string {
+	strs := []string{}
+	for _, step := range steps {
+		switch x := step.S.(type) {
+		case *pbc.StepTemplate_Agent:
+			str := "agent:" + x.Agent.Name
+			if x.Agent.AgentType != "" {
+				str = "agentType:" + x.Agent.AgentType
+			}
+			if x.Agent.Retry != nil {
+				str += fmt.Sprintf("(retry %d)", x.Agent.Retry.MaxAttempts)
+			}
+			strs = append(strs, str)
+		case *pbc.StepTemplate_Jobset:
+			strs = append(strs, "jobset:"+x.Jobset.Name)
+		case *pbc.StepTemplate_Concurrent:
+			strs = append(strs, "concurrent["+formatStepTemplates(x.Concurrent.Steps)+"]")
+		case *pbc.StepTemplate_Foreach:
+			strs = append(strs, "foreach:"+x.Foreach.ConfigKey+":jobset:"+x.Foreach.Name)
+		}
+		if step.Condition != nil {
+			strs[len(strs)-1] += "(when " + formatCondition(step.Condition) + ")"
+		}
+		if step.AllowFailure {
+			strs[len(strs)-1] += "(allow failure)"
+		}
+		if step.Name != "" {
+			strs[len(strs)-1] = step.Name + "=" + strs[len(strs)-1]
+		}
+		if len(step.DependsOn) > 0 {
+			strs[len(strs)-1] += "(after " + strings.Join(step.DependsOn, ",") + ")"
+		}
+	}
+	return strings.Join(strs, ", ")
+}
+
+// formatCondition summarizes a step condition on a single line.
+func formatCondition(cond *pbc.StepCondition) string {
+	parts := []string{}
+	if cond.ConfigKey != "" {
+		if cond.ConfigValue != "" {
+			parts = append(parts, fmt.Sprintf("config %s=%s", cond.ConfigKey, cond.ConfigValue))
+		} else {
+			parts = append(parts, fmt.Sprintf("config %s set", cond.ConfigKey))
+		}
+	}
+	if cond.StepName != "" {
+		outcomes := []string{}
+		for _, o := range cond.StepOutcomes {
+			outcomes = append(outcomes, strings.ToLower(strings.TrimPrefix(o.String(), "STEP_")))
+		}
+		if len(outcomes) == 0 {
+			outcomes = []string{"ok", "degraded"}
+		}
+		parts = append(parts, fmt.Sprintf("step %s %s", cond.StepName, strings.Join(outcomes, "|")))
+	}
+	return strings.Join(parts, " and ")
+}
+
+func printTemplates(jsts []*pbc.JobSetTemplate) {
+	sort.Slice(jsts, func(i, j int) bool { return jsts[i].Name < jsts[j].Name })
+
+	tw := newTable()
+	fmt.Fprintf(tw, "NAME\tSTEPS\n")
+	for _, jst := range jsts {
+		fmt.Fprintf(tw, "%s\t%s\n", jst.Name, formatStepTemplates(jst.Steps))
+	}
+	tw.Flush()
+}
+
+func printTemplate(jst *pbc.JobSetTemplate) {
+	fmt.Printf("name: %s\nsteps:\n", jst.Name)
+	printStepTemplates(jst.Steps, "  ")
+}
+
+// printStepTemplates prints template steps as an indented tree.
+func printStepTemplates(steps []*pbc.StepTemplate, indent string) {
+	for _, step := range steps {
+		switch x := step.S.(type) {
+		case *pbc.StepTemplate_Agent:
+			if x.Agent.AgentType != "" {
+				fmt.Printf("%s- agentType: %s (%s)\n", indent, x.Agent.AgentType, x.Agent.Strategy)
+			} else {
+				fmt.Printf("%s- agent: %s\n", indent, x.Agent.Name)
+			}
+			if rp := x.Agent.Retry; rp != nil {
+				on := []string{}
+				if rp.RetryConnectionErrors {
+					on = append(on, "connection")
+				}
+				if rp.RetryAgentErrors {
+					on = append(on, "agent")
+				}
+				if rp.RetryTimeouts {
+					on = append(on, "timeout")
+				}
+				fmt.Printf("%s  retry: maxAttempts %d, backoff %v, on [%s]\n", indent, rp.MaxAttempts,
+					time.Duration(rp.BackoffMillis)*time.Millisecond, strings.Join(on, ", "))
+			}
+			if x.Agent.TimeoutMillis > 0 {
+				fmt.Printf("%s  timeout: %s\n", indent, formatTimeout(x.Agent.TimeoutMillis))
+			}
+			if x.Agent.ResourceClass != "" {
+				fmt.Printf("%s  resource class: %s\n", indent, x.Agent.ResourceClass)
+			}
+		case *pbc.StepTemplate_Jobset:
+			fmt.Printf("%s- jobset: %s\n", indent, x.Jobset.Name)
+		case *pbc.StepTemplate_Concurrent:
+			fmt.Printf("%s- concurrent:\n", indent)
+			printStepTemplateDeps(step, indent)
+			if step.Condition != nil {
+				fmt.Printf("%s  when: %s\n", indent, formatCondition(step.Condition))
+			}
+			printStepTemplates(x.Concurrent.Steps, indent+"    ")
+			continue
+		case *pbc.StepTemplate_Foreach:
+			fmt.Printf("%s- foreach: %s in config %s\n", indent, x.Foreach.ItemKey, x.Foreach.ConfigKey)
+			fmt.Printf("%s  jobset: %s\n", indent, x.Foreach.Name)
+			if x.Foreach.MaxParallel > 0 {
+				fmt.Printf("%s  max parallel: %d\n", indent, x.Foreach.MaxParallel)
+			}
+		}
+		printStepTemplateDeps(step, indent)
+		if step.Condition != nil {
+			fmt.Printf("%s  when: %s\n", indent, formatCondition(step.Condition))
+		}
+		if step.AllowFailure {
+			fmt.Printf("%s  allow failure: yes\n", indent)
+		}
+	}
+}
+
+// printStepTemplateDeps prints a template step's name and dependencies,
+// if it has any.
+func printStepTemplateDeps(step *pbc.StepTemplate, indent string) {
+	if step.Name != "" {
+		fmt.Printf("%s  name: %s\n", indent, step.Name)
+	}
+	if len(step.DependsOn) > 0 {
+		fmt.Printf("%s  depends on: %s\n", indent, strings.Join(step.DependsOn, ", "))
+	}
+}
+
+func printJobSets(jobSets []*pbc.JobSetDetails) {
+	sort.Slice(jobSets, func(i, j int) bool { return jobSets[i].JobSetID < jobSets[j].JobSetID })
+
+	tw := newTable()
+	fmt.Fprintf(tw, "ID\tTEMPLATE\tTENANT\tPRIORITY\tSTATUS\tHEALTH\tSTARTED\tFINISHED\n")
+	for _, js := range jobSets {
+		runStatus := js.St.RunStatus.String()
+		if js.St.Cancelled {
+			runStatus += " (cancelled)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", js.JobSetID, js.TemplateName, formatTenant(js.Tenant), js.Priority,
+			runStatus, js.St.HealthStatus, formatTime(js.St.TimeStarted), formatTime(js.St.TimeFinished))
+	}
+	tw.Flush()
+}
+
+func printJobSet(js *pbc.JobSetDetails) {
+	tw := newTable()
+	fmt.Fprintf(tw, "jobset:\t%d\n", js.JobSetID)
+	fmt.Fprintf(tw, "template:\t%s\n", js.TemplateName)
+	fmt.Fprintf(tw, "tenant:\t%s\n", formatTenant(js.Tenant))
+	fmt.Fprintf(tw, "priority:\t%d\n", js.Priority)
+	if js.Schedule != "" {
+		fmt.Fprintf(tw, "schedule:\t%s\n", js.Schedule)
+	}
+	fmt.Fprintf(tw, "run status:\t%s\n", js.St.RunStatus)
+	fmt.Fprintf(tw, "health:\t%s\n", js.St.HealthStatus)
+	if js.St.Cancelled {
+		fmt.Fprintf(tw, "cancelled:\tyes\n")
+	}
+	fmt.Fprintf(tw, "started:\t%s\n", formatTime(js.St.TimeStarted))
+	fmt.Fprintf(tw, "finished:\t%s\n", formatTime(js.St.TimeFinished))
+	tw.Flush()
+
+	fmt.Printf("steps:\n")
+	tw = newTable()
+	printSteps(tw, js.Steps, "  ")
+	tw.Flush()
+	printMessages(js.St.OutputMessages, js.St.ErrorMessages)
+}
+
+// printSteps prints JobSet steps as an indented tree, with each step's
+// status in aligned columns.
+func printSteps(tw *tabwriter.Writer, steps []*pbc.Step, indent string) {
+	for _, step := range steps {
+		// a failure that the JobSet carries on past is marked as such
+		health := step.HealthStatus.String()
+		if step.AllowFailure && step.HealthStatus == pbs.Health_ERROR {
+			health += " (allowed)"
+		}
+		// a named step shows its name, and a step in a DAG the steps it
+		// waits for
+		name, after := "", ""
+		if step.Name != "" {
+			name = step.Name + ": "
+		}
+		if len(step.DependsOn) > 0 {
+			after = " after " + formatIDs(step.DependsOn)
+		}
+		switch x := step.S.(type) {
+		case *pbc.Step_Agent:
+			agentName := x.Agent.AgentName
+			if x.Agent.AgentType != "" {
+				if agentName == "" {
+					agentName = "-"
+				}
+				agentName += " [type " + x.Agent.AgentType + "]"
+			}
+			attempts := ""
+			if x.Agent.Attempts > 1 {
+				attempts = fmt.Sprintf(", attempt %d", x.Agent.Attempts)
+			}
+			fmt.Fprintf(tw, "%s%d. %sagent %s (job %d%s)%s\t%s\t%s\n", indent, step.StepID, name, agentName, x.Agent.JobID, attempts, after, step.RunStatus, health)
+		case *pbc.Step_Jobset:
+			// a foreach step's children show their items
+			cfgs := ""
+			for _, cfg := range x.Jobset.Cfgs {
+				cfgs += fmt.Sprintf(" [%s=%s]", cfg.Key, cfg.Value)
+			}
+			fmt.Fprintf(tw, "%s%d. %sjobset %s%s (jobset %d)%s\t%s\t%s\n", indent, step.StepID, name, x.Jobset.TemplateName, cfgs, x.Jobset.JobSetID, after, step.RunStatus, health)
+		case *pbc.Step_Concurrent:
+			fmt.Fprintf(tw, "%s%d. %sconcurrent%s\t%s\t%s\n", indent, step.StepID, name, after, step.RunStatus, step.HealthStatus)
+			printSteps(tw, x.Concurrent.Steps, indent+"    ")
+		case *pbc.Step_Foreach:
+			fmt.Fprintf(tw, "%s%d. %sforeach %s in config %s%s\t%s\t%s\n", indent, step.StepID, name, x.Foreach.ItemKey, x.Foreach.ConfigKey, after, step.RunStatus, health)
+			printSteps(tw, x.Foreach.Steps, indent+"    ")
+		}
+		if step.WaitingReason != "" {
+			fmt.Fprintf(tw, "%s    %s\t\t\n", indent, step.WaitingReason)
+		}
+	}
+}
+
+func printJobs(jobs []*pbc.JobDetails) {
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].JobID < jobs[j].JobID })
+
+	tw := newTable()
+	fmt.Fprintf(tw, "ID\tJOBSET\tSTEP\tAGENT\tSTATUS\tHEALTH\tSTARTED\tFINISHED\n")
+	for _, jd := range jobs {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n", jd.JobID, jd.JobSetID, jd.JobSetStepID, jd.AgentName,
+			jd.St.RunStatus, jd.St.HealthStatus, formatTime(jd.St.TimeStarted), formatTime(jd.St.TimeFinished))
+	}
+	tw.Flush()
+}
+
+func printJob(jd *pbc.JobDetails) {
+	tw := newTable()
+	fmt.Fprintf(tw, "job:\t%d\n", jd.JobID)
+	fmt.Fprintf(tw, "jobset:\t%d\n", jd.JobSetID)
+	fmt.Fprintf(tw, "step:\t%d\n", jd.JobSetStepID)
+	fmt.Fprintf(tw, "attempt:\t%d\n", jd.Attempt)
+	fmt.Fprintf(tw, "agent:\t%s\n", jd.AgentName)
+	if jd.ResourceClass != "" {
+		fmt.Fprintf(tw, "resource class:\t%s\n", jd.ResourceClass)
+	}
+	fmt.Fprintf(tw, "run status:\t%s\n", jd.St.RunStatus)
+	fmt.Fprintf(tw, "health:\t%s\n", jd.St.HealthStatus)
+	if jd.TimeoutMillis > 0 {
+		fmt.Fprintf(tw, "timeout:\t%s\n", formatTimeout(jd.TimeoutMillis))
+	}
+	if jd.TimedOut {
+		fmt.Fprintf(tw, "timed out:\tyes\n")
+	}
+	if jd.ConnectionError {
+		fmt.Fprintf(tw, "connection error:\tyes\n")
+	}
+	if jd.Cancelled {
+		if jd.StepSkipped {
+			fmt.Fprintf(tw, "cancelled:\tyes (step skipped)\n")
+		} else {
+			fmt.Fprintf(tw, "cancelled:\tyes (step failed)\n")
+		}
+	}
+	fmt.Fprintf(tw, "started:\t%s\n", formatTime(jd.St.TimeStarted))
+	fmt.Fprintf(tw, "finished:\t%s\n", formatTime(jd.St.TimeFinished))
+	fmt.Fprintf(tw, "last seen:\t%s\n", formatTime(jd.LastSeen))
+	for _, kv := range jd.Cfg.GetJkvs() {
+		fmt.Fprintf(tw, "config %s:\t%s\n", kv.Key, kv.Value)
+	}
+	tw.Flush()
+	printMessages(jd.St.OutputMessages, jd.St.ErrorMessages)
+}
+
+func printTenants(tenants []*pbc.TenantDetails) {
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Cfg.GetName() < tenants[j].Cfg.GetName() })
+
+	tw := newTable()
+	fmt.Fprintf(tw, "NAME\tWEIGHT\tMIN JOBS\tRUNNING\tQUEUED\tJOBSETS\n")
+	for _, td := range tenants {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\n", formatTenant(td.Cfg.GetName()), td.Cfg.GetWeight(), td.Cfg.GetMinJobs(),
+			td.RunningJobs, td.QueuedSteps, td.ActiveJobSets)
+	}
+	tw.Flush()
+}
+
+// printSchedules prints a table of schedules.
+func printSchedules(schedules []*pbc.ScheduleDetails) {
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].Cfg.GetName() < schedules[j].Cfg.GetName() })
+
+	tw := newTable()
+	fmt.Fprintf(tw, "NAME\tCRON\tTEMPLATE\tTENANT\tOVERLAP\tSTATUS\tNEXT RUN\tLAST RUN\tACTIVE JOBSETS\n")
+	for _, sd := range schedules {
+		status := "active"
+		if sd.Paused {
+			status = "paused"
+		} else if sd.Queued {
+			status = "queued"
+		}
+		active := formatIDs(sd.ActiveJobSetIDs)
+		if active == "" {
+			active = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", sd.Cfg.GetName(), sd.Cfg.GetCron(), sd.Cfg.GetJstName(),
+			formatTenant(sd.Cfg.GetTenant()), formatOverlap(sd.Cfg.GetOverlap()), status,
+			formatTime(sd.NextRun), formatTime(sd.LastRun), active)
+	}
+	tw.Flush()
+}
+
+// formatOverlap formats an overlap policy as the value of the -overlap
+// flag that selects it.
+func formatOverlap(policy pbc.OverlapPolicy) string {
+	return strings.ToLower(strings.TrimPrefix(policy.String(), "OVERLAP_"))
+}
+
+// formatTenant formats a tenant name, showing the default tenant as "-".
+func formatTenant(tenant string) string {
+	if tenant == "" {
+		return "-"
+	}
+	return tenant
+}
@@ -0,0 +1,553 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// configFile is the YAML format for a controller configuration file.
+type configFile struct {
+	// controller settings
+	VolPrefix         string `yaml:"volPrefix"`
+	MaxJobsRunning    int    `yaml:"maxJobsRunning"`
+	StorePath         string `yaml:"storePath"`
+	HeartbeatInterval string `yaml:"heartbeatInterval"`
+	HeartbeatTimeout  string `yaml:"heartbeatTimeout"`
+
+	// agents to register at startup
+	Agents []*configFileAgent `yaml:"agents"`
+
+	// JobSetTemplates to register at startup
+	Templates []*configFileTemplate `yaml:"templates"`
+
+	// tenants to configure at startup
+	Tenants []*configFileTenant `yaml:"tenants"`
+
+	// maximum number of jobs of each resource class that can run at once
+	ResourceClasses map[string]int `yaml:"resourceClasses"`
+}
+
+// configFileAgent is the YAML format for an agent's configuration. Its
+// fields correspond to those of pbc.AgentConfig.
+type configFileAgent struct {
+	Name              string               `yaml:"name"`
+	URL               string               `yaml:"url"`
+	Port              uint32               `yaml:"port"`
+	Type              string               `yaml:"type"`
+	KVs               []*configFileAgentKV `yaml:"kvs"`
+	MaxConcurrentJobs uint32               `yaml:"maxConcurrentJobs"`
+	JobTimeout        string               `yaml:"jobTimeout"`
+	ResourceClass     string               `yaml:"resourceClass"`
+}
+
+// configFileAgentKV is the YAML format for an agent-specific key-value pair.
+type configFileAgentKV struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+// configFileTenant is the YAML format for a tenant's configuration. Its
+// fields correspond to those of pbc.TenantConfig.
+type configFileTenant struct {
+	Name    string `yaml:"name"`
+	Weight  uint32 `yaml:"weight"`
+	MinJobs uint32 `yaml:"minJobs"`
+}
+
+// configFileTemplate is the YAML format for a JobSetTemplate.
+type configFileTemplate struct {
+	Name  string            `yaml:"name"`
+	Steps []*configFileStep `yaml:"steps"`
+}
+
+// configFileStep is the YAML format for a StepTemplate. Exactly one of
+// Agent, AgentType, JobSet, Concurrent or ForEach should be set, depending
+// on the type of step. The other fields are options that only apply to
+// some step types.
+type configFileStep struct {
+	Agent      string             `yaml:"agent"`
+	AgentType  string             `yaml:"agentType"`
+	JobSet     string             `yaml:"jobset"`
+	Concurrent []*configFileStep  `yaml:"concurrent"`
+	ForEach    *configFileForEach `yaml:"foreach"`
+
+	// all step types
+	Name string               `yaml:"name"`
+	When *configFileCondition `yaml:"when"`
+
+	// top-level steps only
+	DependsOn []string `yaml:"dependsOn"`
+
+	// "agent", "agentType", "jobset" and "foreach" only
+	AllowFailure bool `yaml:"allowFailure"`
+
+	// "agent" and "agentType" only
+	Retry         *configFileRetry `yaml:"retry"`
+	Timeout       string           `yaml:"timeout"`
+	ResourceClass string           `yaml:"resourceClass"`
+
+	// "agentType" only: "least-loaded" (the default) or "round-robin"
+	Strategy string `yaml:"strategy"`
+}
+
+// configFileForEach is the YAML format for a "foreach" step. Config names
+// the JobSet config key that lists the items, As names the config key that
+// is set to each item for its JobSet, and JobSet names the JobSetTemplate.
+type configFileForEach struct {
+	Config      string `yaml:"config"`
+	As          string `yaml:"as"`
+	JobSet      string `yaml:"jobset"`
+	MaxParallel uint32 `yaml:"maxParallel"`
+}
+
+// configFileCondition is the YAML format for a StepCondition. Outcome lists
+// the outcomes of the given step that the condition accepts: "ok",
+// "degraded", "error" and/or "skipped".
+type configFileCondition struct {
+	Config  string   `yaml:"config"`
+	Equals  string   `yaml:"equals"`
+	Step    string   `yaml:"step"`
+	Outcome []string `yaml:"outcome"`
+}
+
+// configFileRetry is the YAML format for a RetryPolicy. On lists the
+// failure classes to retry: "connection", "agent" and/or "timeout". If it
+// is omitted, only connection errors are retried.
+type configFileRetry struct {
+	MaxAttempts uint32   `yaml:"maxAttempts"`
+	Backoff     string   `yaml:"backoff"`
+	On          []string `yaml:"on"`
+}
+
+// LoadConfigFile reads a YAML controller configuration file from the given
+// path, and returns the corresponding Config. It returns an error if the
+// file cannot be read or parsed, or if its contents are invalid. Agents and
+// JobSetTemplates defined in the file are registered when the Config is
+// passed to Init.
+func LoadConfigFile(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read config file %s: %v", path, err)
+	}
+
+	cfg, err := ParseConfig(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig parses a YAML controller configuration, and returns the
+// corresponding Config or an error if it is invalid.
+func ParseConfig(b []byte) (*Config, error) {
+	cf := &configFile{}
+	err := yaml.UnmarshalStrict(b, cf)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		VolPrefix:       cf.VolPrefix,
+		MaxJobsRunning:  cf.MaxJobsRunning,
+		StorePath:       cf.StorePath,
+		ResourceClasses: cf.ResourceClasses,
+	}
+	if cfg.MaxJobsRunning < 0 {
+		return nil, fmt.Errorf("maxJobsRunning must not be negative")
+	}
+	for name, maxJobs := range cf.ResourceClasses {
+		if maxJobs <= 0 {
+			return nil, fmt.Errorf("resource class %s must have a positive capacity", name)
+		}
+	}
+	// heartbeats are off unless an interval is given, so 0 is also
+	// accepted here to turn them off explicitly
+	if cf.HeartbeatInterval != "" && cf.HeartbeatInterval != "0" {
+		cfg.HeartbeatInterval, err = parseTimeout(cf.HeartbeatInterval)
+		if err != nil {
+			return nil, fmt.Errorf("heartbeatInterval: %v", err)
+		}
+	}
+	if cf.HeartbeatTimeout != "" {
+		cfg.HeartbeatTimeout, err = parseTimeout(cf.HeartbeatTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("heartbeatTimeout: %v", err)
+		}
+	}
+
+	agentNames := map[string]bool{}
+	for i, cfa := range cf.Agents {
+		if cfa.Name == "" {
+			return nil, fmt.Errorf("agent %d has no name", i+1)
+		}
+		if agentNames[cfa.Name] {
+			return nil, fmt.Errorf("agent %s is defined more than once", cfa.Name)
+		}
+		agentNames[cfa.Name] = true
+		if cfa.URL == "" {
+			return nil, fmt.Errorf("agent %s has no url", cfa.Name)
+		}
+		if cfa.Port == 0 {
+			return nil, fmt.Errorf("agent %s has no port", cfa.Name)
+		}
+
+		ac := &pbc.AgentConfig{
+			Name:              cfa.Name,
+			Url:               cfa.URL,
+			Port:              cfa.Port,
+			Type:              cfa.Type,
+			MaxConcurrentJobs: cfa.MaxConcurrentJobs,
+			ResourceClass:     cfa.ResourceClass,
+		}
+		if cfa.JobTimeout != "" {
+			timeout, err := parseTimeout(cfa.JobTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("agent %s: %v", cfa.Name, err)
+			}
+			ac.JobTimeoutMillis = int64(timeout / time.Millisecond)
+		}
+		for _, kv := range cfa.KVs {
+			ac.Kvs = append(ac.Kvs, &pbc.AgentConfig_AgentKV{Key: kv.Key, Value: kv.Value})
+		}
+		cfg.Agents = append(cfg.Agents, ac)
+	}
+
+	templateNames := map[string]bool{}
+	for i, cft := range cf.Templates {
+		if cft.Name == "" {
+			return nil, fmt.Errorf("template %d has no name", i+1)
+		}
+		if templateNames[cft.Name] {
+			return nil, fmt.Errorf("template %s is defined more than once", cft.Name)
+		}
+		templateNames[cft.Name] = true
+
+		steps, err := createStepTemplatesFromConfigFile(cft.Steps)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", cft.Name, err)
+		}
+		err = validateStepDependencies(steps)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", cft.Name, err)
+		}
+		err = validateStepConditions(steps)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", cft.Name, err)
+		}
+		cfg.JobSetTemplates = append(cfg.JobSetTemplates, &JobSetTemplate{Name: cft.Name, Steps: steps})
+	}
+
+	tenantNames := map[string]bool{}
+	for i, cftn := range cf.Tenants {
+		if cftn.Name == "" {
+			return nil, fmt.Errorf("tenant %d has no name", i+1)
+		}
+		if tenantNames[cftn.Name] {
+			return nil, fmt.Errorf("tenant %s is defined more than once", cftn.Name)
+		}
+		tenantNames[cftn.Name] = true
+
+		cfg.Tenants = append(cfg.Tenants, &pbc.TenantConfig{
+			Name:    cftn.Name,
+			Weight:  cftn.Weight,
+			MinJobs: cftn.MinJobs,
+		})
+	}
+
+	return cfg, nil
+}
+
+// createStepTemplatesFromConfigFile recursively converts the YAML format
+// steps into StepTemplates.
+func createStepTemplatesFromConfigFile(cfss []*configFileStep) ([]*StepTemplate, error) {
+	if len(cfss) == 0 {
+		return nil, fmt.Errorf("no steps defined")
+	}
+
+	steps := []*StepTemplate{}
+	for i, cfs := range cfss {
+		if cfs == nil {
+			return nil, fmt.Errorf("step %d is empty", i+1)
+		}
+
+		// make sure exactly one step type is set
+		n := 0
+		if cfs.Agent != "" {
+			n++
+		}
+		if cfs.AgentType != "" {
+			n++
+		}
+		if cfs.JobSet != "" {
+			n++
+		}
+		if cfs.Concurrent != nil {
+			n++
+		}
+		if cfs.ForEach != nil {
+			n++
+		}
+		if n != 1 {
+			return nil, fmt.Errorf("step %d must have exactly one of agent, agentType, jobset, concurrent or foreach", i+1)
+		}
+
+		if cfs.Agent == "" && cfs.AgentType == "" && cfs.Retry != nil {
+			return nil, fmt.Errorf("step %d: retry is only allowed for agent steps", i+1)
+		}
+		if cfs.Agent == "" && cfs.AgentType == "" && cfs.Timeout != "" {
+			return nil, fmt.Errorf("step %d: timeout is only allowed for agent steps", i+1)
+		}
+		if cfs.Agent == "" && cfs.AgentType == "" && cfs.ResourceClass != "" {
+			return nil, fmt.Errorf("step %d: resourceClass is only allowed for agent steps", i+1)
+		}
+		if cfs.Concurrent != nil && cfs.AllowFailure {
+			return nil, fmt.Errorf("step %d: allowFailure is not allowed for concurrent steps", i+1)
+		}
+		if cfs.AgentType == "" && cfs.Strategy != "" {
+			return nil, fmt.Errorf("step %d: strategy is only allowed for agentType steps", i+1)
+		}
+
+		st := &StepTemplate{
+			Name:         cfs.Name,
+			AllowFailure: cfs.AllowFailure,
+			DependsOn:    cfs.DependsOn,
+		}
+		switch {
+		case cfs.Agent != "" || cfs.AgentType != "":
+			st.T = StepTypeAgent
+			st.AgentName = cfs.Agent
+			st.AgentType = cfs.AgentType
+			switch cfs.Strategy {
+			case "", "least-loaded":
+				st.PoolStrategy = PoolLeastLoaded
+			case "round-robin":
+				st.PoolStrategy = PoolRoundRobin
+			default:
+				return nil, fmt.Errorf("step %d: unknown strategy %q; must be least-loaded or round-robin", i+1, cfs.Strategy)
+			}
+			if cfs.Retry != nil {
+				rp, err := createRetryPolicyFromConfigFile(cfs.Retry)
+				if err != nil {
+					return nil, fmt.Errorf("step %d: %v", i+1, err)
+				}
+				st.Retry = rp
+			}
+			if cfs.Timeout != "" {
+				timeout, err := parseTimeout(cfs.Timeout)
+				if err != nil {
+					return nil, fmt.Errorf("step %d: %v", i+1, err)
+				}
+				st.Timeout = timeout
+			}
+			st.ResourceClass = cfs.ResourceClass
+		case cfs.JobSet != "":
+			st.T = StepTypeJobSet
+			st.JSTemplateName = cfs.JobSet
+		case cfs.ForEach != nil:
+			if cfs.ForEach.Config == "" || cfs.ForEach.As == "" || cfs.ForEach.JobSet == "" {
+				return nil, fmt.Errorf("step %d: foreach must set config, as and jobset", i+1)
+			}
+			st.T = StepTypeForEach
+			st.JSTemplateName = cfs.ForEach.JobSet
+			st.ForEachConfigKey = cfs.ForEach.Config
+			st.ForEachItemKey = cfs.ForEach.As
+			st.ForEachMaxParallel = cfs.ForEach.MaxParallel
+		default:
+			st.T = StepTypeConcurrent
+			subSteps, err := createStepTemplatesFromConfigFile(cfs.Concurrent)
+			if err != nil {
+				return nil, fmt.Errorf("step %d: %v", i+1, err)
+			}
+			st.ConcurrentStepTemplates = subSteps
+		}
+		if cfs.When != nil {
+			cond, err := createStepConditionFromConfigFile(cfs.When)
+			if err != nil {
+				return nil, fmt.Errorf("step %d: %v", i+1, err)
+			}
+			st.Condition = cond
+		}
+		steps = append(steps, st)
+	}
+
+	return steps, nil
+}
+
+// createStepConditionFromConfigFile converts the YAML format condition
+// into a StepCondition.
+func createStepConditionFromConfigFile(cfc *configFileCondition) (*StepCondition, error) {
+	if cfc.Config == "" && cfc.Step == "" {
+		return nil, fmt.Errorf("when must set config and/or step")
+	}
+	if cfc.Config == "" && cfc.Equals != "" {
+		return nil, fmt.Errorf("when can only set equals along with config")
+	}
+	if cfc.Step == "" && cfc.Outcome != nil {
+		return nil, fmt.Errorf("when can only set outcome along with step")
+	}
+
+	cond := &StepCondition{
+		ConfigKey:   cfc.Config,
+		ConfigValue: cfc.Equals,
+		StepName:    cfc.Step,
+	}
+	for _, outcome := range cfc.Outcome {
+		switch outcome {
+		case "ok":
+			cond.StepOutcomes = append(cond.StepOutcomes, StepOutcomeOK)
+		case "degraded":
+			cond.StepOutcomes = append(cond.StepOutcomes, StepOutcomeDegraded)
+		case "error":
+			cond.StepOutcomes = append(cond.StepOutcomes, StepOutcomeError)
+		case "skipped":
+			cond.StepOutcomes = append(cond.StepOutcomes, StepOutcomeSkipped)
+		default:
+			return nil, fmt.Errorf("unknown step outcome %q; must be ok, degraded, error or skipped", outcome)
+		}
+	}
+
+	return cond, nil
+}
+
+// createRetryPolicyFromConfigFile converts the YAML format retry policy
+// into a RetryPolicy.
+func createRetryPolicyFromConfigFile(cfr *configFileRetry) (*RetryPolicy, error) {
+	rp := &RetryPolicy{MaxAttempts: cfr.MaxAttempts}
+	if cfr.MaxAttempts == 0 {
+		return nil, fmt.Errorf("retry must set maxAttempts")
+	}
+
+	if cfr.Backoff != "" {
+		backoff, err := time.ParseDuration(cfr.Backoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry backoff %q: %v", cfr.Backoff, err)
+		}
+		if backoff < 0 {
+			return nil, fmt.Errorf("retry backoff must not be negative")
+		}
+		rp.Backoff = backoff
+	}
+
+	if cfr.On == nil {
+		rp.RetryConnectionErrors = true
+	}
+	for _, on := range cfr.On {
+		switch on {
+		case "connection":
+			rp.RetryConnectionErrors = true
+		case "agent":
+			rp.RetryAgentErrors = true
+		case "timeout":
+			rp.RetryTimeouts = true
+		default:
+			return nil, fmt.Errorf("unknown retry failure class %q; must be connection, agent or timeout", on)
+		}
+	}
+
+	return rp, nil
+}
+
+// parseTimeout parses a YAML format timeout, which must be a positive
+// duration such as "90s" or "2h".
+func parseTimeout(s string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %v", s, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be positive")
+	}
+	return timeout, nil
+}
+
+// applyConfigFile registers the agents, JobSetTemplates and tenants listed
+// in the Config, replacing any with the same names that were reloaded from
+// the Store, since the configuration file is the source of truth for what
+// it defines. It then checks that every step in every template refers to a
+// known agent or template. It should only be called from Init, before the
+// Controller is started, so it does not grab a lock.
+func (c *Controller) applyConfigFile(cfg *Config) error {
+	newJsts := []*JobSetTemplate{}
+	for _, ac := range cfg.Agents {
+		if err := c.checkResourceClass(ac.ResourceClass); err != nil {
+			return fmt.Errorf("agent %s: %v", ac.Name, err)
+		}
+		c.agents[ac.Name] = *ac
+	}
+	for _, jst := range cfg.JobSetTemplates {
+		newJst := &JobSetTemplate{Name: jst.Name, Steps: cloneStepTemplate(jst.Steps)}
+		c.jobSetTemplates[jst.Name] = newJst
+		newJsts = append(newJsts, newJst)
+	}
+
+	// check references before persisting anything, so that an invalid
+	// configuration doesn't get saved to the Store
+	for _, jst := range newJsts {
+		err := c.checkStepTemplateRefs(jst.Steps)
+		if err != nil {
+			return fmt.Errorf("template %s: %v", jst.Name, err)
+		}
+		err = c.checkStepResourceClasses(jst.Steps)
+		if err != nil {
+			return fmt.Errorf("template %s: %v", jst.Name, err)
+		}
+	}
+
+	for _, ac := range cfg.Agents {
+		c.saveAgent(ac)
+	}
+	for _, jst := range newJsts {
+		c.saveJobSetTemplate(jst)
+	}
+	for _, tc := range cfg.Tenants {
+		c.tenants[tc.Name] = *tc
+		c.saveTenant(tc)
+	}
+	return nil
+}
+
+// checkStepTemplateRefs recursively checks that each step refers to a
+// registered agent or JobSetTemplate. It does not grab a lock, as callers
+// should already hold one if needed.
+func (c *Controller) checkStepTemplateRefs(sts []*StepTemplate) error {
+	for _, st := range sts {
+		switch st.T {
+		case StepTypeAgent:
+			if st.AgentType != "" {
+				if !c.hasAgentOfType(st.AgentType) {
+					return fmt.Errorf("step refers to agent type %s, but no agents of that type are registered", st.AgentType)
+				}
+				continue
+			}
+			if _, ok := c.agents[st.AgentName]; !ok {
+				return fmt.Errorf("step refers to unknown agent %s", st.AgentName)
+			}
+		case StepTypeJobSet, StepTypeForEach:
+			if _, ok := c.jobSetTemplates[st.JSTemplateName]; !ok {
+				return fmt.Errorf("step refers to unknown template %s", st.JSTemplateName)
+			}
+		case StepTypeConcurrent:
+			if err := c.checkStepTemplateRefs(st.ConcurrentStepTemplates); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasAgentOfType returns true if any registered agent has the given type.
+// It does not grab a lock, as callers should already hold one if needed.
+func (c *Controller) hasAgentOfType(agentType string) bool {
+	for _, ac := range c.agents {
+		if ac.Type == agentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+volPrefix: /vol/
+maxJobsRunning: 4
+agents:
+  - name: a
+    url: localhost
+    port: 9001
+    kvs:
+      - key: k
+        value: v
+    jobTimeout: 90s
+templates:
+  - name: t
+    steps:
+      - agent: a
+        retry:
+          maxAttempts: 3
+          backoff: 10s
+          on: [agent, timeout]
+      - concurrent:
+          - agentType: scanner
+            strategy: round-robin
+          - jobset: sub
+tenants:
+  - name: team
+    weight: 2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VolPrefix != "/vol/" || cfg.MaxJobsRunning != 4 {
+		t.Errorf("expected settings to be read, got %q and %d", cfg.VolPrefix, cfg.MaxJobsRunning)
+	}
+	if len(cfg.Agents) != 1 || cfg.Agents[0].Port != 9001 || len(cfg.Agents[0].Kvs) != 1 || cfg.Agents[0].JobTimeoutMillis != 90000 {
+		t.Errorf("expected agent to be read, got %v", cfg.Agents)
+	}
+	if len(cfg.Tenants) != 1 || cfg.Tenants[0].Weight != 2 {
+		t.Errorf("expected tenant to be read, got %v", cfg.Tenants)
+	}
+	if len(cfg.JobSetTemplates) != 1 || len(cfg.JobSetTemplates[0].Steps) != 2 {
+		t.Fatalf("expected template with 2 steps, got %v", cfg.JobSetTemplates)
+	}
+	steps := cfg.JobSetTemplates[0].Steps
+	rp := steps[0].Retry
+	if rp == nil || rp.MaxAttempts != 3 || rp.Backoff != 10*time.Second || rp.RetryConnectionErrors || !rp.RetryAgentErrors || !rp.RetryTimeouts {
+		t.Errorf("expected retry policy to be read, got %+v", rp)
+	}
+	sub := steps[1].ConcurrentStepTemplates
+	if steps[1].T != StepTypeConcurrent || len(sub) != 2 || sub[0].PoolStrategy != PoolRoundRobin || sub[1].T != StepTypeJobSet {
+		t.Errorf("expected concurrent steps to be read, got %v", sub)
+	}
+}
+
+func TestDocsExampleConfig(t *testing.T) {
+	b, err := ioutil.ReadFile("../../docs/controller-config.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first YAML block is the full example
+	s := string(b)
+	start := strings.Index(s, "```yaml\n")
+	if start < 0 {
+		t.Fatal("no example found")
+	}
+	s = s[start+len("```yaml\n"):]
+	s = s[:strings.Index(s, "```")]
+
+	cfg, err := ParseConfig([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.StorePath = filepath.Join(t.TempDir(), "state.json")
+	c := &Controller{}
+	if err := c.Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseConfigRejectsInvalidConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		// settings
+		{"unknown field", "bogus: 1", "field bogus not found"},
+		{"negative maxJobsRunning", "maxJobsRunning: -1", "maxJobsRunning must not be negative"},
+		{"zero class capacity", "resourceClasses: {net: 0}", "resource class net must have a positive capacity"},
+		{"negative heartbeatInterval", "heartbeatInterval: -5s", "heartbeatInterval: timeout must be positive"},
+		{"zero heartbeatTimeout", "heartbeatTimeout: 0s", "heartbeatTimeout: timeout must be positive"},
+
+		// agents
+		{"agent without name", "agents: [{url: x, port: 1}]", "agent 1 has no name"},
+		{"duplicate agent", "agents: [{name: a, url: x, port: 1}, {name: a, url: y, port: 2}]", "agent a is defined more than once"},
+		{"agent without url", "agents: [{name: a, port: 1}]", "agent a has no url"},
+		{"agent without port", "agents: [{name: a, url: x}]", "agent a has no port"},
+		{"zero agent jobTimeout", "agents: [{name: a, url: x, port: 1, jobTimeout: 0s}]", "agent a: timeout must be positive"},
+
+		// templates and steps
+		{"template without name", "templates: [{steps: [{agent: a}]}]", "template 1 has no name"},
+		{"duplicate template", "templates: [{name: t, steps: [{agent: a}]}, {name: t, steps: [{agent: a}]}]", "template t is defined more than once"},
+		{"template without steps", "templates: [{name: t}]", "template t: no steps defined"},
+		{"empty step", "templates: [{name: t, steps: [~]}]", "template t: step 1 is empty"},
+		{"two step types", "templates: [{name: t, steps: [{agent: a, jobset: s}]}]", "step 1 must have exactly one of agent, agentType, jobset, concurrent or foreach"},
+		{"no step type", "templates: [{name: t, steps: [{allowFailure: true}]}]", "step 1 must have exactly one of"},
+		{"empty concurrent step", "templates: [{name: t, steps: [{concurrent: []}]}]", "step 1: no steps defined"},
+		{"nested step", "templates: [{name: t, steps: [{agent: a}, {concurrent: [{agent: a, agentType: b}]}]}]", "step 2: step 1 must have exactly one of"},
+		{"retry on jobset step", "templates: [{name: t, steps: [{jobset: s, retry: {maxAttempts: 2}}]}]", "retry is only allowed for agent steps"},
+		{"timeout on foreach step", "templates: [{name: t, steps: [{foreach: {config: c, as: i, jobset: s}, timeout: 1m}]}]", "timeout is only allowed for agent steps"},
+		{"resourceClass on jobset step", "templates: [{name: t, steps: [{jobset: s, resourceClass: net}]}]", "resourceClass is only allowed for agent steps"},
+		{"allowFailure on concurrent step", "templates: [{name: t, steps: [{concurrent: [{agent: a}], allowFailure: true}]}]", "allowFailure is not allowed for concurrent steps"},
+		{"strategy on agent step", "templates: [{name: t, steps: [{agent: a, strategy: round-robin}]}]", "strategy is only allowed for agentType steps"},
+		{"unknown strategy", "templates: [{name: t, steps: [{agentType: b, strategy: random}]}]", `unknown strategy "random"`},
+		{"incomplete foreach", "templates: [{name: t, steps: [{foreach: {config: c, jobset: s}}]}]", "foreach must set config, as and jobset"},
+		{"zero step timeout", "templates: [{name: t, steps: [{agent: a, timeout: 0s}]}]", "step 1: timeout must be positive"},
+		{"negative step timeout", "templates: [{name: t, steps: [{agent: a, timeout: -1m}]}]", "step 1: timeout must be positive"},
+		{"invalid step timeout", "templates: [{name: t, steps: [{agent: a, timeout: soon}]}]", `invalid timeout "soon"`},
+
+		// retry policies
+		{"retry without maxAttempts", "templates: [{name: t, steps: [{agent: a, retry: {backoff: 1s}}]}]", "retry must set maxAttempts"},
+		{"negative backoff", "templates: [{name: t, steps: [{agent: a, retry: {maxAttempts: 2, backoff: -1s}}]}]", "retry backoff must not be negative"},
+		{"invalid backoff", "templates: [{name: t, steps: [{agent: a, retry: {maxAttempts: 2, backoff: later}}]}]", `invalid retry backoff "later"`},
+		{"unknown retry class", "templates: [{name: t, steps: [{agent: a, retry: {maxAttempts: 2, on: [disk]}}]}]", `unknown retry failure class "disk"`},
+
+		// conditions
+		{"empty condition", "templates: [{name: t, steps: [{agent: a, when: {}}]}]", "when must set config and/or step"},
+		{"equals without config", "templates: [{name: t, steps: [{name: x, agent: a}, {agent: a, when: {step: x, equals: y}}]}]", "when can only set equals along with config"},
+		{"unknown outcome", "templates: [{name: t, steps: [{name: x, agent: a}, {agent: a, when: {step: x, outcome: [failed]}}]}]", `unknown step outcome "failed"`},
+		{"condition on unknown step", "templates: [{name: t, steps: [{agent: a, when: {step: x}}]}]", "condition refers to unknown step x"},
+
+		// step names and dependencies
+		{"duplicate step name", "templates: [{name: t, steps: [{name: x, agent: a}, {name: x, agent: a}]}]", "more than one step is named x"},
+		{"unknown dependency", "templates: [{name: t, steps: [{name: x, agent: a, dependsOn: [y]}]}]", "step 1 depends on y, which is not a top-level step"},
+
+		// tenants
+		{"tenant without name", "tenants: [{weight: 2}]", "tenant 1 has no name"},
+		{"duplicate tenant", "tenants: [{name: a}, {name: a}]", "tenant a is defined more than once"},
+	}
+	for _, tc := range tests {
+		_, err := ParseConfig([]byte(tc.yaml))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
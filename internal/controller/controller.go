@@ -9,11 +9,15 @@ import (
 	"container/list"
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/swinslow/peridot-core/internal/jobcontroller"
+	"github.com/swinslow/peridot-core/internal/logging"
 	pbc "github.com/swinslow/peridot-core/pkg/controller"
 	pbs "github.com/swinslow/peridot-core/pkg/status"
+	"google.golang.org/grpc"
 )
 
 // Controller is the full collection of data about the status of the
@@ -34,14 +38,35 @@ type Controller struct {
 	// volume where code and SPDX files live, for building paths
 	volPrefix string
 
-	// maximum number of jobs to have running at any one time
+	// maximum number of jobs to have running at any one time, other than
+	// jobs in the resource classes below
 	maxJobsRunning int
 
+	// maximum number of jobs of each resource class to have running at
+	// any one time. each class's capacity is separate from the others',
+	// and from maxJobsRunning.
+	resourceClasses map[string]int
+
+	// any additional options to use when the JobController connects
+	// to Agents
+	agentDialOptions []grpc.DialOption
+
+	// how the JobController checks that running Jobs' Agents are alive
+	heartbeat jobcontroller.HeartbeatConfig
+
+	// store where all Agents, JobSetTemplates, Jobs and JobSets are
+	// persisted, so that they survive a controller restart
+	store Store
+
 	// ===== status =====
 
 	// are we open to receive new JobSetRequests via inJobSetStream?
 	openForJobSetRequests bool
 
+	// are we draining? if so, no new Jobs will be started, but Jobs
+	// that are already active will be allowed to finish
+	draining bool
+
 	// controller's overall run and health status
 	runStatus    pbs.Status
 	healthStatus pbs.Health
@@ -55,10 +80,25 @@ type Controller struct {
 	// ===== agents =====
 
 	// mapping of agent name to agent configuration. this is used to build the
-	// agent config before we create the JobController, and should not be updated
-	// after Start() is successfully called.
+	// agent config when we create the JobController. if it is updated after
+	// Start() is successfully called, the change must also be sent to the
+	// JobController via inAgentStream.
 	agents map[string]pbc.AgentConfig
 
+	// for agents that recently failed to connect, the time until which
+	// they are treated as unhealthy and skipped when picking an agent of
+	// a given type for a step
+	agentUnhealthyUntil map[string]time.Time
+
+	// for each agent type, the name of the agent that was most recently
+	// picked for a round-robin step
+	poolLastAgent map[string]string
+
+	// agentUpdateM serializes calls that add, update or remove agents, so
+	// that their changes reach the JobController in the same order that
+	// they were made to the agents map. it must be grabbed before m.
+	agentUpdateM sync.Mutex
+
 	// ===== jobs =====
 
 	// mapping of unique ID to all pending, running or completed jobs.
@@ -88,6 +128,28 @@ type Controller struct {
 	// pending JobSetRequests that are queued for addition as actual JobSets
 	pendingJSRs *list.List
 
+	// ===== scheduling =====
+
+	// mapping of tenant name to tenant configuration, for sharing the
+	// running job slots between tenants. tenants that aren't listed here
+	// have a weight of 1 and no guaranteed minimum.
+	tenants map[string]pbc.TenantConfig
+
+	// for each tenant, the number of its steps that were ready to run but
+	// couldn't be started, as of the last time runScheduler ran
+	tenantQueuedSteps map[string]int
+
+	// mapping of schedule name to schedule, for starting JobSets at
+	// set times
+	schedules map[string]*Schedule
+
+	// nextWakeup is the earliest time at which runScheduler will have
+	// something to do that isn't prompted by any event, such as a step
+	// that is waiting to be retried or a schedule that is due, or the
+	// zero time if there is none.
+	// it is set by runScheduler and read by jobSetProcessorLoop.
+	nextWakeup time.Time
+
 	// ===== jobset templates =====
 
 	// mapping of jobset template names to registered templates.
@@ -103,9 +165,10 @@ type Controller struct {
 
 	// inJobSetStream is created by Controller. The Controller's
 	// jobSetProcessingLoop listens on inJobSetStream for requests to start
-	// new JobSets. We own this channel and must close it when we're done.
-	// and therefore must also be careful to block further writes with new
-	// JobSet requests once we are ready to close it.
+	// new JobSets. We own this channel, but we do not close it when we're
+	// done, because StartJobSet could still be trying to write to it.
+	// Instead, writers must also select on loopDone so that they don't
+	// block once the jobSetProcessorLoop has exited.
 	inJobSetStream chan JobSetRequest
 
 	// inJobStream is created by JobController. It is used to submit
@@ -118,6 +181,18 @@ type Controller struct {
 	// must close it when we're done.
 	inJobUpdateStream chan<- uint64
 
+	// inAgentStream is created by JobController. It is used to tell the
+	// JobController about agents that are added, updated or removed while
+	// it is running. We own this channel, but as with inJobSetStream we do
+	// not close it; writers must also select on loopDone.
+	inAgentStream chan<- jobcontroller.AgentUpdate
+
+	// inJobCancelStream is created by JobController. It is used to ask
+	// the JobController to cancel running Jobs. We own this channel, but
+	// as with inJobSetStream we do not close it; writers must also select
+	// on loopDone.
+	inJobCancelStream chan<- uint64
+
 	// jobRecordStream is created by JobController. It receives broadcasts
 	// of JobRecord updates. JobController owns this channel and will
 	// close it.
@@ -127,6 +202,17 @@ type Controller struct {
 	// JobController-level errors. JobController owns this channel and will
 	// close it.
 	errc <-chan error
+
+	// loopDone is created by Controller, and is closed by the
+	// jobSetProcessorLoop once it has exited.
+	loopDone chan struct{}
+
+	// schedulerWake is created by Controller, and is signalled when the
+	// agents change, so that the jobSetProcessorLoop looks again at steps
+	// waiting for them, or when a schedule is added or resumed, so that
+	// it wakes up in time for it. It has a buffer of one, so that
+	// signalling never blocks.
+	schedulerWake chan struct{}
 }
 
 // Config contains configuration values for a newly-created
@@ -135,19 +221,81 @@ type Config struct {
 	// prefix for volumes
 	VolPrefix string
 
-	// maximum number of jobs that can run at once
+	// maximum number of jobs that can run at once, other than jobs in
+	// the resource classes in ResourceClasses
 	MaxJobsRunning int
+
+	// maximum number of jobs of each resource class that can run at
+	// once, by class name. a job is in its step's resource class, or else
+	// its agent's, and counts only against that class's capacity.
+	ResourceClasses map[string]int
+
+	// path to the file where controller state is persisted, if Store
+	// is not set. If empty, defaults to a file under VolPrefix.
+	StorePath string
+
+	// Store to use for persisting controller state. If nil, a FileStore
+	// is created at StorePath.
+	Store Store
+
+	// any additional options to use when connecting to Agents, e.g. a
+	// custom dialer for reaching Agents over an in-memory listener
+	AgentDialOptions []grpc.DialOption
+
+	// agents to register when the Controller is initialized, e.g. from
+	// a configuration file
+	Agents []*pbc.AgentConfig
+
+	// JobSetTemplates to register when the Controller is initialized,
+	// e.g. from a configuration file
+	JobSetTemplates []*JobSetTemplate
+
+	// tenants to configure when the Controller is initialized, e.g. from
+	// a configuration file
+	Tenants []*pbc.TenantConfig
+
+	// how often to ask the agent of each running job for its status; 0
+	// means that running jobs are not checked for liveness
+	HeartbeatInterval time.Duration
+
+	// how long the agent of a running job may go without sending anything
+	// before the job is treated as failed; 0 means no limit
+	HeartbeatTimeout time.Duration
 }
 
+// defaultStoreFilename is the name of the state file within VolPrefix
+// used if neither Store nor StorePath is configured.
+const defaultStoreFilename = "controller-state.json"
+
 // Init is the initialization function that should be called on a newly
 // created Controller, in order to initialize some of its configurations.
-func (c *Controller) Init(cfg *Config) {
+// It also reloads any state previously persisted in the configured Store,
+// and registers any agents and JobSetTemplates listed in the Config. It
+// returns an error if that state could not be loaded, or if the listed
+// templates refer to unknown agents or templates.
+func (c *Controller) Init(cfg *Config) error {
 	// fill in values from configuration
 	c.volPrefix = cfg.VolPrefix
+	c.agentDialOptions = cfg.AgentDialOptions
+	c.heartbeat = jobcontroller.HeartbeatConfig{
+		Interval: cfg.HeartbeatInterval,
+		Timeout:  cfg.HeartbeatTimeout,
+	}
 
-	// perhaps split into sub-categories like long-running jobs,
-	// IO-heavy or CPU-heavy or network-heavy jobs, etc.
+	// jobs can be split into resource classes, like IO-heavy or
+	// CPU-heavy or network-heavy jobs, each with its own capacity; the
+	// rest share maxJobsRunning
 	c.maxJobsRunning = cfg.MaxJobsRunning
+	c.resourceClasses = make(map[string]int)
+	for name, maxJobs := range cfg.ResourceClasses {
+		if name == "" {
+			return fmt.Errorf("resource class must have a name")
+		}
+		if maxJobs <= 0 {
+			return fmt.Errorf("resource class %s must have a positive capacity", name)
+		}
+		c.resourceClasses[name] = maxJobs
+	}
 
 	// create mutex and set default values
 	c.m = &sync.RWMutex{}
@@ -156,59 +304,130 @@ func (c *Controller) Init(cfg *Config) {
 
 	// and create data holders
 	c.agents = make(map[string]pbc.AgentConfig)
+	c.agentUnhealthyUntil = make(map[string]time.Time)
+	c.poolLastAgent = make(map[string]string)
+	c.tenants = make(map[string]pbc.TenantConfig)
+	c.tenantQueuedSteps = make(map[string]int)
+	c.schedules = make(map[string]*Schedule)
+	c.schedulerWake = make(chan struct{}, 1)
 	c.jobs = make(map[uint64]*Job)
 	c.activeJobs = make(map[uint64]*Job)
 	c.jobSets = make(map[uint64]*JobSet)
 	c.activeJobSets = make(map[uint64]*JobSet)
 	c.pendingJSRs = list.New()
 	c.jobSetTemplates = make(map[string]*JobSetTemplate)
+
+	// set initial jobset and job IDs; these will be replaced by the
+	// persisted values, if any
+	c.nextJobSetID = 1
+	c.nextJobID = 1
+
+	// set up the store and reload anything it already contains
+	c.store = cfg.Store
+	if c.store == nil {
+		storePath := cfg.StorePath
+		if storePath == "" {
+			storePath = filepath.Join(cfg.VolPrefix, defaultStoreFilename)
+		}
+		fs, err := NewFileStore(storePath)
+		if err != nil {
+			return err
+		}
+		c.store = fs
+	}
+
+	err := c.loadFromStore()
+	if err != nil {
+		return err
+	}
+
+	err = c.applyConfigFile(cfg)
+	if err != nil {
+		return err
+	}
+
+	// bring any JobSets that were in flight when the controller last
+	// exited back into a consistent state, and if any are still active,
+	// start up right away so that their pipelines can continue
+	if c.reconcile() && len(c.agents) > 0 {
+		return c.tryToStart()
+	}
+
+	return nil
 }
 
 // tryToStart tries to start the controller for regular operation. This means:
 // (1) starting the JobController; and (2) starting the JobSet processing
 // loop. It will return nil on success or an error message if for some reason
 // it is unable to start (e.g. if no agents have been previously set).
-// Note that all AddAgent calls must occur prior to calling tryToStart.
+// At least one agent must be added via AddAgent prior to calling tryToStart;
+// agents added, updated or removed later are passed along to the running
+// JobController.
+// The controller moves from STARTUP (or STOPPED, if it is being restarted)
+// to RUNNING; it cannot be started if it is already RUNNING.
 func (c *Controller) tryToStart() error {
+	// if we are being restarted, the previous processor loop might still
+	// be on its way out. wait for it to finish before starting a new one.
+	// don't hold the lock while waiting, since the loop grabs it as it exits.
+	c.m.RLocker().Lock()
+	runStatus := c.runStatus
+	prevLoopDone := c.loopDone
+	c.m.RLocker().Unlock()
+	if runStatus == pbs.Status_RUNNING {
+		return fmt.Errorf("controller is already running")
+	}
+	if prevLoopDone != nil {
+		<-prevLoopDone
+	}
+
 	// grab a writer lock
 	c.m.Lock()
 	// BE CAREFUL -- not deferring unlock here b/c want to unlock before we
 	// start the jobSetProcessorLoop below
 
+	// check again, in case another start request got here first
+	if c.runStatus == pbs.Status_RUNNING {
+		c.m.Unlock()
+		return fmt.Errorf("controller is already running")
+	}
+
 	// check whether we have any agents defined; if not, error out
 	if len(c.agents) == 0 {
 		c.m.Unlock()
 		return fmt.Errorf("No agents defined prior to start request")
 	}
 
-	// for the time being, we'll manually set the maximum number of
-	// jobs that we'll allow to run concurrently
-	// as we get more familiar with peridot, this should be configurable.
+	// if we are restarting after being stopped, any Jobs that were still
+	// active have lost their JobController, so treat them the same way as
+	// after a controller process restart
+	if c.runStatus == pbs.Status_STOPPED {
+		c.reconcile()
+		c.healthStatus = pbs.Health_OK
+		c.outputMsg = ""
+		c.errorMsg = ""
+	}
 
-	// set initial jobset and job IDs
-	c.nextJobSetID = 1
-	c.nextJobID = 1
+	// note that we don't reset the next jobset and job IDs here; they
+	// were set in Init, possibly from persisted state, and must continue
+	// from where they left off so that IDs are never reused.
 
 	// build configuration for JobController
 	agents := map[string]jobcontroller.AgentRef{}
 	for _, ac := range c.agents {
-		address := fmt.Sprintf("%s:%d", ac.Url, ac.Port)
-		agents[ac.Name] = jobcontroller.AgentRef{
-			Name:    ac.Name,
-			Address: address,
-		}
+		agents[ac.Name] = getAgentRef(&ac)
 	}
 
-	cfg := jobcontroller.Config{Agents: agents}
+	cfg := jobcontroller.Config{Agents: agents, DialOptions: c.agentDialOptions, Heartbeat: c.heartbeat}
 
 	// start JobController
 	jcCtx, jcCancel := context.WithCancel(context.Background())
 	c.jobControllerCancel = jcCancel
-	c.inJobStream, c.inJobUpdateStream, c.jobRecordStream, c.errc = jobcontroller.JobController(jcCtx, cfg)
+	c.inJobStream, c.inJobUpdateStream, c.inAgentStream, c.inJobCancelStream, c.jobRecordStream, c.errc = jobcontroller.JobController(jcCtx, cfg)
 
 	// create and register the channel for submitting requests to start new JobSets
 	c.inJobSetStream = make(chan JobSetRequest)
 	c.openForJobSetRequests = true
+	c.loopDone = make(chan struct{})
 
 	// create the list for pending JSR requests
 	c.pendingJSRs = list.New()
@@ -216,12 +435,15 @@ func (c *Controller) tryToStart() error {
 	// set status to running
 	c.runStatus = pbs.Status_RUNNING
 
+	// create the context for the JobSet processing loop while we still
+	// hold the lock, so that Stop can't see a stale CancelFunc
+	cCtx, cCancel := context.WithCancel(context.Background())
+	c.controllerCancel = cCancel
+
 	// unlocking now
 	c.m.Unlock()
 
 	// then start JobSet processing loop
-	cCtx, cCancel := context.WithCancel(context.Background())
-	c.controllerCancel = cCancel
 	go c.jobSetProcessorLoop(cCtx)
 
 	return nil
@@ -231,41 +453,68 @@ func (c *Controller) tryToStart() error {
 // for ensuring that the JobController channels owned by the Controller are
 // closed when we are exiting.
 func (c *Controller) jobSetProcessorLoop(ctx context.Context) {
+	defer close(c.loopDone)
 	exiting := false
 
+	// run the scheduler once before waiting for any events, in case
+	// there are active JobSets that were reloaded from the Store
+	c.schedule()
+
 	for !exiting {
 		// ===== DEBUG START =====
-		fmt.Printf("***************************\n")
-		fmt.Printf("***** c.jobs: %#v\n", c.jobs)
-		for jid, j := range c.jobs {
-			fmt.Printf("*****     c.jobs[%d]: run %s, health %s,\terrorMessages: %s\n", jid, j.Status.RunStatus.String(), j.Status.HealthStatus.String(), j.Status.ErrorMessages)
+		if logging.DebugEnabled() {
+			logging.Debugf("***************************\n")
+			logging.Debugf("***** c.jobs: %#v\n", c.jobs)
+			for jid, j := range c.jobs {
+				logging.Debugf("*****     c.jobs[%d]: run %s, health %s,\terrorMessages: %s\n", jid, j.Status.RunStatus.String(), j.Status.HealthStatus.String(), j.Status.ErrorMessages)
+			}
+			logging.Debugf("***** c.jobSets: %#v\n", c.jobSets)
+			for jsid, js := range c.jobSets {
+				logging.Debugf("*****     c.jobSets[%d]: run %s, health %s\n", jsid, js.RunStatus.String(), js.HealthStatus.String())
+			}
+			logging.Debugf("***************************\n")
 		}
-		fmt.Printf("***** c.jobSets: %#v\n", c.jobSets)
-		for jsid, js := range c.jobSets {
-			fmt.Printf("*****     c.jobSets[%d]: run %s, health %s\n", jsid, js.RunStatus.String(), js.HealthStatus.String())
-		}
-		fmt.Printf("***************************\n")
 		// ===== DEBUG END =====
+
+		// if the scheduler is waiting for a time to pass, make sure it
+		// runs again then even if nothing else happens
+		var wakec <-chan time.Time
+		if !c.nextWakeup.IsZero() {
+			wakec = time.After(time.Until(c.nextWakeup))
+		}
+
 		select {
 		case <-ctx.Done():
-			fmt.Printf("***** case <-ctx.Done()\n")
+			logging.Debugf("***** case <-ctx.Done()\n")
 			// the Controller has been cancelled and should shut down
 			exiting = true
 		case jsr := <-c.inJobSetStream:
-			fmt.Printf("***** case jsr := <-c.inJobSetStream\n")
-			// add the request to the pending queue
+			logging.Debugf("***** case jsr := <-c.inJobSetStream\n")
+			// add the request to the pending queue. CancelJobSet may
+			// look for it there, so hold the lock.
+			c.m.Lock()
 			c.pendingJSRs.PushBack(jsr)
+			c.m.Unlock()
 			// create new JobSets from the pending queue
 			c.createNewJobSets()
 		case jr := <-c.jobRecordStream:
-			fmt.Printf("***** case jr := <-c.jobRecordStream\n")
+			logging.Debugf("***** case jr := <-c.jobRecordStream\n")
 			c.updateJobStatus(&jr)
+		case <-wakec:
+			logging.Debugf("***** case <-wakec\n")
+			// nothing to do here; runScheduler will pick up whatever
+			// is now ready
+		case <-c.schedulerWake:
+			logging.Debugf("***** case <-c.schedulerWake\n")
+			// nothing to do here either; runScheduler will start any
+			// steps that can now use a changed agent, and work out
+			// when each schedule is next due
 		case err := <-c.errc:
 			// an error on errc signals a significant problem in either the
 			// Controller or the JobController, such as two Jobs that were
 			// submitted with the same JobID. The Controller should be moved
 			// into an error state and should shut down.
-			fmt.Printf("***** case err := <-c.errc\n")
+			logging.Debugf("***** case err := <-c.errc\n")
 			c.m.Lock()
 			c.healthStatus = pbs.Health_ERROR
 			c.errorMsg += err.Error() + "\n"
@@ -280,7 +529,7 @@ func (c *Controller) jobSetProcessorLoop(ctx context.Context) {
 
 		if !exiting {
 			// if we aren't exiting, time to update statuses, run Jobs
-			c.runScheduler()
+			c.schedule()
 		}
 	}
 
@@ -290,7 +539,6 @@ func (c *Controller) jobSetProcessorLoop(ctx context.Context) {
 	c.m.Lock()
 	c.openForJobSetRequests = false
 	c.m.Unlock()
-	close(c.inJobSetStream)
 
 	// need to clean up by closing channels we own
 	close(c.inJobStream)
@@ -299,6 +547,40 @@ func (c *Controller) jobSetProcessorLoop(ctx context.Context) {
 	// tell JobController to shut down also
 	c.jobControllerCancel()
 
-	// FIXME do we also need to drain the channels that come from
-	// FIXME JobController to ensure they aren't blocked, waiting to be read?
+	// and now we're stopped; we can be restarted by calling Start again
+	c.m.Lock()
+	c.runStatus = pbs.Status_STOPPED
+	c.m.Unlock()
+
+	// there's no need to drain the channels that come from the
+	// JobController: once its context is cancelled, neither it nor its
+	// runJobAgent goroutines block sending on them, and any updates for
+	// Jobs that were still running are lost along with their streams, to
+	// be dealt with by reconcile if the Controller is started again
+}
+
+// schedule runs the scheduler. If it reached any "jobset" steps, it also
+// creates their JobSets and then runs the scheduler again so that they
+// can start, until no more are requested.
+func (c *Controller) schedule() {
+	for {
+		c.runScheduler()
+
+		c.m.RLocker().Lock()
+		pending := c.pendingJSRs.Len()
+		c.m.RLocker().Unlock()
+		if pending == 0 {
+			return
+		}
+		c.createNewJobSets()
+	}
+}
+
+// getAgentRef builds the JobController's AgentRef for the given agent
+// configuration.
+func getAgentRef(ac *pbc.AgentConfig) jobcontroller.AgentRef {
+	return jobcontroller.AgentRef{
+		Name:    ac.Name,
+		Address: fmt.Sprintf("%s:%d", ac.Url, ac.Port),
+	}
 }
@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSpec is a parsed cron-style schedule. Each field is a bitset of the
+// values at which the schedule is due.
+type cronSpec struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// did the day of month and day of week fields start with "*", as in
+	// "*" or "*/2"? if neither did, a day matches if either field
+	// matches, as in cron
+	domStar bool
+	dowStar bool
+
+	// if set, the schedule is instead due every this often
+	every time.Duration
+}
+
+// cronDescriptors maps the "@" shorthands to their full cron specs.
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// cronSearchLimit is how far ahead nextRun looks for a matching time,
+// before deciding that a spec never matches (e.g. "0 0 30 2 *").
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// parseCron parses a cron spec with five fields: minute, hour, day of
+// month, month and day of week. Each field is "*", or a comma-separated
+// list of numbers or ranges such as "1-5", each optionally followed by a
+// step such as "*/15". Day of week runs from 0 (Sunday) to 7 (Sunday
+// again). The "@" shorthands in cronDescriptors and "@every DURATION" are
+// also accepted.
+func parseCron(s string) (*cronSpec, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(s, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %v", s, err)
+		}
+		if every < time.Second {
+			return nil, fmt.Errorf("invalid cron spec %q: must be at least 1s", s)
+		}
+		return &cronSpec{every: every}, nil
+	}
+	if full, ok := cronDescriptors[s]; ok {
+		s = full
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec %q: must have 5 fields", s)
+	}
+
+	cs := &cronSpec{domStar: strings.HasPrefix(fields[2], "*"), dowStar: strings.HasPrefix(fields[4], "*")}
+	var err error
+	if cs.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: minute: %v", s, err)
+	}
+	if cs.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: hour: %v", s, err)
+	}
+	if cs.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: day of month: %v", s, err)
+	}
+	if cs.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: month: %v", s, err)
+	}
+	if cs.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: day of week: %v", s, err)
+	}
+	// 7 is also Sunday
+	if cs.dow&(1<<7) != 0 {
+		cs.dow |= 1
+	}
+
+	return cs, nil
+}
+
+// parseCronField parses one field of a cron spec, whose values must be
+// between min and max, into a bitset.
+func parseCronField(f string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			var err error
+			bounds := strings.SplitN(part, "-", 2)
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if step > 1 {
+				// "a/n" means from a to the end of the range
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// nextRun returns the first time after the given time at which the
+// schedule is due, or the zero time if it never is. The schedule's times
+// are wall clock times in the given time's location. A time that is
+// skipped when the clocks go forward for daylight saving time is due as
+// much later as they went forward, and one that is repeated when they go
+// back is only due once.
+func (cs *cronSpec) nextRun(after time.Time) time.Time {
+	if cs.every > 0 {
+		return after.Add(cs.every)
+	}
+
+	// search the wall clock times from the next whole minute on, in UTC
+	// so that each one comes up exactly once, and skip ahead a month, day,
+	// hour or minute at a time until every field matches
+	loc := after.Location()
+	w := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, time.UTC).Add(time.Minute)
+	limit := w.Add(cronSearchLimit)
+	for w.Before(limit) {
+		if cs.month&(1<<uint(w.Month())) == 0 {
+			w = time.Date(w.Year(), w.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !cs.dayMatches(w) {
+			w = time.Date(w.Year(), w.Month(), w.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if cs.hour&(1<<uint(w.Hour())) == 0 {
+			w = time.Date(w.Year(), w.Month(), w.Day(), w.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if cs.minute&(1<<uint(w.Minute())) == 0 {
+			w = w.Add(time.Minute)
+			continue
+		}
+		t := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), 0, 0, loc)
+		if t.Hour() != w.Hour() || t.Minute() != w.Minute() {
+			// the clocks went forward over this time, and time.Date
+			// normalized it to before they did
+			t = t.Add(w.Sub(time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)))
+		}
+		// if the given time is in an hour that the clocks went back over,
+		// a wall clock time after it can be an earlier time
+		if t.After(after) {
+			return t
+		}
+		w = w.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// dayMatches returns true if the given time's day matches the spec's day
+// of month and day of week fields.
+func (cs *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := cs.dom&(1<<uint(t.Day())) != 0
+	dowMatch := cs.dow&(1<<uint(t.Weekday())) != 0
+	if cs.domStar || cs.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsInvalidSpecs(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 500ms",
+		"@every soon",
+		"@fortnightly",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestCronNextRun(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	// 2026-10-16 is a Friday
+	tests := []struct {
+		name  string
+		spec  string
+		after string
+		want  string
+	}{
+		{"next minute", "* * * * *", "2026-10-16 10:07:30", "2026-10-16 10:08:00"},
+		{"minute step", "*/15 * * * *", "2026-10-16 10:07:30", "2026-10-16 10:15:00"},
+		{"strictly after", "*/15 * * * *", "2026-10-16 10:15:00", "2026-10-16 10:30:00"},
+		{"list and range", "0 9-17/4,20 * * *", "2026-10-16 13:00:00", "2026-10-16 17:00:00"},
+		{"weekdays", "0 2 * * 1-5", "2026-10-16 03:00:00", "2026-10-19 02:00:00"},
+		{"7 is Sunday", "0 0 * * 7", "2026-10-16 00:00:00", "2026-10-18 00:00:00"},
+		{"next month", "0 0 1 * *", "2026-10-16 00:00:00", "2026-11-01 00:00:00"},
+		{"next year", "@yearly", "2026-10-16 00:00:00", "2027-01-01 00:00:00"},
+		{"daily", "@daily", "2026-10-16 10:00:00", "2026-10-17 00:00:00"},
+		{"leap day", "0 0 29 2 *", "2026-10-16 00:00:00", "2028-02-29 00:00:00"},
+
+		// if neither day field starts with "*", either can match
+		{"day of month or week: week", "0 0 13 * 5", "2026-10-16 00:00:00", "2026-10-23 00:00:00"},
+		{"day of month or week: month", "0 0 13 * 5", "2026-11-06 00:00:00", "2026-11-13 00:00:00"},
+		// but if one does, even with a step, both must match: an odd day
+		// that is also a Monday
+		{"stepped day of month and week", "0 0 */2 * 1", "2026-10-16 00:00:00", "2026-10-19 00:00:00"},
+		{"stepped day of week and month", "0 0 16 * */2", "2026-10-16 00:00:00", "2027-01-16 00:00:00"},
+
+		{"every", "@every 90m", "2026-10-16 10:07:30", "2026-10-16 11:37:30"},
+	}
+	for _, tc := range tests {
+		cs, err := parseCron(tc.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := cs.nextRun(at(tc.after)); !got.Equal(at(tc.want)) {
+			t.Errorf("%s: expected %q after %s to be due at %s, got %s", tc.name, tc.spec, tc.after, tc.want, got)
+		}
+	}
+}
+
+func TestCronNextRunNeverDue(t *testing.T) {
+	for _, spec := range []string{"0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		cs, err := parseCron(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cs.nextRun(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+			t.Errorf("expected %q never to be due, got %s", spec, got)
+		}
+	}
+}
+
+func TestCronNextRunAcrossDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	edt := time.FixedZone("EDT", -4*60*60)
+	est := time.FixedZone("EST", -5*60*60)
+
+	// clocks went forward from 02:00 EST to 03:00 EDT on 2026-03-08, and
+	// back from 02:00 EDT to 01:00 EST on 2026-11-01
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"skipped time runs an hour later", "30 2 * * *", time.Date(2026, 3, 8, 0, 0, 0, 0, est), time.Date(2026, 3, 8, 3, 30, 0, 0, edt)},
+		{"and as usual the next day", "30 2 * * *", time.Date(2026, 3, 8, 3, 30, 0, 0, edt), time.Date(2026, 3, 9, 2, 30, 0, 0, edt)},
+		{"hourly across the gap", "0 * * * *", time.Date(2026, 3, 8, 1, 0, 0, 0, est), time.Date(2026, 3, 8, 3, 0, 0, 0, edt)},
+		{"repeated time runs once", "30 1 * * *", time.Date(2026, 11, 1, 0, 0, 0, 0, edt), time.Date(2026, 11, 1, 1, 30, 0, 0, edt)},
+		{"and not again when repeated", "30 1 * * *", time.Date(2026, 11, 1, 1, 30, 0, 0, edt), time.Date(2026, 11, 2, 1, 30, 0, 0, est)},
+		{"hourly across the repeat", "0 * * * *", time.Date(2026, 11, 1, 1, 0, 0, 0, edt), time.Date(2026, 11, 1, 2, 0, 0, 0, est)},
+		{"from within the repeat", "45 1 * * *", time.Date(2026, 11, 1, 1, 30, 0, 0, est), time.Date(2026, 11, 2, 1, 45, 0, 0, est)},
+		{"daily across the change", "0 12 * * *", time.Date(2026, 10, 31, 12, 0, 0, 0, edt), time.Date(2026, 11, 1, 12, 0, 0, 0, est)},
+	}
+	for _, tc := range tests {
+		cs, err := parseCron(tc.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cs.nextRun(tc.after.In(loc)); !got.Equal(tc.want) {
+			t.Errorf("%s: expected %q after %s to be due at %s, got %s", tc.name, tc.spec, tc.after, tc.want, got.In(loc))
+		}
+	}
+}
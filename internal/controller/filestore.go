@@ -0,0 +1,548 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/swinslow/peridot-core/internal/logging"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+// FileStore is an embedded Store which keeps the Controller's state in a
+// single file. The file starts with a JSON snapshot of the full state,
+// followed by one JSON line for each record saved or deleted since the
+// snapshot was written, so that each Save call appends only its own record
+// rather than rewriting everything. Protobuf messages within records are
+// marshalled with jsonpb rather than encoding/json, which doesn't handle
+// their oneofs and internal fields. A record that is saved unchanged isn't
+// appended at all. Each line is synced to disk before its Save call
+// returns.
+//
+// Once the appended lines outgrow the snapshot, they are compacted into a
+// new snapshot, which is written to a temporary file, synced and renamed
+// into place, after which the rename is synced too. So a crash leaves
+// either the old file or the new one behind, with at worst a last line
+// that was only partly appended, which is ignored when the file is next
+// read.
+type FileStore struct {
+	// m synchronizes access to the in-memory copy of the state
+	m sync.Mutex
+
+	// path to the state file
+	path string
+
+	// in-memory copy of the state that is written to the file
+	st fileStoreState
+
+	// sizes in bytes of the snapshot at the start of the file, and of the
+	// lines appended after it
+	snapshotSize int64
+	logSize      int64
+}
+
+// fileStoreCompactMinSize is the least number of bytes that must have been
+// appended to the state file before it is compacted, so that a small state
+// file isn't rewritten every few saves.
+const fileStoreCompactMinSize = 1 << 20
+
+// fileStoreState is the format of the snapshot at the start of the state
+// file. Records are kept as already-marshalled JSON, so that the FileStore
+// holds a snapshot of each record as of its most recent Save call rather
+// than a pointer to the Controller's live data.
+type fileStoreState struct {
+	NextJobID       uint64
+	NextJobSetID    uint64
+	Agents          map[string]json.RawMessage
+	JobSetTemplates map[string]json.RawMessage
+	Tenants         map[string]json.RawMessage
+	Schedules       map[string]json.RawMessage
+	Jobs            map[uint64]json.RawMessage
+	JobSets         map[uint64]json.RawMessage
+}
+
+// fileStoreEntry is one line appended to the state file after its
+// snapshot, recording a single change to the state.
+type fileStoreEntry struct {
+	// which kind of record changed: "agent", "template", "tenant",
+	// "schedule", "job", "jobset" or "nextIDs"
+	Kind string
+
+	// the record's name, or for Jobs and JobSets its ID
+	Name string `json:",omitempty"`
+	ID   uint64 `json:",omitempty"`
+
+	// the record itself, or nil if it was deleted
+	Data json.RawMessage `json:",omitempty"`
+
+	// "nextIDs" only: the next Job and JobSet IDs
+	NextJobID    uint64 `json:",omitempty"`
+	NextJobSetID uint64 `json:",omitempty"`
+}
+
+// fileStoreJob wraps a Job so that its unexported fields are persisted
+// along with it, and so that its protobuf fields are marshalled with
+// jsonpb. Its Cfg and Status fields take the place of the Job's own.
+type fileStoreJob struct {
+	*Job
+	Submitted bool
+	Cfg       json.RawMessage
+	Status    json.RawMessage
+}
+
+// fileStoreSchedule wraps a Schedule so that its protobuf Cfg field is
+// marshalled with jsonpb, in place of the Schedule's own.
+type fileStoreSchedule struct {
+	*Schedule
+	Cfg json.RawMessage
+}
+
+// marshalProto marshals a protobuf message to JSON.
+func marshalProto(pb proto.Message) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, pb); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalProto unmarshals a protobuf message from JSON, leaving it
+// unchanged if b is empty. Fields that the message doesn't know, such as
+// ones written by a newer version, are ignored.
+func unmarshalProto(b json.RawMessage, pb proto.Message) error {
+	if len(b) == 0 {
+		return nil
+	}
+	u := &jsonpb.Unmarshaler{AllowUnknownFields: true}
+	return u.Unmarshal(bytes.NewReader(b), pb)
+}
+
+// NewFileStore creates a FileStore which persists state to the file at
+// the given path, creating its parent directories if needed. If the file
+// already exists, its contents are read in so that they can be returned
+// by Load, and any lines appended after its snapshot are compacted into a
+// new snapshot.
+func NewFileStore(path string) (*FileStore, error) {
+	fs := &FileStore{
+		path: path,
+		st: fileStoreState{
+			Agents:          map[string]json.RawMessage{},
+			JobSetTemplates: map[string]json.RawMessage{},
+			Tenants:         map[string]json.RawMessage{},
+			Schedules:       map[string]json.RawMessage{},
+			Jobs:            map[uint64]json.RawMessage{},
+			JobSets:         map[uint64]json.RawMessage{},
+		},
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create directory for state file %s: %v", path, err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		// nothing persisted yet; start with an empty snapshot
+		if err = fs.compact(); err != nil {
+			return nil, err
+		}
+		return fs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read state file %s: %v", path, err)
+	}
+
+	// the snapshot is the first line; state files written before lines
+	// were appended are just a snapshot with no newline. every appended
+	// line ends with a newline, so anything after the last newline was
+	// only partly written.
+	lines := bytes.Split(b, []byte("\n"))
+	err = json.Unmarshal(lines[0], &fs.st)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse state file %s: %v", path, err)
+	}
+	if len(lines) == 1 {
+		// rewrite it with a newline, so that lines can be appended
+		if err = fs.compact(); err != nil {
+			return nil, err
+		}
+		return fs, nil
+	}
+
+	entries := lines[1 : len(lines)-1]
+	for i, line := range entries {
+		e := &fileStoreEntry{}
+		if err = json.Unmarshal(line, e); err == nil {
+			err = fs.apply(e)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse state file %s: line %d: %v", path, i+2, err)
+		}
+	}
+	torn := len(lines[len(lines)-1]) > 0
+	if torn {
+		logging.Infof("ignoring partly-written last line of state file %s", path)
+	}
+	if len(entries) == 0 && !torn {
+		// just a snapshot, so there's nothing to compact
+		fs.snapshotSize = int64(len(b))
+		return fs, nil
+	}
+
+	if err = fs.compact(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Load returns the state that was read from the file, together with
+// anything saved since then.
+func (fs *FileStore) Load() (*StoreState, error) {
+	fs.m.Lock()
+	defer fs.m.Unlock()
+
+	st := &StoreState{
+		NextJobID:    fs.st.NextJobID,
+		NextJobSetID: fs.st.NextJobSetID,
+	}
+
+	for name, b := range fs.st.Agents {
+		ac := &pbc.AgentConfig{}
+		if err := unmarshalProto(b, ac); err != nil {
+			return nil, fmt.Errorf("couldn't parse agent %s: %v", name, err)
+		}
+		st.Agents = append(st.Agents, ac)
+	}
+
+	for name, b := range fs.st.JobSetTemplates {
+		jst := &JobSetTemplate{}
+		if err := json.Unmarshal(b, jst); err != nil {
+			return nil, fmt.Errorf("couldn't parse template %s: %v", name, err)
+		}
+		st.JobSetTemplates = append(st.JobSetTemplates, jst)
+	}
+
+	for name, b := range fs.st.Tenants {
+		tc := &pbc.TenantConfig{}
+		if err := unmarshalProto(b, tc); err != nil {
+			return nil, fmt.Errorf("couldn't parse tenant %s: %v", name, err)
+		}
+		st.Tenants = append(st.Tenants, tc)
+	}
+
+	for name, b := range fs.st.Schedules {
+		fsch := &fileStoreSchedule{Schedule: &Schedule{}}
+		err := json.Unmarshal(b, fsch)
+		if err == nil {
+			err = unmarshalProto(fsch.Cfg, &fsch.Schedule.Cfg)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse schedule %s: %v", name, err)
+		}
+		st.Schedules = append(st.Schedules, fsch.Schedule)
+	}
+
+	for jobID, b := range fs.st.Jobs {
+		fj := &fileStoreJob{}
+		if err := json.Unmarshal(b, fj); err != nil {
+			return nil, fmt.Errorf("couldn't parse job %d: %v", jobID, err)
+		}
+		if fj.Job == nil {
+			return nil, fmt.Errorf("couldn't parse job %d: empty record", jobID)
+		}
+		err := unmarshalProto(fj.Cfg, &fj.Job.Cfg)
+		if err == nil {
+			err = unmarshalProto(fj.Status, &fj.Job.Status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse job %d: %v", jobID, err)
+		}
+		fj.Job.submitted = fj.Submitted
+		st.Jobs = append(st.Jobs, fj.Job)
+	}
+
+	for jobSetID, b := range fs.st.JobSets {
+		js := &JobSet{}
+		if err := json.Unmarshal(b, js); err != nil {
+			return nil, fmt.Errorf("couldn't parse jobSet %d: %v", jobSetID, err)
+		}
+		st.JobSets = append(st.JobSets, js)
+	}
+
+	// return in ID order, so that callers see the same order as the
+	// records were created in
+	sort.Slice(st.Jobs, func(i, j int) bool { return st.Jobs[i].JobID < st.Jobs[j].JobID })
+	sort.Slice(st.JobSets, func(i, j int) bool { return st.JobSets[i].JobSetID < st.JobSets[j].JobSetID })
+
+	return st, nil
+}
+
+// SaveAgent persists the configuration for one Agent.
+func (fs *FileStore) SaveAgent(cfg *pbc.AgentConfig) error {
+	b, err := marshalProto(cfg)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal agent %s: %v", cfg.Name, err)
+	}
+
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if bytes.Equal(fs.st.Agents[cfg.Name], b) {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "agent", Name: cfg.Name, Data: b})
+}
+
+// DeleteAgent removes the configuration for one Agent.
+func (fs *FileStore) DeleteAgent(name string) error {
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if _, ok := fs.st.Agents[name]; !ok {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "agent", Name: name})
+}
+
+// SaveJobSetTemplate persists one JobSetTemplate.
+func (fs *FileStore) SaveJobSetTemplate(jst *JobSetTemplate) error {
+	b, err := json.Marshal(jst)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal template %s: %v", jst.Name, err)
+	}
+
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if bytes.Equal(fs.st.JobSetTemplates[jst.Name], b) {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "template", Name: jst.Name, Data: b})
+}
+
+// SaveTenant persists the configuration for one tenant.
+func (fs *FileStore) SaveTenant(cfg *pbc.TenantConfig) error {
+	b, err := marshalProto(cfg)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal tenant %s: %v", cfg.Name, err)
+	}
+
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if bytes.Equal(fs.st.Tenants[cfg.Name], b) {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "tenant", Name: cfg.Name, Data: b})
+}
+
+// SaveSchedule persists one schedule.
+func (fs *FileStore) SaveSchedule(s *Schedule) error {
+	cfg, err := marshalProto(&s.Cfg)
+	var b []byte
+	if err == nil {
+		b, err = json.Marshal(&fileStoreSchedule{Schedule: s, Cfg: cfg})
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't marshal schedule %s: %v", s.Cfg.Name, err)
+	}
+
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if bytes.Equal(fs.st.Schedules[s.Cfg.Name], b) {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "schedule", Name: s.Cfg.Name, Data: b})
+}
+
+// DeleteSchedule removes one schedule.
+func (fs *FileStore) DeleteSchedule(name string) error {
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if _, ok := fs.st.Schedules[name]; !ok {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "schedule", Name: name})
+}
+
+// SaveJob persists one Job.
+func (fs *FileStore) SaveJob(job *Job) error {
+	cfg, err := marshalProto(&job.Cfg)
+	var status, b []byte
+	if err == nil {
+		status, err = marshalProto(&job.Status)
+	}
+	if err == nil {
+		b, err = json.Marshal(&fileStoreJob{Job: job, Submitted: job.submitted, Cfg: cfg, Status: status})
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't marshal job %d: %v", job.JobID, err)
+	}
+
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if bytes.Equal(fs.st.Jobs[job.JobID], b) {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "job", ID: job.JobID, Data: b})
+}
+
+// SaveJobSet persists one JobSet.
+func (fs *FileStore) SaveJobSet(js *JobSet) error {
+	b, err := json.Marshal(js)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal jobSet %d: %v", js.JobSetID, err)
+	}
+
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if bytes.Equal(fs.st.JobSets[js.JobSetID], b) {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "jobset", ID: js.JobSetID, Data: b})
+}
+
+// SaveNextIDs persists the next Job and JobSet IDs.
+func (fs *FileStore) SaveNextIDs(nextJobID uint64, nextJobSetID uint64) error {
+	fs.m.Lock()
+	defer fs.m.Unlock()
+	if fs.st.NextJobID == nextJobID && fs.st.NextJobSetID == nextJobSetID {
+		return nil
+	}
+	return fs.save(&fileStoreEntry{Kind: "nextIDs", NextJobID: nextJobID, NextJobSetID: nextJobSetID})
+}
+
+// save appends the given entry to the state file and applies it to the
+// in-memory state, and then compacts the file if the appended lines have
+// outgrown its snapshot. The caller must hold fs.m.
+func (fs *FileStore) save(e *fileStoreEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal %s record: %v", e.Kind, err)
+	}
+	b = append(b, '\n')
+
+	f, err := os.OpenFile(fs.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't open state file %s: %v", fs.path, err)
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		// don't leave a partial line behind for later lines to follow
+		f.Truncate(fs.snapshotSize + fs.logSize)
+		f.Close()
+		return fmt.Errorf("couldn't write state file %s: %v", fs.path, err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("couldn't write state file %s: %v", fs.path, err)
+	}
+	fs.logSize += int64(len(b))
+
+	if err = fs.apply(e); err != nil {
+		return err
+	}
+
+	if fs.logSize > fs.snapshotSize && fs.logSize > fileStoreCompactMinSize {
+		return fs.compact()
+	}
+	return nil
+}
+
+// apply applies the given entry to the in-memory state. The caller must
+// hold fs.m, unless it is NewFileStore.
+func (fs *FileStore) apply(e *fileStoreEntry) error {
+	switch e.Kind {
+	case "agent":
+		setNamedRecord(fs.st.Agents, e.Name, e.Data)
+	case "template":
+		setNamedRecord(fs.st.JobSetTemplates, e.Name, e.Data)
+	case "tenant":
+		setNamedRecord(fs.st.Tenants, e.Name, e.Data)
+	case "schedule":
+		setNamedRecord(fs.st.Schedules, e.Name, e.Data)
+	case "job":
+		setIDRecord(fs.st.Jobs, e.ID, e.Data)
+	case "jobset":
+		setIDRecord(fs.st.JobSets, e.ID, e.Data)
+	case "nextIDs":
+		fs.st.NextJobID = e.NextJobID
+		fs.st.NextJobSetID = e.NextJobSetID
+	default:
+		return fmt.Errorf("unknown record kind %q", e.Kind)
+	}
+	return nil
+}
+
+// setNamedRecord sets the record with the given name in m, or deletes it
+// if data is nil.
+func setNamedRecord(m map[string]json.RawMessage, name string, data json.RawMessage) {
+	if data == nil {
+		delete(m, name)
+		return
+	}
+	m[name] = data
+}
+
+// setIDRecord sets the record with the given ID in m, or deletes it if
+// data is nil.
+func setIDRecord(m map[uint64]json.RawMessage, id uint64, data json.RawMessage) {
+	if data == nil {
+		delete(m, id)
+		return
+	}
+	m[id] = data
+}
+
+// compact replaces the state file with a new snapshot of the full state,
+// with nothing appended after it. The caller must hold fs.m, unless it is
+// NewFileStore.
+func (fs *FileStore) compact() error {
+	b, err := json.Marshal(&fs.st)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal state: %v", err)
+	}
+	b = append(b, '\n')
+
+	tmpPath := fs.path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't create state file %s: %v", tmpPath, err)
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't write state file %s: %v", tmpPath, err)
+	}
+
+	err = os.Rename(tmpPath, fs.path)
+	if err != nil {
+		return fmt.Errorf("couldn't replace state file %s: %v", fs.path, err)
+	}
+
+	// and make sure that the rename itself reaches the disk
+	dir, err := os.Open(filepath.Dir(fs.path))
+	if err == nil {
+		err = dir.Sync()
+		dir.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't sync directory of state file %s: %v", fs.path, err)
+	}
+
+	fs.snapshotSize = int64(len(b))
+	fs.logSize = 0
+	return nil
+}
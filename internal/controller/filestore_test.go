@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pba "github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// newTestFileStore creates a FileStore in a new temporary directory, and
+// returns it with the path to its state file.
+func newTestFileStore(t *testing.T) (*FileStore, string) {
+	dir, err := ioutil.TempDir("", "peridot-filestore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "state.json")
+	fs, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fs, path
+}
+
+// fileSize returns the size of the file at path.
+func fileSize(t *testing.T, path string) int64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fi.Size()
+}
+
+func TestFileStoreReloadsSavedRecords(t *testing.T) {
+	fs, path := newTestFileStore(t)
+
+	saves := []error{
+		fs.SaveAgent(&pbc.AgentConfig{Name: "a1", Url: "localhost", Port: 9001}),
+		fs.SaveAgent(&pbc.AgentConfig{Name: "a2", Url: "localhost", Port: 9002}),
+		fs.DeleteAgent("a1"),
+		fs.SaveJob(&Job{JobID: 4, JobSetID: 2, submitted: true}),
+		fs.SaveJobSet(&JobSet{JobSetID: 2, TemplateName: "t", RunStatus: pbs.Status_RUNNING}),
+		fs.SaveJobSet(&JobSet{JobSetID: 2, TemplateName: "t", RunStatus: pbs.Status_STOPPED}),
+		fs.SaveNextIDs(5, 3),
+	}
+	for i, err := range saves {
+		if err != nil {
+			t.Fatalf("save %d: %v", i, err)
+		}
+	}
+
+	fs2, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := fs2.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Agents) != 1 || st.Agents[0].Name != "a2" {
+		t.Errorf("expected only agent a2, got %v", st.Agents)
+	}
+	if len(st.Jobs) != 1 || st.Jobs[0].JobID != 4 || !st.Jobs[0].submitted {
+		t.Errorf("expected submitted job 4, got %v", st.Jobs)
+	}
+	if len(st.JobSets) != 1 || st.JobSets[0].RunStatus != pbs.Status_STOPPED {
+		t.Errorf("expected stopped jobSet 2, got %v", st.JobSets)
+	}
+	if st.NextJobID != 5 || st.NextJobSetID != 3 {
+		t.Errorf("expected next IDs 5 and 3, got %d and %d", st.NextJobID, st.NextJobSetID)
+	}
+
+	// reopening compacts the appended records into the snapshot
+	if fs2.logSize != 0 || fs2.snapshotSize != fileSize(t, path) {
+		t.Errorf("expected compacted file of %d bytes, got snapshot %d and log %d", fileSize(t, path), fs2.snapshotSize, fs2.logSize)
+	}
+}
+
+func TestFileStoreAppendsOnlyChangedRecords(t *testing.T) {
+	fs, path := newTestFileStore(t)
+
+	job := &Job{JobID: 1, JobSetID: 1}
+	if err := fs.SaveJob(job); err != nil {
+		t.Fatal(err)
+	}
+	size := fileSize(t, path)
+
+	// saving the same record again writes nothing
+	if err := fs.SaveJob(job); err != nil {
+		t.Fatal(err)
+	}
+	if got := fileSize(t, path); got != size {
+		t.Errorf("expected unchanged record not to be written; size went from %d to %d", size, got)
+	}
+
+	// but a changed one is appended, without rewriting the rest
+	job.Status.RunStatus = pba.JobRunStatus_RUNNING
+	if err := fs.SaveJob(job); err != nil {
+		t.Fatal(err)
+	}
+	if got := fileSize(t, path); got <= size {
+		t.Errorf("expected changed record to be appended; size went from %d to %d", size, got)
+	}
+	if fs.snapshotSize+fs.logSize != fileSize(t, path) {
+		t.Errorf("expected snapshot %d and log %d to add up to file size %d", fs.snapshotSize, fs.logSize, fileSize(t, path))
+	}
+}
+
+func TestFileStoreIgnoresPartlyWrittenLastLine(t *testing.T) {
+	fs, path := newTestFileStore(t)
+	if err := fs.SaveAgent(&pbc.AgentConfig{Name: "a1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a crash partway through appending a record
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"Kind":"agent","Name":"a2","Da`)
+	f.Close()
+
+	fs2, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("expected partly-written line to be ignored, got %v", err)
+	}
+	st, err := fs2.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Agents) != 1 || st.Agents[0].Name != "a1" {
+		t.Errorf("expected only agent a1, got %v", st.Agents)
+	}
+
+	// and later records can still be appended and read back
+	if err := fs2.SaveAgent(&pbc.AgentConfig{Name: "a3"}); err != nil {
+		t.Fatal(err)
+	}
+	fs3, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, _ = fs3.Load()
+	if len(st.Agents) != 2 {
+		t.Errorf("expected agents a1 and a3, got %v", st.Agents)
+	}
+}
+
+func TestFileStoreRejectsCorruptLine(t *testing.T) {
+	_, path := newTestFileStore(t)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+
+	if _, err := NewFileStore(path); err == nil {
+		t.Error("expected error for corrupt line that isn't the last one")
+	}
+}
+
+func TestFileStoreReadsSnapshotWithoutNewline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peridot-filestore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// as written before records were appended to the file
+	path := filepath.Join(dir, "state.json")
+	err = ioutil.WriteFile(path, []byte(`{"NextJobID":7,"NextJobSetID":3,"Agents":{"a1":{"name":"a1"}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.SaveAgent(&pbc.AgentConfig{Name: "a2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	fs2, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := fs2.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Agents) != 2 || st.NextJobID != 7 {
+		t.Errorf("expected agents a1 and a2 and next job ID 7, got %v and %d", st.Agents, st.NextJobID)
+	}
+}
+
+func TestFileStoreReloadsProtoFields(t *testing.T) {
+	fs, path := newTestFileStore(t)
+
+	job := &Job{JobID: 1, JobSetID: 1}
+	job.Cfg.Jkvs = []*pba.JobConfig_JobKV{{Key: "repo", Value: "a"}}
+	job.Status = pba.StatusReport{RunStatus: pba.JobRunStatus_STOPPED, TimeStarted: 1234, ErrorMessages: "oops"}
+	s := &Schedule{Cfg: pbc.ScheduleConfig{Name: "nightly", Cron: "@daily", Cfgs: []*pbc.JobSetConfig{{Key: "k", Value: "v"}}}, LastJobSetID: 3}
+	saves := []error{
+		fs.SaveJob(job),
+		fs.SaveSchedule(s),
+		fs.SaveAgent(&pbc.AgentConfig{Name: "a1", Kvs: []*pbc.AgentConfig_AgentKV{{Key: "k", Value: "v"}}}),
+		fs.SaveTenant(&pbc.TenantConfig{Name: "team", Weight: 2}),
+	}
+	for i, err := range saves {
+		if err != nil {
+			t.Fatalf("save %d: %v", i, err)
+		}
+	}
+
+	fs2, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := fs2.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Jobs) != 1 {
+		t.Fatalf("expected 1 job, got %v", st.Jobs)
+	}
+	got := st.Jobs[0]
+	if len(got.Cfg.Jkvs) != 1 || got.Cfg.Jkvs[0].Value != "a" || got.Status.TimeStarted != 1234 || got.Status.ErrorMessages != "oops" {
+		t.Errorf("expected job's config and status to be kept, got %v and %v", &got.Cfg, &got.Status)
+	}
+	if len(st.Schedules) != 1 || st.Schedules[0].Cfg.Cron != "@daily" || len(st.Schedules[0].Cfg.Cfgs) != 1 || st.Schedules[0].LastJobSetID != 3 {
+		t.Errorf("expected schedule to be kept, got %v", st.Schedules)
+	}
+	if len(st.Agents) != 1 || len(st.Agents[0].Kvs) != 1 || st.Agents[0].Kvs[0].Value != "v" {
+		t.Errorf("expected agent to be kept, got %v", st.Agents)
+	}
+	if len(st.Tenants) != 1 || st.Tenants[0].Weight != 2 {
+		t.Errorf("expected tenant to be kept, got %v", st.Tenants)
+	}
+}
+
+func TestFileStoreReadsRecordsWrittenWithEncodingJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peridot-filestore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// as written before protobuf messages were marshalled with jsonpb
+	path := filepath.Join(dir, "state.json")
+	err = ioutil.WriteFile(path, []byte(`{"Agents":{"a1":{"name":"a1","port":9001}},"Jobs":{"1":{"JobID":1,"Cfg":{"jkvs":[{"key":"repo","value":"a"}]},"Status":{"runStatus":3,"timeStarted":1234},"Submitted":true}}}`+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := fs.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Agents) != 1 || st.Agents[0].Port != 9001 {
+		t.Errorf("expected agent a1, got %v", st.Agents)
+	}
+	if len(st.Jobs) != 1 || len(st.Jobs[0].Cfg.Jkvs) != 1 || st.Jobs[0].Status.TimeStarted != 1234 || !st.Jobs[0].submitted {
+		t.Errorf("expected submitted job 1 with its config and status, got %v", st.Jobs)
+	}
+}
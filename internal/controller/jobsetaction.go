@@ -14,9 +14,9 @@ import (
 )
 
 // createNewJobSets walks through the current pendingJSRs queue, and creates
-// new JobSets based on the requests found there. It can recursively add new
-// JobSetRequests to the end of the queue and it will handle them in turn
-// before returning.
+// new JobSets based on the requests found there. Requests for the JobSets
+// of "jobset" steps are added to the queue later, by the scheduler, once
+// those steps are reached.
 func (c *Controller) createNewJobSets() {
 	// grab a writer lock
 	c.m.Lock()
@@ -33,6 +33,7 @@ func (c *Controller) createNewJobSets() {
 		} else {
 			jobSetID = c.nextJobSetID
 			c.nextJobSetID++
+			c.saveNextIDs()
 		}
 
 		// first things first, create a new JobSet entry in our jobSets map
@@ -41,6 +42,9 @@ func (c *Controller) createNewJobSets() {
 			TemplateName: jsr.TemplateName,
 			RunStatus:    pbs.Status_STARTUP,
 			HealthStatus: pbs.Health_OK,
+			Priority:     jsr.Priority,
+			Tenant:       jsr.Tenant,
+			ScheduleName: jsr.ScheduleName,
 			TimeStarted:  time.Now(),
 			// leave TimeFinished as zero value
 		}
@@ -48,30 +52,11 @@ func (c *Controller) createNewJobSets() {
 		// also add to active JobSet list
 		c.activeJobSets[js.JobSetID] = js
 
-		// make sure the TemplateName is a template we actually know about
-		jst, ok := c.jobSetTemplates[js.TemplateName]
-		if !ok {
-			// unknown template; error out
-			js.ErrorMessages = fmt.Sprintf("%s is not a known JobSetTemplate name", js.TemplateName)
-			js.RunStatus = pbs.Status_STOPPED
-			js.HealthStatus = pbs.Health_ERROR
-			js.TimeFinished = time.Now()
-			return
-		}
-
-		// copy over configs from JobSetRequest
-		js.Configs = make(map[string]string)
-		for k, v := range jsr.Configs {
-			js.Configs[k] = v
-		}
-
-		// now create steps from template
-		js.Steps = createStepsFromTemplate(js, c.pendingJSRs, jst.Steps)
-
-		// finally, if we have a parentJobSetID / parentJobStepID, then
-		// this JobSet was created as a step within another JobSet.
-		// We should update the parent's step to let it know what the
-		// finally-determined JobSet ID was.
+		// if we have a parentJobSetID / parentJobStepID, then this
+		// JobSet was created as a step within another JobSet. We should
+		// update the parent's step to let it know what the
+		// finally-determined JobSet ID was. we do this first, so that
+		// the parent will see if this JobSet fails below.
 		if jsr.ParentJobSetID != 0 {
 			// find the parent JobSet
 			parentJS, ok := c.jobSets[jsr.ParentJobSetID]
@@ -84,7 +69,8 @@ func (c *Controller) createNewJobSets() {
 				c.runStatus = pbs.Status_STOPPED
 				c.healthStatus = pbs.Health_ERROR
 				c.errorMsg += errMsg
-				return
+				c.saveJobSet(js)
+				continue
 			}
 
 			// find the right step within the parent JobSet's steps
@@ -99,14 +85,38 @@ func (c *Controller) createNewJobSets() {
 				c.runStatus = pbs.Status_STOPPED
 				c.healthStatus = pbs.Health_ERROR
 				c.errorMsg += errMsg
-				return
+				c.saveJobSet(js)
+				continue
 			}
 
 			// if we get here, we're good to update the parent step's SubJobSetID
 			stepToUpdate.SubJobSetID = js.JobSetID
+			c.saveJobSet(parentJS)
 		}
 
+		// make sure the TemplateName is a template we actually know about
+		jst, ok := c.jobSetTemplates[js.TemplateName]
+		if !ok {
+			// unknown template; error out
+			js.ErrorMessages = fmt.Sprintf("%s is not a known JobSetTemplate name", js.TemplateName)
+			js.RunStatus = pbs.Status_STOPPED
+			js.HealthStatus = pbs.Health_ERROR
+			js.TimeFinished = time.Now()
+			c.saveJobSet(js)
+			continue
+		}
+
+		// copy over configs from JobSetRequest
+		js.Configs = make(map[string]string)
+		for k, v := range jsr.Configs {
+			js.Configs[k] = v
+		}
+
+		// and create steps from template
+		js.Steps = createStepsFromTemplate(js, jst.Steps)
+
 		// and we're done with this one!
+		c.saveJobSet(js)
 	}
 
 	// now, dump and recreate pendingJSRs because these have now been handled
@@ -131,8 +141,37 @@ func (c *Controller) updateJobStatus(jr *jobcontroller.JobRecord) {
 		return
 	}
 
-	// update this job's status
+	// a job that has already stopped has had its outcome applied to its
+	// step, so ignore any further records for it. otherwise a repeated
+	// STOPPED record could retry its step a second time.
+	if job.Status.RunStatus == pba.JobRunStatus_STOPPED {
+		return
+	}
+
+	// update this job's status. an error from the JobController itself,
+	// rather than a status reported by the agent, means that the agent
+	// couldn't be reached, that its stream failed or that it stopped
+	// answering heartbeats, unless the stream was closed because the job
+	// timed out or the agent has since been removed.
+	_, agentRegistered := c.agents[job.AgentName]
 	job.Status = jr.Status
+	job.TimedOut = jr.TimedOut
+	job.ConnectionError = jr.Err != nil && !jr.TimedOut && agentRegistered
+	job.LastSeen = jr.LastSeen
+	if jr.Err != nil && !agentRegistered {
+		if job.Status.ErrorMessages != "" {
+			job.Status.ErrorMessages += "\n"
+		}
+		job.Status.ErrorMessages += fmt.Sprintf("agent %s was removed", job.AgentName)
+	}
+
+	// keep track of agents that can't be reached, so that steps picking
+	// from the agents of a given type can avoid them for a while
+	if job.ConnectionError {
+		c.agentUnhealthyUntil[job.AgentName] = time.Now().Add(agentUnhealthyPeriod)
+	} else if agentRegistered {
+		delete(c.agentUnhealthyUntil, job.AgentName)
+	}
 
 	// also update the status of the corresponding step
 	// (runScheduler will be responsible for updating dependent steps)
@@ -141,8 +180,18 @@ func (c *Controller) updateJobStatus(jr *jobcontroller.JobRecord) {
 		// FIXME this shouldn't happen; job with unknown jobSet ID
 		log.Fatalf("failed; job ID %d has unknown job set ID %d", job.JobID, job.JobSetID)
 	}
-	for _, step := range js.Steps {
-		if step.T == StepTypeAgent && step.AgentJobID == job.JobID {
+	// the step may be nested within concurrent steps, so search for it
+	// rather than only looking at the top level
+	step := findStepInSteps(js.Steps, job.JobSetStepID)
+	// if the job was cancelled with CancelJob, its step's outcome was
+	// already decided then, so leave it be. a job whose agent has been
+	// removed can't succeed if retried, so it isn't.
+	if step != nil && step.T == StepTypeAgent && step.AgentJobID == job.JobID &&
+		!(job.Cancelled && (step.RunStatus == pbs.Status_STOPPED || step.RunStatus == pbs.Status_SKIPPED)) {
+		if agentRegistered && shouldRetryStep(js, step, job) {
+			// the step hasn't failed yet, so leave its health alone
+			retryStep(js, step, job)
+		} else {
 			if job.Status.RunStatus == pba.JobRunStatus_STOPPED {
 				step.RunStatus = pbs.Status_STOPPED
 			}
@@ -151,7 +200,14 @@ func (c *Controller) updateJobStatus(jr *jobcontroller.JobRecord) {
 			}
 			if job.Status.HealthStatus == pba.JobHealthStatus_ERROR {
 				step.HealthStatus = pbs.Health_ERROR
+				if step.AllowFailure && step.RunStatus == pbs.Status_STOPPED {
+					js.OutputMessages += fmt.Sprintf("step %d: job %d failed; continuing, as the step is allowed to fail\n", step.StepID, job.JobID)
+				}
 			}
 		}
 	}
+
+	// and persist the updated job and step
+	c.saveJob(job)
+	c.saveJobSet(js)
 }
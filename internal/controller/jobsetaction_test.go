@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/jobcontroller"
+	pba "github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// newJobStatusTestController returns a Controller with the given agents,
+// holding a single running Job for the given step.
+func newJobStatusTestController(t *testing.T, step *Step, agentNames ...string) (*Controller, *Job) {
+	c, _ := newReconcileTestController(t, []*Step{step}, &Job{JobID: 3, JobSetStepID: step.StepID, AgentName: "a1", submitted: true})
+	c.m = &sync.RWMutex{}
+	c.agents = map[string]pbc.AgentConfig{}
+	c.agentUnhealthyUntil = map[string]time.Time{}
+	for _, name := range agentNames {
+		c.agents[name] = pbc.AgentConfig{Name: name}
+	}
+	return c, c.jobs[3]
+}
+
+// failedJobRecord returns a JobRecord for Job 3 that failed with an error
+// from the JobController itself.
+func failedJobRecord() *jobcontroller.JobRecord {
+	return &jobcontroller.JobRecord{
+		JobID: 3,
+		Err:   errors.New("stream failed"),
+		Status: pba.StatusReport{
+			RunStatus:     pba.JobRunStatus_STOPPED,
+			HealthStatus:  pba.JobHealthStatus_ERROR,
+			ErrorMessages: "stream failed",
+		},
+	}
+}
+
+func TestUpdateJobStatusRetriesConnectionError(t *testing.T) {
+	step := &Step{T: StepTypeAgent, JobSetID: 1, StepID: 1, RunStatus: pbs.Status_RUNNING, AgentJobID: 3, Attempts: 1,
+		Retry: &RetryPolicy{MaxAttempts: 2, RetryConnectionErrors: true}}
+	c, job := newJobStatusTestController(t, step, "a1")
+
+	c.updateJobStatus(failedJobRecord())
+	if !job.ConnectionError {
+		t.Error("expected a connection error")
+	}
+	if _, ok := c.agentUnhealthyUntil["a1"]; !ok {
+		t.Error("expected agent to be marked unhealthy")
+	}
+	if step.RunStatus != pbs.Status_STARTUP {
+		t.Errorf("expected step to be retried, got %s", step.RunStatus)
+	}
+}
+
+func TestUpdateJobStatusFailsJobOfRemovedAgent(t *testing.T) {
+	step := &Step{T: StepTypeAgent, JobSetID: 1, StepID: 1, RunStatus: pbs.Status_RUNNING, AgentJobID: 3, Attempts: 1,
+		Retry: &RetryPolicy{MaxAttempts: 2, RetryConnectionErrors: true, RetryAgentErrors: true}}
+	c, job := newJobStatusTestController(t, step)
+
+	c.updateJobStatus(failedJobRecord())
+	if job.ConnectionError {
+		t.Error("expected job of a removed agent not to count as a connection error")
+	}
+	if !strings.Contains(job.Status.ErrorMessages, "agent a1 was removed") {
+		t.Errorf("expected agent removed error, got %q", job.Status.ErrorMessages)
+	}
+	if len(c.agentUnhealthyUntil) != 0 {
+		t.Errorf("expected removed agent not to be marked unhealthy, got %v", c.agentUnhealthyUntil)
+	}
+	if step.RunStatus != pbs.Status_STOPPED || step.HealthStatus != pbs.Health_ERROR {
+		t.Errorf("expected step to fail without a retry, got %s %s", step.RunStatus, step.HealthStatus)
+	}
+}
+
+func TestUpdateJobStatusIgnoresRecordsForStoppedJob(t *testing.T) {
+	step := &Step{T: StepTypeAgent, JobSetID: 1, StepID: 1, RunStatus: pbs.Status_RUNNING, AgentJobID: 3, Attempts: 1,
+		Retry: &RetryPolicy{MaxAttempts: 3, RetryConnectionErrors: true}}
+	c, _ := newJobStatusTestController(t, step, "a1")
+	js := c.jobSets[1]
+
+	c.updateJobStatus(failedJobRecord())
+	retryAfter := step.RetryAfter
+	if step.RunStatus != pbs.Status_STARTUP {
+		t.Fatalf("expected step to be retried, got %s", step.RunStatus)
+	}
+
+	// a repeated record for the same job doesn't retry the step again
+	c.updateJobStatus(failedJobRecord())
+	if n := strings.Count(js.OutputMessages, "retrying in"); n != 1 || step.RetryAfter != retryAfter {
+		t.Errorf("expected a single retry, got %d: %q", n, js.OutputMessages)
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/logging"
+
+	pba "github.com/swinslow/peridot-core/pkg/agent"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// reconcile walks through state that was reloaded from the Store after a
+// controller restart (or left behind when the Controller was stopped), and
+// brings anything that was in flight when the
+// previous controller process died back into a consistent state:
+// 1) submitted Jobs that had not yet stopped have lost their agent stream,
+// so they are marked STOPPED / ERROR, and their Steps are either retried
+// (if their retry policy allows it) or also marked STOPPED / ERROR
+// 2) a Job is only persisted once it has been submitted, together with
+// its Step in the same scheduler pass. if the process died in between,
+// the persisted Step doesn't refer to the Job yet, so it is left to be
+// dispatched again as a new Job; and if it died before the Job was
+// persisted, only the Step remains. either way, the agent may already
+// have received the lost Job, so dispatch is at-least-once across a crash
+// 3) Jobs that were persisted without being submitted, as older versions
+// did, are marked STOPPED, and their Steps are moved back to STARTUP to
+// be dispatched again, even though the Steps were already marked RUNNING.
+// if such a Job has a status from its agent, though, it must have been
+// submitted after all, so it is handled as in 1)
+// 4) "jobset" Steps whose JobSetRequest never became an actual JobSet are
+// marked as not yet submitted, so that a new JobSetRequest is created
+// It returns true if there are active JobSets that should be resumed.
+// It is called from Init, and from tryToStart when restarting a stopped
+// Controller. It does not grab a lock, as it should only be called either
+// before the Controller is started or by a function that already holds
+// a writer lock.
+func (c *Controller) reconcile() bool {
+	now := time.Now()
+
+	for _, job := range c.activeJobs {
+		js, ok := c.jobSets[job.JobSetID]
+		if !ok {
+			// can't find this job's jobSet; nothing else will be able to
+			// update it, so just stop it here
+			job.Status.RunStatus = pba.JobRunStatus_STOPPED
+			job.Status.HealthStatus = pba.JobHealthStatus_ERROR
+			job.Status.TimeFinished = now.Unix()
+			job.Status.ErrorMessages += fmt.Sprintf("unknown jobSet ID %d found on controller restart\n", job.JobSetID)
+			c.saveJob(job)
+			delete(c.activeJobs, job.JobID)
+			continue
+		}
+		step := findStepInSteps(js.Steps, job.JobSetStepID)
+		if step != nil && step.AgentJobID != job.JobID {
+			// the step was persisted before it was given this job, so
+			// it will be started again as a new job
+			step = nil
+		}
+
+		// a job that its agent has reported on must have been submitted
+		submitted := job.submitted || job.Status.RunStatus != pba.JobRunStatus_STARTUP
+
+		job.Status.RunStatus = pba.JobRunStatus_STOPPED
+		job.Status.TimeFinished = now.Unix()
+		if submitted {
+			// the agent may have been running this job, but we have lost
+			// the stream and can't tell what happened to it
+			logging.Infof("marking job %d as failed: agent stream lost on controller restart", job.JobID)
+			job.Status.HealthStatus = pba.JobHealthStatus_ERROR
+			job.Status.ErrorMessages += "agent stream lost: controller restarted while job was in progress\n"
+			job.ConnectionError = true
+			if step != nil && shouldRetryStep(js, step, job) {
+				retryStep(js, step, job)
+			} else if step != nil {
+				step.RunStatus = pbs.Status_STOPPED
+				step.HealthStatus = pbs.Health_ERROR
+			}
+		} else {
+			// the agent never saw this job, so it is safe to run the
+			// step again as a new job
+			logging.Infof("requeueing step %d in jobSet %d: job %d was never submitted before controller restart", job.JobSetStepID, job.JobSetID, job.JobID)
+			job.Status.OutputMessages += "never submitted to agent before controller restart; step was requeued as a new job\n"
+			if step != nil {
+				step.RunStatus = pbs.Status_STARTUP
+				step.AgentJobID = 0
+				// and it doesn't count as an attempt
+				if step.Attempts > 0 {
+					step.Attempts--
+				}
+			}
+		}
+		c.saveJob(job)
+		c.saveJobSet(js)
+	}
+
+	for _, js := range c.activeJobSets {
+		if resetLostJobSetRequests(js.Steps) {
+			c.saveJobSet(js)
+		}
+	}
+
+	return len(c.activeJobSets) > 0
+}
+
+// resetLostJobSetRequests recursively walks through the given steps and
+// marks any "jobset" step whose JobSetRequest was submitted, but which
+// never received a JobSet ID, as not yet submitted. It returns true if
+// any step was changed.
+func resetLostJobSetRequests(steps []*Step) bool {
+	changed := false
+	for _, step := range steps {
+		switch step.T {
+		case StepTypeJobSet:
+			if step.RunStatus != pbs.Status_STOPPED && step.SubJobSetRequestSubmitted && step.SubJobSetID == 0 {
+				step.SubJobSetRequestSubmitted = false
+				changed = true
+			}
+		case StepTypeConcurrent, StepTypeForEach:
+			if resetLostJobSetRequests(step.ConcurrentSteps) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"testing"
+
+	pba "github.com/swinslow/peridot-core/pkg/agent"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// newReconcileTestController returns a Controller holding the given Jobs
+// and a single JobSet with the given steps, as if reloaded from a Store.
+func newReconcileTestController(t *testing.T, steps []*Step, jobs ...*Job) (*Controller, *JobSet) {
+	fs, _ := newTestFileStore(t)
+	js := &JobSet{JobSetID: 1, RunStatus: pbs.Status_RUNNING, HealthStatus: pbs.Health_OK, Steps: steps}
+	c := &Controller{
+		store:         fs,
+		jobs:          map[uint64]*Job{},
+		activeJobs:    map[uint64]*Job{},
+		jobSets:       map[uint64]*JobSet{1: js},
+		activeJobSets: map[uint64]*JobSet{1: js},
+	}
+	for _, job := range jobs {
+		job.JobSetID = 1
+		c.jobs[job.JobID] = job
+		c.activeJobs[job.JobID] = job
+	}
+	return c, js
+}
+
+func TestReconcileFailsSubmittedJob(t *testing.T) {
+	step := &Step{T: StepTypeAgent, JobSetID: 1, StepID: 1, RunStatus: pbs.Status_RUNNING, AgentJobID: 3, Attempts: 1}
+	job := &Job{JobID: 3, JobSetStepID: 1, submitted: true, Status: pba.StatusReport{RunStatus: pba.JobRunStatus_RUNNING}}
+	c, _ := newReconcileTestController(t, []*Step{step}, job)
+
+	c.reconcile()
+	if job.Status.RunStatus != pba.JobRunStatus_STOPPED || job.Status.HealthStatus != pba.JobHealthStatus_ERROR || !job.ConnectionError {
+		t.Errorf("expected job to fail with a connection error, got %s %s", job.Status.RunStatus, job.Status.HealthStatus)
+	}
+	if step.RunStatus != pbs.Status_STOPPED || step.HealthStatus != pbs.Health_ERROR {
+		t.Errorf("expected step to fail, got %s %s", step.RunStatus, step.HealthStatus)
+	}
+}
+
+func TestReconcileRequeuesStepOfUnsubmittedJob(t *testing.T) {
+	// the step was already marked RUNNING along with its job, but the job
+	// never reached its agent
+	step := &Step{T: StepTypeAgent, JobSetID: 1, StepID: 1, RunStatus: pbs.Status_RUNNING, AgentJobID: 3, Attempts: 1}
+	job := &Job{JobID: 3, JobSetStepID: 1, Status: pba.StatusReport{RunStatus: pba.JobRunStatus_STARTUP}}
+	c, _ := newReconcileTestController(t, []*Step{step}, job)
+
+	c.reconcile()
+	if job.Status.RunStatus != pba.JobRunStatus_STOPPED || job.Status.HealthStatus == pba.JobHealthStatus_ERROR {
+		t.Errorf("expected job to stop without an error, got %s %s", job.Status.RunStatus, job.Status.HealthStatus)
+	}
+	if step.RunStatus != pbs.Status_STARTUP || step.AgentJobID != 0 || step.Attempts != 0 {
+		t.Errorf("expected step to be requeued, got %s with job %d after %d attempts", step.RunStatus, step.AgentJobID, step.Attempts)
+	}
+}
+
+func TestReconcileTreatsReportedJobAsSubmitted(t *testing.T) {
+	// not recorded as submitted, but its agent has reported on it
+	step := &Step{T: StepTypeAgent, JobSetID: 1, StepID: 1, RunStatus: pbs.Status_RUNNING, AgentJobID: 3, Attempts: 1}
+	job := &Job{JobID: 3, JobSetStepID: 1, Status: pba.StatusReport{RunStatus: pba.JobRunStatus_RUNNING}}
+	c, _ := newReconcileTestController(t, []*Step{step}, job)
+
+	c.reconcile()
+	if job.Status.HealthStatus != pba.JobHealthStatus_ERROR || !job.ConnectionError {
+		t.Errorf("expected job to fail with a connection error, got %s", job.Status.HealthStatus)
+	}
+	if step.RunStatus != pbs.Status_STOPPED {
+		t.Errorf("expected step to fail rather than be requeued, got %s", step.RunStatus)
+	}
+}
+
+func TestReconcileLeavesStepPersistedBeforeItsJob(t *testing.T) {
+	// the job was persisted, but its step was last persisted while it
+	// was still waiting to start
+	step := &Step{T: StepTypeAgent, JobSetID: 1, StepID: 1, RunStatus: pbs.Status_STARTUP}
+	job := &Job{JobID: 3, JobSetStepID: 1, submitted: true, Status: pba.StatusReport{RunStatus: pba.JobRunStatus_STARTUP}}
+	c, _ := newReconcileTestController(t, []*Step{step}, job)
+
+	if !c.reconcile() {
+		t.Error("expected jobSet to be resumed")
+	}
+	if job.Status.RunStatus != pba.JobRunStatus_STOPPED || job.Status.HealthStatus != pba.JobHealthStatus_ERROR {
+		t.Errorf("expected lost job to fail, got %s %s", job.Status.RunStatus, job.Status.HealthStatus)
+	}
+	if step.RunStatus != pbs.Status_STARTUP || step.HealthStatus == pbs.Health_ERROR {
+		t.Errorf("expected step to be left to start again, got %s %s", step.RunStatus, step.HealthStatus)
+	}
+}
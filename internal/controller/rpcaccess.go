@@ -4,13 +4,20 @@ package controller
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/swinslow/peridot-core/internal/jobcontroller"
+	pba "github.com/swinslow/peridot-core/pkg/agent"
 	pbc "github.com/swinslow/peridot-core/pkg/controller"
 	pbs "github.com/swinslow/peridot-core/pkg/status"
 )
 
 // Start tries to start the Controller, and returns error message explaining
-// why not if it can't.
+// why not if it can't. A Controller that has been stopped can be started
+// again; its history is kept and its Job and JobSet IDs continue on from
+// where they left off.
 func (c *Controller) Start() error {
 	// do NOT grab a writer lock here, the controller's own Start function
 	// will get one and will return the error result to us
@@ -24,33 +31,394 @@ func (c *Controller) GetStatus() (pbs.Status, pbs.Health, string, string) {
 	return c.runStatus, c.healthStatus, c.outputMsg, c.errorMsg
 }
 
-// Stop tries to stop the Controller.
+// Stop tries to stop the Controller. It returns once the Controller has
+// moved to STOPPED, after which it can be started again with Start.
 func (c *Controller) Stop() {
-	// do not grab a lock; we're just hitting the cancel button
-	c.controllerCancel()
+	// grab a reader lock, just long enough to check that we're running
+	c.m.RLocker().Lock()
+	if c.runStatus != pbs.Status_RUNNING {
+		c.m.RLocker().Unlock()
+		return
+	}
+	controllerCancel := c.controllerCancel
+	loopDone := c.loopDone
+	c.m.RLocker().Unlock()
+
+	// hit the cancel button, and wait for the processor loop to exit
+	controllerCancel()
+	<-loopDone
+}
+
+// drainPollInterval is how often DrainAndStop checks whether all active
+// Jobs have finished.
+const drainPollInterval = 250 * time.Millisecond
+
+// drainCancelGracePeriod is how long DrainAndStop waits, after cancelling
+// the Jobs that are still running at its deadline, for their agents to
+// report that they have stopped.
+const drainCancelGracePeriod = 5 * time.Second
+
+// DrainAndStop stops the Controller gracefully. It immediately stops
+// accepting new JobSets and stops starting new Jobs, then waits up to
+// the given timeout for active Jobs to finish before stopping the
+// Controller. Jobs that are still running at the deadline are cancelled
+// on their agents and marked as failed; any whose agents haven't stopped
+// them within drainCancelGracePeriod have their streams closed. It
+// returns the IDs of the Jobs that were cancelled, and of the JobSets
+// that those Jobs belonged to. Other unfinished JobSets are left active
+// so that they can be resumed when the Controller is next started.
+func (c *Controller) DrainAndStop(timeout time.Duration) ([]uint64, []uint64) {
+	// grab a writer lock to close off new JobSets and Jobs
+	c.m.Lock()
+	if c.runStatus != pbs.Status_RUNNING {
+		// nothing is running, so there's nothing to drain
+		c.m.Unlock()
+		return []uint64{}, []uint64{}
+	}
+	c.openForJobSetRequests = false
+	c.draining = true
+	c.outputMsg += "draining: not accepting new JobSets or starting new Jobs\n"
+	controllerCancel := c.controllerCancel
+	loopDone := c.loopDone
+	c.m.Unlock()
+
+	// wait until no Jobs are running, or until we reach the deadline
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	waiting := true
+	for waiting && c.countRunningJobs() > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			waiting = false
+		case <-loopDone:
+			waiting = false
+		}
+	}
+
+	// cancel whatever is still running, and give the agents a chance to
+	// stop those Jobs and report back while the processor loop is still
+	// around to hear them
+	interruptedJobIDs := c.cancelJobsForDrain()
+	if len(interruptedJobIDs) > 0 {
+		grace := time.After(drainCancelGracePeriod)
+		waiting = true
+		for waiting && c.countRunningJobs() > 0 {
+			select {
+			case <-ticker.C:
+			case <-grace:
+				waiting = false
+			case <-loopDone:
+				waiting = false
+			}
+		}
+	}
+
+	// stop the processor loop, and wait for it to exit so that no more
+	// JobRecord updates will come in. this also closes the streams of
+	// any Jobs whose agents didn't stop them in time.
+	controllerCancel()
+	<-loopDone
+
+	// grab a writer lock, and report on whatever remains
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	jobSetsWithInterruptedJobs := map[uint64]bool{}
+	for _, jobID := range interruptedJobIDs {
+		if job, ok := c.jobs[jobID]; ok {
+			jobSetsWithInterruptedJobs[job.JobSetID] = true
+		}
+	}
+	for _, job := range c.activeJobs {
+		if job.Status.RunStatus == pba.JobRunStatus_STOPPED {
+			continue
+		}
+		job.Status.RunStatus = pba.JobRunStatus_STOPPED
+		job.Status.HealthStatus = pba.JobHealthStatus_ERROR
+		job.Status.TimeFinished = time.Now().Unix()
+		job.Status.ErrorMessages += "interrupted: controller stopped before job finished\n"
+		if js, ok := c.jobSets[job.JobSetID]; ok {
+			if step := findStepInSteps(js.Steps, job.JobSetStepID); step != nil {
+				step.RunStatus = pbs.Status_STOPPED
+				step.HealthStatus = pbs.Health_ERROR
+			}
+		}
+		// no Jobs start while draining, so this one was already
+		// cancelled and listed above
+		c.saveJob(job)
+	}
+
+	// bring JobSet statuses up to date now that the cancelled Jobs have
+	// stopped; updateJobSetStatusForJob also handles the parents of
+	// sub-JobSets, since determineStepStatuses looks up their status
+	for jobID, job := range c.activeJobs {
+		if job.Status.RunStatus == pba.JobRunStatus_STOPPED {
+			c.updateJobSetStatusForJob(job)
+			delete(c.activeJobs, jobID)
+		}
+	}
+
+	for jobSetID, js := range c.activeJobSets {
+		if js.RunStatus == pbs.Status_STOPPED {
+			// finished, either normally or because of a cancelled Job
+			delete(c.activeJobSets, jobSetID)
+		}
+		c.saveJobSet(js)
+	}
+
+	// only report the JobSets that had Jobs interrupted; any others that
+	// haven't finished stay active, to be resumed on the next Start
+	interruptedJobSetIDs := []uint64{}
+	for jobSetID := range jobSetsWithInterruptedJobs {
+		js, ok := c.jobSets[jobSetID]
+		if !ok {
+			continue
+		}
+		if js.RunStatus == pbs.Status_STOPPED && js.TimeFinished.IsZero() {
+			js.TimeFinished = time.Now()
+		}
+		js.ErrorMessages += "interrupted: controller stopped before jobSet finished\n"
+		c.saveJobSet(js)
+		interruptedJobSetIDs = append(interruptedJobSetIDs, jobSetID)
+	}
+
+	c.draining = false
+	sort.Slice(interruptedJobIDs, func(i, j int) bool { return interruptedJobIDs[i] < interruptedJobIDs[j] })
+	sort.Slice(interruptedJobSetIDs, func(i, j int) bool { return interruptedJobSetIDs[i] < interruptedJobSetIDs[j] })
+	return interruptedJobIDs, interruptedJobSetIDs
+}
+
+// cancelJobsForDrain asks the agents of all active Jobs that have not yet
+// stopped to cancel them, and marks their steps as failed, as CancelJob
+// does. It returns the IDs of those Jobs, including any that were already
+// being cancelled.
+func (c *Controller) cancelJobsForDrain() []uint64 {
+	// grab a writer lock
+	c.m.Lock()
+
+	jobIDs := []uint64{}
+	toCancel := []uint64{}
+	for jobID, job := range c.activeJobs {
+		if job.Status.RunStatus == pba.JobRunStatus_STOPPED {
+			continue
+		}
+		jobIDs = append(jobIDs, jobID)
+		if job.Cancelled {
+			// its agent has already been asked to cancel it
+			continue
+		}
+		job.Cancelled = true
+		if js, ok := c.jobSets[job.JobSetID]; ok {
+			step := findStepInSteps(js.Steps, job.JobSetStepID)
+			if step != nil && step.T == StepTypeAgent && step.AgentJobID == job.JobID {
+				step.RunStatus = pbs.Status_STOPPED
+				step.HealthStatus = pbs.Health_ERROR
+			}
+			c.saveJobSet(js)
+		}
+		c.saveJob(job)
+		toCancel = append(toCancel, jobID)
+	}
+
+	inJobCancelStream, loopDone := c.getJobCancelChannels()
+	c.m.Unlock()
+
+	for _, jobID := range toCancel {
+		sendJobCancel(inJobCancelStream, loopDone, jobID)
+	}
+	return jobIDs
 }
 
-// AddAgent asks the Controller to add the requested new agent,
-// prior to starting the JobController. It returns nil if the agent
-// is added to the configuration structure for JobController, or a
-// non-nil error if unsuccessful.
+// countRunningJobs returns the number of active Jobs that have not yet
+// stopped.
+func (c *Controller) countRunningJobs() int {
+	// grab a reader lock
+	c.m.RLocker().Lock()
+	defer c.m.RLocker().Unlock()
+
+	n := 0
+	for _, job := range c.activeJobs {
+		if job.Status.RunStatus != pba.JobRunStatus_STOPPED {
+			n++
+		}
+	}
+	return n
+}
+
+// AddAgent asks the Controller to add the requested new agent. It can be
+// called either before or after the Controller is started; if the
+// Controller is running, the new agent is also passed along to the
+// JobController so that it is available for new Jobs. It returns nil if
+// the agent is added, or a non-nil error if unsuccessful.
 func (c *Controller) AddAgent(cfg *pbc.AgentConfig) error {
+	if err := c.checkResourceClass(cfg.ResourceClass); err != nil {
+		return fmt.Errorf("agent %s: %v", cfg.Name, err)
+	}
+	if cfg.JobTimeoutMillis < 0 {
+		return fmt.Errorf("agent %s: job timeout must not be negative", cfg.Name)
+	}
+
+	// serialize with other agent changes until the JobController is told
+	c.agentUpdateM.Lock()
+	defer c.agentUpdateM.Unlock()
+
 	// grab a writer lock; we cannot unlock after we check on availability
 	c.m.Lock()
-	defer c.m.Unlock()
 
 	// first check whether an agent with this name is already registered
 	_, ok := c.agents[cfg.Name]
 	if ok {
 		// an agent already exists in the config with this name; error out
+		c.m.Unlock()
 		return fmt.Errorf("agent with name %s is already registered", cfg.Name)
 	}
 
 	// name is available, so we'll register it
 	c.agents[cfg.Name] = *cfg
+	c.saveAgent(cfg)
+	inAgentStream, loopDone := c.getAgentUpdateChannels()
+	c.m.Unlock()
+
+	// and tell the JobController, if it is running, and then the
+	// scheduler, as steps may be waiting for this agent
+	sendAgentUpdate(inAgentStream, loopDone, jobcontroller.AgentUpdate{Ref: getAgentRef(cfg)})
+	c.wakeScheduler()
 	return nil
 }
 
+// UpdateAgent asks the Controller to replace the configuration of an
+// existing agent. If the Controller is running, the change is passed along
+// to the JobController; it applies to new Jobs, while Jobs that are already
+// running on the agent are not affected. It returns nil if the agent is
+// updated, or a non-nil error if unsuccessful.
+func (c *Controller) UpdateAgent(cfg *pbc.AgentConfig) error {
+	if err := c.checkResourceClass(cfg.ResourceClass); err != nil {
+		return fmt.Errorf("agent %s: %v", cfg.Name, err)
+	}
+	if cfg.JobTimeoutMillis < 0 {
+		return fmt.Errorf("agent %s: job timeout must not be negative", cfg.Name)
+	}
+
+	// serialize with other agent changes until the JobController is told
+	c.agentUpdateM.Lock()
+	defer c.agentUpdateM.Unlock()
+
+	// grab a writer lock
+	c.m.Lock()
+
+	// check whether an agent with this name is registered
+	_, ok := c.agents[cfg.Name]
+	if !ok {
+		c.m.Unlock()
+		return fmt.Errorf("no agent found with name %s", cfg.Name)
+	}
+
+	// replace its configuration
+	c.agents[cfg.Name] = *cfg
+	c.saveAgent(cfg)
+	inAgentStream, loopDone := c.getAgentUpdateChannels()
+	c.m.Unlock()
+
+	// and tell the JobController, if it is running, and then the
+	// scheduler, as steps may be waiting for this agent
+	sendAgentUpdate(inAgentStream, loopDone, jobcontroller.AgentUpdate{Ref: getAgentRef(cfg)})
+	c.wakeScheduler()
+	return nil
+}
+
+// RemoveAgent asks the Controller to remove the agent with the given name.
+// It will refuse if the agent has active Jobs, unless force is true, in
+// which case those Jobs are left to finish but no new Jobs will be started
+// on the agent. Steps that name the agent and haven't started yet, now or
+// in JobSets started later from templates that still name it, fail with
+// an "agent removed" error and are not retried. It returns nil if the
+// agent is removed, or a non-nil error if unsuccessful.
+func (c *Controller) RemoveAgent(agentName string, force bool) error {
+	// serialize with other agent changes until the JobController is told
+	c.agentUpdateM.Lock()
+	defer c.agentUpdateM.Unlock()
+
+	// grab a writer lock
+	c.m.Lock()
+
+	// check whether an agent with this name is registered
+	_, ok := c.agents[agentName]
+	if !ok {
+		c.m.Unlock()
+		return fmt.Errorf("no agent found with name %s", agentName)
+	}
+
+	// check whether it has any active jobs
+	if !force {
+		activeJobIDs := []uint64{}
+		for jobID, job := range c.activeJobs {
+			if job.AgentName == agentName && job.Status.RunStatus != pba.JobRunStatus_STOPPED {
+				activeJobIDs = append(activeJobIDs, jobID)
+			}
+		}
+		if len(activeJobIDs) > 0 {
+			c.m.Unlock()
+			sort.Slice(activeJobIDs, func(i, j int) bool { return activeJobIDs[i] < activeJobIDs[j] })
+			return fmt.Errorf("agent with name %s has active jobs %v", agentName, activeJobIDs)
+		}
+	}
+
+	// remove it
+	delete(c.agents, agentName)
+	delete(c.agentUnhealthyUntil, agentName)
+	c.deleteAgent(agentName)
+	inAgentStream, loopDone := c.getAgentUpdateChannels()
+	c.m.Unlock()
+
+	// and tell the JobController, if it is running
+	au := jobcontroller.AgentUpdate{
+		Ref:    jobcontroller.AgentRef{Name: agentName},
+		Remove: true,
+	}
+	sendAgentUpdate(inAgentStream, loopDone, au)
+	c.wakeScheduler()
+	return nil
+}
+
+// getAgentUpdateChannels returns the channels needed to send an
+// AgentUpdate to the JobController, or nil channels if the Controller is
+// not running. It does not grab a lock, as callers should already hold one.
+func (c *Controller) getAgentUpdateChannels() (chan<- jobcontroller.AgentUpdate, <-chan struct{}) {
+	if c.runStatus != pbs.Status_RUNNING {
+		return nil, nil
+	}
+	return c.inAgentStream, c.loopDone
+}
+
+// sendAgentUpdate sends an AgentUpdate to the JobController, unless
+// inAgentStream is nil (because the Controller wasn't running) or the
+// Controller stops first. It should be called without holding the
+// Controller's lock, since the JobController may be waiting for the
+// Controller to read a JobRecord.
+func sendAgentUpdate(inAgentStream chan<- jobcontroller.AgentUpdate, loopDone <-chan struct{}, au jobcontroller.AgentUpdate) {
+	if inAgentStream == nil {
+		return
+	}
+	select {
+	case inAgentStream <- au:
+	case <-loopDone:
+	}
+}
+
+// wakeScheduler tells the jobSetProcessorLoop that an agent was added,
+// changed or removed, or that a schedule was added or resumed, so that it
+// runs the scheduler again. It does not block; if a wake-up is already
+// pending, that one will do. It does not need a lock, as the channel is
+// only created once, by Init.
+func (c *Controller) wakeScheduler() {
+	select {
+	case c.schedulerWake <- struct{}{}:
+	default:
+	}
+}
+
 // GetAgent returns config information about the Agent with the given name,
 // or error if not found. It does not provide status info (e.g., is the
 // Agent running?) since that would be better addressed by checking the
@@ -88,16 +456,93 @@ func (c *Controller) GetAllAgents() []*pbc.AgentConfig {
 	return cfgs
 }
 
+// SetTenant adds or replaces the configuration for a tenant. The new
+// weight and guaranteed minimum apply the next time Jobs are started. It
+// returns an error if the tenant has no name.
+func (c *Controller) SetTenant(cfg *pbc.TenantConfig) error {
+	if cfg.Name == "" {
+		return fmt.Errorf("tenant must have a name")
+	}
+
+	// grab a writer lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.tenants[cfg.Name] = *cfg
+	c.saveTenant(cfg)
+	return nil
+}
+
+// GetAllTenants returns details for all configured tenants, and for any
+// other tenants that own active JobSets, including how many of their Jobs
+// are running and how many of their steps are waiting to start.
+func (c *Controller) GetAllTenants() []*pbc.TenantDetails {
+	// grab a reader lock
+	c.m.RLocker().Lock()
+	defer c.m.RLocker().Unlock()
+
+	tds := map[string]*pbc.TenantDetails{}
+	getDetails := func(tenant string) *pbc.TenantDetails {
+		td, ok := tds[tenant]
+		if !ok {
+			// make a copy -- don't return the pointer to the actual record
+			tc := c.tenants[tenant]
+			tc.Name = tenant
+			tc.Weight = getTenantWeight(tc)
+			td = &pbc.TenantDetails{Cfg: &tc}
+			tds[tenant] = td
+		}
+		return td
+	}
+
+	for tenant := range c.tenants {
+		getDetails(tenant)
+	}
+	for _, js := range c.activeJobSets {
+		getDetails(js.Tenant).ActiveJobSets++
+	}
+	for _, job := range c.activeJobs {
+		if js, ok := c.jobSets[job.JobSetID]; ok {
+			getDetails(js.Tenant).RunningJobs++
+		}
+	}
+	for tenant, n := range c.tenantQueuedSteps {
+		getDetails(tenant).QueuedSteps = uint32(n)
+	}
+
+	details := []*pbc.TenantDetails{}
+	for _, td := range tds {
+		details = append(details, td)
+	}
+	return details
+}
+
 func cloneStepTemplate(inSteps []*StepTemplate) []*StepTemplate {
 	steps := []*StepTemplate{}
 
 	for _, inStep := range inSteps {
-		newStep := &StepTemplate{T: inStep.T}
+		newStep := &StepTemplate{
+			T:            inStep.T,
+			Condition:    cloneStepCondition(inStep.Condition),
+			AllowFailure: inStep.AllowFailure,
+			Name:         inStep.Name,
+			DependsOn:    append([]string(nil), inStep.DependsOn...),
+		}
 		switch newStep.T {
 		case StepTypeAgent:
 			newStep.AgentName = inStep.AgentName
+			newStep.AgentType = inStep.AgentType
+			newStep.PoolStrategy = inStep.PoolStrategy
+			newStep.Retry = cloneRetryPolicy(inStep.Retry)
+			newStep.Timeout = inStep.Timeout
+			newStep.ResourceClass = inStep.ResourceClass
 		case StepTypeJobSet:
 			newStep.JSTemplateName = inStep.JSTemplateName
+		case StepTypeForEach:
+			newStep.JSTemplateName = inStep.JSTemplateName
+			newStep.ForEachConfigKey = inStep.ForEachConfigKey
+			newStep.ForEachItemKey = inStep.ForEachItemKey
+			newStep.ForEachMaxParallel = inStep.ForEachMaxParallel
 		case StepTypeConcurrent:
 			newStep.ConcurrentStepTemplates = cloneStepTemplate(inStep.ConcurrentStepTemplates)
 		}
@@ -107,6 +552,453 @@ func cloneStepTemplate(inSteps []*StepTemplate) []*StepTemplate {
 	return steps
 }
 
+// cloneRetryPolicy returns a copy of the RetryPolicy, or nil if it is nil.
+func cloneRetryPolicy(rp *RetryPolicy) *RetryPolicy {
+	if rp == nil {
+		return nil
+	}
+	newRp := *rp
+	return &newRp
+}
+
+// cloneStepCondition returns a copy of the StepCondition, or nil if it is
+// nil.
+func cloneStepCondition(cond *StepCondition) *StepCondition {
+	if cond == nil {
+		return nil
+	}
+	newCond := *cond
+	newCond.StepOutcomes = append([]StepOutcome(nil), cond.StepOutcomes...)
+	return &newCond
+}
+
+// AddSchedule adds a new schedule, which starts a JobSet from the named
+// JobSetTemplate each time its cron spec is due. It returns an error if
+// a schedule with that name already exists, if the cron spec is invalid
+// or never matches, or if the template is unknown.
+func (c *Controller) AddSchedule(cfg *pbc.ScheduleConfig) error {
+	if cfg.Name == "" {
+		return fmt.Errorf("schedule must have a name")
+	}
+	if _, ok := pbc.OverlapPolicy_name[int32(cfg.Overlap)]; !ok {
+		return fmt.Errorf("unknown overlap policy %d", cfg.Overlap)
+	}
+	cs, err := parseCron(cfg.Cron)
+	if err != nil {
+		return err
+	}
+	nextRun := cs.nextRun(time.Now())
+	if nextRun.IsZero() {
+		return fmt.Errorf("cron spec %q never matches", cfg.Cron)
+	}
+
+	// grab a writer lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if _, ok := c.schedules[cfg.Name]; ok {
+		return fmt.Errorf("schedule with name %s already exists", cfg.Name)
+	}
+	if _, ok := c.jobSetTemplates[cfg.JstName]; !ok {
+		return fmt.Errorf("%s is not a known JobSetTemplate name", cfg.JstName)
+	}
+
+	s := &Schedule{Cfg: *cfg, NextRun: nextRun}
+	c.schedules[cfg.Name] = s
+	c.saveSchedule(s)
+	c.wakeScheduler()
+	return nil
+}
+
+// GetAllSchedules returns details for all schedules, including the IDs of
+// the JobSets that each has started which haven't yet stopped.
+func (c *Controller) GetAllSchedules() []*pbc.ScheduleDetails {
+	// grab a reader lock
+	c.m.RLocker().Lock()
+	defer c.m.RLocker().Unlock()
+
+	details := []*pbc.ScheduleDetails{}
+	for _, s := range c.getSchedulesInOrder() {
+		// make a copy -- don't return the pointer to the actual record
+		cfg := s.Cfg
+		sd := &pbc.ScheduleDetails{
+			Cfg:             &cfg,
+			Paused:          s.Paused,
+			LastJobSetID:    s.LastJobSetID,
+			Queued:          s.Queued,
+			ActiveJobSetIDs: c.getActiveJobSetIDsForSchedule(s.Cfg.Name),
+		}
+		if !s.NextRun.IsZero() {
+			sd.NextRun = s.NextRun.Unix()
+		}
+		if !s.LastRun.IsZero() {
+			sd.LastRun = s.LastRun.Unix()
+		}
+		details = append(details, sd)
+	}
+	return details
+}
+
+// PauseSchedule pauses or resumes the schedule with the given name. A
+// paused schedule starts no JobSets, and drops any queued run; once it is
+// resumed, it is next due at the first time its cron spec matches from
+// then on.
+func (c *Controller) PauseSchedule(name string, paused bool) error {
+	// grab a writer lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	s, ok := c.schedules[name]
+	if !ok {
+		return fmt.Errorf("no schedule found with name %s", name)
+	}
+	if paused == s.Paused {
+		return nil
+	}
+
+	s.Paused = paused
+	s.Queued = false
+	s.NextRun = time.Time{}
+	if !paused {
+		s.NextRun = getNextScheduleRun(s, time.Now())
+		c.wakeScheduler()
+	}
+	c.saveSchedule(s)
+	return nil
+}
+
+// DeleteSchedule removes the schedule with the given name. JobSets that it
+// has already started carry on, and still record its name.
+func (c *Controller) DeleteSchedule(name string) error {
+	// grab a writer lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if _, ok := c.schedules[name]; !ok {
+		return fmt.Errorf("no schedule found with name %s", name)
+	}
+	delete(c.schedules, name)
+	c.deleteSchedule(name)
+	return nil
+}
+
+// validateStepConditions checks that every step condition in a template's
+// steps refers by name to a step that will have finished by the time the
+// conditional step is reached, i.e. to one within an earlier top-level
+// step. Step IDs are numbered in the same way as createStepsFromTemplate
+// numbers them. It assumes that validateStepDependencies has already
+// checked that the names are unique.
+func validateStepConditions(sts []*StepTemplate) error {
+	topStepIDs := getTopLevelStepTemplateIDs(sts)
+	dag := hasStepTemplateDependencies(sts)
+	stepIDs := map[string]uint64{}
+	getStepTemplateIDsByName(sts, 1, stepIDs)
+
+	nextStepID := uint64(1)
+	for i, st := range sts {
+		// every step within this top-level step can only refer to steps
+		// that will have finished when it is reached: those in earlier
+		// top-level steps, or for a DAG, those in top-level steps that it
+		// depends on, directly or indirectly
+		topStepID := nextStepID
+		finished := func(stepID uint64) bool {
+			return stepID < topStepID
+		}
+		if dag {
+			ancestors := getStepTemplateAncestors(sts, i)
+			finished = func(stepID uint64) bool {
+				for j := range ancestors {
+					if stepID >= topStepIDs[j] && stepID < topStepIDs[j]+countStepTemplates(sts[j]) {
+						return true
+					}
+				}
+				return false
+			}
+		}
+
+		var err error
+		nextStepID, err = validateStepConditionsHelper(st, stepIDs, finished, nextStepID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStepConditionsHelper recursively validates the conditions for
+// the given step and any steps within it, which start at stepID, given
+// the step IDs of the named steps and which step IDs will have finished
+// when they are reached. It returns the next step ID after them.
+func validateStepConditionsHelper(st *StepTemplate, stepIDs map[string]uint64, finished func(uint64) bool, stepID uint64) (uint64, error) {
+	if cond := st.Condition; cond != nil {
+		if cond.ConfigKey == "" && cond.ConfigValue != "" {
+			return 0, fmt.Errorf("step %d: condition has a config value but no config key", stepID)
+		}
+		if cond.StepName == "" && len(cond.StepOutcomes) > 0 {
+			return 0, fmt.Errorf("step %d: condition has step outcomes but no step name", stepID)
+		}
+		if cond.StepName != "" {
+			condStepID, ok := stepIDs[cond.StepName]
+			if !ok {
+				return 0, fmt.Errorf("step %d: condition refers to unknown step %s", stepID, cond.StepName)
+			}
+			if !finished(condStepID) {
+				return 0, fmt.Errorf("step %d: condition refers to step %s, which won't have finished when step %d is reached", stepID, cond.StepName, stepID)
+			}
+		}
+	}
+
+	nextStepID := stepID + 1
+	if st.T == StepTypeConcurrent {
+		for _, subSt := range st.ConcurrentStepTemplates {
+			var err error
+			nextStepID, err = validateStepConditionsHelper(subSt, stepIDs, finished, nextStepID)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return nextStepID, nil
+}
+
+// getStepTemplateIDsByName recursively records in stepIDs the step ID of
+// each named step among the given steps and any steps within them, which
+// start at stepID. It returns the next step ID after them.
+func getStepTemplateIDsByName(sts []*StepTemplate, stepID uint64, stepIDs map[string]uint64) uint64 {
+	for _, st := range sts {
+		if st.Name != "" {
+			stepIDs[st.Name] = stepID
+		}
+		stepID = getStepTemplateIDsByName(st.ConcurrentStepTemplates, stepID+1, stepIDs)
+	}
+	return stepID
+}
+
+// validateStepDependencies checks the names and dependencies of a
+// template's steps. Names must be unique, including those of steps within
+// concurrent steps; only top-level steps may have dependencies; each
+// dependency must name another top-level step; and the dependencies must
+// not form a cycle.
+func validateStepDependencies(sts []*StepTemplate) error {
+	topStepIDs := getTopLevelStepTemplateIDs(sts)
+
+	if err := checkUniqueStepNames(sts, map[string]bool{}); err != nil {
+		return err
+	}
+
+	indexes := map[string]int{}
+	for i, st := range sts {
+		if st.T == StepTypeConcurrent {
+			if err := checkNoStepDependencies(st.ConcurrentStepTemplates); err != nil {
+				return err
+			}
+		}
+		if st.Name != "" {
+			indexes[st.Name] = i
+		}
+	}
+
+	for i, st := range sts {
+		for _, dep := range st.DependsOn {
+			j, ok := indexes[dep]
+			if !ok {
+				return fmt.Errorf("step %d depends on %s, which is not a top-level step", topStepIDs[i], dep)
+			}
+			if j == i {
+				return fmt.Errorf("step %d depends on itself", topStepIDs[i])
+			}
+		}
+	}
+
+	// look for cycles with a depth-first search, keeping track of the
+	// path taken so that a cycle can be reported. every step in a cycle
+	// is depended on, and so has a name.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(sts))
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		path = append(path, sts[i].Name)
+		switch state[i] {
+		case visiting:
+			for k, name := range path {
+				if name == sts[i].Name {
+					return fmt.Errorf("steps form a cycle: %s", strings.Join(path[k:], " -> "))
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[i] = visiting
+		for _, dep := range sts[i].DependsOn {
+			if err := visit(indexes[dep], path); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range sts {
+		if err := visit(i, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUniqueStepNames recursively checks that none of the given steps,
+// or the steps within them, has a name that is already in names, or that
+// another of them has. It adds their names to names.
+func checkUniqueStepNames(sts []*StepTemplate, names map[string]bool) error {
+	for _, st := range sts {
+		if st.Name != "" {
+			if names[st.Name] {
+				return fmt.Errorf("more than one step is named %s", st.Name)
+			}
+			names[st.Name] = true
+		}
+		if err := checkUniqueStepNames(st.ConcurrentStepTemplates, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNoStepDependencies recursively checks that none of the given steps,
+// which are within a concurrent step, have dependencies.
+func checkNoStepDependencies(sts []*StepTemplate) error {
+	for _, st := range sts {
+		if len(st.DependsOn) > 0 {
+			return fmt.Errorf("only top-level steps can have dependencies")
+		}
+		if err := checkNoStepDependencies(st.ConcurrentStepTemplates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasStepTemplateDependencies returns true if any of the given top-level
+// step templates depends on another, meaning that they form a DAG.
+func hasStepTemplateDependencies(sts []*StepTemplate) bool {
+	for _, st := range sts {
+		if len(st.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// getStepTemplateAncestors returns the indexes of the top-level step
+// templates that the one at index i depends on, directly or indirectly.
+// The dependencies should already have been validated.
+func getStepTemplateAncestors(sts []*StepTemplate, i int) map[int]bool {
+	indexes := map[string]int{}
+	for j, st := range sts {
+		if st.Name != "" {
+			indexes[st.Name] = j
+		}
+	}
+
+	ancestors := map[int]bool{}
+	var visit func(j int)
+	visit = func(j int) {
+		for _, dep := range sts[j].DependsOn {
+			k, ok := indexes[dep]
+			if ok && !ancestors[k] {
+				ancestors[k] = true
+				visit(k)
+			}
+		}
+	}
+	visit(i)
+	return ancestors
+}
+
+// getTopLevelStepTemplateIDs returns the step ID that each of the given
+// top-level step templates will have, within a JobSet created from them.
+func getTopLevelStepTemplateIDs(sts []*StepTemplate) []uint64 {
+	ids := []uint64{}
+	nextStepID := uint64(1)
+	for _, st := range sts {
+		ids = append(ids, nextStepID)
+		nextStepID += countStepTemplates(st)
+	}
+	return ids
+}
+
+// countStepTemplates returns the number of steps that the given step
+// template creates when a JobSet is created, counting itself and,
+// recursively, any concurrent steps within it. The child steps of a
+// "foreach" step aren't counted, since they are created later.
+func countStepTemplates(st *StepTemplate) uint64 {
+	n := uint64(1)
+	for _, subSt := range st.ConcurrentStepTemplates {
+		n += countStepTemplates(subSt)
+	}
+	return n
+}
+
+// validateForEachSteps recursively checks that every "foreach" step in a
+// template's steps says which config keys to use.
+func validateForEachSteps(sts []*StepTemplate) error {
+	for _, st := range sts {
+		switch st.T {
+		case StepTypeForEach:
+			if st.ForEachConfigKey == "" {
+				return fmt.Errorf("foreach step for template %s has no config key to list its items", st.JSTemplateName)
+			}
+			if st.ForEachItemKey == "" {
+				return fmt.Errorf("foreach step for template %s has no config key to set to each item", st.JSTemplateName)
+			}
+		case StepTypeConcurrent:
+			if err := validateForEachSteps(st.ConcurrentStepTemplates); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateAgentStepOptions recursively checks the options that only agent
+// steps can have, among the given steps and any steps within them, which
+// start at stepID, by the same rules as for a configuration file. It
+// returns the next step ID after them.
+func validateAgentStepOptions(sts []*StepTemplate, stepID uint64) (uint64, error) {
+	for _, st := range sts {
+		if rp := st.Retry; rp != nil {
+			if st.T != StepTypeAgent {
+				return 0, fmt.Errorf("step %d: retry is only allowed for agent steps", stepID)
+			}
+			if rp.MaxAttempts == 0 {
+				return 0, fmt.Errorf("step %d: retry must set maxAttempts", stepID)
+			}
+			if rp.Backoff < 0 {
+				return 0, fmt.Errorf("step %d: retry backoff must not be negative", stepID)
+			}
+		}
+		if st.Timeout != 0 {
+			if st.T != StepTypeAgent {
+				return 0, fmt.Errorf("step %d: timeout is only allowed for agent steps", stepID)
+			}
+			if st.Timeout < 0 {
+				return 0, fmt.Errorf("step %d: timeout must not be negative", stepID)
+			}
+		}
+
+		var err error
+		stepID, err = validateAgentStepOptions(st.ConcurrentStepTemplates, stepID+1)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return stepID, nil
+}
+
 // AddJobSetTemplate asks the Controller to register a new jobSetTemplate.
 // It returns nil if the jobSetTemplate was successfully added, or a non-nil
 // error if unsuccessful.
@@ -115,6 +1007,21 @@ func (c *Controller) AddJobSetTemplate(name string, inSteps []*StepTemplate) err
 	// structure so we're ready to add it if the name is available
 	steps := cloneStepTemplate(inSteps)
 	jst := &JobSetTemplate{Name: name, Steps: steps}
+	if err := validateStepDependencies(steps); err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
+	if err := validateStepConditions(steps); err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
+	if err := validateForEachSteps(steps); err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
+	if _, err := validateAgentStepOptions(steps, 1); err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
+	if err := c.checkStepResourceClasses(steps); err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
 
 	// grab a writer lock; we cannot unlock after we check on availability
 	// until we have actually registered the template
@@ -130,6 +1037,7 @@ func (c *Controller) AddJobSetTemplate(name string, inSteps []*StepTemplate) err
 
 	// name is available, so we'll register it
 	c.jobSetTemplates[name] = jst
+	c.saveJobSetTemplate(jst)
 	return nil
 }
 
@@ -184,6 +1092,14 @@ func (c *Controller) GetJob(jobID uint64) (*Job, error) {
 		AgentName:       jd.AgentName,
 		Cfg:             jd.Cfg,
 		Status:          jd.Status,
+		Cancelled:       jd.Cancelled,
+		StepSkipped:     jd.StepSkipped,
+		Attempt:         jd.Attempt,
+		ConnectionError: jd.ConnectionError,
+		Timeout:         jd.Timeout,
+		TimedOut:        jd.TimedOut,
+		ResourceClass:   jd.ResourceClass,
+		LastSeen:        jd.LastSeen,
 	}
 	return jobDetails, nil
 }
@@ -206,6 +1122,14 @@ func (c *Controller) GetAllJobs() []*Job {
 			AgentName:       jd.AgentName,
 			Cfg:             jd.Cfg,
 			Status:          jd.Status,
+			Cancelled:       jd.Cancelled,
+			StepSkipped:     jd.StepSkipped,
+			Attempt:         jd.Attempt,
+			ConnectionError: jd.ConnectionError,
+			Timeout:         jd.Timeout,
+			TimedOut:        jd.TimedOut,
+			ResourceClass:   jd.ResourceClass,
+			LastSeen:        jd.LastSeen,
 		}
 
 		jobs = append(jobs, jobDetails)
@@ -233,6 +1157,14 @@ func (c *Controller) GetAllJobsForJobSet(jobSetID uint64) []*Job {
 				AgentName:       jd.AgentName,
 				Cfg:             jd.Cfg,
 				Status:          jd.Status,
+				Cancelled:       jd.Cancelled,
+				StepSkipped:     jd.StepSkipped,
+				Attempt:         jd.Attempt,
+				ConnectionError: jd.ConnectionError,
+				Timeout:         jd.Timeout,
+				TimedOut:        jd.TimedOut,
+				ResourceClass:   jd.ResourceClass,
+				LastSeen:        jd.LastSeen,
 			}
 
 			jobs = append(jobs, jobDetails)
@@ -242,6 +1174,19 @@ func (c *Controller) GetAllJobsForJobSet(jobSetID uint64) []*Job {
 	return jobs
 }
 
+// cloneConfigs returns a copy of the config values, or nil if there are
+// none.
+func cloneConfigs(configs map[string]string) map[string]string {
+	if configs == nil {
+		return nil
+	}
+	newConfigs := map[string]string{}
+	for k, v := range configs {
+		newConfigs[k] = v
+	}
+	return newConfigs
+}
+
 func cloneSteps(inSteps []*Step) []*Step {
 	if inSteps == nil {
 		return nil
@@ -257,10 +1202,26 @@ func cloneSteps(inSteps []*Step) []*Step {
 			StepOrder:             inStep.StepOrder,
 			RunStatus:             inStep.RunStatus,
 			HealthStatus:          inStep.HealthStatus,
+			WaitingReason:         inStep.WaitingReason,
+			Name:                  inStep.Name,
+			DependsOn:             append([]uint64(nil), inStep.DependsOn...),
+			Condition:             cloneStepCondition(inStep.Condition),
+			AllowFailure:          inStep.AllowFailure,
 			AgentJobID:            inStep.AgentJobID,
 			AgentName:             inStep.AgentName,
+			AgentType:             inStep.AgentType,
+			PoolStrategy:          inStep.PoolStrategy,
+			Retry:                 cloneRetryPolicy(inStep.Retry),
+			Timeout:               inStep.Timeout,
+			ResourceClass:         inStep.ResourceClass,
+			Attempts:              inStep.Attempts,
+			RetryAfter:            inStep.RetryAfter,
 			SubJobSetID:           inStep.SubJobSetID,
 			SubJobSetTemplateName: inStep.SubJobSetTemplateName,
+			SubJobSetConfigs:      cloneConfigs(inStep.SubJobSetConfigs),
+			ForEachConfigKey:      inStep.ForEachConfigKey,
+			ForEachItemKey:        inStep.ForEachItemKey,
+			ForEachMaxParallel:    inStep.ForEachMaxParallel,
 			ConcurrentSteps:       cloneSteps(inStep.ConcurrentSteps),
 		}
 		steps = append(steps, newStep)
@@ -269,10 +1230,11 @@ func cloneSteps(inSteps []*Step) []*Step {
 }
 
 // StartJobSet sends a request to start a JobSet with the given template
-// name and configuration.
-func (c *Controller) StartJobSet(jstName string, cfg []*pbc.JobSetConfig) (uint64, error) {
+// name and configuration, with the given scheduling priority and owned by
+// the given tenant.
+func (c *Controller) StartJobSet(jstName string, cfg []*pbc.JobSetConfig, priority int32, tenant string) (uint64, error) {
 	// create a JobSetRequest
-	jsr := JobSetRequest{TemplateName: jstName}
+	jsr := JobSetRequest{TemplateName: jstName, Priority: priority, Tenant: tenant}
 
 	// copy Configs one-by-one
 	jsr.Configs = map[string]string{}
@@ -280,17 +1242,29 @@ func (c *Controller) StartJobSet(jstName string, cfg []*pbc.JobSetConfig) (uint6
 		jsr.Configs[jsConfig.Key] = jsConfig.Value
 	}
 
-	// grab a writer lock, just long enough to reserve a JobSet ID
+	// grab a writer lock, just long enough to check that we're accepting
+	// new JobSets and to reserve a JobSet ID
 	var requestedJobSetID uint64
 	c.m.Lock()
+	if !c.openForJobSetRequests {
+		c.m.Unlock()
+		return 0, fmt.Errorf("controller is not accepting new JobSets")
+	}
 	requestedJobSetID = c.nextJobSetID
 	c.nextJobSetID++
+	c.saveNextIDs()
+	inJobSetStream := c.inJobSetStream
+	loopDone := c.loopDone
 	c.m.Unlock()
 
 	jsr.RequestedJobSetID = requestedJobSetID
 
-	// submit the JobSetRequest
-	c.inJobSetStream <- jsr
+	// submit the JobSetRequest, unless the controller stops first
+	select {
+	case inJobSetStream <- jsr:
+	case <-loopDone:
+		return 0, fmt.Errorf("controller stopped before JobSet could be started")
+	}
 
 	return jsr.RequestedJobSetID, nil
 }
@@ -308,11 +1282,19 @@ func (c *Controller) GetJobSet(jobSetID uint64) (*JobSet, error) {
 
 	// make a copy
 	jobSetDetails := &JobSet{
-		JobSetID:     js.JobSetID,
-		TemplateName: js.TemplateName,
-		RunStatus:    js.RunStatus,
-		HealthStatus: js.HealthStatus,
-		Steps:        cloneSteps(js.Steps),
+		JobSetID:       js.JobSetID,
+		TemplateName:   js.TemplateName,
+		RunStatus:      js.RunStatus,
+		HealthStatus:   js.HealthStatus,
+		Cancelled:      js.Cancelled,
+		Priority:       js.Priority,
+		Tenant:         js.Tenant,
+		ScheduleName:   js.ScheduleName,
+		TimeStarted:    js.TimeStarted,
+		TimeFinished:   js.TimeFinished,
+		Steps:          cloneSteps(js.Steps),
+		OutputMessages: js.OutputMessages,
+		ErrorMessages:  js.ErrorMessages,
 	}
 	// copy Configs one-by-one as well
 	jobSetDetails.Configs = map[string]string{}
@@ -333,11 +1315,19 @@ func (c *Controller) GetAllJobSets() []*JobSet {
 	for _, js := range c.jobSets {
 		// make a copy
 		jobSetDetails := &JobSet{
-			JobSetID:     js.JobSetID,
-			TemplateName: js.TemplateName,
-			RunStatus:    js.RunStatus,
-			HealthStatus: js.HealthStatus,
-			Steps:        cloneSteps(js.Steps),
+			JobSetID:       js.JobSetID,
+			TemplateName:   js.TemplateName,
+			RunStatus:      js.RunStatus,
+			HealthStatus:   js.HealthStatus,
+			Cancelled:      js.Cancelled,
+			Priority:       js.Priority,
+			Tenant:         js.Tenant,
+			ScheduleName:   js.ScheduleName,
+			TimeStarted:    js.TimeStarted,
+			TimeFinished:   js.TimeFinished,
+			Steps:          cloneSteps(js.Steps),
+			OutputMessages: js.OutputMessages,
+			ErrorMessages:  js.ErrorMessages,
 		}
 		// copy Configs one-by-one as well
 		jobSetDetails.Configs = map[string]string{}
@@ -350,3 +1340,266 @@ func (c *Controller) GetAllJobSets() []*JobSet {
 
 	return jobSets
 }
+
+// CancelJobSet cancels the JobSet with the given ID, together with any
+// sub-JobSets that have been created from its steps. No further steps will
+// be started for them, and the JobController is asked to cancel any of
+// their Jobs that are still running. A JobSet that has been requested but
+// not yet created is recorded as cancelled without being started. It
+// returns an error if the JobSet is unknown or has already stopped.
+func (c *Controller) CancelJobSet(jobSetID uint64) error {
+	// grab a writer lock
+	c.m.Lock()
+
+	js, ok := c.jobSets[jobSetID]
+	if !ok {
+		err := c.cancelPendingJobSet(jobSetID)
+		c.m.Unlock()
+		return err
+	}
+	if js.RunStatus == pbs.Status_STOPPED {
+		c.m.Unlock()
+		return fmt.Errorf("jobSet %d has already stopped", jobSetID)
+	}
+
+	// find this JobSet and all of its sub-JobSets, and mark them as
+	// cancelled and no longer active
+	cancelled := map[uint64]bool{}
+	c.cancelJobSetAndSubJobSets(js, cancelled)
+
+	// drop any queued requests for new sub-JobSets of the cancelled ones
+	for e := c.pendingJSRs.Front(); e != nil; {
+		next := e.Next()
+		if cancelled[e.Value.(JobSetRequest).ParentJobSetID] {
+			c.pendingJSRs.Remove(e)
+		}
+		e = next
+	}
+
+	// find their running Jobs. these stay active until the JobController
+	// reports that they have stopped, so they still count towards the
+	// maximum number of running Jobs until then.
+	jobIDs := []uint64{}
+	for jobID, job := range c.activeJobs {
+		if cancelled[job.JobSetID] && !job.Cancelled {
+			job.Cancelled = true
+			c.saveJob(job)
+			jobIDs = append(jobIDs, jobID)
+		}
+	}
+
+	inJobCancelStream, loopDone := c.getJobCancelChannels()
+	c.m.Unlock()
+
+	// and ask the JobController to cancel them. as with agent updates,
+	// don't hold the lock while sending.
+	for _, jobID := range jobIDs {
+		sendJobCancel(inJobCancelStream, loopDone, jobID)
+	}
+
+	return nil
+}
+
+// cancelPendingJobSet removes the queued request for the JobSet with the
+// given ID, if there is one, and records the JobSet as cancelled. It
+// returns an error if no such request is queued. It does not grab a lock,
+// as CancelJobSet already holds one.
+func (c *Controller) cancelPendingJobSet(jobSetID uint64) error {
+	for e := c.pendingJSRs.Front(); e != nil; e = e.Next() {
+		jsr := e.Value.(JobSetRequest)
+		if jsr.RequestedJobSetID == 0 || jsr.RequestedJobSetID != jobSetID {
+			continue
+		}
+		c.pendingJSRs.Remove(e)
+
+		now := time.Now()
+		js := &JobSet{
+			JobSetID:       jobSetID,
+			TemplateName:   jsr.TemplateName,
+			RunStatus:      pbs.Status_STOPPED,
+			HealthStatus:   pbs.Health_OK,
+			Cancelled:      true,
+			Priority:       jsr.Priority,
+			Tenant:         jsr.Tenant,
+			ScheduleName:   jsr.ScheduleName,
+			TimeStarted:    now,
+			TimeFinished:   now,
+			Configs:        jsr.Configs,
+			OutputMessages: "jobSet cancelled\n",
+		}
+		c.jobSets[jobSetID] = js
+		c.saveJobSet(js)
+		return nil
+	}
+	return fmt.Errorf("no jobSet found with ID %d", jobSetID)
+}
+
+// cancelJobSetAndSubJobSets marks the JobSet and, recursively, any of its
+// sub-JobSets that are still running as cancelled, and records their IDs
+// in cancelled. Their steps that haven't started are stopped. It does not
+// grab a lock, as CancelJobSet already holds one.
+func (c *Controller) cancelJobSetAndSubJobSets(js *JobSet, cancelled map[uint64]bool) {
+	if js.RunStatus != pbs.Status_STOPPED {
+		js.RunStatus = pbs.Status_STOPPED
+		js.Cancelled = true
+		js.TimeFinished = time.Now()
+		js.OutputMessages += "jobSet cancelled\n"
+		stopCancelledSteps(js.Steps)
+		delete(c.activeJobSets, js.JobSetID)
+		c.saveJobSet(js)
+	}
+	cancelled[js.JobSetID] = true
+
+	for _, subJobSetID := range getSubJobSetIDs(js.Steps) {
+		subJs, ok := c.jobSets[subJobSetID]
+		if ok {
+			c.cancelJobSetAndSubJobSets(subJs, cancelled)
+		}
+	}
+}
+
+// SetJobSetPriority changes the scheduling priority of the JobSet with the
+// given ID, together with any sub-JobSets that have been or will be
+// created from its steps. It returns an error if the JobSet is unknown or
+// has already stopped.
+func (c *Controller) SetJobSetPriority(jobSetID uint64, priority int32) error {
+	// grab a writer lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	js, ok := c.jobSets[jobSetID]
+	if !ok {
+		return fmt.Errorf("no jobSet found with ID %d", jobSetID)
+	}
+	if js.RunStatus == pbs.Status_STOPPED {
+		return fmt.Errorf("jobSet %d has already stopped", jobSetID)
+	}
+
+	updated := map[uint64]bool{}
+	c.setJobSetAndSubJobSetsPriority(js, priority, updated)
+
+	// also update any queued requests for new sub-JobSets
+	for e := c.pendingJSRs.Front(); e != nil; e = e.Next() {
+		jsr := e.Value.(JobSetRequest)
+		if updated[jsr.ParentJobSetID] {
+			jsr.Priority = priority
+			e.Value = jsr
+		}
+	}
+
+	return nil
+}
+
+// setJobSetAndSubJobSetsPriority sets the priority of the JobSet and,
+// recursively, of any of its sub-JobSets that are still running, and
+// records their IDs in updated. It does not grab a lock, as
+// SetJobSetPriority already holds one.
+func (c *Controller) setJobSetAndSubJobSetsPriority(js *JobSet, priority int32, updated map[uint64]bool) {
+	if js.RunStatus == pbs.Status_STOPPED {
+		return
+	}
+	js.Priority = priority
+	c.saveJobSet(js)
+	updated[js.JobSetID] = true
+
+	for _, subJobSetID := range getSubJobSetIDs(js.Steps) {
+		subJs, ok := c.jobSets[subJobSetID]
+		if ok {
+			c.setJobSetAndSubJobSetsPriority(subJs, priority, updated)
+		}
+	}
+}
+
+// getSubJobSetIDs returns the IDs of all sub-JobSets that have been created
+// for "jobset" steps, recursing into concurrent and foreach steps.
+func getSubJobSetIDs(steps []*Step) []uint64 {
+	ids := []uint64{}
+	for _, step := range steps {
+		switch step.T {
+		case StepTypeJobSet:
+			if step.SubJobSetID != 0 {
+				ids = append(ids, step.SubJobSetID)
+			}
+		case StepTypeConcurrent, StepTypeForEach:
+			ids = append(ids, getSubJobSetIDs(step.ConcurrentSteps)...)
+		}
+	}
+	return ids
+}
+
+// getJobCancelChannels returns the channels needed to ask the JobController
+// to cancel a Job, or nil channels if the Controller is not running. It
+// does not grab a lock, as callers should already hold one.
+func (c *Controller) getJobCancelChannels() (chan<- uint64, <-chan struct{}) {
+	if c.runStatus != pbs.Status_RUNNING {
+		return nil, nil
+	}
+	return c.inJobCancelStream, c.loopDone
+}
+
+// sendJobCancel asks the JobController to cancel the Job with the given
+// ID, unless inJobCancelStream is nil (because the Controller wasn't
+// running) or the Controller stops first. It should be called without
+// holding the Controller's lock.
+func sendJobCancel(inJobCancelStream chan<- uint64, loopDone <-chan struct{}, jobID uint64) {
+	if inJobCancelStream == nil {
+		return
+	}
+	select {
+	case inJobCancelStream <- jobID:
+	case <-loopDone:
+	}
+}
+
+// CancelJob cancels the running Job with the given ID. If skipStep is
+// false, the Job's step is marked as failed, so that its JobSet will stop
+// with ERROR health. If skipStep is true, the step is marked as skipped,
+// so that the JobSet's later steps can proceed. Either way, the JobSet is
+// updated once the Job has actually stopped. It returns an error if the
+// Job is unknown, has already stopped or is already being cancelled.
+func (c *Controller) CancelJob(jobID uint64, skipStep bool) error {
+	// grab a writer lock
+	c.m.Lock()
+
+	job, ok := c.jobs[jobID]
+	if !ok {
+		c.m.Unlock()
+		return fmt.Errorf("no job found with ID %d", jobID)
+	}
+	if job.Status.RunStatus == pba.JobRunStatus_STOPPED {
+		c.m.Unlock()
+		return fmt.Errorf("job %d has already stopped", jobID)
+	}
+	if job.Cancelled {
+		c.m.Unlock()
+		return fmt.Errorf("job %d is already being cancelled", jobID)
+	}
+
+	job.Cancelled = true
+	job.StepSkipped = skipStep
+	c.saveJob(job)
+
+	// decide the step's outcome now; updateJobStatus will leave it alone
+	// when the Job's final status comes in
+	js, ok := c.jobSets[job.JobSetID]
+	if ok {
+		step := findStepInSteps(js.Steps, job.JobSetStepID)
+		if step != nil && step.T == StepTypeAgent && step.AgentJobID == job.JobID {
+			if skipStep {
+				step.RunStatus = pbs.Status_SKIPPED
+				js.OutputMessages += fmt.Sprintf("step %d skipped: job %d was cancelled\n", step.StepID, jobID)
+			} else {
+				step.RunStatus = pbs.Status_STOPPED
+				step.HealthStatus = pbs.Health_ERROR
+				js.ErrorMessages += fmt.Sprintf("step %d failed: job %d was cancelled\n", step.StepID, jobID)
+			}
+		}
+		c.saveJobSet(js)
+	}
+
+	inJobCancelStream, loopDone := c.getJobCancelChannels()
+	c.m.Unlock()
+
+	sendJobCancel(inJobCancelStream, loopDone, jobID)
+	return nil
+}
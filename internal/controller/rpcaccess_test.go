@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"container/list"
+	"sync"
+	"testing"
+
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// newCancelTestController returns a Controller that isn't running, holding
+// a single running JobSet with the given steps.
+func newCancelTestController(t *testing.T, steps []*Step) (*Controller, *JobSet) {
+	c, js := newReconcileTestController(t, steps)
+	c.m = &sync.RWMutex{}
+	c.pendingJSRs = list.New()
+	return c, js
+}
+
+func TestCancelJobSetStopsUnstartedSteps(t *testing.T) {
+	running := &Step{T: StepTypeAgent, StepID: 2, RunStatus: pbs.Status_RUNNING, AgentJobID: 1}
+	waiting := &Step{T: StepTypeAgent, StepID: 3, RunStatus: pbs.Status_STARTUP, WaitingReason: "waiting for agent"}
+	concurrent := &Step{T: StepTypeConcurrent, StepID: 1, RunStatus: pbs.Status_RUNNING, ConcurrentSteps: []*Step{running, waiting}}
+	last := &Step{T: StepTypeAgent, StepID: 4, RunStatus: pbs.Status_STARTUP}
+	c, js := newCancelTestController(t, []*Step{concurrent, last})
+
+	if err := c.CancelJobSet(1); err != nil {
+		t.Fatal(err)
+	}
+	if !js.Cancelled || js.RunStatus != pbs.Status_STOPPED {
+		t.Errorf("expected jobSet to be cancelled, got %s", js.RunStatus)
+	}
+	if waiting.RunStatus != pbs.Status_STOPPED || waiting.WaitingReason != "" || last.RunStatus != pbs.Status_STOPPED {
+		t.Errorf("expected unstarted steps to stop, got %s and %s", waiting.RunStatus, last.RunStatus)
+	}
+	// the running step is left for its Job to stop
+	if running.RunStatus != pbs.Status_RUNNING || concurrent.RunStatus != pbs.Status_RUNNING {
+		t.Errorf("expected running steps to be left running, got %s and %s", running.RunStatus, concurrent.RunStatus)
+	}
+
+	// once its Job has stopped, the concurrent step stops too
+	running.RunStatus = pbs.Status_STOPPED
+	c.updateJobSetStatus(js)
+	if concurrent.RunStatus != pbs.Status_STOPPED {
+		t.Errorf("expected concurrent step to stop, got %s", concurrent.RunStatus)
+	}
+}
+
+func TestCancelJobSetCancelsPendingRequest(t *testing.T) {
+	c, _ := newCancelTestController(t, nil)
+	c.pendingJSRs.PushBack(JobSetRequest{TemplateName: "sub", ParentJobSetID: 1, ParentJobStepID: 1})
+	c.pendingJSRs.PushBack(JobSetRequest{TemplateName: "t", RequestedJobSetID: 5, Tenant: "team"})
+
+	if err := c.CancelJobSet(5); err != nil {
+		t.Fatal(err)
+	}
+	js, ok := c.jobSets[5]
+	if !ok {
+		t.Fatal("expected cancelled jobSet to be recorded")
+	}
+	if !js.Cancelled || js.RunStatus != pbs.Status_STOPPED || js.TemplateName != "t" || js.Tenant != "team" {
+		t.Errorf("expected stopped, cancelled jobSet from template t, got %+v", js)
+	}
+	if _, ok := c.activeJobSets[5]; ok {
+		t.Error("expected cancelled jobSet not to be active")
+	}
+	// only its own request is removed
+	if c.pendingJSRs.Len() != 1 || c.pendingJSRs.Front().Value.(JobSetRequest).TemplateName != "sub" {
+		t.Errorf("expected only the sub-jobSet request to be left, got %d requests", c.pendingJSRs.Len())
+	}
+
+	if err := c.CancelJobSet(6); err == nil {
+		t.Error("expected error for unknown jobSet")
+	}
+}
+
+func TestValidateStepConditionsInDAG(t *testing.T) {
+	cond := func(name string) *StepCondition { return &StepCondition{StepName: name} }
+	sts := []*StepTemplate{
+		{T: StepTypeConcurrent, Name: "fetch", ConcurrentStepTemplates: []*StepTemplate{
+			{T: StepTypeAgent, Name: "fetch-a", AgentName: "a"},
+			{T: StepTypeAgent, AgentName: "a"},
+		}},
+		{T: StepTypeAgent, Name: "licenses", AgentName: "a"},
+		{T: StepTypeAgent, AgentName: "a", DependsOn: []string{"fetch"}, Condition: cond("fetch-a")},
+	}
+	if err := validateStepDependencies(sts); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateStepConditions(sts); err != nil {
+		t.Errorf("expected condition on a step within a dependency to be allowed, got %v", err)
+	}
+
+	// licenses runs alongside the last step, so won't have finished
+	sts[2].Condition = cond("licenses")
+	if err := validateStepConditions(sts); err == nil {
+		t.Error("expected condition on a step that isn't a dependency to be rejected")
+	}
+}
+
+func TestValidateStepDependencies(t *testing.T) {
+	agentStep := func(name string, deps ...string) *StepTemplate {
+		return &StepTemplate{T: StepTypeAgent, AgentName: "a", Name: name, DependsOn: deps}
+	}
+	concurrentStep := func(name string, sts ...*StepTemplate) *StepTemplate {
+		return &StepTemplate{T: StepTypeConcurrent, Name: name, ConcurrentStepTemplates: sts}
+	}
+
+	tests := []struct {
+		name string
+		sts  []*StepTemplate
+		err  string
+	}{
+		{"no names", []*StepTemplate{agentStep(""), agentStep("")}, ""},
+		{"DAG", []*StepTemplate{agentStep("x"), agentStep("y"), agentStep("", "x", "y")}, ""},
+		{"nested names", []*StepTemplate{concurrentStep("c", agentStep("x")), agentStep("y", "c")}, ""},
+		{"duplicate", []*StepTemplate{agentStep("x"), agentStep("x")}, "more than one step is named x"},
+		{"duplicate nested", []*StepTemplate{concurrentStep("", agentStep("x")), concurrentStep("", agentStep("x"))}, "more than one step is named x"},
+		{"unknown", []*StepTemplate{agentStep("x", "y")}, "step 1 depends on y, which is not a top-level step"},
+		{"nested dependency", []*StepTemplate{concurrentStep("", agentStep("x")), agentStep("", "x")}, "step 3 depends on x, which is not a top-level step"},
+		{"dependency of nested step", []*StepTemplate{agentStep("x"), concurrentStep("", agentStep("", "x"))}, "only top-level steps can have dependencies"},
+		{"self", []*StepTemplate{agentStep("x"), agentStep("y", "y")}, "step 2 depends on itself"},
+		{"cycle", []*StepTemplate{agentStep("x", "y"), agentStep("y", "z"), agentStep("z", "x")}, "steps form a cycle: x -> y -> z -> x"},
+		{"cycle after a step", []*StepTemplate{agentStep("w"), agentStep("x", "w", "y"), agentStep("y", "x")}, "steps form a cycle: x -> y -> x"},
+	}
+	for _, tc := range tests {
+		err := validateStepDependencies(tc.sts)
+		if tc.err == "" && err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("%s: expected error %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
@@ -5,22 +5,43 @@ package controller
 import (
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/swinslow/peridot-core/internal/jobcontroller"
+	"github.com/swinslow/peridot-core/internal/logging"
 	"github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
 	pbs "github.com/swinslow/peridot-core/pkg/status"
 )
 
+// agentUnhealthyPeriod is how long an agent is skipped when picking an
+// agent of a given type for a step, after a Job failed because the agent
+// couldn't be reached or its stream failed.
+const agentUnhealthyPeriod = 30 * time.Second
+
 // runScheduler is the main "decider" within the Controller.
 // It walks through active Jobs and JobSets, decides whether to update them,
 // and decides whether to start new Jobs based on the current overall state.
 func (c *Controller) runScheduler() {
 	// grab a writer lock
 	c.m.Lock()
-	fmt.Println("===> ENTERING runScheduler")
+	logging.Debugf("===> ENTERING runScheduler")
 	defer c.m.Unlock()
-	defer fmt.Println("===> LEAVING runScheduler")
+	defer logging.Debugf("===> LEAVING runScheduler")
+
+	// any jobSet that is active on entry may have its status or steps
+	// updated below, so persist all of them once we're done. the Store
+	// only writes out the ones that actually changed.
+	touchedJobSets := []*JobSet{}
+	for _, js := range c.activeJobSets {
+		touchedJobSets = append(touchedJobSets, js)
+	}
+	defer func() {
+		for _, js := range touchedJobSets {
+			c.saveJobSet(js)
+		}
+	}()
 
 	// first, remove any stopped jobs from the active list, and update
 	// corresponding JobSets' statuses
@@ -34,81 +55,576 @@ func (c *Controller) runScheduler() {
 		}
 	}
 
-	// next, remove any stopped jobSets from the active list
+	// next, bring the active jobSets up to date, and collect the agent
+	// steps that are ready to run. this also skips any steps that have
+	// been reached but whose conditions aren't met, which can finish a
+	// jobSet, so its status is checked again afterwards. jobSets are
+	// handled newest first, so that a sub-jobSet is up to date before its
+	// parent looks at its status. while draining, no new steps are
+	// started, so they aren't looked for.
+	readySteps := map[uint64][]*Step{}
+	for _, js := range c.getActiveJobSetsNewestFirst() {
+		if js.RunStatus == pbs.Status_STOPPED {
+			continue
+		}
+		c.updateJobSetStatus(js)
+		if js.RunStatus == pbs.Status_STOPPED || c.draining {
+			continue
+		}
+		readySteps[js.JobSetID] = c.getReadyStepsForJobSet(js)
+		c.updateJobSetStatus(js)
+	}
+
+	// then remove any stopped jobSets from the active list
 	for jobSetID, js := range c.activeJobSets {
 		if js.RunStatus == pbs.Status_STOPPED {
 			delete(c.activeJobSets, jobSetID)
 		}
 	}
 
-	// now, see if we're already at capacity for maximum number of running
-	// jobs. If we are, return early without checking for new jobs to add.
-	if len(c.activeJobs) >= c.maxJobsRunning {
+	// request new jobSets for any schedules that are now due. this comes
+	// after stopped jobSets are removed from the active list, so that
+	// each schedule's overlap policy sees which of its runs have ended.
+	now := time.Now()
+	c.startDueSchedules(now)
+
+	// find the next time that a step waiting to be retried will be
+	// ready, that an unhealthy agent can be tried again, or that a
+	// schedule is due, so that the jobSetProcessorLoop can wake us up then
+	c.nextWakeup = time.Time{}
+	wakeAt := func(t time.Time) {
+		if t.After(now) && (c.nextWakeup.IsZero() || t.Before(c.nextWakeup)) {
+			c.nextWakeup = t
+		}
+	}
+	for _, js := range c.activeJobSets {
+		wakeAt(getNextRetryTime(js.Steps))
+	}
+	for _, t := range c.agentUnhealthyUntil {
+		wakeAt(t)
+	}
+	for _, s := range c.schedules {
+		wakeAt(s.NextRun)
+	}
+
+	// if we're draining, we don't start any new jobs
+	if c.draining {
 		return
 	}
 
-	// we have capacity for new jobs. start walking through the active
-	// jobSets, check for ready jobs and add them as we go.
-	for _, js := range c.activeJobSets {
+	// count the jobs that each agent, each tenant and each resource class
+	// is already running, so that steps for agents or resource classes
+	// that are at their own capacity can be held back and so that job
+	// slots can be shared fairly between tenants. also count the steps
+	// held back for each agent to give their places in its queue.
+	agentJobs := map[string]uint32{}
+	tenantJobs := map[string]uint32{}
+	classJobs := map[string]int{}
+	for _, job := range c.activeJobs {
+		agentJobs[job.AgentName]++
+		classJobs[c.getResourceClassBucket(job.ResourceClass)]++
+		if js, ok := c.jobSets[job.JobSetID]; ok {
+			tenantJobs[js.Tenant]++
+		}
+	}
+	agentWaiting := map[string]int{}
+
+	// walk through the active jobSets, highest priority first and then
+	// oldest first, and collect each tenant's ready steps in that order.
+	// tenants are listed in the order that their first ready step was
+	// found, to break ties between them.
+	tenants := []string{}
+	tenantSteps := map[string][]*Step{}
+	for _, js := range c.getActiveJobSetsInOrder() {
 		// if this jobset was still in STARTUP status, it's now running
 		if js.RunStatus == pbs.Status_STARTUP {
 			js.RunStatus = pbs.Status_RUNNING
 		}
 
-		readyAgentSteps := c.getReadyStepsForJobSet(js)
-		for _, readyAgent := range readyAgentSteps {
-			// ready to submit this as a new Job to run
-			jobID := c.nextJobID
-			c.nextJobID++
-
-			// update corresponding step with job ID, now that we know it
-			readyAgent.AgentJobID = jobID
-
-			// and tell this Step that it is now running
-			readyAgent.RunStatus = pbs.Status_RUNNING
-
-			// create the Job's configuration
-			cfg := c.getJobConfigForStep(readyAgent)
-
-			// create a Job to store data within the controller
-			job := &Job{
-				JobID:           jobID,
-				JobSetID:        readyAgent.JobSetID,
-				JobSetStepID:    readyAgent.StepID,
-				JobSetStepOrder: readyAgent.StepOrder,
-				AgentName:       readyAgent.AgentName,
-				Cfg:             *cfg,
-				Status: agent.StatusReport{
-					RunStatus:    agent.JobRunStatus_STARTUP,
-					HealthStatus: agent.JobHealthStatus_OK,
-					TimeStarted:  time.Now().Unix(),
-				},
+		readyAgentSteps := readySteps[js.JobSetID]
+		if len(readyAgentSteps) == 0 {
+			continue
+		}
+		if _, ok := tenantSteps[js.Tenant]; !ok {
+			tenants = append(tenants, js.Tenant)
+		}
+		tenantSteps[js.Tenant] = append(tenantSteps[js.Tenant], readyAgentSteps...)
+	}
+
+	// now start jobs while we have capacity in any resource class, each
+	// time taking the next ready step from whichever tenant is furthest
+	// below its fair share
+	c.tenantQueuedSteps = map[string]int{}
+	for c.hasFreeJobSlot(classJobs) {
+		tenant, ok := c.pickTenant(tenants, tenantSteps, tenantJobs)
+		if !ok {
+			break
+		}
+		readyAgent := tenantSteps[tenant][0]
+		tenantSteps[tenant] = tenantSteps[tenant][1:]
+
+		// pick the agent to run this step's job. if there isn't one
+		// with capacity, leave this step in STARTUP, but keep going
+		// so that steps for other agents can still start
+		agentName, waitingReason := c.pickAgentForStep(readyAgent, agentJobs, agentWaiting, classJobs)
+		if agentName == "" {
+			readyAgent.WaitingReason = waitingReason
+			c.tenantQueuedSteps[tenant]++
+			continue
+		}
+		agentJobs[agentName]++
+		tenantJobs[tenant]++
+		classJobs[c.getResourceClassBucket(c.getJobResourceClass(readyAgent, agentName))]++
+		c.startJobForStep(readyAgent, agentName)
+	}
+
+	// any steps that are left are waiting for a free job slot, either in
+	// their resource class, if it is known yet, or in their tenant's queue
+	for _, tenant := range tenants {
+		for i, readyAgent := range tenantSteps[tenant] {
+			class := readyAgent.ResourceClass
+			if class == "" && readyAgent.AgentType == "" {
+				class = c.agents[readyAgent.AgentName].ResourceClass
 			}
+			readyAgent.WaitingReason = ""
+			if c.getResourceClassBucket(class) != "" {
+				readyAgent.WaitingReason = c.checkResourceClassCapacity(class, classJobs, agentWaiting)
+			}
+			if readyAgent.WaitingReason == "" {
+				readyAgent.WaitingReason = fmt.Sprintf("waiting for a free job slot (position %d in its tenant's queue)", i+1)
+			}
+			c.tenantQueuedSteps[tenant]++
+		}
+	}
+}
+
+// startJobForStep creates a new Job to run the given ready "agent" step on
+// the named agent, and submits it to the JobController. It does not grab
+// a lock, as runScheduler has already grabbed one.
+func (c *Controller) startJobForStep(readyAgent *Step, agentName string) {
+	readyAgent.AgentName = agentName
+	readyAgent.WaitingReason = ""
+
+	// ready to submit this as a new Job to run
+	jobID := c.nextJobID
+	c.nextJobID++
+
+	// update corresponding step with job ID, now that we know it
+	readyAgent.AgentJobID = jobID
+
+	// and tell this Step that it is now running, as its next
+	// attempt
+	readyAgent.RunStatus = pbs.Status_RUNNING
+	readyAgent.Attempts++
+	readyAgent.RetryAfter = time.Time{}
+
+	// create the Job's configuration, and work out how long it may run
+	cfg := c.getJobConfigForStep(readyAgent)
+	timeout := readyAgent.Timeout
+	if timeout == 0 {
+		timeout = time.Duration(c.agents[agentName].JobTimeoutMillis) * time.Millisecond
+	}
+
+	// create a Job to store data within the controller
+	job := &Job{
+		JobID:           jobID,
+		JobSetID:        readyAgent.JobSetID,
+		JobSetStepID:    readyAgent.StepID,
+		JobSetStepOrder: readyAgent.StepOrder,
+		AgentName:       readyAgent.AgentName,
+		Cfg:             *cfg,
+		Attempt:         readyAgent.Attempts,
+		Timeout:         timeout,
+		ResourceClass:   c.getJobResourceClass(readyAgent, agentName),
+		Status: agent.StatusReport{
+			RunStatus:    agent.JobRunStatus_STARTUP,
+			HealthStatus: agent.JobHealthStatus_OK,
+			TimeStarted:  time.Now().Unix(),
+		},
+	}
 
-			// add it to the main jobs and active jobs maps
-			c.jobs[jobID] = job
-			c.activeJobs[jobID] = job
-
-			// now, create a JobRequest
-			// we do this _after_ adding to main jobs / active jobs maps
-			// so that the controller will already know about them, whenever
-			// the jobcontroller gets back to us with status updates
-			jr := jobcontroller.JobRequest{
-				JobID:     jobID,
-				AgentName: readyAgent.AgentName,
-				Cfg:       *cfg,
+	// add it to the main jobs and active jobs maps
+	c.jobs[jobID] = job
+	c.activeJobs[jobID] = job
+
+	// now, create a JobRequest
+	// we do this _after_ adding to main jobs / active jobs maps
+	// so that the controller will already know about them, whenever
+	// the jobcontroller gets back to us with status updates
+	jr := jobcontroller.JobRequest{
+		JobID:     jobID,
+		AgentName: readyAgent.AgentName,
+		Cfg:       *cfg,
+		Timeout:   timeout,
+	}
+
+	// submit it to the channel
+	c.inJobStream <- jr
+
+	// and only now persist it, recording that it has been submitted so
+	// that after a restart we know an agent may have started it. if we
+	// crash before this, the Job is never persisted, and reconcile lets
+	// its step be started again as a new Job, so across a crash a step's
+	// Job may reach its agent more than once.
+	job.submitted = true
+	c.saveJob(job)
+	c.saveNextIDs()
+}
+
+// pickTenant returns the tenant whose next ready step should be started,
+// or false if no tenant has any ready steps left. Tenants running fewer
+// jobs than their guaranteed minimum come first; otherwise, the tenant
+// running the fewest jobs relative to its weight is picked. Ties go to the
+// tenant listed first in tenants. It does not grab a lock, as
+// runScheduler has already grabbed one.
+func (c *Controller) pickTenant(tenants []string, tenantSteps map[string][]*Step, tenantJobs map[string]uint32) (string, bool) {
+	chosen := ""
+	found := false
+	chosenUnderMin := false
+	for _, tenant := range tenants {
+		if len(tenantSteps[tenant]) == 0 {
+			continue
+		}
+		tc := c.tenants[tenant]
+		underMin := tenantJobs[tenant] < tc.MinJobs
+		if !found || (underMin && !chosenUnderMin) {
+			chosen, found, chosenUnderMin = tenant, true, underMin
+			continue
+		}
+		if underMin != chosenUnderMin {
+			continue
+		}
+
+		// compare jobs / weight without dividing
+		weight := uint64(getTenantWeight(tc))
+		chosenWeight := uint64(getTenantWeight(c.tenants[chosen]))
+		if uint64(tenantJobs[tenant])*chosenWeight < uint64(tenantJobs[chosen])*weight {
+			chosen = tenant
+		}
+	}
+	return chosen, found
+}
+
+// getTenantWeight returns the tenant's weight, treating 0 as 1.
+func getTenantWeight(tc pbc.TenantConfig) uint32 {
+	if tc.Weight == 0 {
+		return 1
+	}
+	return tc.Weight
+}
+
+// pickAgentForStep returns the name of the agent that should run the next
+// Job for the given ready "agent" step, or an empty name and the reason
+// why the step must wait. If the step names an agent type rather than an
+// agent, one of the healthy agents of that type with spare capacity is
+// picked using the step's PoolStrategy. Either way, the step must also wait
+// if the resource class of its Job is at capacity. agentJobs holds the
+// number of jobs that each agent is running, classJobs the number that each
+// resource class is running, and agentWaiting the number of steps already
+// waiting for each agent, agent type or resource class. It does not grab a
+// lock, as runScheduler has already grabbed one.
+func (c *Controller) pickAgentForStep(step *Step, agentJobs map[string]uint32, agentWaiting map[string]int, classJobs map[string]int) (string, string) {
+	if step.AgentType == "" {
+		agentName := step.AgentName
+		maxJobs := c.agents[agentName].MaxConcurrentJobs
+		if maxJobs > 0 && agentJobs[agentName] >= maxJobs {
+			agentWaiting[agentName]++
+			return "", fmt.Sprintf("waiting for agent %s, which is running %d of at most %d jobs (position %d in its queue)", agentName, agentJobs[agentName], maxJobs, agentWaiting[agentName])
+		}
+		if reason := c.checkResourceClassCapacity(c.getJobResourceClass(step, agentName), classJobs, agentWaiting); reason != "" {
+			return "", reason
+		}
+		return agentName, ""
+	}
+
+	// if the step sets its own resource class, it applies whichever agent
+	// is picked
+	if step.ResourceClass != "" {
+		if reason := c.checkResourceClassCapacity(step.ResourceClass, classJobs, agentWaiting); reason != "" {
+			return "", reason
+		}
+	}
+
+	// find the agents of this type that can take a job now
+	now := time.Now()
+	nTotal := 0
+	nUnhealthy := 0
+	candidates := []string{}
+	for agentName, ac := range c.agents {
+		if ac.Type != step.AgentType {
+			continue
+		}
+		nTotal++
+		if now.Before(c.agentUnhealthyUntil[agentName]) {
+			nUnhealthy++
+			continue
+		}
+		if ac.MaxConcurrentJobs > 0 && agentJobs[agentName] >= ac.MaxConcurrentJobs {
+			continue
+		}
+		bucket := c.getResourceClassBucket(c.getJobResourceClass(step, agentName))
+		if classJobs[bucket] >= c.getResourceClassCapacity(bucket) {
+			continue
+		}
+		candidates = append(candidates, agentName)
+	}
+
+	if len(candidates) == 0 {
+		queue := "type " + step.AgentType
+		agentWaiting[queue]++
+		switch {
+		case nTotal == 0:
+			return "", fmt.Sprintf("waiting for an agent of type %s to be registered", step.AgentType)
+		case nUnhealthy == nTotal:
+			return "", fmt.Sprintf("waiting for one of the %d agents of type %s to become healthy", nTotal, step.AgentType)
+		default:
+			return "", fmt.Sprintf("waiting for one of the %d agents of type %s to have capacity (position %d in its queue)", nTotal, step.AgentType, agentWaiting[queue])
+		}
+	}
+
+	sort.Strings(candidates)
+	chosen := candidates[0]
+	switch step.PoolStrategy {
+	case PoolRoundRobin:
+		// take the next agent after the one picked last time, by name,
+		// wrapping around to the first
+		last := c.poolLastAgent[step.AgentType]
+		for _, agentName := range candidates {
+			if agentName > last {
+				chosen = agentName
+				break
 			}
+		}
+		c.poolLastAgent[step.AgentType] = chosen
+	default:
+		for _, agentName := range candidates[1:] {
+			if agentJobs[agentName] < agentJobs[chosen] {
+				chosen = agentName
+			}
+		}
+	}
+	return chosen, ""
+}
 
-			// submit it to the channel
-			c.inJobStream <- jr
+// getJobResourceClass returns the resource class of a Job for the given
+// "agent" step, run on the named agent: the step's own resource class if
+// it has one, or else the agent's. It does not grab a lock, as its callers
+// have already grabbed one.
+func (c *Controller) getJobResourceClass(step *Step, agentName string) string {
+	if step.ResourceClass != "" {
+		return step.ResourceClass
+	}
+	return c.agents[agentName].ResourceClass
+}
+
+// getResourceClassBucket returns the resource class whose capacity a Job
+// in the given resource class counts against. That is the class itself if
+// it is configured, or otherwise the empty string, for the jobs that share
+// maxJobsRunning.
+func (c *Controller) getResourceClassBucket(class string) string {
+	if _, ok := c.resourceClasses[class]; ok {
+		return class
+	}
+	return ""
+}
+
+// getResourceClassCapacity returns the maximum number of jobs that can run
+// at once in the given bucket, as returned by getResourceClassBucket.
+func (c *Controller) getResourceClassCapacity(bucket string) int {
+	if bucket == "" {
+		return c.maxJobsRunning
+	}
+	return c.resourceClasses[bucket]
+}
+
+// hasFreeJobSlot returns true if any resource class, or the jobs without
+// one, can run another job. classJobs holds the number of jobs that each
+// bucket is running. It does not grab a lock, as runScheduler has already
+// grabbed one.
+func (c *Controller) hasFreeJobSlot(classJobs map[string]int) bool {
+	if classJobs[""] < c.maxJobsRunning {
+		return true
+	}
+	for class, maxJobs := range c.resourceClasses {
+		if classJobs[class] < maxJobs {
+			return true
+		}
+	}
+	return false
+}
+
+// checkResourceClassCapacity returns an empty string if a job in the given
+// resource class can start now, or otherwise the reason why its step must
+// wait. classJobs holds the number of jobs that each bucket is running, and
+// classWaiting the number of steps already waiting for each bucket. It does
+// not grab a lock, as runScheduler has already grabbed one.
+func (c *Controller) checkResourceClassCapacity(class string, classJobs map[string]int, classWaiting map[string]int) string {
+	bucket := c.getResourceClassBucket(class)
+	maxJobs := c.getResourceClassCapacity(bucket)
+	if classJobs[bucket] < maxJobs {
+		return ""
+	}
+
+	// keep these queues apart from the agents' queues in classWaiting
+	queue := "class " + bucket
+	classWaiting[queue]++
+	if bucket == "" {
+		return fmt.Sprintf("waiting for a free job slot (position %d in its queue)", classWaiting[queue])
+	}
+	return fmt.Sprintf("waiting for a free job slot in resource class %s, which is running %d of at most %d jobs (position %d in its queue)", bucket, classJobs[bucket], maxJobs, classWaiting[queue])
+}
+
+// checkResourceClass returns an error if the given resource class is set
+// but isn't one that the Controller is configured with. It doesn't need to
+// grab a lock, as the resource classes don't change once the Controller
+// has been initialized.
+func (c *Controller) checkResourceClass(class string) error {
+	if class == "" {
+		return nil
+	}
+	if _, ok := c.resourceClasses[class]; !ok {
+		return fmt.Errorf("unknown resource class %s", class)
+	}
+	return nil
+}
+
+// checkStepResourceClasses recursively checks that each "agent" step's
+// resource class, if any, is one that the Controller is configured with.
+func (c *Controller) checkStepResourceClasses(sts []*StepTemplate) error {
+	for _, st := range sts {
+		if err := c.checkResourceClass(st.ResourceClass); err != nil {
+			return err
+		}
+		if err := c.checkStepResourceClasses(st.ConcurrentStepTemplates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getActiveJobSetsNewestFirst returns the active JobSets in descending
+// order of ID. Since a sub-JobSet is always created after its parent, it
+// comes before the parent. It does not grab a lock, as runScheduler has
+// already grabbed one.
+func (c *Controller) getActiveJobSetsNewestFirst() []*JobSet {
+	jobSets := make([]*JobSet, 0, len(c.activeJobSets))
+	for _, js := range c.activeJobSets {
+		jobSets = append(jobSets, js)
+	}
+	sort.Slice(jobSets, func(i, j int) bool { return jobSets[i].JobSetID > jobSets[j].JobSetID })
+	return jobSets
+}
+
+// getActiveJobSetsInOrder returns the active JobSets in the order their
+// steps should be considered: highest priority first, and then by ID so
+// that JobSets that were submitted earlier come first. It does not grab a
+// lock, as runScheduler has already grabbed one.
+func (c *Controller) getActiveJobSetsInOrder() []*JobSet {
+	jobSets := make([]*JobSet, 0, len(c.activeJobSets))
+	for _, js := range c.activeJobSets {
+		jobSets = append(jobSets, js)
+	}
+	sort.Slice(jobSets, func(i, j int) bool {
+		if jobSets[i].Priority != jobSets[j].Priority {
+			return jobSets[i].Priority > jobSets[j].Priority
+		}
+		return jobSets[i].JobSetID < jobSets[j].JobSetID
+	})
+	return jobSets
+}
 
-			// finally, check and see whether we're now at max jobs running
-			// and if we are, time to stop
-			if len(c.activeJobs) >= c.maxJobsRunning {
-				return
+// startDueSchedules adds a JobSetRequest to the pending queue for each
+// schedule that is due, or that has a queued run which can now start, and
+// works out when each due schedule is next due. If JobSets from earlier
+// runs of a schedule haven't stopped yet, its overlap policy decides
+// whether to skip this run, queue it until they have, or start it anyway.
+// No requests are added while draining or stopping. It does not grab a
+// lock, as runScheduler has already grabbed one.
+func (c *Controller) startDueSchedules(now time.Time) {
+	if c.draining || !c.openForJobSetRequests {
+		return
+	}
+
+	for _, s := range c.getSchedulesInOrder() {
+		if s.Paused {
+			continue
+		}
+		due := !s.NextRun.IsZero() && !now.Before(s.NextRun)
+		if !due && !s.Queued {
+			continue
+		}
+		if due {
+			// if runs were missed, e.g. while the controller was
+			// stopped, they are folded into this one
+			s.NextRun = getNextScheduleRun(s, now)
+		}
+
+		if s.Cfg.Overlap != pbc.OverlapPolicy_OVERLAP_ALLOW && len(c.getActiveJobSetIDsForSchedule(s.Cfg.Name)) > 0 {
+			if due {
+				if s.Cfg.Overlap == pbc.OverlapPolicy_OVERLAP_QUEUE {
+					s.Queued = true
+				}
+				c.saveSchedule(s)
 			}
+			continue
+		}
+
+		jsr := JobSetRequest{
+			TemplateName:      s.Cfg.JstName,
+			Configs:           map[string]string{},
+			RequestedJobSetID: c.nextJobSetID,
+			Priority:          s.Cfg.Priority,
+			Tenant:            s.Cfg.Tenant,
+			ScheduleName:      s.Cfg.Name,
+		}
+		for _, cfg := range s.Cfg.Cfgs {
+			jsr.Configs[cfg.Key] = cfg.Value
+		}
+		c.nextJobSetID++
+		c.saveNextIDs()
+		c.pendingJSRs.PushBack(jsr)
+
+		s.Queued = false
+		s.LastRun = now
+		s.LastJobSetID = jsr.RequestedJobSetID
+		c.saveSchedule(s)
+	}
+}
+
+// getNextScheduleRun returns the first time after the given time at which
+// the schedule is due, or the zero time if it never is.
+func getNextScheduleRun(s *Schedule, after time.Time) time.Time {
+	cs, err := parseCron(s.Cfg.Cron)
+	if err != nil {
+		// the spec was checked when the schedule was added
+		return time.Time{}
+	}
+	return cs.nextRun(after)
+}
+
+// getSchedulesInOrder returns all schedules, sorted by name. It does not
+// grab a lock, as its callers have already grabbed one.
+func (c *Controller) getSchedulesInOrder() []*Schedule {
+	schedules := []*Schedule{}
+	for _, s := range c.schedules {
+		schedules = append(schedules, s)
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].Cfg.Name < schedules[j].Cfg.Name })
+	return schedules
+}
+
+// getActiveJobSetIDsForSchedule returns the IDs of the active JobSets
+// that were started by the named schedule, in ID order. It does not grab
+// a lock, as its callers have already grabbed one.
+func (c *Controller) getActiveJobSetIDsForSchedule(name string) []uint64 {
+	ids := []uint64{}
+	for _, js := range c.activeJobSets {
+		if js.ScheduleName == name {
+			ids = append(ids, js.JobSetID)
 		}
 	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
 }
 
 // updateJobSetStatusForJob updates the status of the JobSet containing the
@@ -125,8 +641,27 @@ func (c *Controller) updateJobSetStatusForJob(job *Job) {
 		log.Fatalf("failed; job ID %d has unknown job set ID %d", job.JobID, job.JobSetID)
 	}
 
+	c.updateJobSetStatus(js)
+}
+
+// updateJobSetStatus updates the status of the given JobSet, based on the
+// current run and health status of its steps. It does not grab a lock, as
+// its callers have already grabbed one.
+func (c *Controller) updateJobSetStatus(js *JobSet) {
+	// a cancelled jobSet keeps the status it had when it was cancelled,
+	// even as its remaining Jobs report that they have stopped, but the
+	// steps that were waiting for those Jobs can now stop too
+	if js.Cancelled {
+		stopCancelledSteps(js.Steps)
+		c.saveJobSet(js)
+		return
+	}
+
 	newStatus, newHealth := c.determineStepStatuses(js.Steps)
 	if newStatus != pbs.Status_STATUS_SAME {
+		if newStatus == pbs.Status_STOPPED && js.RunStatus != pbs.Status_STOPPED {
+			js.TimeFinished = time.Now()
+		}
 		js.RunStatus = newStatus
 	}
 	if newHealth != pbs.Health_HEALTH_SAME {
@@ -145,8 +680,11 @@ func (c *Controller) determineStepStatuses(steps []*Step) (pbs.Status, pbs.Healt
 
 	for _, step := range steps {
 		// first, if concurrent, get sub-steps' own status and health
-		// so we can update the concurrent step itself
-		if step.T == StepTypeConcurrent {
+		// so we can update the concurrent step itself, unless it was
+		// skipped along with all of its sub-steps. likewise for foreach,
+		// once its sub-steps have been created.
+		if (step.T == StepTypeConcurrent && step.RunStatus != pbs.Status_SKIPPED) ||
+			(step.T == StepTypeForEach && len(step.ConcurrentSteps) > 0) {
 			// run recursively on sub-steps
 			subStatus, subHealth := c.determineStepStatuses(step.ConcurrentSteps)
 
@@ -158,8 +696,9 @@ func (c *Controller) determineStepStatuses(steps []*Step) (pbs.Status, pbs.Healt
 			}
 		}
 
-		// if jobset, get the separate jobSet's status and health
-		if step.T == StepTypeJobSet {
+		// if jobset, get the separate jobSet's status and health, once it
+		// has been created
+		if step.T == StepTypeJobSet && step.SubJobSetID != 0 {
 			subJs, ok := c.jobSets[step.SubJobSetID]
 			if !ok {
 				// FIXME this shouldn't happen; job with unknown jobSet ID
@@ -167,21 +706,32 @@ func (c *Controller) determineStepStatuses(steps []*Step) (pbs.Status, pbs.Healt
 			}
 			step.RunStatus = subJs.RunStatus
 			step.HealthStatus = subJs.HealthStatus
+			// a sub-jobSet that was cancelled on its own didn't finish
+			// its work, so this step has failed
+			if subJs.Cancelled {
+				step.HealthStatus = pbs.Health_ERROR
+			}
 		}
 
 		// now, evaluate and bubble upwards for this step
-		// if it is still running or in startup, check health but go on
-		if step.RunStatus != pbs.Status_STOPPED {
+		// if it is still running or in startup, check health but go on.
+		// a skipped step counts as stopped.
+		if step.RunStatus != pbs.Status_STOPPED && step.RunStatus != pbs.Status_SKIPPED {
 			allStopped = false
 		}
 		// check and update health
-		// note degraded, unless we're already in error state
-		if step.HealthStatus == pbs.Health_DEGRADED && newHealth != pbs.Health_ERROR {
+		// note degraded, unless we're already in error state. a step that
+		// is allowed to fail only counts as degraded if it errors.
+		stepHealth := step.HealthStatus
+		if stepHealth == pbs.Health_ERROR && step.AllowFailure {
+			stepHealth = pbs.Health_DEGRADED
+		}
+		if stepHealth == pbs.Health_DEGRADED && newHealth != pbs.Health_ERROR {
 			newHealth = pbs.Health_DEGRADED
 		}
 		// and error health means the overall set of steps will be in error
 		// and should also stop
-		if step.HealthStatus == pbs.Health_ERROR {
+		if stepHealth == pbs.Health_ERROR {
 			newStatus = pbs.Status_STOPPED
 			newHealth = pbs.Health_ERROR
 		}
@@ -206,14 +756,37 @@ func (c *Controller) determineStepStatuses(steps []*Step) (pbs.Status, pbs.Healt
 // in the returned steps; instead, its children (potentially including more
 // sub-concurrent steps) should be handled as described above and included
 // in the returned steps if they are of type "agent".
+// If the JobSet's steps form a DAG, every step whose dependencies have
+// finished is ready to run, not just the first one that hasn't finished.
 func (c *Controller) getReadyStepsForJobSet(js *JobSet) []*Step {
-	readyAgentSteps, readyJobSetSteps, problem := retrieveReadySteps(js.Steps)
+	var readyAgentSteps, readyJobSetSteps []*Step
+	var problem bool
+	if hasStepDependencies(js.Steps) {
+		readyAgentSteps, readyJobSetSteps, problem = retrieveDAGReadySteps(js, js.Steps)
+	} else {
+		readyAgentSteps, readyJobSetSteps, problem = retrieveReadySteps(js, js.Steps)
+	}
 
 	if problem {
 		// some problem occurred; return and don't provide any ready steps
 		return nil
 	}
 
+	// a step naming an agent that has since been removed can never run,
+	// so fail it rather than leaving it waiting
+	stillReady := readyAgentSteps[:0]
+	for _, step := range readyAgentSteps {
+		if _, ok := c.agents[step.AgentName]; step.AgentType == "" && !ok {
+			step.RunStatus = pbs.Status_STOPPED
+			step.HealthStatus = pbs.Health_ERROR
+			step.WaitingReason = ""
+			js.ErrorMessages += fmt.Sprintf("step %d failed: agent %s was removed\n", step.StepID, step.AgentName)
+			continue
+		}
+		stillReady = append(stillReady, step)
+	}
+	readyAgentSteps = stillReady
+
 	// create JobSetRequests for each JobSet that is ready
 	if c.openForJobSetRequests {
 		for _, jsStep := range readyJobSetSteps {
@@ -225,11 +798,25 @@ func (c *Controller) getReadyStepsForJobSet(js *JobSet) []*Step {
 				continue
 			}
 
+			// a foreach step's children each add their own item
+			configs := parentJobSet.Configs
+			if len(jsStep.SubJobSetConfigs) > 0 {
+				configs = map[string]string{}
+				for k, v := range parentJobSet.Configs {
+					configs[k] = v
+				}
+				for k, v := range jsStep.SubJobSetConfigs {
+					configs[k] = v
+				}
+			}
+
 			jsr := JobSetRequest{
 				TemplateName:    jsStep.SubJobSetTemplateName,
-				Configs:         parentJobSet.Configs,
+				Configs:         configs,
 				ParentJobSetID:  parentJobSetID,
 				ParentJobStepID: jsStep.StepID,
+				Priority:        parentJobSet.Priority,
+				Tenant:          parentJobSet.Tenant,
 			}
 			// add directly to pendingJSRs list; don't send through channel
 			// because this is the same goroutine that would need to read
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"testing"
+
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+func TestPickTenant(t *testing.T) {
+	c := &Controller{tenants: map[string]pbc.TenantConfig{
+		"heavy":      {Name: "heavy", Weight: 3},
+		"guaranteed": {Name: "guaranteed", MinJobs: 2},
+	}}
+	ready := []*Step{{}}
+
+	tests := []struct {
+		name       string
+		tenants    []string
+		tenantJobs map[string]uint32
+		want       string
+	}{
+		{"first listed breaks ties", []string{"a", "b"}, map[string]uint32{}, "a"},
+		{"fewest running jobs", []string{"a", "b"}, map[string]uint32{"a": 2, "b": 1}, "b"},
+		{"jobs relative to weight", []string{"a", "heavy"}, map[string]uint32{"a": 1, "heavy": 2}, "heavy"},
+		{"weight is used up", []string{"a", "heavy"}, map[string]uint32{"a": 1, "heavy": 3}, "a"},
+		{"below minimum comes first", []string{"a", "guaranteed"}, map[string]uint32{"guaranteed": 1}, "guaranteed"},
+		{"at minimum shares as usual", []string{"a", "guaranteed"}, map[string]uint32{"guaranteed": 2, "a": 1}, "a"},
+	}
+	for _, tc := range tests {
+		tenantSteps := map[string][]*Step{}
+		for _, tenant := range tc.tenants {
+			tenantSteps[tenant] = ready
+		}
+		got, ok := c.pickTenant(tc.tenants, tenantSteps, tc.tenantJobs)
+		if !ok || got != tc.want {
+			t.Errorf("%s: expected %s, got %s (%v)", tc.name, tc.want, got, ok)
+		}
+	}
+
+	// tenants without ready steps are passed over
+	got, ok := c.pickTenant([]string{"a", "b"}, map[string][]*Step{"b": ready}, map[string]uint32{"b": 5})
+	if !ok || got != "b" {
+		t.Errorf("expected b, got %s (%v)", got, ok)
+	}
+	if _, ok := c.pickTenant([]string{"a"}, map[string][]*Step{}, map[string]uint32{}); ok {
+		t.Error("expected no tenant when none has ready steps")
+	}
+}
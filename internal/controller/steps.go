@@ -3,8 +3,9 @@
 package controller
 
 import (
-	"container/list"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/swinslow/peridot-core/pkg/agent"
 	pbs "github.com/swinslow/peridot-core/pkg/status"
@@ -21,8 +22,8 @@ func findStepInSteps(steps []*Step, stepID uint64) *Step {
 		if step.StepID == stepID {
 			return step
 		}
-		// if concurrent, check this step's sub-steps too
-		if step.T == StepTypeConcurrent {
+		// if concurrent or foreach, check this step's sub-steps too
+		if step.T == StepTypeConcurrent || step.T == StepTypeForEach {
 			checkStep := findStepInSteps(step.ConcurrentSteps, stepID)
 			if checkStep != nil {
 				return checkStep
@@ -32,19 +33,54 @@ func findStepInSteps(steps []*Step, stepID uint64) *Step {
 	return nil
 }
 
+// findStepByName finds the step with the given name within this Steps
+// slice, checking recursively within concurrent sub-steps. It returns a
+// pointer to the Step or nil if not found.
+// It will not grab a reader lock, as it assumes that the calling function
+// has already grabbed one if needed.
+func findStepByName(steps []*Step, name string) *Step {
+	for _, step := range steps {
+		if step.Name == name {
+			return step
+		}
+		if step.T == StepTypeConcurrent {
+			if checkStep := findStepByName(step.ConcurrentSteps, name); checkStep != nil {
+				return checkStep
+			}
+		}
+	}
+	return nil
+}
+
 // createStepsFromTemplate gets the recursive creation of steps going.
 // It discards the nextStepID since we don't need it any longer.
-func createStepsFromTemplate(js *JobSet, pendingJSRs *list.List, sts []*StepTemplate) []*Step {
-	steps, _ := createStepsFromTemplateHelper(js, pendingJSRs, sts, 1)
+// The top-level steps' dependencies are then resolved from step names into
+// step IDs.
+func createStepsFromTemplate(js *JobSet, sts []*StepTemplate) []*Step {
+	steps, _ := createStepsFromTemplateHelper(js, sts, 1)
+
+	stepIDs := map[string]uint64{}
+	for i, st := range sts {
+		if st.Name != "" {
+			stepIDs[st.Name] = steps[i].StepID
+		}
+	}
+	for i, st := range sts {
+		for _, dep := range st.DependsOn {
+			steps[i].DependsOn = append(steps[i].DependsOn, stepIDs[dep])
+		}
+	}
+
 	return steps
 }
 
 // createStepsFromTemplateHelper recursively creates a set of actual Steps
-// within a JobSet based on its JobSetTemplate Steps. It will enqueue new
-// pending JSRs where needed for additional JobSets. It returns the created
-// Steps as well as the next Step ID to be used so that subsequent recursive
-// calls continue to update with unique and ordered Step IDs.
-func createStepsFromTemplateHelper(js *JobSet, pendingJSRs *list.List, sts []*StepTemplate, nextStepID uint64) ([]*Step, uint64) {
+// within a JobSet based on its JobSetTemplate Steps. The JobSets for
+// "jobset" steps are not requested until the steps are reached, since
+// they might be skipped. It returns the created Steps as well as the next
+// Step ID to be used so that subsequent recursive calls continue to update
+// with unique and ordered Step IDs.
+func createStepsFromTemplateHelper(js *JobSet, sts []*StepTemplate, nextStepID uint64) ([]*Step, uint64) {
 	steps := []*Step{}
 
 	for _, st := range sts {
@@ -56,6 +92,8 @@ func createStepsFromTemplateHelper(js *JobSet, pendingJSRs *list.List, sts []*St
 			StepOrder:    nextStepID,
 			RunStatus:    pbs.Status_STARTUP,
 			HealthStatus: pbs.Health_OK,
+			Name:         st.Name,
+			Condition:    cloneStepCondition(st.Condition),
 		}
 		nextStepID++
 
@@ -64,27 +102,33 @@ func createStepsFromTemplateHelper(js *JobSet, pendingJSRs *list.List, sts []*St
 		case StepTypeAgent:
 			// ===== AGENT =====
 			step.AgentName = st.AgentName
+			step.AgentType = st.AgentType
+			step.PoolStrategy = st.PoolStrategy
+			step.Retry = cloneRetryPolicy(st.Retry)
+			step.Timeout = st.Timeout
+			step.ResourceClass = st.ResourceClass
+			step.AllowFailure = st.AllowFailure
 
 		case StepTypeJobSet:
 			// ===== JOBSET =====
+			// getReadyStepsForJobSet will request the new JobSet once
+			// this step is reached
 			step.SubJobSetTemplateName = st.JSTemplateName
-			// create and enqueue new JobSetRequest
-			jsr := JobSetRequest{
-				TemplateName:    st.JSTemplateName,
-				Configs:         map[string]string{},
-				ParentJobSetID:  js.JobSetID,
-				ParentJobStepID: step.StepID,
-			}
-			// copy over all config strings from parent JobSet
-			for k, v := range js.Configs {
-				jsr.Configs[k] = v
-			}
-			// and submit the request
-			pendingJSRs.PushBack(jsr)
+			step.AllowFailure = st.AllowFailure
+
+		case StepTypeForEach:
+			// ===== FOREACH =====
+			// the child "jobset" steps are created once this step is
+			// reached, when the items are known
+			step.SubJobSetTemplateName = st.JSTemplateName
+			step.ForEachConfigKey = st.ForEachConfigKey
+			step.ForEachItemKey = st.ForEachItemKey
+			step.ForEachMaxParallel = st.ForEachMaxParallel
+			step.AllowFailure = st.AllowFailure
 
 		case StepTypeConcurrent:
 			// ===== CONCURRENT =====
-			step.ConcurrentSteps, nextStepID = createStepsFromTemplateHelper(js, pendingJSRs, st.ConcurrentStepTemplates, nextStepID)
+			step.ConcurrentSteps, nextStepID = createStepsFromTemplateHelper(js, st.ConcurrentStepTemplates, nextStepID)
 		}
 
 		// and add this step to the steps slice
@@ -100,10 +144,13 @@ func createStepsFromTemplateHelper(js *JobSet, pendingJSRs *list.List, sts []*St
 // a slice of pointers to "jobset" steps that have not yet been queued and are
 // ready to be added as new JobSetRequests.
 // It will recursively read through any "concurrent" steps in order to bubble
-// up any "agent" and "jobset" steps that are contained therein.
+// up any "agent" and "jobset" steps that are contained therein, and through
+// the children of any "foreach" steps.
+// Steps that are reached but whose conditions aren't met, checked against
+// the given JobSet, are marked as SKIPPED and passed over.
 // It also returns a boolean, which will be set to true if there is some
 // failure or error detected which should prevent running any further steps.
-func retrieveReadySteps(steps []*Step) ([]*Step, []*Step, bool) {
+func retrieveReadySteps(js *JobSet, steps []*Step) ([]*Step, []*Step, bool) {
 	// walk through the steps in order, checking whether to proceed and/or
 	// whether to add a new step as ready
 	for _, step := range steps {
@@ -114,8 +161,8 @@ func retrieveReadySteps(steps []*Step) ([]*Step, []*Step, bool) {
 			return nil, nil, false
 
 		case pbs.Status_STOPPED:
-			// check whether this step errored out
-			if step.HealthStatus == pbs.Health_ERROR {
+			// check whether this step errored out, and isn't allowed to
+			if step.HealthStatus == pbs.Health_ERROR && !step.AllowFailure {
 				// this step failed. We don't want to keep running later
 				// steps. This JobSet should be getting an error status
 				// and removed from the active list. For now, we'll just
@@ -123,14 +170,29 @@ func retrieveReadySteps(steps []*Step) ([]*Step, []*Step, bool) {
 				return nil, nil, true
 			}
 
-			// otherwise, no error means keep going past this step
+			// otherwise, no error (or an allowed one) means keep going
+			// past this step
+			continue
+
+		case pbs.Status_SKIPPED:
+			// this step's condition wasn't met; keep going past it
 			continue
 
 		case pbs.Status_STARTUP:
-			// this step is the one which is ready to run. check its type
-			// and figure out which ready steps to add.
+			// this step has been reached. if its condition isn't met,
+			// skip it and go on to the next one
+			if skipStepIfConditionNotMet(js, step) {
+				continue
+			}
+
+			// otherwise, it is the one which is ready to run. check its
+			// type and figure out which ready steps to add.
 			switch step.T {
 			case StepTypeAgent:
+				if waitingToRetry(step) {
+					// it failed and isn't due to be retried yet
+					return nil, nil, false
+				}
 				return []*Step{step}, nil, false
 			case StepTypeJobSet:
 				if step.SubJobSetRequestSubmitted {
@@ -143,8 +205,12 @@ func retrieveReadySteps(steps []*Step) ([]*Step, []*Step, bool) {
 				// for concurrent steps, we now want to pick up EVERY sub-step
 				// within this one that is still in startup state, recursing
 				// through sub-concurrent steps.
-				cAgentSteps, cJobSetSteps := retrieveConcurrentStartupSteps(step.ConcurrentSteps)
+				cAgentSteps, cJobSetSteps := retrieveConcurrentStartupSteps(js, step.ConcurrentSteps)
 				return cAgentSteps, cJobSetSteps, false
+			case StepTypeForEach:
+				// for foreach steps, we want the child "jobset" steps that
+				// can be submitted now
+				return nil, retrieveForEachSteps(js, step), false
 			}
 
 		default:
@@ -158,20 +224,114 @@ func retrieveReadySteps(steps []*Step) ([]*Step, []*Step, bool) {
 	return nil, nil, false
 }
 
+// retrieveDAGReadySteps is like retrieveReadySteps, but for steps that form
+// a DAG. Rather than stopping at the first step that hasn't finished, it
+// treats every step whose dependencies have all finished as reached. Steps
+// are visited in dependency order, so that if a step is skipped, the steps
+// that depend on it can be reached on the same pass.
+func retrieveDAGReadySteps(js *JobSet, steps []*Step) ([]*Step, []*Step, bool) {
+	for _, step := range steps {
+		if step.RunStatus == pbs.Status_STOPPED && step.HealthStatus == pbs.Health_ERROR && !step.AllowFailure {
+			// this step failed, so as for sequential steps, we don't want
+			// to start any others
+			return nil, nil, true
+		}
+	}
+
+	readyAgentSteps := []*Step{}
+	readyJobSetSteps := []*Step{}
+	for _, step := range orderStepsByDependencies(steps) {
+		if !dependenciesFinished(steps, step) {
+			continue
+		}
+		// this step has been reached, so it is handled just like a step
+		// within a concurrent step
+		subAgents, subJobSets := retrieveConcurrentStartupSteps(js, []*Step{step})
+		readyAgentSteps = append(readyAgentSteps, subAgents...)
+		readyJobSetSteps = append(readyJobSetSteps, subJobSets...)
+	}
+	return readyAgentSteps, readyJobSetSteps, false
+}
+
+// hasStepDependencies returns true if any of the given top-level steps
+// depends on another, meaning that they form a DAG rather than running in
+// sequence.
+func hasStepDependencies(steps []*Step) bool {
+	for _, step := range steps {
+		if len(step.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dependenciesFinished returns true if every step that the given step
+// depends on has finished, i.e. has stopped or been skipped.
+func dependenciesFinished(steps []*Step, step *Step) bool {
+	for _, depID := range step.DependsOn {
+		dep := findStepInSteps(steps, depID)
+		if dep == nil {
+			return false
+		}
+		if _, finished := getStepOutcome(dep); !finished {
+			return false
+		}
+	}
+	return true
+}
+
+// orderStepsByDependencies returns the given top-level steps sorted so that
+// each one comes after the steps it depends on, and otherwise in their
+// original order.
+func orderStepsByDependencies(steps []*Step) []*Step {
+	ordered := []*Step{}
+	placed := map[uint64]bool{}
+	for len(ordered) < len(steps) {
+		progress := false
+		for _, step := range steps {
+			if placed[step.StepID] {
+				continue
+			}
+			ready := true
+			for _, depID := range step.DependsOn {
+				if !placed[depID] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, step)
+				placed[step.StepID] = true
+				progress = true
+			}
+		}
+		if !progress {
+			// the remaining steps must form a cycle, which templates are
+			// checked for when they are added. we'll never reach them.
+			break
+		}
+	}
+	return ordered
+}
+
 // retrieveConcurrentStartupSteps recursively retrieves all steps within
 // this one that are in STARTUP state. It returns a slice of "agent" steps
-// and a slice of "jobset" steps.
-func retrieveConcurrentStartupSteps(steps []*Step) ([]*Step, []*Step) {
+// and a slice of "jobset" steps. As with retrieveReadySteps, steps whose
+// conditions aren't met are marked as SKIPPED instead.
+func retrieveConcurrentStartupSteps(js *JobSet, steps []*Step) ([]*Step, []*Step) {
 	readyAgentSteps := []*Step{}
 	readyJobSetSteps := []*Step{}
 
 	for _, step := range steps {
-		if step.RunStatus == pbs.Status_STARTUP {
+		if step.RunStatus == pbs.Status_STARTUP && !skipStepIfConditionNotMet(js, step) {
 			// this step is ready to run; check its type and figure out
 			// where to put it, and/or its sub-steps.
 			switch step.T {
 			case StepTypeAgent:
-				readyAgentSteps = append(readyAgentSteps, step)
+				// skip any that are waiting to be retried
+				if !waitingToRetry(step) {
+					readyAgentSteps = append(readyAgentSteps, step)
+				}
 			case StepTypeJobSet:
 				// only return those that are not yet submitted
 				if !step.SubJobSetRequestSubmitted {
@@ -179,27 +339,296 @@ func retrieveConcurrentStartupSteps(steps []*Step) ([]*Step, []*Step) {
 				}
 			case StepTypeConcurrent:
 				// recursively retrieve all of its children
-				subAgents, subJobSets := retrieveConcurrentStartupSteps(step.ConcurrentSteps)
+				subAgents, subJobSets := retrieveConcurrentStartupSteps(js, step.ConcurrentSteps)
 				for _, aStep := range subAgents {
 					readyAgentSteps = append(readyAgentSteps, aStep)
 				}
 				for _, jsStep := range subJobSets {
 					readyJobSetSteps = append(readyJobSetSteps, jsStep)
 				}
+			case StepTypeForEach:
+				// retrieve the children that can be submitted now
+				readyJobSetSteps = append(readyJobSetSteps, retrieveForEachSteps(js, step)...)
 			}
 		}
 	}
 	return readyAgentSteps, readyJobSetSteps
 }
 
+// skipStepIfConditionNotMet checks the condition, if any, of a step that
+// has just been reached. If it isn't met, the step and any steps within it
+// are marked as SKIPPED, and it returns true.
+func skipStepIfConditionNotMet(js *JobSet, step *Step) bool {
+	if step.Condition == nil {
+		return false
+	}
+	reason := checkStepCondition(js, step.Condition)
+	if reason == "" {
+		return false
+	}
+
+	skipStep(step)
+	js.OutputMessages += fmt.Sprintf("step %d skipped: %s\n", step.StepID, reason)
+	return true
+}
+
+// checkStepCondition returns an empty string if the given condition is met
+// within the JobSet, or otherwise the reason why it isn't.
+func checkStepCondition(js *JobSet, cond *StepCondition) string {
+	if cond.ConfigKey != "" {
+		value := js.Configs[cond.ConfigKey]
+		if value == "" {
+			return fmt.Sprintf("config key %s is not set", cond.ConfigKey)
+		}
+		if cond.ConfigValue != "" && value != cond.ConfigValue {
+			return fmt.Sprintf("config key %s is %q, not %q", cond.ConfigKey, value, cond.ConfigValue)
+		}
+	}
+
+	if cond.StepName != "" {
+		priorStep := findStepByName(js.Steps, cond.StepName)
+		if priorStep == nil {
+			return fmt.Sprintf("step %s does not exist", cond.StepName)
+		}
+		outcome, finished := getStepOutcome(priorStep)
+		if !finished {
+			return fmt.Sprintf("step %s has not finished", cond.StepName)
+		}
+		outcomes := cond.StepOutcomes
+		if len(outcomes) == 0 {
+			outcomes = []StepOutcome{StepOutcomeOK, StepOutcomeDegraded}
+		}
+		met := false
+		for _, o := range outcomes {
+			if o == outcome {
+				met = true
+			}
+		}
+		if !met {
+			return fmt.Sprintf("step %s finished as %s", cond.StepName, outcome)
+		}
+	}
+
+	return ""
+}
+
+// getStepOutcome returns how the given step finished, or false if it
+// hasn't finished yet.
+func getStepOutcome(step *Step) (StepOutcome, bool) {
+	switch step.RunStatus {
+	case pbs.Status_SKIPPED:
+		return StepOutcomeSkipped, true
+	case pbs.Status_STOPPED:
+		switch step.HealthStatus {
+		case pbs.Health_DEGRADED:
+			return StepOutcomeDegraded, true
+		case pbs.Health_ERROR:
+			return StepOutcomeError, true
+		default:
+			return StepOutcomeOK, true
+		}
+	}
+	return StepOutcomeOK, false
+}
+
+// retrieveForEachSteps returns the child "jobset" steps of a "foreach" step
+// that have not yet been queued, as many as its parallelism limit allows.
+// The child steps are first created when the step is reached.
+func retrieveForEachSteps(js *JobSet, step *Step) []*Step {
+	if step.ConcurrentSteps == nil {
+		expandForEachStep(js, step)
+	}
+
+	// count the children that have been queued and haven't yet stopped
+	var running uint32
+	for _, subStep := range step.ConcurrentSteps {
+		if subStep.SubJobSetRequestSubmitted && subStep.RunStatus != pbs.Status_STOPPED {
+			running++
+		}
+	}
+
+	readyJobSetSteps := []*Step{}
+	for _, subStep := range step.ConcurrentSteps {
+		if step.ForEachMaxParallel > 0 && running >= step.ForEachMaxParallel {
+			break
+		}
+		if subStep.RunStatus == pbs.Status_STARTUP && !subStep.SubJobSetRequestSubmitted {
+			readyJobSetSteps = append(readyJobSetSteps, subStep)
+			running++
+		}
+	}
+	return readyJobSetSteps
+}
+
+// expandForEachStep creates a child "jobset" step for each item listed in
+// the "foreach" step's config key. The children are given step IDs after
+// all of the JobSet's existing steps. If there are no items, the step
+// fails.
+func expandForEachStep(js *JobSet, step *Step) {
+	step.ConcurrentSteps = []*Step{}
+
+	items := splitForEachItems(js.Configs[step.ForEachConfigKey])
+	if len(items) == 0 {
+		step.RunStatus = pbs.Status_STOPPED
+		step.HealthStatus = pbs.Health_ERROR
+		js.ErrorMessages += fmt.Sprintf("step %d failed: config %s lists no items\n", step.StepID, step.ForEachConfigKey)
+		return
+	}
+
+	nextStepID := getMaxStepID(js.Steps) + 1
+	for _, item := range items {
+		step.ConcurrentSteps = append(step.ConcurrentSteps, &Step{
+			T:                     StepTypeJobSet,
+			JobSetID:              js.JobSetID,
+			StepID:                nextStepID,
+			StepOrder:             nextStepID,
+			RunStatus:             pbs.Status_STARTUP,
+			HealthStatus:          pbs.Health_OK,
+			AllowFailure:          step.AllowFailure,
+			SubJobSetTemplateName: step.SubJobSetTemplateName,
+			SubJobSetConfigs:      map[string]string{step.ForEachItemKey: item},
+		})
+		nextStepID++
+	}
+	js.OutputMessages += fmt.Sprintf("step %d: running %s for %d items\n", step.StepID, step.SubJobSetTemplateName, len(items))
+}
+
+// splitForEachItems splits a comma-separated list of items, ignoring any
+// surrounding whitespace and empty items.
+func splitForEachItems(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getMaxStepID returns the highest step ID among the given steps,
+// recursing into concurrent and foreach steps.
+func getMaxStepID(steps []*Step) uint64 {
+	var maxID uint64
+	for _, step := range steps {
+		if step.StepID > maxID {
+			maxID = step.StepID
+		}
+		if subMaxID := getMaxStepID(step.ConcurrentSteps); subMaxID > maxID {
+			maxID = subMaxID
+		}
+	}
+	return maxID
+}
+
+// skipStep marks a step, and recursively any concurrent steps within it,
+// as SKIPPED.
+func skipStep(step *Step) {
+	step.RunStatus = pbs.Status_SKIPPED
+	step.WaitingReason = ""
+	for _, subStep := range step.ConcurrentSteps {
+		skipStep(subStep)
+	}
+}
+
+// stopCancelledSteps marks each of a cancelled JobSet's steps that hasn't
+// started as STOPPED, as well as each concurrent or foreach step whose
+// sub-steps have all stopped. An agent step whose Job is still running is
+// left for the Job to stop. It returns true if all of the steps have now
+// stopped or been skipped.
+func stopCancelledSteps(steps []*Step) bool {
+	allStopped := true
+	for _, step := range steps {
+		if step.RunStatus == pbs.Status_STOPPED || step.RunStatus == pbs.Status_SKIPPED {
+			continue
+		}
+		if step.T == StepTypeAgent && step.RunStatus == pbs.Status_RUNNING {
+			allStopped = false
+			continue
+		}
+		if len(step.ConcurrentSteps) > 0 && !stopCancelledSteps(step.ConcurrentSteps) {
+			allStopped = false
+			continue
+		}
+		step.RunStatus = pbs.Status_STOPPED
+		step.WaitingReason = ""
+	}
+	return allStopped
+}
+
+// waitingToRetry returns true if the step's last Job failed and the step is
+// waiting for its retry backoff to pass before starting another attempt.
+func waitingToRetry(step *Step) bool {
+	return step.T == StepTypeAgent && step.RunStatus == pbs.Status_STARTUP && time.Now().Before(step.RetryAfter)
+}
+
+// getNextRetryTime recursively walks through the steps, and returns the
+// earliest time at which a step that is waiting to be retried will be ready
+// to run. It returns the zero time if no steps are waiting to be retried.
+func getNextRetryTime(steps []*Step) time.Time {
+	var next time.Time
+	for _, step := range steps {
+		var t time.Time
+		switch step.T {
+		case StepTypeAgent:
+			if waitingToRetry(step) {
+				t = step.RetryAfter
+			}
+		case StepTypeConcurrent:
+			t = getNextRetryTime(step.ConcurrentSteps)
+		}
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
+
+// shouldRetryStep returns true if the given Job, which has just stopped,
+// failed in a way that its step's retry policy says should be retried, and
+// the step has attempts left. Jobs that were cancelled, or that belong to a
+// cancelled JobSet, are never retried.
+func shouldRetryStep(js *JobSet, step *Step, job *Job) bool {
+	if job.Status.RunStatus != agent.JobRunStatus_STOPPED || job.Status.HealthStatus != agent.JobHealthStatus_ERROR {
+		return false
+	}
+	if job.Cancelled || js.Cancelled || step.Retry == nil || step.Attempts >= step.Retry.MaxAttempts {
+		return false
+	}
+	if job.ConnectionError {
+		return step.Retry.RetryConnectionErrors
+	}
+	if job.TimedOut {
+		return step.Retry.RetryTimeouts
+	}
+	return step.Retry.RetryAgentErrors
+}
+
+// retryStep moves a step whose Job has failed back to STARTUP, so that the
+// scheduler will start a new Job for it once its backoff has passed. The
+// failed Job is left as it is, so that each attempt's history is kept.
+func retryStep(js *JobSet, step *Step, job *Job) {
+	backoff := step.Retry.Backoff
+	for i := uint32(1); i < step.Attempts; i++ {
+		backoff *= 2
+	}
+
+	step.RunStatus = pbs.Status_STARTUP
+	step.RetryAfter = time.Now().Add(backoff)
+	js.OutputMessages += fmt.Sprintf("step %d: job %d failed (attempt %d of %d); retrying in %v\n", step.StepID, job.JobID, step.Attempts, step.Retry.MaxAttempts, backoff)
+}
+
 // getFinalStep returns a pointer to the last step for the corresponding steps.
-// If it is a concurrent step, it will recurse to point to either an agent or
-// a JobSet as its actual final step.
+// If it is a concurrent or foreach step, it will recurse to point to either
+// an agent or a JobSet as its actual final step.
 func getFinalStep(steps []*Step) *Step {
+	if len(steps) == 0 {
+		return nil
+	}
 	finalStep := steps[len(steps)-1]
 	if finalStep.T == StepTypeAgent || finalStep.T == StepTypeJobSet {
 		return finalStep
-	} else if finalStep.T == StepTypeConcurrent {
+	} else if finalStep.T == StepTypeConcurrent || finalStep.T == StepTypeForEach {
 		return getFinalStep(finalStep.ConcurrentSteps)
 	} else {
 		return nil
@@ -248,7 +677,9 @@ type priorStepID struct {
 
 // getPriorStepIDs returns a slice of all step Job or JobSet IDs, for all
 // "agent" and "jobset" steps prior to the given Step. It will recurse down
-// into prior concurrent steps to include those as well.
+// into prior concurrent steps to include those as well. If the steps form a
+// DAG, only the steps that the given Step's top-level step depends on
+// directly are prior to it.
 func getPriorStepIDs(steps []*Step, curStep *Step) []priorStepID {
 	priorStepIDs := []priorStepID{}
 
@@ -259,6 +690,15 @@ func getPriorStepIDs(steps []*Step, curStep *Step) []priorStepID {
 		return nil
 	}
 
+	if hasStepDependencies(steps) {
+		for _, depID := range curTopStep.DependsOn {
+			if dep := findStepInSteps(steps, depID); dep != nil {
+				priorStepIDs = addPriorStepIDs(priorStepIDs, dep)
+			}
+		}
+		return priorStepIDs
+	}
+
 	// now, walk through until we get to the curTopStep, and add all
 	// preceding steps. If we find a concurrent step, roll in all of its
 	// steps too.
@@ -268,7 +708,7 @@ func getPriorStepIDs(steps []*Step, curStep *Step) []priorStepID {
 		}
 
 		// still prior to current top step; add to prior step IDs
-		addPriorStepIDs(priorStepIDs, step)
+		priorStepIDs = addPriorStepIDs(priorStepIDs, step)
 	}
 
 	return priorStepIDs
@@ -284,8 +724,8 @@ func findTopLevelStepID(steps []*Step, stepID uint64) *Step {
 			return step
 		}
 
-		// or if this is a concurrent step, recurse down into it
-		if step.T == StepTypeConcurrent {
+		// or if this is a concurrent or foreach step, recurse down into it
+		if step.T == StepTypeConcurrent || step.T == StepTypeForEach {
 			cStep := findTopLevelStepID(step.ConcurrentSteps, stepID)
 			if cStep != nil {
 				// this concurrent step contains it, so send back ourself
@@ -299,24 +739,21 @@ func findTopLevelStepID(steps []*Step, stepID uint64) *Step {
 }
 
 // addPriorStepIDs adds the given step to priorStepIDs, recursively including
-// concurrent steps.
-func addPriorStepIDs(priorStepIDs []priorStepID, step *Step) {
-	var ps priorStepID
-
+// concurrent steps, and returns the updated slice.
+func addPriorStepIDs(priorStepIDs []priorStepID, step *Step) []priorStepID {
 	switch step.T {
 	case StepTypeAgent:
-		ps.T = StepTypeAgent
-		ps.agentJobID = step.AgentJobID
+		priorStepIDs = append(priorStepIDs, priorStepID{T: StepTypeAgent, agentJobID: step.AgentJobID})
 
 	case StepTypeJobSet:
-		ps.T = StepTypeJobSet
-		ps.jobSetSubID = step.SubJobSetID
+		priorStepIDs = append(priorStepIDs, priorStepID{T: StepTypeJobSet, jobSetSubID: step.SubJobSetID})
 
-	case StepTypeConcurrent:
+	case StepTypeConcurrent, StepTypeForEach:
 		for _, subStep := range step.ConcurrentSteps {
-			addPriorStepIDs(priorStepIDs, subStep)
+			priorStepIDs = addPriorStepIDs(priorStepIDs, subStep)
 		}
 	}
+	return priorStepIDs
 }
 
 // getJobConfigForStep returns the JobConfig corresponding to a given Step.
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"testing"
+)
+
+func TestOrderStepsByDependencies(t *testing.T) {
+	tests := []struct {
+		name string
+		deps map[uint64][]uint64
+		ids  []uint64
+		want []uint64
+	}{
+		{"no dependencies", nil, []uint64{1, 2, 3}, []uint64{1, 2, 3}},
+		{"already in order", map[uint64][]uint64{2: {1}, 3: {1, 2}}, []uint64{1, 2, 3}, []uint64{1, 2, 3}},
+		{"moved after dependency", map[uint64][]uint64{1: {3}}, []uint64{1, 2, 3}, []uint64{2, 3, 1}},
+		{"reversed chain", map[uint64][]uint64{1: {2}, 2: {3}}, []uint64{1, 2, 3}, []uint64{3, 2, 1}},
+		// step 2 is a concurrent step holding steps 3 and 4
+		{"concurrent step IDs", map[uint64][]uint64{1: {5}, 5: {2}}, []uint64{1, 2, 5}, []uint64{2, 5, 1}},
+		{"cycle left out", map[uint64][]uint64{1: {2}, 2: {1}}, []uint64{1, 2, 3}, []uint64{3}},
+	}
+	for _, tc := range tests {
+		steps := []*Step{}
+		for _, id := range tc.ids {
+			steps = append(steps, &Step{StepID: id, DependsOn: tc.deps[id]})
+		}
+		got := orderStepsByDependencies(steps)
+		ok := len(got) == len(tc.want)
+		for i := 0; ok && i < len(got); i++ {
+			ok = got[i].StepID == tc.want[i]
+		}
+		if !ok {
+			gotIDs := []uint64{}
+			for _, step := range got {
+				gotIDs = append(gotIDs, step.StepID)
+			}
+			t.Errorf("%s: expected order %v, got %v", tc.name, tc.want, gotIDs)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/swinslow/peridot-core/internal/logging"
+
+	pba "github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// Store is the interface for persisting the Controller's state, so that
+// Agents, JobSetTemplates, tenants, schedules, Jobs and JobSets survive a
+// controller restart.
+// The Controller calls the Save functions while it is holding its own
+// writer lock, so a Store will not receive concurrent calls from a single
+// Controller. Each Save function should persist the record before
+// returning. The Controller may save a record again without having changed
+// it, for instance each active JobSet after every scheduler pass, so a
+// Store should make saving an unchanged record cheap.
+type Store interface {
+	// Load returns the full previously-persisted state. If nothing has
+	// been persisted yet, it returns an empty StoreState.
+	Load() (*StoreState, error)
+
+	// SaveAgent persists the configuration for one Agent, replacing any
+	// earlier record with the same name.
+	SaveAgent(cfg *pbc.AgentConfig) error
+
+	// DeleteAgent removes the persisted configuration for the Agent with
+	// the given name, if any.
+	DeleteAgent(name string) error
+
+	// SaveJobSetTemplate persists one JobSetTemplate, replacing any
+	// earlier record with the same name.
+	SaveJobSetTemplate(jst *JobSetTemplate) error
+
+	// SaveTenant persists the configuration for one tenant, replacing any
+	// earlier record with the same name.
+	SaveTenant(cfg *pbc.TenantConfig) error
+
+	// SaveSchedule persists one schedule, including its state, replacing
+	// any earlier record with the same name.
+	SaveSchedule(s *Schedule) error
+
+	// DeleteSchedule removes the persisted schedule with the given name,
+	// if any.
+	DeleteSchedule(name string) error
+
+	// SaveJob persists one Job, replacing any earlier record with the
+	// same ID.
+	SaveJob(job *Job) error
+
+	// SaveJobSet persists one JobSet, including all of its Steps,
+	// replacing any earlier record with the same ID.
+	SaveJobSet(js *JobSet) error
+
+	// SaveNextIDs persists the IDs to be used for the next new Job and
+	// the next new JobSet.
+	SaveNextIDs(nextJobID uint64, nextJobSetID uint64) error
+}
+
+// StoreState is the full collection of persisted Controller state, as
+// returned by a Store when the Controller is initialized.
+type StoreState struct {
+	// ID to be used for the next new Job; 0 if not yet persisted
+	NextJobID uint64
+
+	// ID to be used for the next new JobSet; 0 if not yet persisted
+	NextJobSetID uint64
+
+	// all persisted Agent configurations
+	Agents []*pbc.AgentConfig
+
+	// all persisted JobSetTemplates
+	JobSetTemplates []*JobSetTemplate
+
+	// all persisted tenant configurations
+	Tenants []*pbc.TenantConfig
+
+	// all persisted schedules
+	Schedules []*Schedule
+
+	// all persisted Jobs
+	Jobs []*Job
+
+	// all persisted JobSets
+	JobSets []*JobSet
+}
+
+// loadFromStore fills in the Controller's data holders from the state
+// persisted in its Store. It should only be called from Init, before
+// the Controller is started, so it does not grab a lock.
+func (c *Controller) loadFromStore() error {
+	st, err := c.store.Load()
+	if err != nil {
+		return fmt.Errorf("couldn't load controller state: %v", err)
+	}
+
+	for _, ac := range st.Agents {
+		c.agents[ac.Name] = *ac
+	}
+	for _, jst := range st.JobSetTemplates {
+		c.jobSetTemplates[jst.Name] = jst
+	}
+	for _, tc := range st.Tenants {
+		c.tenants[tc.Name] = *tc
+	}
+	for _, s := range st.Schedules {
+		c.schedules[s.Cfg.Name] = s
+	}
+	for _, job := range st.Jobs {
+		c.jobs[job.JobID] = job
+		if job.Status.RunStatus != pba.JobRunStatus_STOPPED {
+			c.activeJobs[job.JobID] = job
+		}
+	}
+	for _, js := range st.JobSets {
+		c.jobSets[js.JobSetID] = js
+		if js.RunStatus != pbs.Status_STOPPED {
+			c.activeJobSets[js.JobSetID] = js
+		}
+	}
+
+	if st.NextJobID != 0 {
+		c.nextJobID = st.NextJobID
+	}
+	if st.NextJobSetID != 0 {
+		c.nextJobSetID = st.NextJobSetID
+	}
+
+	return nil
+}
+
+// storeFailed records a failure to persist state. The Controller keeps
+// running, since its in-memory state is still correct, but its health is
+// moved to DEGRADED so that the failure is visible via GetStatus.
+// It does not grab a lock, as it is only called by functions that
+// already hold a writer lock.
+func (c *Controller) storeFailed(err error) {
+	logging.Errorf("couldn't persist controller state: %v", err)
+	if c.healthStatus != pbs.Health_ERROR {
+		c.healthStatus = pbs.Health_DEGRADED
+	}
+	c.errorMsg += fmt.Sprintf("couldn't persist controller state: %v\n", err)
+}
+
+// saveAgent persists the given Agent configuration. It does not grab a
+// lock, as callers should already hold a writer lock.
+func (c *Controller) saveAgent(cfg *pbc.AgentConfig) {
+	if err := c.store.SaveAgent(cfg); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// deleteAgent removes the persisted configuration for the given Agent.
+// It does not grab a lock, as callers should already hold a writer lock.
+func (c *Controller) deleteAgent(name string) {
+	if err := c.store.DeleteAgent(name); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// saveJobSetTemplate persists the given JobSetTemplate. It does not grab a
+// lock, as callers should already hold a writer lock.
+func (c *Controller) saveJobSetTemplate(jst *JobSetTemplate) {
+	if err := c.store.SaveJobSetTemplate(jst); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// saveTenant persists the given tenant configuration. It does not grab a
+// lock, as callers should already hold a writer lock.
+func (c *Controller) saveTenant(cfg *pbc.TenantConfig) {
+	if err := c.store.SaveTenant(cfg); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// saveSchedule persists the given schedule. It does not grab a lock, as
+// callers should already hold a writer lock.
+func (c *Controller) saveSchedule(s *Schedule) {
+	if err := c.store.SaveSchedule(s); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// deleteSchedule removes the persisted schedule with the given name. It
+// does not grab a lock, as callers should already hold a writer lock.
+func (c *Controller) deleteSchedule(name string) {
+	if err := c.store.DeleteSchedule(name); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// saveJob persists the given Job. It does not grab a lock, as callers
+// should already hold a writer lock.
+func (c *Controller) saveJob(job *Job) {
+	if err := c.store.SaveJob(job); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// saveJobSet persists the given JobSet. It does not grab a lock, as
+// callers should already hold a writer lock.
+func (c *Controller) saveJobSet(js *JobSet) {
+	if err := c.store.SaveJobSet(js); err != nil {
+		c.storeFailed(err)
+	}
+}
+
+// saveNextIDs persists the current next Job and JobSet IDs. It does not
+// grab a lock, as callers should already hold a writer lock.
+func (c *Controller) saveNextIDs() {
+	if err := c.store.SaveNextIDs(c.nextJobID, c.nextJobSetID); err != nil {
+		c.storeFailed(err)
+	}
+}
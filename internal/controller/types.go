@@ -3,9 +3,11 @@
 package controller
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
 	pbs "github.com/swinslow/peridot-core/pkg/status"
 )
 
@@ -34,6 +36,33 @@ type Job struct {
 	// the job's current status
 	Status agent.StatusReport
 
+	// was the job cancelled? if so, StepSkipped records whether its step
+	// was treated as skipped, rather than as failed
+	Cancelled   bool
+	StepSkipped bool
+
+	// which attempt at its step this job is, starting from 1
+	Attempt uint32
+
+	// did the job fail because its agent couldn't be reached, or because
+	// the stream to the agent failed, rather than because the agent
+	// reported an error?
+	ConnectionError bool
+
+	// how long the job could run before being stopped; 0 means no limit
+	Timeout time.Duration
+
+	// was the job stopped because it ran past Timeout?
+	TimedOut bool
+
+	// the job's resource class, from its step or else its agent; empty
+	// if it has none
+	ResourceClass string
+
+	// when the job's agent last sent anything about it, including replies
+	// to heartbeat status requests; the zero time if it never has
+	LastSeen time.Time
+
 	// has this job been submitted to the JobController?
 	// an instance of any job should only be submitted once.
 	submitted bool
@@ -52,6 +81,21 @@ type JobSet struct {
 	RunStatus    pbs.Status
 	HealthStatus pbs.Health
 
+	// was the jobSet cancelled? if so, RunStatus is STOPPED and
+	// HealthStatus is left as it was when it was cancelled
+	Cancelled bool
+
+	// scheduling priority; ready steps from jobSets with a higher
+	// priority are started first. sub-jobSets inherit their parent's.
+	Priority int32
+
+	// tenant that owns this jobSet, for sharing running job slots
+	// fairly. sub-jobSets inherit their parent's.
+	Tenant string
+
+	// name of the schedule that started this jobSet, if any
+	ScheduleName string
+
 	// time started and finished
 	TimeStarted  time.Time
 	TimeFinished time.Time
@@ -71,10 +115,12 @@ type JobSet struct {
 }
 
 // Step is a single step within a JobSet. each step must be completed before the
-// next one proceeds. a step can be:
+// next one proceeds, unless the JobSet's steps declare dependencies, in which
+// case each step waits only for those. a step can be:
 // 1) "agent" - represents a single Job run on the specified Agent
 // 2) "jobset" - represents a separate JobSet with its own collection of steps
 // 3) "concurrent" - represents a collection of steps that can run concurrently
+// 4) "foreach" - represents a separate JobSet for each item in a list
 type Step struct {
 	// what type of step is this?
 	T StepType
@@ -93,19 +139,76 @@ type Step struct {
 	RunStatus    pbs.Status
 	HealthStatus pbs.Health
 
-	// "agent" only: what is the corresponding job ID? 0 means not yet assigned
+	// if this step is ready to run but is being held back, why?
+	WaitingReason string
+
+	// what is this step's name, if any?
+	Name string
+
+	// top-level steps only: what are the IDs of the steps that must finish
+	// before this one is reached? if any top-level step in a jobSet has
+	// dependencies, the jobSet's steps form a DAG, as described for
+	// StepTemplate.DependsOn.
+	DependsOn []uint64
+
+	// when should this step run? nil means always. if the condition isn't
+	// met when the step is reached, its RunStatus is set to SKIPPED.
+	Condition *StepCondition
+
+	// "agent", "jobset" and "foreach" only: if this step fails with ERROR
+	// health, should its JobSet carry on regardless? if so, the failure
+	// only makes the JobSet DEGRADED.
+	AllowFailure bool
+
+	// "agent" only: what is the corresponding job ID? 0 means not yet assigned.
+	// if the step has been retried, this is the latest attempt's job.
 	AgentJobID uint64
-	// "agent" only: what is the corresponding agent's name?
+	// "agent" only: what is the corresponding agent's name? if the agent is
+	// picked from a pool, this is empty until a job is started.
 	AgentName string
+	// "agent" only: if set, the agent is picked from the agents of this
+	// type, using PoolStrategy, whenever a job is started for the step
+	AgentType    string
+	PoolStrategy PoolStrategy
+	// "agent" only: how should a failed job be retried? nil means never
+	Retry *RetryPolicy
+	// "agent" only: how long may each job run? 0 means that the agent's
+	// own job timeout, if any, applies
+	Timeout time.Duration
+	// "agent" only: what resource class are the step's jobs in? empty
+	// means that the agent's own resource class, if any, applies
+	ResourceClass string
+	// "agent" only: how many jobs have been started for this step so far?
+	Attempts uint32
+	// "agent" only: if waiting to retry, the earliest time to start the
+	// next attempt
+	RetryAfter time.Time
 
 	// "jobset" only: what is the corresponding jobSet ID? 0 means not yet assigned
 	SubJobSetID uint64
-	// "jobset" only: what is the corresponding jobSet's template name?
+	// "jobset" and "foreach" only: what is the corresponding jobSet's
+	// template name?
 	SubJobSetTemplateName string
 	// "jobset" only: has a JobSetRequest been submitted yet for this new JobSet?
 	SubJobSetRequestSubmitted bool
-
-	// "concurrent" only: what are the concurrent child steps?
+	// "jobset" only: config values for the new JobSet, in addition to (and
+	// overriding) those of this step's JobSet. set for the child steps of
+	// a "foreach" step.
+	SubJobSetConfigs map[string]string
+
+	// "foreach" only: which JobSet config key lists the items, as a
+	// comma-separated list?
+	ForEachConfigKey string
+	// "foreach" only: which config key is set to the item, for each
+	// child JobSet?
+	ForEachItemKey string
+	// "foreach" only: how many child JobSets may run at once? 0 means
+	// no limit.
+	ForEachMaxParallel uint32
+
+	// "concurrent" and "foreach": what are the concurrent child steps?
+	// for "foreach", these are "jobset" steps, one per item, and are only
+	// created once the step is reached.
 	ConcurrentSteps []*Step
 }
 
@@ -120,6 +223,9 @@ const (
 	// StepTypeConcurrent is a step that runs multiple sub-steps, which can
 	// optionally run concurrently with one another.
 	StepTypeConcurrent
+	// StepTypeForEach is a step that runs a separate JobSet for each item
+	// in a list-valued config key, concurrently with one another.
+	StepTypeForEach
 )
 
 // JobSetTemplate is a template for creating jobSets.
@@ -138,19 +244,163 @@ type StepTemplate struct {
 	// T specifies what type of step this is
 	T StepType
 
+	// Condition is for all types: when should the step run? nil means
+	// that it always runs.
+	Condition *StepCondition
+
+	// Name is for all types, and is optional: a name that is unique within
+	// the template, by which other steps' conditions can refer to this
+	// one, and, for top-level steps, by which other steps can depend on it.
+	Name string
+
+	// DependsOn is for top-level steps only: the names of the top-level
+	// steps that must finish before this one is reached. If any step in a
+	// template lists dependencies, its top-level steps form a DAG: each one
+	// is reached as soon as all of its dependencies have finished, and
+	// those that list none are reached right away. Otherwise, each step is
+	// reached once the step before it has finished.
+	DependsOn []string
+
+	// AllowFailure is for "agent", "jobset" and "foreach" types only: if
+	// the step fails with ERROR health, should the JobSet carry on with its
+	// later steps, and be marked DEGRADED rather than ERROR? for "foreach",
+	// this also applies to each item's JobSet.
+	AllowFailure bool
+
 	// AgentName is for "agent" type only: what is the corresponding
 	// agent's name?
 	AgentName string
 
-	// JSTemplateName is for "jobset" only: what is the name of the
-	// corresponding jobSetTemplate?
+	// AgentType is for "agent" type only, as an alternative to AgentName:
+	// the step's Job may run on any agent of this type, picked using
+	// PoolStrategy when the Job is started.
+	AgentType    string
+	PoolStrategy PoolStrategy
+
+	// Retry is for "agent" type only: how should the step's Job be
+	// retried if it fails? nil means that it is never retried.
+	Retry *RetryPolicy
+
+	// Timeout is for "agent" type only: how long may the step's Job run
+	// before it is stopped and marked as timed out? 0 means that the
+	// agent's own job timeout, if any, applies.
+	Timeout time.Duration
+
+	// ResourceClass is for "agent" type only: which resource class is the
+	// step's Job in? If empty, the agent's own resource class applies.
+	ResourceClass string
+
+	// JSTemplateName is for "jobset" and "foreach" only: what is the name
+	// of the corresponding jobSetTemplate?
 	JSTemplateName string
 
+	// ForEachConfigKey is for "foreach" only: which JobSet config key
+	// lists the items, as a comma-separated list?
+	ForEachConfigKey string
+
+	// ForEachItemKey is for "foreach" only: which config key is set to
+	// the item, for each item's JobSet?
+	ForEachItemKey string
+
+	// ForEachMaxParallel is for "foreach" only: how many of the items'
+	// JobSets may run at once? 0 means that there is no limit.
+	ForEachMaxParallel uint32
+
 	// ConcurrentStepTemplates is for "concurrent" only: what are the
 	// templates for the concurrent child steps?
 	ConcurrentStepTemplates []*StepTemplate
 }
 
+// StepCondition says when a step should run. It is evaluated when the step
+// is reached, i.e. when it would otherwise be started, and the step is
+// skipped if it isn't met. Every part of the condition that is set must
+// be met.
+type StepCondition struct {
+	// ConfigKey, if set, is a JobSet config key that must have a
+	// non-empty value, or the value ConfigValue if that is also set.
+	ConfigKey   string
+	ConfigValue string
+
+	// StepName, if set, is the name of a step that must have finished
+	// with one of the outcomes in StepOutcomes. It must be a step that
+	// finishes before this one is reached, i.e. one in an earlier
+	// top-level step, or if the template's steps form a DAG, one in a
+	// top-level step that this one depends on, directly or indirectly.
+	// If StepOutcomes is empty, the step must have finished with OK or
+	// DEGRADED health.
+	StepName     string
+	StepOutcomes []StepOutcome
+}
+
+// StepOutcome is how a step finished, for checking StepConditions.
+type StepOutcome int
+
+const (
+	// StepOutcomeOK is a step that finished with OK health.
+	StepOutcomeOK StepOutcome = iota
+	// StepOutcomeDegraded is a step that finished with DEGRADED health.
+	StepOutcomeDegraded
+	// StepOutcomeError is a step that finished with ERROR health.
+	StepOutcomeError
+	// StepOutcomeSkipped is a step that was skipped because its own
+	// condition wasn't met, or because its Job was cancelled with
+	// skipStep.
+	StepOutcomeSkipped
+)
+
+// String returns the outcome's name, as used in configuration files.
+func (o StepOutcome) String() string {
+	switch o {
+	case StepOutcomeOK:
+		return "ok"
+	case StepOutcomeDegraded:
+		return "degraded"
+	case StepOutcomeError:
+		return "error"
+	case StepOutcomeSkipped:
+		return "skipped"
+	}
+	return fmt.Sprintf("StepOutcome(%d)", int(o))
+}
+
+// PoolStrategy is how an "agent" step that names an agent type picks one
+// of the agents of that type to run its Job. Either way, agents that are
+// unhealthy or already at their own capacity are skipped.
+type PoolStrategy int
+
+const (
+	// PoolLeastLoaded picks the agent running the fewest Jobs, breaking
+	// ties by name.
+	PoolLeastLoaded PoolStrategy = iota
+	// PoolRoundRobin picks each agent of the type in turn, by name.
+	PoolRoundRobin
+)
+
+// RetryPolicy says whether and how an "agent" step's Job is retried if it
+// fails. Each attempt is run as a separate Job for the same step.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of Jobs to run for the step,
+	// including the first. 0 or 1 means that the step is not retried.
+	MaxAttempts uint32
+
+	// Backoff is how long to wait before the first retry. The wait
+	// doubles for each retry after that.
+	Backoff time.Duration
+
+	// RetryConnectionErrors is true if the step should be retried when
+	// its Job failed because the agent couldn't be reached, or because
+	// the stream to the agent failed.
+	RetryConnectionErrors bool
+
+	// RetryAgentErrors is true if the step should be retried when the
+	// agent itself reported that the Job ended with ERROR.
+	RetryAgentErrors bool
+
+	// RetryTimeouts is true if the step should be retried when its Job
+	// was stopped for running past its timeout.
+	RetryTimeouts bool
+}
+
 // JobSetRequest is a request to start a new JobSet, based on a
 // JobSetTemplate that has already been defined.
 type JobSetRequest struct {
@@ -168,4 +418,34 @@ type JobSetRequest struct {
 
 	// step ID within parent JobSet, if being created as a sub-JobSet
 	ParentJobStepID uint64
+
+	// scheduling priority for the new JobSet
+	Priority int32
+
+	// tenant that owns the new JobSet
+	Tenant string
+
+	// schedule that requested the new JobSet, if any
+	ScheduleName string
+}
+
+// Schedule starts a new JobSet from a JobSetTemplate each time its
+// cron-style spec is due.
+type Schedule struct {
+	// the schedule's configuration, as given when it was added
+	Cfg pbc.ScheduleConfig
+
+	// is the schedule paused? if so, it starts no JobSets
+	Paused bool
+
+	// when is the schedule next due? the zero time if it is paused
+	NextRun time.Time
+
+	// when did the schedule last start a JobSet, and which one?
+	LastRun      time.Time
+	LastJobSetID uint64
+
+	// for the "queue" overlap policy: is a run waiting for the JobSets
+	// from earlier runs to stop?
+	Queued bool
 }
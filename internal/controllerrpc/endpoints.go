@@ -4,9 +4,11 @@ package controllerrpc
 
 import (
 	"context"
-	"fmt"
+	"sort"
+	"time"
 
 	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/internal/logging"
 	pbc "github.com/swinslow/peridot-core/pkg/controller"
 )
 
@@ -37,12 +39,27 @@ func (cs *CServer) GetStatus(ctx context.Context, req *pbc.GetStatusReq) (*pbc.G
 
 // Stop corresponds to the Stop endpoint for pkg/controller.
 func (cs *CServer) Stop(ctx context.Context, req *pbc.StopReq) (*pbc.StopResp, error) {
+	if req.Drain {
+		timeout := time.Duration(req.DrainTimeoutSeconds) * time.Second
+		jobIDs, jobSetIDs := cs.C.DrainAndStop(timeout)
+		return &pbc.StopResp{
+			InterruptedJobIDs:    jobIDs,
+			InterruptedJobSetIDs: jobSetIDs,
+		}, nil
+	}
+
 	cs.C.Stop()
 	return &pbc.StopResp{}, nil
 }
 
 // AddAgent corresponds to the AddAgent endpoint for pkg/controller.
 func (cs *CServer) AddAgent(ctx context.Context, req *pbc.AddAgentReq) (*pbc.AddAgentResp, error) {
+	if req.Cfg == nil {
+		return &pbc.AddAgentResp{
+			Success:  false,
+			ErrorMsg: "no agent configuration given",
+		}, nil
+	}
 	err := cs.C.AddAgent(req.Cfg)
 	if err != nil {
 		return &pbc.AddAgentResp{
@@ -53,6 +70,36 @@ func (cs *CServer) AddAgent(ctx context.Context, req *pbc.AddAgentReq) (*pbc.Add
 	return &pbc.AddAgentResp{Success: true}, nil
 }
 
+// UpdateAgent corresponds to the UpdateAgent endpoint for pkg/controller.
+func (cs *CServer) UpdateAgent(ctx context.Context, req *pbc.UpdateAgentReq) (*pbc.UpdateAgentResp, error) {
+	if req.Cfg == nil {
+		return &pbc.UpdateAgentResp{
+			Success:  false,
+			ErrorMsg: "no agent configuration given",
+		}, nil
+	}
+	err := cs.C.UpdateAgent(req.Cfg)
+	if err != nil {
+		return &pbc.UpdateAgentResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.UpdateAgentResp{Success: true}, nil
+}
+
+// RemoveAgent corresponds to the RemoveAgent endpoint for pkg/controller.
+func (cs *CServer) RemoveAgent(ctx context.Context, req *pbc.RemoveAgentReq) (*pbc.RemoveAgentResp, error) {
+	err := cs.C.RemoveAgent(req.Name, req.Force)
+	if err != nil {
+		return &pbc.RemoveAgentResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.RemoveAgentResp{Success: true}, nil
+}
+
 // GetAgent corresponds to the GetAgent endpoint for pkg/controller.
 func (cs *CServer) GetAgent(ctx context.Context, req *pbc.GetAgentReq) (*pbc.GetAgentResp, error) {
 	cfg, err := cs.C.GetAgent(req.Name)
@@ -78,17 +125,33 @@ func createStepTemplateFromProtoSteps(inSteps []*pbc.StepTemplate) []*controller
 	steps := []*controller.StepTemplate{}
 
 	for _, inStep := range inSteps {
-		newStep := &controller.StepTemplate{}
+		newStep := &controller.StepTemplate{
+			Condition:    createStepConditionFromProto(inStep.Condition),
+			AllowFailure: inStep.AllowFailure,
+			Name:         inStep.Name,
+			DependsOn:    inStep.DependsOn,
+		}
 		switch x := inStep.S.(type) {
 		case *pbc.StepTemplate_Agent:
 			newStep.T = controller.StepTypeAgent
 			newStep.AgentName = x.Agent.Name
+			newStep.AgentType = x.Agent.AgentType
+			newStep.PoolStrategy = controller.PoolStrategy(x.Agent.Strategy)
+			newStep.Retry = createRetryPolicyFromProto(x.Agent.Retry)
+			newStep.Timeout = time.Duration(x.Agent.TimeoutMillis) * time.Millisecond
+			newStep.ResourceClass = x.Agent.ResourceClass
 		case *pbc.StepTemplate_Jobset:
 			newStep.T = controller.StepTypeJobSet
 			newStep.JSTemplateName = x.Jobset.Name
 		case *pbc.StepTemplate_Concurrent:
 			newStep.T = controller.StepTypeConcurrent
 			newStep.ConcurrentStepTemplates = createStepTemplateFromProtoSteps(x.Concurrent.Steps)
+		case *pbc.StepTemplate_Foreach:
+			newStep.T = controller.StepTypeForEach
+			newStep.JSTemplateName = x.Foreach.Name
+			newStep.ForEachConfigKey = x.Foreach.ConfigKey
+			newStep.ForEachItemKey = x.Foreach.ItemKey
+			newStep.ForEachMaxParallel = x.Foreach.MaxParallel
 		}
 		steps = append(steps, newStep)
 	}
@@ -96,19 +159,41 @@ func createStepTemplateFromProtoSteps(inSteps []*pbc.StepTemplate) []*controller
 	return steps
 }
 
-func createProtoStepsFromStepTemplate(inSteps []*controller.StepTemplate) []*pbc.StepTemplate {
+// CreateProtoStepsFromStepTemplate converts StepTemplates into their
+// protobuf form. It is exported so that clients which parse templates
+// from a configuration file can submit them.
+func CreateProtoStepsFromStepTemplate(inSteps []*controller.StepTemplate) []*pbc.StepTemplate {
 	steps := []*pbc.StepTemplate{}
 
 	for _, inStep := range inSteps {
-		newStep := &pbc.StepTemplate{}
+		newStep := &pbc.StepTemplate{
+			Condition:    createProtoStepCondition(inStep.Condition),
+			AllowFailure: inStep.AllowFailure,
+			Name:         inStep.Name,
+			DependsOn:    inStep.DependsOn,
+		}
 		switch inStep.T {
 		case controller.StepTypeAgent:
-			newStep.S = &pbc.StepTemplate_Agent{Agent: &pbc.StepAgentTemplate{Name: inStep.AgentName}}
+			newStep.S = &pbc.StepTemplate_Agent{Agent: &pbc.StepAgentTemplate{
+				Name:          inStep.AgentName,
+				AgentType:     inStep.AgentType,
+				Strategy:      pbc.PoolStrategy(inStep.PoolStrategy),
+				Retry:         createProtoRetryPolicy(inStep.Retry),
+				TimeoutMillis: int64(inStep.Timeout / time.Millisecond),
+				ResourceClass: inStep.ResourceClass,
+			}}
 		case controller.StepTypeJobSet:
 			newStep.S = &pbc.StepTemplate_Jobset{Jobset: &pbc.StepJobSetTemplate{Name: inStep.JSTemplateName}}
 		case controller.StepTypeConcurrent:
-			subSteps := createProtoStepsFromStepTemplate(inStep.ConcurrentStepTemplates)
+			subSteps := CreateProtoStepsFromStepTemplate(inStep.ConcurrentStepTemplates)
 			newStep.S = &pbc.StepTemplate_Concurrent{Concurrent: &pbc.StepConcurrentTemplate{Steps: subSteps}}
+		case controller.StepTypeForEach:
+			newStep.S = &pbc.StepTemplate_Foreach{Foreach: &pbc.StepForEachTemplate{
+				Name:        inStep.JSTemplateName,
+				ConfigKey:   inStep.ForEachConfigKey,
+				ItemKey:     inStep.ForEachItemKey,
+				MaxParallel: inStep.ForEachMaxParallel,
+			}}
 		}
 		steps = append(steps, newStep)
 	}
@@ -116,6 +201,62 @@ func createProtoStepsFromStepTemplate(inSteps []*controller.StepTemplate) []*pbc
 	return steps
 }
 
+func createRetryPolicyFromProto(rp *pbc.RetryPolicy) *controller.RetryPolicy {
+	if rp == nil {
+		return nil
+	}
+	return &controller.RetryPolicy{
+		MaxAttempts:           rp.MaxAttempts,
+		Backoff:               time.Duration(rp.BackoffMillis) * time.Millisecond,
+		RetryConnectionErrors: rp.RetryConnectionErrors,
+		RetryAgentErrors:      rp.RetryAgentErrors,
+		RetryTimeouts:         rp.RetryTimeouts,
+	}
+}
+
+func createProtoRetryPolicy(rp *controller.RetryPolicy) *pbc.RetryPolicy {
+	if rp == nil {
+		return nil
+	}
+	return &pbc.RetryPolicy{
+		MaxAttempts:           rp.MaxAttempts,
+		BackoffMillis:         int64(rp.Backoff / time.Millisecond),
+		RetryConnectionErrors: rp.RetryConnectionErrors,
+		RetryAgentErrors:      rp.RetryAgentErrors,
+		RetryTimeouts:         rp.RetryTimeouts,
+	}
+}
+
+func createStepConditionFromProto(cond *pbc.StepCondition) *controller.StepCondition {
+	if cond == nil {
+		return nil
+	}
+	newCond := &controller.StepCondition{
+		ConfigKey:   cond.ConfigKey,
+		ConfigValue: cond.ConfigValue,
+		StepName:    cond.StepName,
+	}
+	for _, outcome := range cond.StepOutcomes {
+		newCond.StepOutcomes = append(newCond.StepOutcomes, controller.StepOutcome(outcome))
+	}
+	return newCond
+}
+
+func createProtoStepCondition(cond *controller.StepCondition) *pbc.StepCondition {
+	if cond == nil {
+		return nil
+	}
+	newCond := &pbc.StepCondition{
+		ConfigKey:   cond.ConfigKey,
+		ConfigValue: cond.ConfigValue,
+		StepName:    cond.StepName,
+	}
+	for _, outcome := range cond.StepOutcomes {
+		newCond.StepOutcomes = append(newCond.StepOutcomes, pbc.StepOutcome(outcome))
+	}
+	return newCond
+}
+
 // AddJobSetTemplate corresponds to the AddJobSetTemplate endpoint for pkg/controller.
 func (cs *CServer) AddJobSetTemplate(ctx context.Context, req *pbc.AddJobSetTemplateReq) (*pbc.AddJobSetTemplateResp, error) {
 	// build the jobSetTemplate structure to send to the controller
@@ -144,7 +285,7 @@ func (cs *CServer) GetJobSetTemplate(ctx context.Context, req *pbc.GetJobSetTemp
 
 	jst := &pbc.JobSetTemplate{
 		Name:  req.Name,
-		Steps: createProtoStepsFromStepTemplate(steps),
+		Steps: CreateProtoStepsFromStepTemplate(steps),
 	}
 	return &pbc.GetJobSetTemplateResp{
 		Success: true,
@@ -160,7 +301,7 @@ func (cs *CServer) GetAllJobSetTemplates(ctx context.Context, req *pbc.GetAllJob
 	for name, steps := range templates {
 		jst := &pbc.JobSetTemplate{
 			Name:  name,
-			Steps: createProtoStepsFromStepTemplate(steps),
+			Steps: CreateProtoStepsFromStepTemplate(steps),
 		}
 		protoTemplates = append(protoTemplates, jst)
 	}
@@ -186,6 +327,14 @@ func (cs *CServer) GetJob(ctx context.Context, req *pbc.GetJobReq) (*pbc.GetJobR
 		AgentName:       job.AgentName,
 		Cfg:             &job.Cfg,
 		St:              &job.Status,
+		Cancelled:       job.Cancelled,
+		StepSkipped:     job.StepSkipped,
+		Attempt:         job.Attempt,
+		ConnectionError: job.ConnectionError,
+		TimeoutMillis:   int64(job.Timeout / time.Millisecond),
+		TimedOut:        job.TimedOut,
+		ResourceClass:   job.ResourceClass,
+		LastSeen:        getProtoTime(job.LastSeen),
 	}
 	return &pbc.GetJobResp{
 		Success: true,
@@ -208,6 +357,14 @@ func (cs *CServer) GetAllJobs(ctx context.Context, req *pbc.GetAllJobsReq) (*pbc
 			AgentName:       job.AgentName,
 			Cfg:             &job.Cfg,
 			St:              &job.Status,
+			Cancelled:       job.Cancelled,
+			StepSkipped:     job.StepSkipped,
+			Attempt:         job.Attempt,
+			ConnectionError: job.ConnectionError,
+			TimeoutMillis:   int64(job.Timeout / time.Millisecond),
+			TimedOut:        job.TimedOut,
+			ResourceClass:   job.ResourceClass,
+			LastSeen:        getProtoTime(job.LastSeen),
 		}
 		jds = append(jds, jd)
 	}
@@ -230,6 +387,14 @@ func (cs *CServer) GetAllJobsForJobSet(ctx context.Context, req *pbc.GetAllJobsF
 			AgentName:       job.AgentName,
 			Cfg:             &job.Cfg,
 			St:              &job.Status,
+			Cancelled:       job.Cancelled,
+			StepSkipped:     job.StepSkipped,
+			Attempt:         job.Attempt,
+			ConnectionError: job.ConnectionError,
+			TimeoutMillis:   int64(job.Timeout / time.Millisecond),
+			TimedOut:        job.TimedOut,
+			ResourceClass:   job.ResourceClass,
+			LastSeen:        getProtoTime(job.LastSeen),
 		}
 		jds = append(jds, jd)
 	}
@@ -237,24 +402,62 @@ func (cs *CServer) GetAllJobsForJobSet(ctx context.Context, req *pbc.GetAllJobsF
 	return &pbc.GetAllJobsForJobSetResp{Jobs: jds}, nil
 }
 
+// getProtoTime converts a time into Unix seconds for a protobuf field,
+// or 0 if it is unset.
+func getProtoTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// createProtoConfigs converts config values into their protobuf form,
+// sorted by key.
+func createProtoConfigs(configs map[string]string) []*pbc.JobSetConfig {
+	keys := []string{}
+	for k := range configs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cfgs := []*pbc.JobSetConfig{}
+	for _, k := range keys {
+		cfgs = append(cfgs, &pbc.JobSetConfig{Key: k, Value: configs[k]})
+	}
+	return cfgs
+}
+
 func createProtoStepsFromSteps(inSteps []*controller.Step) []*pbc.Step {
 	steps := []*pbc.Step{}
 
 	for _, inStep := range inSteps {
 		newStep := &pbc.Step{
-			StepID:       inStep.StepID,
-			StepOrder:    inStep.StepOrder,
-			RunStatus:    inStep.RunStatus,
-			HealthStatus: inStep.HealthStatus,
+			StepID:        inStep.StepID,
+			StepOrder:     inStep.StepOrder,
+			RunStatus:     inStep.RunStatus,
+			HealthStatus:  inStep.HealthStatus,
+			WaitingReason: inStep.WaitingReason,
+			AllowFailure:  inStep.AllowFailure,
+			Name:          inStep.Name,
+			DependsOn:     inStep.DependsOn,
 		}
 		switch inStep.T {
 		case controller.StepTypeAgent:
-			newStep.S = &pbc.Step_Agent{Agent: &pbc.StepAgent{AgentName: inStep.AgentName, JobID: inStep.AgentJobID}}
+			newStep.S = &pbc.Step_Agent{Agent: &pbc.StepAgent{AgentName: inStep.AgentName, JobID: inStep.AgentJobID, Attempts: inStep.Attempts, AgentType: inStep.AgentType, TimeoutMillis: int64(inStep.Timeout / time.Millisecond), ResourceClass: inStep.ResourceClass}}
 		case controller.StepTypeJobSet:
-			newStep.S = &pbc.Step_Jobset{Jobset: &pbc.StepJobSet{TemplateName: inStep.SubJobSetTemplateName, JobSetID: inStep.SubJobSetID}}
+			newStep.S = &pbc.Step_Jobset{Jobset: &pbc.StepJobSet{TemplateName: inStep.SubJobSetTemplateName, JobSetID: inStep.SubJobSetID, Cfgs: createProtoConfigs(inStep.SubJobSetConfigs)}}
 		case controller.StepTypeConcurrent:
 			subSteps := createProtoStepsFromSteps(inStep.ConcurrentSteps)
 			newStep.S = &pbc.Step_Concurrent{Concurrent: &pbc.StepConcurrent{Steps: subSteps}}
+		case controller.StepTypeForEach:
+			subSteps := createProtoStepsFromSteps(inStep.ConcurrentSteps)
+			newStep.S = &pbc.Step_Foreach{Foreach: &pbc.StepForEach{
+				TemplateName: inStep.SubJobSetTemplateName,
+				ConfigKey:    inStep.ForEachConfigKey,
+				ItemKey:      inStep.ForEachItemKey,
+				MaxParallel:  inStep.ForEachMaxParallel,
+				Steps:        subSteps,
+			}}
 		}
 		steps = append(steps, newStep)
 	}
@@ -264,12 +467,12 @@ func createProtoStepsFromSteps(inSteps []*controller.Step) []*pbc.Step {
 
 // StartJobSet corresponds to the StartJobSet endpoint for pkg/controller.
 func (cs *CServer) StartJobSet(ctx context.Context, req *pbc.StartJobSetReq) (*pbc.StartJobSetResp, error) {
-	fmt.Printf("In StartJobSet, req is %#v\n", req)
+	logging.Debugf("In StartJobSet, req is %#v\n", req)
 	for _, cfg := range req.Cfgs {
-		fmt.Printf("  - key: %s\n", cfg.Key)
-		fmt.Printf("    value: %s\n", cfg.Value)
+		logging.Debugf("  - key: %s\n", cfg.Key)
+		logging.Debugf("    value: %s\n", cfg.Value)
 	}
-	jobSetID, err := cs.C.StartJobSet(req.JstName, req.Cfgs)
+	jobSetID, err := cs.C.StartJobSet(req.JstName, req.Cfgs, req.Priority, req.Tenant)
 	if err != nil {
 		return &pbc.StartJobSetResp{
 			Success:  false,
@@ -299,6 +502,7 @@ func (cs *CServer) GetJobSet(ctx context.Context, req *pbc.GetJobSetReq) (*pbc.G
 		TimeFinished:   js.TimeFinished.Unix(),
 		OutputMessages: js.OutputMessages,
 		ErrorMessages:  js.ErrorMessages,
+		Cancelled:      js.Cancelled,
 	}
 
 	steps := createProtoStepsFromSteps(js.Steps)
@@ -308,6 +512,9 @@ func (cs *CServer) GetJobSet(ctx context.Context, req *pbc.GetJobSetReq) (*pbc.G
 		TemplateName: js.TemplateName,
 		St:           st,
 		Steps:        steps,
+		Priority:     js.Priority,
+		Tenant:       js.Tenant,
+		Schedule:     js.ScheduleName,
 	}
 	return &pbc.GetJobSetResp{
 		Success: true,
@@ -329,6 +536,7 @@ func (cs *CServer) GetAllJobSets(ctx context.Context, req *pbc.GetAllJobSetsReq)
 			TimeFinished:   js.TimeFinished.Unix(),
 			OutputMessages: js.OutputMessages,
 			ErrorMessages:  js.ErrorMessages,
+			Cancelled:      js.Cancelled,
 		}
 
 		steps := createProtoStepsFromSteps(js.Steps)
@@ -338,9 +546,123 @@ func (cs *CServer) GetAllJobSets(ctx context.Context, req *pbc.GetAllJobSetsReq)
 			TemplateName: js.TemplateName,
 			St:           st,
 			Steps:        steps,
+			Priority:     js.Priority,
+			Tenant:       js.Tenant,
+			Schedule:     js.ScheduleName,
 		}
 
 		jobSets = append(jobSets, jsd)
 	}
 	return &pbc.GetAllJobSetsResp{JobSets: jobSets}, nil
 }
+
+// CancelJobSet corresponds to the CancelJobSet endpoint for pkg/controller.
+func (cs *CServer) CancelJobSet(ctx context.Context, req *pbc.CancelJobSetReq) (*pbc.CancelJobSetResp, error) {
+	err := cs.C.CancelJobSet(req.JobSetID)
+	if err != nil {
+		return &pbc.CancelJobSetResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.CancelJobSetResp{Success: true}, nil
+}
+
+// SetJobSetPriority corresponds to the SetJobSetPriority endpoint for
+// pkg/controller.
+func (cs *CServer) SetJobSetPriority(ctx context.Context, req *pbc.SetJobSetPriorityReq) (*pbc.SetJobSetPriorityResp, error) {
+	err := cs.C.SetJobSetPriority(req.JobSetID, req.Priority)
+	if err != nil {
+		return &pbc.SetJobSetPriorityResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.SetJobSetPriorityResp{Success: true}, nil
+}
+
+// SetTenant corresponds to the SetTenant endpoint for pkg/controller.
+func (cs *CServer) SetTenant(ctx context.Context, req *pbc.SetTenantReq) (*pbc.SetTenantResp, error) {
+	if req.Cfg == nil {
+		return &pbc.SetTenantResp{
+			Success:  false,
+			ErrorMsg: "no tenant configuration given",
+		}, nil
+	}
+	err := cs.C.SetTenant(req.Cfg)
+	if err != nil {
+		return &pbc.SetTenantResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.SetTenantResp{Success: true}, nil
+}
+
+// GetAllTenants corresponds to the GetAllTenants endpoint for
+// pkg/controller.
+func (cs *CServer) GetAllTenants(ctx context.Context, req *pbc.GetAllTenantsReq) (*pbc.GetAllTenantsResp, error) {
+	return &pbc.GetAllTenantsResp{Tenants: cs.C.GetAllTenants()}, nil
+}
+
+// AddSchedule corresponds to the AddSchedule endpoint for pkg/controller.
+func (cs *CServer) AddSchedule(ctx context.Context, req *pbc.AddScheduleReq) (*pbc.AddScheduleResp, error) {
+	if req.Cfg == nil {
+		return &pbc.AddScheduleResp{
+			Success:  false,
+			ErrorMsg: "no schedule configuration given",
+		}, nil
+	}
+	err := cs.C.AddSchedule(req.Cfg)
+	if err != nil {
+		return &pbc.AddScheduleResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.AddScheduleResp{Success: true}, nil
+}
+
+// GetAllSchedules corresponds to the GetAllSchedules endpoint for
+// pkg/controller.
+func (cs *CServer) GetAllSchedules(ctx context.Context, req *pbc.GetAllSchedulesReq) (*pbc.GetAllSchedulesResp, error) {
+	return &pbc.GetAllSchedulesResp{Schedules: cs.C.GetAllSchedules()}, nil
+}
+
+// PauseSchedule corresponds to the PauseSchedule endpoint for
+// pkg/controller.
+func (cs *CServer) PauseSchedule(ctx context.Context, req *pbc.PauseScheduleReq) (*pbc.PauseScheduleResp, error) {
+	err := cs.C.PauseSchedule(req.Name, req.Paused)
+	if err != nil {
+		return &pbc.PauseScheduleResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.PauseScheduleResp{Success: true}, nil
+}
+
+// DeleteSchedule corresponds to the DeleteSchedule endpoint for
+// pkg/controller.
+func (cs *CServer) DeleteSchedule(ctx context.Context, req *pbc.DeleteScheduleReq) (*pbc.DeleteScheduleResp, error) {
+	err := cs.C.DeleteSchedule(req.Name)
+	if err != nil {
+		return &pbc.DeleteScheduleResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.DeleteScheduleResp{Success: true}, nil
+}
+
+// CancelJob corresponds to the CancelJob endpoint for pkg/controller.
+func (cs *CServer) CancelJob(ctx context.Context, req *pbc.CancelJobReq) (*pbc.CancelJobResp, error) {
+	err := cs.C.CancelJob(req.JobID, req.SkipStep)
+	if err != nil {
+		return &pbc.CancelJobResp{
+			Success:  false,
+			ErrorMsg: err.Error(),
+		}, nil
+	}
+	return &pbc.CancelJobResp{Success: true}, nil
+}
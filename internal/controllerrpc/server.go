@@ -14,21 +14,21 @@ import (
 	"google.golang.org/grpc"
 )
 
-const (
-	port = ":8900"
-)
+// DefaultAddress is the address that the gRPC server listens on, if no
+// other address is configured.
+const DefaultAddress = ":8900"
 
 // CServer is a gRPC server wrapping a peridot Controller.
 type CServer struct {
 	C *controller.Controller
 }
 
-// RunGRPCServer runs the gRPC server.
-func RunGRPCServer(cs *CServer) {
+// RunGRPCServer runs the gRPC server, listening on the given address.
+func RunGRPCServer(cs *CServer, address string) {
 	// open a socket for listening
-	lis, err := net.Listen("tcp", port)
+	lis, err := net.Listen("tcp", address)
 	if err != nil {
-		log.Fatalf("couldn't open port %v: %v", port, err)
+		log.Fatalf("couldn't open port %v: %v", address, err)
 	}
 
 	// create and register new GRPC server for controller
@@ -7,9 +7,10 @@ package jobcontroller
 import (
 	"context"
 	"fmt"
-	"log"
 	"sync"
+	"time"
 
+	"github.com/swinslow/peridot-core/internal/logging"
 	"github.com/swinslow/peridot-core/pkg/agent"
 )
 
@@ -20,22 +21,38 @@ type jobsData struct {
 	cfg Config
 	// jobs is where the actual master record of the Job's status lives
 	jobs map[uint64]*JobRecord
+	// pending holds JobRecords waiting to be broadcast on jobRecordStream,
+	// so that the main loop never blocks on the caller reading them
+	pending []JobRecord
+	// cancels holds, for each running Job, a channel that is closed to
+	// ask its runJobAgent goroutine to cancel the Job
+	cancels map[uint64]chan struct{}
 }
 
 // JobController is the main Job runner function. It creates and returns
 // three channels (described from the caller's perspective):
-// * inJobStream, a write-only channel to submit new JobRequests, which must
-//   be closed by the caller
-// * inJobUpdateStream, a write-only channel to submit a request for an
-//   update of one Job's status given its jobID, or 0 for all Jobs
-// * jobRecordStream, a read-only channel with jobRecord updates
-// * errc, a read-only channel where an error will be written or else
-//   nil if no errors in the controller itself are encountered.
-func JobController(ctx context.Context, cfg Config) (chan<- JobRequest, chan<- uint64, <-chan JobRecord, <-chan error) {
+//   - inJobStream, a write-only channel to submit new JobRequests, which must
+//     be closed by the caller
+//   - inJobUpdateStream, a write-only channel to submit a request for an
+//     update of one Job's status given its jobID, or 0 for all Jobs
+//   - inAgentStream, a write-only channel to submit AgentUpdates that add,
+//     change or remove Agents while the JobController is running
+//   - inJobCancelStream, a write-only channel to submit a request that the
+//     running Job with the given jobID be cancelled
+//   - jobRecordStream, a read-only channel with jobRecord updates
+//   - errc, a read-only channel where an error will be written or else
+//     nil if no errors in the controller itself are encountered.
+func JobController(ctx context.Context, cfg Config) (chan<- JobRequest, chan<- uint64, chan<- AgentUpdate, chan<- uint64, <-chan JobRecord, <-chan error) {
 	// the caller will own the inJobStream channel and must close it
 	inJobStream := make(chan JobRequest)
 	// the caller will also own the inJobUpdateStream channel and must close it
 	inJobUpdateStream := make(chan uint64)
+	// the caller will also own the inAgentStream channel. it need not close
+	// it, since we stop reading from it once ctx is cancelled.
+	inAgentStream := make(chan AgentUpdate)
+	// the caller will also own the inJobCancelStream channel, and likewise
+	// need not close it.
+	inJobCancelStream := make(chan uint64)
 	// we own the jobRecordStream channel
 	jobRecordStream := make(chan JobRecord)
 	// we own the errc channel. make it buffered so we can write 1 error
@@ -43,8 +60,14 @@ func JobController(ctx context.Context, cfg Config) (chan<- JobRequest, chan<- u
 	errc := make(chan error, 1)
 
 	js := jobsData{
-		cfg:  cfg,
-		jobs: map[uint64]*JobRecord{},
+		cfg:     Config{Agents: map[string]AgentRef{}, DialOptions: cfg.DialOptions, Heartbeat: cfg.Heartbeat},
+		jobs:    map[uint64]*JobRecord{},
+		cancels: map[uint64]chan struct{}{},
+	}
+	// copy the agents so that later AgentUpdates don't modify the
+	// caller's map
+	for name, ar := range cfg.Agents {
+		js.cfg.Agents[name] = ar
 	}
 
 	// rc is the response channel for all Job status messages.
@@ -77,6 +100,18 @@ func JobController(ctx context.Context, cfg Config) (chan<- JobRequest, chan<- u
 		exiting := false
 
 		for !exiting {
+			// only offer the next pending JobRecord to the caller if there
+			// is one. sending on a nil channel blocks forever, so the case
+			// below is skipped otherwise. this lets us keep accepting new
+			// JobRequests while the caller is busy submitting several of
+			// them, rather than deadlocking until it reads a JobRecord.
+			var outStream chan<- JobRecord
+			var nextRecord JobRecord
+			if len(js.pending) > 0 {
+				outStream = jobRecordStream
+				nextRecord = js.pending[0]
+			}
+
 			select {
 			case <-ctx.Done():
 				// the JobController has been cancelled and should shut down
@@ -89,15 +124,34 @@ func JobController(ctx context.Context, cfg Config) (chan<- JobRequest, chan<- u
 				if newJobID != 0 {
 					// otherwise broadcast the job record, whether or not it was
 					// started successfully, as long as it actually got a job ID.
-					updateJobRecord(&js, newJobID, nil, jobRecordStream)
+					updateJobRecord(&js, newJobID, nil)
 				}
 			case ju := <-rc:
 				// an agent has sent a JobUpdate
-				updateJobRecord(&js, ju.JobID, &ju, jobRecordStream)
+				updateJobRecord(&js, ju.JobID, &ju)
+				// once a Job has stopped, it can no longer be cancelled
+				if ju.Status.RunStatus == agent.JobRunStatus_STOPPED {
+					delete(js.cancels, ju.JobID)
+				}
 			case jobID := <-inJobUpdateStream:
 				// the caller has submitted a request for a JobRecord update
 				// we can get it by sending nil to updateJobRecord
-				updateJobRecord(&js, jobID, nil, jobRecordStream)
+				updateJobRecord(&js, jobID, nil)
+			case au := <-inAgentStream:
+				// the caller has added, changed or removed an Agent. this
+				// only affects new Jobs; Jobs that are already running
+				// keep talking to the Agent at their original address.
+				updateAgent(&js, au)
+			case jobID := <-inJobCancelStream:
+				// the caller has asked for a Job to be cancelled. its
+				// runJobAgent goroutine will pass this along to the Agent.
+				if cancelc, ok := js.cancels[jobID]; ok {
+					close(cancelc)
+					delete(js.cancels, jobID)
+				}
+			case outStream <- nextRecord:
+				// the caller has taken the next pending JobRecord
+				js.pending = js.pending[1:]
 			}
 		}
 
@@ -106,11 +160,11 @@ func JobController(ctx context.Context, cfg Config) (chan<- JobRequest, chan<- u
 	}()
 
 	// finally we return the channels so that the caller can kick things off
-	return inJobStream, inJobUpdateStream, jobRecordStream, errc
+	return inJobStream, inJobUpdateStream, inAgentStream, inJobCancelStream, jobRecordStream, errc
 }
 
 func startNewJob(ctx context.Context, js *jobsData, jr JobRequest, n *sync.WaitGroup, rc chan<- JobUpdate, errc chan<- error) uint64 {
-	log.Printf("===> In startNewJob: jr = %s\n", jr.String())
+	logging.Debugf("===> In startNewJob: jr = %s\n", jr.String())
 	// check that this job ID isn't already taken
 	_, ok := js.jobs[jr.JobID]
 	if ok {
@@ -136,21 +190,27 @@ func startNewJob(ctx context.Context, js *jobsData, jr JobRequest, n *sync.WaitG
 	// check whether the requested agent name is valid
 	ar, ok := js.cfg.Agents[rec.AgentName]
 	if !ok {
-		log.Printf("===> Error\n")
-		// agent name is invalid; set error and bail out
+		logging.Debugf("===> Error\n")
+		// agent name is invalid; set error and bail out. the job will
+		// never run, so mark it as stopped
 		rec.Err = fmt.Errorf("unknown agent name: %s", rec.AgentName)
+		rec.Status.RunStatus = agent.JobRunStatus_STOPPED
+		rec.Status.HealthStatus = agent.JobHealthStatus_ERROR
+		rec.Status.ErrorMessages = rec.Err.Error()
 		return rec.JobID
 	}
 	// agent name was valid, we have the AgentRef now
 	// time to actually create the job
+	cancelc := make(chan struct{})
+	js.cancels[rec.JobID] = cancelc
 	n.Add(1)
-	go runJobAgent(ctx, rec.JobID, ar, rec.Cfg, n, rc)
+	go runJobAgent(ctx, rec.JobID, ar, js.cfg.DialOptions, rec.Cfg, jr.Timeout, js.cfg.Heartbeat, cancelc, n, rc)
 
 	// return new job's ID
 	return rec.JobID
 }
 
-func updateJobRecord(js *jobsData, jobID uint64, ju *JobUpdate, jobRecordStream chan<- JobRecord) {
+func updateJobRecord(js *jobsData, jobID uint64, ju *JobUpdate) {
 	// if ju is nil, we're just sending the original record upon job creation
 	if ju != nil {
 		// if ju is non-nil, we need to update the record first
@@ -161,11 +221,28 @@ func updateJobRecord(js *jobsData, jobID uint64, ju *JobUpdate, jobRecordStream
 			// but we also don't want to send it out on the stream; just exit
 			return
 		}
-		jr.Status = ju.Status
+		if ju.Err != nil {
+			// the JobController itself hit an error, so the agent won't
+			// be reporting anything further. keep what it last reported,
+			// but mark the job as failed.
+			jr.Status.RunStatus = agent.JobRunStatus_STOPPED
+			jr.Status.HealthStatus = agent.JobHealthStatus_ERROR
+			jr.Status.TimeFinished = time.Now().Unix()
+			if jr.Status.ErrorMessages != "" {
+				jr.Status.ErrorMessages += "\n"
+			}
+			jr.Status.ErrorMessages += ju.Err.Error()
+		} else {
+			jr.Status = ju.Status
+		}
 		jr.Err = ju.Err
+		jr.TimedOut = ju.TimedOut
+		if !ju.LastSeen.IsZero() {
+			jr.LastSeen = ju.LastSeen
+		}
 	}
 
-	// now we broadcast the updated (or not) record
+	// now we queue the updated (or not) record for broadcast
 	// make sure the job with this jobID exists (if ju was nil, we
 	// didn't check earlier)
 	jr, ok := js.jobs[jobID]
@@ -174,5 +251,13 @@ func updateJobRecord(js *jobsData, jobID uint64, ju *JobUpdate, jobRecordStream
 		// but we also don't want to send it out on the stream; just exit
 		return
 	}
-	jobRecordStream <- *jr
+	js.pending = append(js.pending, *jr)
+}
+
+func updateAgent(js *jobsData, au AgentUpdate) {
+	if au.Remove {
+		delete(js.cfg.Agents, au.Ref.Name)
+		return
+	}
+	js.cfg.Agents[au.Ref.Name] = au.Ref
 }
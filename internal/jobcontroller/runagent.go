@@ -6,14 +6,19 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/swinslow/peridot-core/internal/logging"
 	"github.com/swinslow/peridot-core/pkg/agent"
 	"google.golang.org/grpc"
 )
 
+// cancelGracePeriod is how long an Agent has to stop a Job after being sent
+// a CancelReq, before the Job's stream is closed without waiting further.
+const cancelGracePeriod = 10 * time.Second
+
 func getErrorUpdate(jobID uint64, err error) JobUpdate {
 	return JobUpdate{
 		JobID: jobID,
@@ -25,41 +30,68 @@ func getErrorUpdate(jobID uint64, err error) JobUpdate {
 	}
 }
 
-func runJobAgent(ctx context.Context, jobID uint64, ar AgentRef, cfg agent.JobConfig, n *sync.WaitGroup, rc chan<- JobUpdate) {
+// sendJobUpdate sends a JobUpdate to the JobController, unless the
+// JobController's context is cancelled first, in which case nobody is
+// left to read it and it is dropped.
+func sendJobUpdate(ctx context.Context, rc chan<- JobUpdate, ju JobUpdate) {
+	select {
+	case rc <- ju:
+	case <-ctx.Done():
+	}
+}
+
+// runJobAgent runs a single Job on its Agent, passing along status reports
+// on rc until the Job stops. The Job's stream is closed if ctx, the
+// JobController's context, is cancelled, if the Job runs for longer than
+// timeout, unless timeout is 0, or if the Agent stops answering the
+// heartbeat StatusReqs configured by hb.
+func runJobAgent(ctx context.Context, jobID uint64, ar AgentRef, dialOpts []grpc.DialOption, cfg agent.JobConfig, timeout time.Duration, hb HeartbeatConfig, cancelc <-chan struct{}, n *sync.WaitGroup, rc chan<- JobUpdate) {
 	defer n.Done()
 
-	log.Printf("===> in runJobAgent\n")
+	logging.Debugf("===> in runJobAgent\n")
 
 	// connect and get client for each agent server
-	conn, err := grpc.Dial(ar.Address, grpc.WithInsecure())
+	opts := append([]grpc.DialOption{grpc.WithInsecure()}, dialOpts...)
+	conn, err := grpc.Dial(ar.Address, opts...)
 	if err != nil {
-		rc <- getErrorUpdate(jobID, fmt.Errorf("could not connect to %s (%s): %v", ar.Name, ar.Address, err))
+		sendJobUpdate(ctx, rc, getErrorUpdate(jobID, fmt.Errorf("could not connect to %s (%s): %v", ar.Name, ar.Address, err)))
 		return
 	}
 	defer conn.Close()
 	c := agent.NewAgentClient(conn)
 
-	// set up context
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	// set up context for the stream, so that it is closed if the
+	// JobController shuts down or if we close it ourselves below
+	streamCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// start NewJob stream
-	stream, err := c.NewJob(ctx)
+	stream, err := c.NewJob(streamCtx)
 	if err != nil {
-		rc <- getErrorUpdate(jobID, fmt.Errorf("could not connect for %s (%s): %v", ar.Name, ar.Address, err))
+		sendJobUpdate(ctx, rc, getErrorUpdate(jobID, fmt.Errorf("could not connect for %s (%s): %v", ar.Name, ar.Address, err)))
 		return
 	}
 
 	// make server call to start job
 	startReq := &agent.StartReq{Config: &cfg}
 	cm := &agent.ControllerMsg{Cm: &agent.ControllerMsg_Start{Start: startReq}}
-	log.Printf("== controller SEND StartReq for jobID %d", jobID)
+	logging.Debugf("== controller SEND StartReq for jobID %d", jobID)
 	err = stream.Send(cm)
 	if err != nil {
-		rc <- getErrorUpdate(jobID, fmt.Errorf("could not start job for %s (%s): %v", ar.Name, ar.Address, err))
+		sendJobUpdate(ctx, rc, getErrorUpdate(jobID, fmt.Errorf("could not start job for %s (%s): %v", ar.Name, ar.Address, err)))
 		return
 	}
 
+	// timedOut is set before the stream is closed because the Job ran
+	// past its timeout, and noHeartbeat because the Agent went silent
+	// for too long, so that the listener can report it as such
+	var timedOut int32
+	var noHeartbeat int32
+
+	// lastSeen is when we last heard anything from the Agent, in
+	// UnixNano. it starts out as the time that the Job was started.
+	lastSeen := time.Now().UnixNano()
+
 	// set up listener + status updater goroutine
 	// until we get past waitc, ONLY the listener goroutine should be
 	// updating the job status
@@ -69,31 +101,44 @@ func runJobAgent(ctx context.Context, jobID uint64, ar AgentRef, cfg agent.JobCo
 			in, err := stream.Recv()
 			if err == io.EOF {
 				// done with reading
-				log.Printf("== controller CLOSING got io.EOF")
+				logging.Debugf("== controller CLOSING got io.EOF")
 				close(waitc)
 				return
 			}
 			if err != nil {
-				log.Printf("== controller CLOSING got error: %v", err)
-				rc <- getErrorUpdate(jobID, fmt.Errorf("error for %s (%s): %v", ar.Name, ar.Address, err))
+				logging.Debugf("== controller CLOSING got error: %v", err)
+				if atomic.LoadInt32(&timedOut) != 0 {
+					ju := getErrorUpdate(jobID, fmt.Errorf("job timed out after %v", timeout))
+					ju.TimedOut = true
+					sendJobUpdate(ctx, rc, ju)
+				} else if atomic.LoadInt32(&noHeartbeat) != 0 {
+					sendJobUpdate(ctx, rc, getErrorUpdate(jobID, fmt.Errorf("no heartbeat from %s (%s) for over %v", ar.Name, ar.Address, hb.Timeout)))
+				} else {
+					sendJobUpdate(ctx, rc, getErrorUpdate(jobID, fmt.Errorf("error for %s (%s): %v", ar.Name, ar.Address, err)))
+				}
 				close(waitc)
 				return
 			}
 
+			// anything at all from the Agent shows that it is still alive
+			now := time.Now()
+			atomic.StoreInt64(&lastSeen, now.UnixNano())
+
 			// update status if we got a status report
 			switch x := in.Am.(type) {
 			case *agent.AgentMsg_Status:
 				st := *x.Status
-				log.Printf("== controller RECV StatusReport for jobID %d: %s\n", jobID, st.String())
-				rc <- JobUpdate{
-					JobID:  jobID,
-					Status: st,
-				}
+				logging.Debugf("== controller RECV StatusReport for jobID %d: %s\n", jobID, st.String())
+				sendJobUpdate(ctx, rc, JobUpdate{
+					JobID:    jobID,
+					Status:   st,
+					LastSeen: now,
+				})
 
 				// if this was a STOPPED message, the job is done
 				// and we need to close the stream to start exiting
 				if st.RunStatus == agent.JobRunStatus_STOPPED {
-					log.Printf("== controller CLOSING got Job STOPPED")
+					logging.Debugf("== controller CLOSING got Job STOPPED")
 					close(waitc)
 					return
 				}
@@ -101,14 +146,71 @@ func runJobAgent(ctx context.Context, jobID uint64, ar AgentRef, cfg agent.JobCo
 		}
 	}()
 
-	// wait until listener loop is done
-	// FIXME ordinarily this should probably ping occasionally with a heartbeat
-	// FIXME request, and/or eventually exit if we see an error or if a job
-	// FIXME hasn't responded for ___ time
-	// FIXME also, does CloseSend need to come before we wait for agent to close?
+	// wait until listener loop is done, sending heartbeat StatusReqs
+	// along the way
+	// FIXME does CloseSend need to come before we wait for agent to close?
+	// gracec stays nil until the Job has been cancelled, timeoutc stays
+	// nil if the Job has no timeout, and heartbeatc stays nil if
+	// heartbeats are turned off
+	var gracec <-chan time.Time
+	var timeoutc <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutc = timer.C
+	}
+	var heartbeatc <-chan time.Time
+	if hb.Interval > 0 {
+		ticker := time.NewTicker(hb.Interval)
+		defer ticker.Stop()
+		heartbeatc = ticker.C
+	}
 	exiting := false
 	for !exiting {
 		select {
+		case <-heartbeatc:
+			// if the Agent has been silent for too long, give up on it.
+			// closing the stream makes the listener report the Job as
+			// failed.
+			silence := time.Since(time.Unix(0, atomic.LoadInt64(&lastSeen)))
+			if hb.Timeout > 0 && silence > hb.Timeout {
+				logging.Infof("job %d on %s (%s) has sent nothing for %v; closing its stream", jobID, ar.Name, ar.Address, silence.Round(time.Millisecond))
+				atomic.StoreInt32(&noHeartbeat, 1)
+				cancel()
+				heartbeatc = nil
+				continue
+			}
+			// otherwise, ask the Agent how the Job is doing
+			logging.Debugf("== controller SEND StatusReq for jobID %d", jobID)
+			cm := &agent.ControllerMsg{Cm: &agent.ControllerMsg_Status{Status: &agent.StatusReq{}}}
+			if err := stream.Send(cm); err != nil {
+				logging.Debugf("could not send StatusReq for job %d to %s (%s): %v", jobID, ar.Name, ar.Address, err)
+			}
+		case <-timeoutc:
+			// the Job has run for too long, so close its stream, which
+			// also cancels it on the Agent. the listener will then
+			// report it as timed out.
+			logging.Infof("job %d on %s (%s) did not finish within its timeout of %v; closing its stream", jobID, ar.Name, ar.Address, timeout)
+			atomic.StoreInt32(&timedOut, 1)
+			cancel()
+			timeoutc = nil
+		case <-cancelc:
+			// the Job is being cancelled; tell the Agent, and keep
+			// waiting for it to report that the Job has stopped
+			logging.Debugf("== controller SEND CancelReq for jobID %d", jobID)
+			cm := &agent.ControllerMsg{Cm: &agent.ControllerMsg_Cancel{Cancel: &agent.CancelReq{}}}
+			if err := stream.Send(cm); err != nil {
+				logging.Errorf("could not cancel job %d for %s (%s): %v", jobID, ar.Name, ar.Address, err)
+			}
+			// only send it once
+			cancelc = nil
+			gracec = time.After(cancelGracePeriod)
+		case <-gracec:
+			// the Agent hasn't stopped the Job, so give up on it. closing
+			// the stream makes the listener report the Job as failed.
+			logging.Infof("job %d on %s (%s) did not stop within %v of being cancelled; closing its stream", jobID, ar.Name, ar.Address, cancelGracePeriod)
+			cancel()
+			gracec = nil
 		case <-waitc:
 			stream.CloseSend()
 			exiting = true
@@ -4,8 +4,10 @@ package jobcontroller
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/swinslow/peridot-core/pkg/agent"
+	"google.golang.org/grpc"
 )
 
 // Config defines the JobController's own configuration.
@@ -13,6 +15,28 @@ type Config struct {
 	// Agents defines all Agents that the JobController knows about.
 	// It maps the unique Agent instance's name to its AgentRef.
 	Agents map[string]AgentRef
+
+	// DialOptions are any additional options to use when connecting to
+	// Agents, e.g. a custom dialer for reaching Agents over an in-memory
+	// listener.
+	DialOptions []grpc.DialOption
+
+	// Heartbeat configures how running Jobs are checked for liveness.
+	Heartbeat HeartbeatConfig
+}
+
+// HeartbeatConfig defines how often the JobController asks each running
+// Job's Agent for its status, and how long it waits to hear back.
+type HeartbeatConfig struct {
+	// Interval is how often a StatusReq is sent to each running Job's
+	// Agent. 0 means that no StatusReqs are sent, and that Jobs are never
+	// checked for liveness.
+	Interval time.Duration
+
+	// Timeout is how long a running Job's Agent may go without sending
+	// anything before the Job's stream is closed and the Job is reported
+	// as failed. 0 means that Jobs never time out this way.
+	Timeout time.Duration
 }
 
 // String provides a compact string representation of the Config.
@@ -36,6 +60,18 @@ type AgentRef struct {
 	Address string
 }
 
+// AgentUpdate defines a change to the Agents that a running JobController
+// knows about.
+type AgentUpdate struct {
+	// Ref is the Agent's new AgentRef. If Remove is true, only Ref.Name
+	// is used.
+	Ref AgentRef
+
+	// Remove is true if the Agent should be removed, or false if it
+	// should be added or have its AgentRef replaced.
+	Remove bool
+}
+
 // JobRequest defines the metadata needed to start a Job.
 type JobRequest struct {
 	// requested job ID
@@ -47,6 +83,10 @@ type JobRequest struct {
 
 	// Cfg describes the configuration for this Job.
 	Cfg agent.JobConfig
+
+	// Timeout is how long the Job may run before its stream is closed
+	// and it is reported as timed out. 0 means no limit.
+	Timeout time.Duration
 }
 
 // String provides a compact string representation of the JobRequest.
@@ -74,6 +114,14 @@ type JobRecord struct {
 	// Err defines any error messages that have arisen on the controller
 	// for this Job. (Agent errors will be found in Status.ErrorMessages.)
 	Err error
+
+	// TimedOut is true if the Job was stopped because it ran past its
+	// timeout. Err is also set in that case.
+	TimedOut bool
+
+	// LastSeen is when the Job's Agent last sent anything about it, or
+	// the zero time if it never has.
+	LastSeen time.Time
 }
 
 // String provides a compact string representation of the JobRecord.
@@ -94,6 +142,14 @@ type JobUpdate struct {
 	// Err defines any error messages that have arisen on the controller
 	// for this Job. (Agent errors will be found in Status.ErrorMessages.)
 	Err error
+
+	// TimedOut is true if the Job was stopped because it ran past its
+	// timeout. Err is also set in that case.
+	TimedOut bool
+
+	// LastSeen is when the Job's Agent last sent anything about it. It
+	// is the zero time for updates that don't come from the Agent.
+	LastSeen time.Time
 }
 
 // JobShortStatus is a shorter status response for this Job. Full details
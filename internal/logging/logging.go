@@ -0,0 +1,74 @@
+// Package logging provides leveled logging for the peridot controller.
+// It wraps the standard library's log package, and drops any messages
+// below the currently-configured level.
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is a logging level. Messages are only logged if their level is
+// at or above the currently-configured level.
+type Level int32
+
+const (
+	// LevelDebug is for detailed tracing of the controller's internals.
+	LevelDebug Level = iota
+	// LevelInfo is for notable events during normal operation.
+	LevelInfo
+	// LevelError is for problems that need attention.
+	LevelError
+)
+
+// level is the currently-configured level, accessed atomically.
+var level = int32(LevelInfo)
+
+// ParseLevel returns the Level with the given name: "debug", "info" or
+// "error".
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q; must be debug, info or error", name)
+}
+
+// SetLevel sets the minimum level of messages that will be logged.
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+// DebugEnabled returns true if debug messages are currently being logged.
+func DebugEnabled() bool {
+	return atomic.LoadInt32(&level) <= int32(LevelDebug)
+}
+
+// Debugf logs a debug message, with arguments handled as for fmt.Printf.
+func Debugf(format string, v ...interface{}) {
+	logAt(LevelDebug, format, v...)
+}
+
+// Infof logs an info message, with arguments handled as for fmt.Printf.
+func Infof(format string, v ...interface{}) {
+	logAt(LevelInfo, format, v...)
+}
+
+// Errorf logs an error message, with arguments handled as for fmt.Printf.
+func Errorf(format string, v ...interface{}) {
+	logAt(LevelError, format, v...)
+}
+
+func logAt(l Level, format string, v ...interface{}) {
+	if int32(l) < atomic.LoadInt32(&level) {
+		return
+	}
+	log.Printf(format, v...)
+}
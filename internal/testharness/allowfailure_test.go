@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+func TestAllowFailure(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "bad", Behavior{Health: agent.JobHealthStatus_ERROR})
+	addAgent(t, h, "good", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: sub
+    steps:
+      - agent: bad
+  - name: advisory
+    steps:
+      - name: advice
+        agent: bad
+        allowFailure: true
+      - concurrent:
+          - agent: good
+          - jobset: sub
+            allowFailure: true
+      - agent: good
+        when:
+          step: advice
+          outcome: [error]
+  - name: required
+    steps:
+      - agent: bad
+      - agent: good
+`)
+	start(t, h)
+
+	// the allowed failures only make the JobSet DEGRADED, and it carries
+	// on past them
+	id := startJobSet(t, h, "advisory")
+	js := waitForJobSet(t, h, id, "DEGRADED")
+	advice, concurrent, last := js.Steps[0], js.Steps[1], js.Steps[2]
+	if advice.RunStatus != pbs.Status_STOPPED || advice.HealthStatus != pbs.Health_ERROR || !advice.AllowFailure {
+		t.Errorf("expected advisory step to keep its ERROR health, got %s %s", advice.RunStatus, advice.HealthStatus)
+	}
+	if concurrent.HealthStatus != pbs.Health_DEGRADED {
+		t.Errorf("expected concurrent step with an allowed failure to be DEGRADED, got %s", concurrent.HealthStatus)
+	}
+	if sub := concurrent.ConcurrentSteps[1]; sub.HealthStatus != pbs.Health_ERROR {
+		t.Errorf("expected allowed sub-jobSet step to keep its ERROR health, got %s", sub.HealthStatus)
+	}
+	// and a later step can check for the failure
+	if last.RunStatus != pbs.Status_STOPPED || last.HealthStatus != pbs.Health_OK {
+		t.Errorf("expected step conditional on the failure to run, got %s %s", last.RunStatus, last.HealthStatus)
+	}
+	if !strings.Contains(js.OutputMessages, "continuing, as the step is allowed to fail") {
+		t.Errorf("expected allowed failure to be noted, got %q", js.OutputMessages)
+	}
+	if n := len(h.Agent("good").Jobs()); n != 2 {
+		t.Errorf("expected 2 good jobs, got %d", n)
+	}
+
+	// without allowFailure, the failure stops the JobSet
+	id = startJobSet(t, h, "required")
+	js = waitForJobSet(t, h, id, "ERROR")
+	if js.Steps[1].RunStatus == pbs.Status_STOPPED {
+		t.Errorf("expected step after the failure not to run, got %s", js.Steps[1].RunStatus)
+	}
+	if n := len(h.Agent("good").Jobs()); n != 2 {
+		t.Errorf("expected no more good jobs, got %d", n)
+	}
+}
+
+func TestAllowFailureAfterRetries(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "bad", Behavior{Health: agent.JobHealthStatus_ERROR})
+	addAgent(t, h, "flaky", failFirst(1))
+	addAgent(t, h, "good", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: advisory
+    steps:
+      - agent: bad
+        allowFailure: true
+        retry:
+          maxAttempts: 2
+          backoff: 10ms
+          on: [agent]
+      - agent: flaky
+        allowFailure: true
+        retry:
+          maxAttempts: 2
+          backoff: 10ms
+          on: [agent]
+      - agent: good
+`)
+	start(t, h)
+
+	// the step is only allowed to fail once its retries are used up, and
+	// a retry that succeeds leaves the JobSet OK
+	id := startJobSet(t, h, "advisory")
+	js := waitForJobSet(t, h, id, "DEGRADED")
+	if n := len(h.Agent("bad").Jobs()); n != 2 {
+		t.Errorf("expected 2 bad jobs, got %d", n)
+	}
+	if js.Steps[1].HealthStatus != pbs.Health_OK {
+		t.Errorf("expected retried step to succeed, got %s", js.Steps[1].HealthStatus)
+	}
+	if js.Steps[2].RunStatus != pbs.Status_STOPPED || js.Steps[2].HealthStatus != pbs.Health_OK {
+		t.Errorf("expected last step to run, got %s %s", js.Steps[2].RunStatus, js.Steps[2].HealthStatus)
+	}
+}
+
+func TestAllowFailureRejectedForConcurrentSteps(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "good", Behavior{})
+	err := h.AddTemplatesYAML(`
+templates:
+  - name: bad
+    steps:
+      - concurrent:
+          - agent: good
+        allowFailure: true
+`)
+	if err == nil || !strings.Contains(err.Error(), "allowFailure is not allowed for concurrent steps") {
+		t.Errorf("expected allowFailure to be rejected for a concurrent step, got %v", err)
+	}
+}
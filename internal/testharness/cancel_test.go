@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+func TestCancelJobSetStopsItsStepsAndSubJobSets(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "slow", Behavior{Delay: 10 * time.Second})
+	addAgent(t, h, "report", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: slowsub
+    steps:
+      - agent: slow
+  - name: slowjs
+    steps:
+      - concurrent:
+          - agent: slow
+          - jobset: slowsub
+      - agent: report
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "slowjs")
+	waitForRunningJob(t, h, id)
+	if err := h.Controller.CancelJobSet(id); err != nil {
+		t.Fatal(err)
+	}
+	js := waitForJobSet(t, h, id, "OK")
+	if !js.Cancelled {
+		t.Error("expected jobSet to be cancelled")
+	}
+
+	// the agent stops the running job, and then every step has stopped
+	job, err := h.WaitForJob(js.Steps[0].ConcurrentSteps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.Cancelled || job.Status.HealthStatus != agent.JobHealthStatus_ERROR {
+		t.Errorf("expected job to be cancelled, got %s", job.Status.HealthStatus)
+	}
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		js, _ = h.Controller.GetJobSet(id)
+		if js.Steps[0].RunStatus == pbs.Status_STOPPED || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+	checkStepsRunStatus(t, js.Steps, pbs.Status_STOPPED)
+	if n := len(h.Agent("report").Jobs()); n != 0 {
+		t.Errorf("expected no report jobs, got %d", n)
+	}
+
+	sub, err := h.Controller.GetJobSet(js.Steps[0].ConcurrentSteps[1].SubJobSetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sub.Cancelled || sub.RunStatus != pbs.Status_STOPPED {
+		t.Errorf("expected sub-jobSet to be cancelled, got %s", sub.RunStatus)
+	}
+
+	// and the jobSet can't be cancelled twice
+	if err := h.Controller.CancelJobSet(id); err == nil {
+		t.Error("expected error cancelling a stopped jobSet")
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "hang", heldBehavior(make(chan struct{})))
+	addAgent(t, h, "quick", Behavior{})
+	after := addAgent(t, h, "after", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: hangs
+    steps:
+      - concurrent:
+          - agent: hang
+          - agent: quick
+      - agent: after
+`)
+	start(t, h)
+
+	if err := h.Controller.CancelJob(99, false); err == nil {
+		t.Error("expected error cancelling an unknown job")
+	}
+
+	// hangingJob waits for the given JobSet's hanging Job to start, and
+	// returns its ID
+	hangingJob := func(id uint64) uint64 {
+		js := waitForJobSetState(t, h, id, func(js *controller.JobSet) bool {
+			return js.Steps[0].ConcurrentSteps[0].RunStatus == pbs.Status_RUNNING
+		})
+		return js.Steps[0].ConcurrentSteps[0].AgentJobID
+	}
+
+	// a Job cancelled as failed fails its step, which stops the JobSet
+	id := startJobSet(t, h, "hangs")
+	jobID := hangingJob(id)
+	if err := h.Controller.CancelJob(jobID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Controller.CancelJob(jobID, false); err == nil {
+		t.Error("expected error cancelling a job twice")
+	}
+	js := waitForJobSet(t, h, id, "ERROR")
+	if step := js.Steps[0].ConcurrentSteps[0]; step.RunStatus != pbs.Status_STOPPED || step.HealthStatus != pbs.Health_ERROR {
+		t.Errorf("expected cancelled job's step to fail, got %s and %s", step.RunStatus, step.HealthStatus)
+	}
+	if !strings.Contains(js.ErrorMessages, "was cancelled") {
+		t.Errorf("expected cancellation error message, got %q", js.ErrorMessages)
+	}
+	if n := len(after.Jobs()); n != 0 {
+		t.Errorf("expected the step after not to run, got %d jobs", n)
+	}
+	job, err := h.WaitForJob(jobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.Cancelled || job.StepSkipped {
+		t.Errorf("expected job to be cancelled without skipping its step, got cancelled %v and skipped %v", job.Cancelled, job.StepSkipped)
+	}
+
+	// but one cancelled with skipStep skips its step, and the JobSet
+	// carries on
+	id = startJobSet(t, h, "hangs")
+	jobID = hangingJob(id)
+	if err := h.Controller.CancelJob(jobID, true); err != nil {
+		t.Fatal(err)
+	}
+	js = waitForJobSet(t, h, id, "OK")
+	if step := js.Steps[0].ConcurrentSteps[0]; step.RunStatus != pbs.Status_SKIPPED {
+		t.Errorf("expected cancelled job's step to be skipped, got %s", step.RunStatus)
+	}
+	if !strings.Contains(js.OutputMessages, "skipped: job") {
+		t.Errorf("expected skipped step output message, got %q", js.OutputMessages)
+	}
+	if n := len(after.Jobs()); n != 1 {
+		t.Errorf("expected the step after to run, got %d jobs", n)
+	}
+	job, err = h.WaitForJob(jobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.Cancelled || !job.StepSkipped || job.Status.RunStatus != agent.JobRunStatus_STOPPED {
+		t.Errorf("expected job to be cancelled and stopped with its step skipped, got cancelled %v, skipped %v and %s", job.Cancelled, job.StepSkipped, job.Status.RunStatus)
+	}
+	if err := h.Controller.CancelJob(jobID, true); err == nil {
+		t.Error("expected error cancelling a stopped job")
+	}
+}
+
+// checkStepsRunStatus checks that each of the given steps, and each of
+// their concurrent sub-steps, has the expected run status.
+func checkStepsRunStatus(t *testing.T, steps []*controller.Step, status pbs.Status) {
+	t.Helper()
+	for _, step := range steps {
+		if step.RunStatus != status {
+			t.Errorf("expected step %d to be %s, got %s", step.StepID, status, step.RunStatus)
+		}
+		checkStepsRunStatus(t, step.ConcurrentSteps, status)
+	}
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// stepRunStatuses returns the RunStatus of each of the given steps, and
+// the steps within them, by step ID.
+func stepRunStatuses(steps []*controller.Step, statuses map[uint64]pbs.Status) map[uint64]pbs.Status {
+	if statuses == nil {
+		statuses = map[uint64]pbs.Status{}
+	}
+	for _, step := range steps {
+		statuses[step.StepID] = step.RunStatus
+		stepRunStatuses(step.ConcurrentSteps, statuses)
+	}
+	return statuses
+}
+
+func TestConditions(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "quick", Behavior{Health: agent.JobHealthStatus_DEGRADED})
+	addAgent(t, h, "deep", Behavior{})
+	addAgent(t, h, "policy", Behavior{})
+	addAgent(t, h, "a", Behavior{Delay: 50 * time.Millisecond})
+	addTemplates(t, h, `
+templates:
+  - name: sub
+    steps:
+      - agent: a
+  - name: cond
+    steps:
+      - name: quick-scan
+        agent: quick
+      - agent: deep
+        when:
+          step: quick-scan
+          outcome: [degraded]
+      - name: policy
+        agent: policy
+        when:
+          config: policy
+      - jobset: sub
+        when:
+          config: mode
+          equals: full
+      - concurrent:
+          - agent: a
+          - name: nested-policy
+            agent: policy
+            when:
+              config: policy
+      - agent: deep
+        when:
+          step: policy
+          outcome: [skipped]
+      - agent: a
+        when:
+          step: nested-policy
+`)
+	start(t, h)
+
+	S, R := pbs.Status_SKIPPED, pbs.Status_STOPPED
+	tests := []struct {
+		name     string
+		cfgs     map[string]string
+		statuses []pbs.Status
+		skipped  string
+	}{
+		{"no configs", nil, []pbs.Status{R, R, S, S, R, R, S, R, S}, "step 9 skipped: step nested-policy finished as skipped"},
+		{"configs set", map[string]string{"policy": "yes", "mode": "full"}, []pbs.Status{R, R, R, R, R, R, R, S, R}, "step 8 skipped: step policy finished as ok"},
+		{"wrong mode", map[string]string{"policy": "yes", "mode": "quick"}, []pbs.Status{R, R, R, S, R, R, R, S, R}, `step 4 skipped: config key mode is "quick", not "full"`},
+	}
+	for _, tc := range tests {
+		id, err := h.StartJobSet("cond", tc.cfgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the quick scan is always DEGRADED, and nothing fails
+		js := waitForJobSet(t, h, id, "DEGRADED")
+		statuses := stepRunStatuses(js.Steps, nil)
+		for i, want := range tc.statuses {
+			if got := statuses[uint64(i+1)]; got != want {
+				t.Errorf("%s: expected step %d to be %s, got %s", tc.name, i+1, want, got)
+			}
+		}
+		if !strings.Contains(js.OutputMessages, tc.skipped) {
+			t.Errorf("%s: expected %q in output messages, got %q", tc.name, tc.skipped, js.OutputMessages)
+		}
+	}
+
+	// skipped agent steps have no Jobs: deep runs in each JobSet once, and
+	// policy only when its config key is set
+	if n := len(h.Agent("deep").Jobs()); n != 4 {
+		t.Errorf("expected 4 deep jobs, got %d", n)
+	}
+	if n := len(h.Agent("policy").Jobs()); n != 4 {
+		t.Errorf("expected 4 policy jobs, got %d", n)
+	}
+}
+
+func TestConditionsAfterCancelledJob(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "held", heldBehavior(make(chan struct{})))
+	ifSkipped := addAgent(t, h, "if-skipped", Behavior{})
+	ifOK := addAgent(t, h, "if-ok", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: cond
+    steps:
+      - name: scan
+        agent: held
+      - agent: if-skipped
+        when:
+          step: scan
+          outcome: [skipped]
+      - agent: if-ok
+        when:
+          step: scan
+          outcome: [ok]
+`)
+	start(t, h)
+
+	// a step whose Job is cancelled with skipStep counts as skipped, not
+	// as having finished OK
+	id := startJobSet(t, h, "cond")
+	jobID := waitForRunningJob(t, h, id)
+	if err := h.Controller.CancelJob(jobID, true); err != nil {
+		t.Fatal(err)
+	}
+	js := waitForJobSet(t, h, id, "OK")
+	statuses := stepRunStatuses(js.Steps, nil)
+	for i, want := range []pbs.Status{pbs.Status_SKIPPED, pbs.Status_STOPPED, pbs.Status_SKIPPED} {
+		if got := statuses[uint64(i+1)]; got != want {
+			t.Errorf("expected step %d to be %s, got %s", i+1, want, got)
+		}
+	}
+	if n := len(ifSkipped.Jobs()); n != 1 {
+		t.Errorf("expected the step for a skipped scan to run, got %d jobs", n)
+	}
+	if n := len(ifOK.Jobs()); n != 0 {
+		t.Errorf("expected the step for an OK scan not to run, got %d jobs", n)
+	}
+}
+
+func TestConditionsRejected(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "a", Behavior{})
+
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"unknown step", `
+      - agent: a
+        when:
+          step: nope
+`, "condition refers to unknown step nope"},
+		{"later step", `
+      - agent: a
+        when:
+          step: later
+      - name: later
+        agent: a
+`, "condition refers to step later, which won't have finished"},
+		{"concurrent sibling", `
+      - concurrent:
+          - name: first
+            agent: a
+          - agent: a
+            when:
+              step: first
+`, "condition refers to step first, which won't have finished"},
+		{"outcome without step", `
+      - agent: a
+        when:
+          config: c
+          outcome: [ok]
+`, "when can only set outcome along with step"},
+	}
+	for _, tc := range tests {
+		err := h.AddTemplatesYAML("templates:\n  - name: bad\n    steps:" + tc.yaml)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
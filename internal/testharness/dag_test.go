@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+func TestDAGOrder(t *testing.T) {
+	h := newHarness(t, Options{})
+	release := make(chan struct{})
+	addAgent(t, h, "a", heldBehavior(release))
+	addAgent(t, h, "b", Behavior{})
+	addAgent(t, h, "c", Behavior{})
+	d := addAgent(t, h, "d", Behavior{})
+	addAgent(t, h, "s", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: dag
+    steps:
+      - name: A
+        agent: a
+      - name: B
+        agent: b
+      - name: C
+        agent: c
+        dependsOn: [B]
+      - name: S
+        agent: s
+        when:
+          config: never
+      - name: D
+        agent: d
+        dependsOn: [A, C, S]
+        when:
+          step: B
+`)
+	start(t, h)
+
+	// C only waits for B, so it runs while A is still going, but D waits
+	// for A too
+	id := startJobSet(t, h, "dag")
+	js := waitForJobSetState(t, h, id, func(js *controller.JobSet) bool {
+		return js.Steps[2].RunStatus == pbs.Status_STOPPED
+	})
+	if js.Steps[0].RunStatus != pbs.Status_RUNNING {
+		t.Errorf("expected A to still be running, got %s", js.Steps[0].RunStatus)
+	}
+	if js.Steps[3].RunStatus != pbs.Status_SKIPPED {
+		t.Errorf("expected S to be skipped, got %s", js.Steps[3].RunStatus)
+	}
+	if js.Steps[4].RunStatus != pbs.Status_STARTUP || len(d.Jobs()) != 0 {
+		t.Errorf("expected D to wait for A, got %s", js.Steps[4].RunStatus)
+	}
+
+	// once A finishes, D runs, after a skipped dependency too
+	close(release)
+	js = waitForJobSet(t, h, id, "OK")
+	if js.Steps[4].RunStatus != pbs.Status_STOPPED || len(d.Jobs()) != 1 {
+		t.Fatalf("expected D to run, got %s", js.Steps[4].RunStatus)
+	}
+	if deps := js.Steps[4].DependsOn; len(deps) != 3 || deps[0] != 1 || deps[1] != 3 || deps[2] != 4 {
+		t.Errorf("expected D to depend on steps 1, 3 and 4, got %v", deps)
+	}
+
+	// and is given only the outputs of the steps it depends on that ran
+	if n := len(d.Jobs()[0].CodeInputs); n != 2 {
+		t.Errorf("expected D to have code inputs from A and C, got %d", n)
+	}
+}
+
+func TestDAGRejected(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "a", Behavior{})
+
+	tests := []struct {
+		name  string
+		steps string
+		err   string
+	}{
+		{"cycle", "[{name: X, agent: a, dependsOn: [Z]}, {name: Y, agent: a, dependsOn: [X]}, {name: Z, agent: a, dependsOn: [Y]}]", "steps form a cycle: X -> Z -> Y -> X"},
+		{"self", "[{name: X, agent: a, dependsOn: [X]}]", "step 1 depends on itself"},
+		{"unknown", "[{name: X, agent: a, dependsOn: [Y]}]", "step 1 depends on Y, which is not a top-level step"},
+		{"nested", "[{concurrent: [{name: X, agent: a}]}, {agent: a, dependsOn: [X]}]", "step 3 depends on X, which is not a top-level step"},
+		{"nested dependencies", "[{name: X, agent: a}, {concurrent: [{agent: a, dependsOn: [X]}]}]", "only top-level steps can have dependencies"},
+		{"duplicate", "[{name: X, agent: a}, {concurrent: [{name: X, agent: a}]}]", "more than one step is named X"},
+	}
+	for _, tc := range tests {
+		err := h.AddTemplatesYAML("templates:\n  - name: bad\n    steps: " + tc.steps + "\n")
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
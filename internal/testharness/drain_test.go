@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+func TestDrainCancelsRunningJobsOnAgents(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "slow", Behavior{Delay: 10 * time.Second})
+	if err := h.Controller.UpdateAgent(&pbc.AgentConfig{Name: "slow", Url: "slow", Port: fakeAgentPort, Type: "fake", MaxConcurrentJobs: 1}); err != nil {
+		t.Fatal(err)
+	}
+	addTemplates(t, h, `
+templates:
+  - name: slow
+    steps:
+      - agent: slow
+`)
+	start(t, h)
+
+	running := startJobSet(t, h, "slow")
+	waitForRunningJob(t, h, running)
+	// this one waits for the agent, so it has no Job to interrupt
+	waiting := startJobSet(t, h, "slow")
+
+	begin := time.Now()
+	jobIDs, jobSetIDs := h.Controller.DrainAndStop(100 * time.Millisecond)
+	if elapsed := time.Since(begin); elapsed > 3*time.Second {
+		t.Errorf("expected agent to stop the job promptly once cancelled, took %v", elapsed)
+	}
+
+	if len(jobSetIDs) != 1 || jobSetIDs[0] != running {
+		t.Errorf("expected only jobSet %d to be interrupted, got %v", running, jobSetIDs)
+	}
+	if len(jobIDs) != 1 {
+		t.Fatalf("expected one interrupted job, got %v", jobIDs)
+	}
+
+	// the agent stopped the job itself, rather than having its stream cut
+	job, err := h.Controller.GetJob(jobIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status.RunStatus != agent.JobRunStatus_STOPPED || job.Status.HealthStatus != agent.JobHealthStatus_ERROR {
+		t.Errorf("expected job to stop with ERROR, got %s %s", job.Status.RunStatus, job.Status.HealthStatus)
+	}
+	if !job.Cancelled || strings.Contains(job.Status.ErrorMessages, "controller stopped before job finished") {
+		t.Errorf("expected agent to report the cancelled job, got %q", job.Status.ErrorMessages)
+	}
+
+	js, err := h.Controller.GetJobSet(running)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if js.RunStatus != pbs.Status_STOPPED || js.HealthStatus != pbs.Health_ERROR {
+		t.Errorf("expected interrupted jobSet to stop with ERROR, got %s %s", js.RunStatus, js.HealthStatus)
+	}
+	js, err = h.Controller.GetJobSet(waiting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if js.RunStatus == pbs.Status_STOPPED {
+		t.Errorf("expected waiting jobSet to be left to resume, got %s", js.RunStatus)
+	}
+}
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+// startTenantJobSet starts a JobSet for the given tenant from the named
+// template, and returns its ID.
+func startTenantJobSet(t *testing.T, h *Harness, jstName string, tenant string) uint64 {
+	t.Helper()
+	id, err := h.Controller.StartJobSet(jstName, nil, 0, tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// jobTenants returns the tenant of each Job's JobSet, in the order that
+// the Jobs were started.
+func jobTenants(t *testing.T, h *Harness) []string {
+	t.Helper()
+	jobs := h.Controller.GetAllJobs()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].JobID < jobs[j].JobID })
+	tenants := []string{}
+	for _, job := range jobs {
+		js, err := h.Controller.GetJobSet(job.JobSetID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tenants = append(tenants, js.Tenant)
+	}
+	return tenants
+}
+
+// waitUntilQueued waits until the JobSet with the given ID has a step
+// waiting for a job slot.
+func waitUntilQueued(t *testing.T, h *Harness, jobSetID uint64) {
+	t.Helper()
+	waitForJobSetState(t, h, jobSetID, func(js *controller.JobSet) bool {
+		return len(js.Steps) > 0 && js.Steps[0].WaitingReason != ""
+	})
+}
+
+// getTenant returns the details of the named tenant.
+func getTenant(t *testing.T, h *Harness, tenant string) *pbc.TenantDetails {
+	t.Helper()
+	resp, err := h.Client.GetAllTenants(context.Background(), &pbc.GetAllTenantsReq{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, td := range resp.Tenants {
+		if td.Cfg.Name == tenant {
+			return td
+		}
+	}
+	t.Fatalf("no details for tenant %s", tenant)
+	return nil
+}
+
+func TestFairShareAcrossTenants(t *testing.T) {
+	h := newHarness(t, Options{MaxJobsRunning: 2})
+	release := make(chan struct{})
+	addAgent(t, h, "w", heldBehavior(release))
+	addTemplates(t, h, `
+templates:
+  - name: one
+    steps:
+      - agent: w
+`)
+	start(t, h)
+
+	// one tenant floods the queue before another submits anything
+	ids := []uint64{}
+	for i := 0; i < 6; i++ {
+		ids = append(ids, startTenantJobSet(t, h, "one", "flood"))
+	}
+	ids = append(ids, startTenantJobSet(t, h, "one", "other"))
+	waitUntilQueued(t, h, ids[6])
+
+	// queue depth is visible for each tenant
+	td := getTenant(t, h, "flood")
+	if td.RunningJobs != 2 || td.QueuedSteps != 4 || td.ActiveJobSets != 6 {
+		t.Errorf("expected flood to run 2 jobs with 4 queued in 6 jobSets, got %d, %d and %d", td.RunningJobs, td.QueuedSteps, td.ActiveJobSets)
+	}
+	close(release)
+
+	for _, id := range ids {
+		waitForJobSet(t, h, id, "OK")
+	}
+	// the other tenant gets one of the two slots that free up, rather
+	// than waiting behind the whole flood
+	tenants := jobTenants(t, h)
+	if len(tenants) != 7 || (tenants[2] != "other" && tenants[3] != "other") {
+		t.Errorf("expected other tenant's job third or fourth, got %v", tenants)
+	}
+}
+
+func TestFairShareMinimumJobs(t *testing.T) {
+	h := newHarness(t, Options{MaxJobsRunning: 3})
+	release := make(chan struct{})
+	addAgent(t, h, "w", heldBehavior(release))
+	addTemplates(t, h, `
+templates:
+  - name: one
+    steps:
+      - agent: w
+`)
+	ctx := context.Background()
+	for _, tc := range []*pbc.TenantConfig{{Name: "big", Weight: 5}, {Name: "small", MinJobs: 1}} {
+		if _, err := h.Client.SetTenant(ctx, &pbc.SetTenantReq{Cfg: tc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start(t, h)
+
+	// fill the slots first, so that the rest queue up together
+	ids := []uint64{}
+	for i := 0; i < 3; i++ {
+		ids = append(ids, startTenantJobSet(t, h, "one", "first"))
+	}
+	for i := 0; i < 4; i++ {
+		ids = append(ids, startTenantJobSet(t, h, "one", "big"))
+	}
+	ids = append(ids, startTenantJobSet(t, h, "one", "small"))
+	waitUntilQueued(t, h, ids[7])
+	close(release)
+
+	for _, id := range ids {
+		waitForJobSet(t, h, id, "OK")
+	}
+	// once the first jobs finish, small is below its minimum and goes
+	// first, even though big was queued before it
+	tenants := jobTenants(t, h)
+	if got := strings.Join(tenants, ","); got != "first,first,first,small,big,big,big,big" {
+		t.Errorf("expected small's job right after the first ones, got %s", got)
+	}
+}
+
+func TestSetTenantRejectsEmptyName(t *testing.T) {
+	h := newHarness(t, Options{})
+	resp, err := h.Client.SetTenant(context.Background(), &pbc.SetTenantReq{Cfg: &pbc.TenantConfig{Weight: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success || !strings.Contains(resp.ErrorMsg, "tenant must have a name") {
+		t.Errorf("expected tenant without a name to be rejected, got %v", resp)
+	}
+	if tenants := h.Controller.GetAllTenants(); len(tenants) != 0 {
+		t.Errorf("expected no tenants, got %v", tenants)
+	}
+}
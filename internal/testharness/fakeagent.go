@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	"github.com/swinslow/peridot-core/pkg/agentsdk"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Behavior scripts how a FakeAgent responds to each Job.
+type Behavior struct {
+	// Delay is how long the Job runs before finishing.
+	Delay time.Duration
+
+	// Health is the health the Job finishes with: OK (the default),
+	// DEGRADED or ERROR.
+	Health agent.JobHealthStatus
+
+	// Disconnect causes the agent to drop the stream once the Job is
+	// running, after Delay, without ever reporting it as STOPPED.
+	Disconnect bool
+
+	// Output is an optional output message to report.
+	Output string
+
+	// CodeFiles maps file paths, relative to the Job's CodeOutputDir,
+	// to the contents to write there.
+	CodeFiles map[string]string
+
+	// SpdxFiles maps file paths, relative to the Job's SpdxOutputDir,
+	// to the contents to write there.
+	SpdxFiles map[string]string
+
+	// Run, if set, is called after the files above have been written
+	// and after Delay, to script anything further. If it returns an
+	// error, the Job finishes with ERROR health.
+	Run agentsdk.RunJobFunc
+}
+
+// FakeAgent is a scriptable Agent that serves over an in-memory listener.
+type FakeAgent struct {
+	// Name is the agent's name, as registered with the Controller.
+	Name string
+
+	// m guards behavior and jobs
+	m        sync.Mutex
+	behavior Behavior
+	jobs     []*agent.JobConfig
+
+	sdk    *agentsdk.Server
+	lis    *bufconn.Listener
+	server *grpc.Server
+}
+
+// newFakeAgent creates a FakeAgent and starts serving it.
+func newFakeAgent(name string, agentType string, b Behavior) *FakeAgent {
+	fa := &FakeAgent{
+		Name:     name,
+		behavior: b,
+		lis:      bufconn.Listen(bufSize),
+		server:   grpc.NewServer(),
+	}
+	fa.sdk = agentsdk.NewServer(agentsdk.Description{Name: name, Type: agentType}, fa.runJob)
+	agent.RegisterAgentServer(fa.server, fa)
+	go fa.server.Serve(fa.lis)
+	return fa
+}
+
+// SetBehavior replaces the agent's Behavior for any Jobs started from now on.
+func (fa *FakeAgent) SetBehavior(b Behavior) {
+	fa.m.Lock()
+	defer fa.m.Unlock()
+	fa.behavior = b
+}
+
+// Jobs returns the configurations of all Jobs this agent has been asked
+// to run, in the order they were started.
+func (fa *FakeAgent) Jobs() []*agent.JobConfig {
+	fa.m.Lock()
+	defer fa.m.Unlock()
+	return append([]*agent.JobConfig{}, fa.jobs...)
+}
+
+// getBehavior returns the current Behavior.
+func (fa *FakeAgent) getBehavior() Behavior {
+	fa.m.Lock()
+	defer fa.m.Unlock()
+	return fa.behavior
+}
+
+// NewJob implements agent.AgentServer. Jobs are handled by the SDK, except
+// that if the Behavior says to disconnect, the stream is dropped once the
+// Job has started.
+func (fa *FakeAgent) NewJob(stream agent.Agent_NewJobServer) error {
+	b := fa.getBehavior()
+	if !b.Disconnect {
+		return fa.sdk.NewJob(stream)
+	}
+
+	for {
+		in, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if x, ok := in.Cm.(*agent.ControllerMsg_Start); ok {
+			fa.recordJob(x.Start.Config)
+			st := &agent.StatusReport{
+				RunStatus:    agent.JobRunStatus_RUNNING,
+				HealthStatus: agent.JobHealthStatus_OK,
+				TimeStarted:  time.Now().Unix(),
+			}
+			stream.Send(&agent.AgentMsg{Am: &agent.AgentMsg_Status{Status: st}})
+			time.Sleep(b.Delay)
+			return fmt.Errorf("fake agent %s disconnected", fa.Name)
+		}
+	}
+}
+
+// recordJob records that a Job was started with the given configuration.
+func (fa *FakeAgent) recordJob(cfg *agent.JobConfig) {
+	fa.m.Lock()
+	defer fa.m.Unlock()
+	fa.jobs = append(fa.jobs, cfg)
+}
+
+// runJob is the FakeAgent's agentsdk.RunJobFunc.
+func (fa *FakeAgent) runJob(ctx context.Context, cfg *agent.JobConfig, r agentsdk.Reporter) error {
+	fa.recordJob(cfg)
+	b := fa.getBehavior()
+
+	if err := writeFiles(cfg.CodeOutputDir, b.CodeFiles); err != nil {
+		return err
+	}
+	if err := writeFiles(cfg.SpdxOutputDir, b.SpdxFiles); err != nil {
+		return err
+	}
+	if b.Output != "" {
+		r.Outputf("%s", b.Output)
+	}
+
+	select {
+	case <-time.After(b.Delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if b.Run != nil {
+		if err := b.Run(ctx, cfg, r); err != nil {
+			return err
+		}
+	}
+
+	switch b.Health {
+	case agent.JobHealthStatus_DEGRADED:
+		r.Errorf("fake agent %s reporting DEGRADED", fa.Name)
+	case agent.JobHealthStatus_ERROR:
+		return fmt.Errorf("fake agent %s reporting ERROR", fa.Name)
+	}
+	return nil
+}
+
+// writeFiles writes each file's contents under dir.
+func writeFiles(dir string, files map[string]string) error {
+	for relPath, contents := range files {
+		path := filepath.Join(dir, relPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("couldn't create directory for %s: %v", path, err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			return fmt.Errorf("couldn't write %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// stop shuts down the agent's server.
+func (fa *FakeAgent) stop() {
+	fa.server.Stop()
+}
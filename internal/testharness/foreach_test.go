@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	"github.com/swinslow/peridot-core/pkg/agentsdk"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// failRepo returns a Behavior whose Jobs fail with an agent error if their
+// "repo" config key is bad, and otherwise succeed.
+func failRepo(bad string) Behavior {
+	return Behavior{Run: func(ctx context.Context, cfg *agent.JobConfig, r agentsdk.Reporter) error {
+		if repo, _ := agentsdk.GetJobKV(cfg, "repo"); repo == bad {
+			return fmt.Errorf("can't scan %s", repo)
+		}
+		return nil
+	}}
+}
+
+func TestForEachMaxParallel(t *testing.T) {
+	h := newHarness(t, Options{})
+	var limited, unlimited concurrencyTracker
+	scan := addAgent(t, h, "scan", limited.behavior(100*time.Millisecond))
+	addAgent(t, h, "scan-all", unlimited.behavior(100*time.Millisecond))
+	addAgent(t, h, "after", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: scan-repo
+    steps:
+      - agent: scan
+  - name: scan-all-repo
+    steps:
+      - agent: scan-all
+  - name: product
+    steps:
+      - foreach:
+          config: repos
+          as: repo
+          jobset: scan-repo
+          maxParallel: 2
+      - agent: after
+  - name: product-all
+    steps:
+      - foreach:
+          config: repos
+          as: repo
+          jobset: scan-all-repo
+`)
+	start(t, h)
+
+	id, err := h.StartJobSet("product", map[string]string{"repos": "a, b,c,,d,e "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := waitForJobSet(t, h, id, "OK")
+	if n := len(js.Steps[0].ConcurrentSteps); n != 5 {
+		t.Errorf("expected a step for each of 5 items, got %d", n)
+	}
+	checkStepsStopped(t, js.Steps, pbs.Health_OK)
+	if !strings.Contains(js.OutputMessages, "running scan-repo for 5 items") {
+		t.Errorf("expected items to be noted, got %q", js.OutputMessages)
+	}
+
+	// each item's JobSet is given its item, and no more than 2 run at once
+	repos := strings.Split(jobKVOrder(scan, "repo"), ",")
+	sort.Strings(repos)
+	if got := strings.Join(repos, ","); got != "a,b,c,d,e" {
+		t.Errorf("expected a job for each item, got %s", got)
+	}
+	if max := atomic.LoadInt32(&limited.max); max != 2 {
+		t.Errorf("expected 2 items at most to run at once, got %d", max)
+	}
+	if n := len(h.Agent("after").Jobs()); n != 1 {
+		t.Errorf("expected the step after to run once, got %d", n)
+	}
+
+	// without maxParallel, every item runs at once
+	id, err = h.StartJobSet("product-all", map[string]string{"repos": "a,b,c,d,e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForJobSet(t, h, id, "OK")
+	if max := atomic.LoadInt32(&unlimited.max); max != 5 {
+		t.Errorf("expected all 5 items to run at once, got %d", max)
+	}
+}
+
+func TestForEachFailures(t *testing.T) {
+	h := newHarness(t, Options{})
+	scan := addAgent(t, h, "scan", failRepo("bad"))
+	after := addAgent(t, h, "after", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: scan-repo
+    steps:
+      - agent: scan
+  - name: product
+    steps:
+      - foreach:
+          config: repos
+          as: repo
+          jobset: scan-repo
+          maxParallel: 1
+      - agent: after
+  - name: product-advisory
+    steps:
+      - foreach:
+          config: repos
+          as: repo
+          jobset: scan-repo
+          maxParallel: 1
+        allowFailure: true
+      - agent: after
+`)
+	start(t, h)
+
+	// a list with no items fails the step
+	id, err := h.StartJobSet("product", map[string]string{"repos": " , "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := waitForJobSet(t, h, id, "ERROR")
+	if !strings.Contains(js.ErrorMessages, "config repos lists no items") {
+		t.Errorf("expected no items error, got %q", js.ErrorMessages)
+	}
+
+	// a failing item stops the JobSet, and the items after it aren't run
+	id, err = h.StartJobSet("product", map[string]string{"repos": "a,bad,c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForJobSet(t, h, id, "ERROR")
+	if got := jobKVOrder(scan, "repo"); got != "a,bad" {
+		t.Errorf("expected items to stop at the failure, got %s", got)
+	}
+	if n := len(after.Jobs()); n != 0 {
+		t.Errorf("expected the step after not to run, got %d jobs", n)
+	}
+
+	// unless the step is allowed to fail, when the other items carry on
+	id, err = h.StartJobSet("product-advisory", map[string]string{"repos": "a,bad,c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	js = waitForJobSet(t, h, id, "DEGRADED")
+	if got := jobKVOrder(scan, "repo"); got != "a,bad,a,bad,c" {
+		t.Errorf("expected every item to run, got %s", got)
+	}
+	if item := js.Steps[0].ConcurrentSteps[1]; item.HealthStatus != pbs.Health_ERROR {
+		t.Errorf("expected failed item's step to keep its ERROR health, got %s", item.HealthStatus)
+	}
+	if js.Steps[0].HealthStatus != pbs.Health_DEGRADED {
+		t.Errorf("expected foreach step with an allowed failure to be DEGRADED, got %s", js.Steps[0].HealthStatus)
+	}
+	if n := len(after.Jobs()); n != 1 {
+		t.Errorf("expected the step after to run, got %d jobs", n)
+	}
+}
@@ -0,0 +1,310 @@
+// Package testharness runs a peridot Controller, its gRPC service and any
+// number of scriptable fake Agents in a single process, connected over
+// in-memory listeners rather than real ports. It is meant for end-to-end
+// tests of JobSetTemplates and of the Controller's scheduling.
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/internal/controllerrpc"
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufSize is the buffer size for each in-memory listener.
+const bufSize = 1024 * 1024
+
+// fakeAgentPort is the port registered with the Controller for every
+// FakeAgent. Each agent's URL is its name, so addresses stay unique.
+const fakeAgentPort = 1
+
+// pollInterval is how often the Wait functions check for status changes.
+const pollInterval = 10 * time.Millisecond
+
+// Options configures a new Harness.
+type Options struct {
+	// VolPrefix is the Controller's volume prefix. If empty, a temporary
+	// directory is created and then removed by Close.
+	VolPrefix string
+
+	// MaxJobsRunning is the maximum number of Jobs that can run at once.
+	// If zero, defaults to 10.
+	MaxJobsRunning int
+
+	// ResourceClasses maps the name of each resource class to the maximum
+	// number of Jobs in that class that can run at once.
+	ResourceClasses map[string]int
+
+	// HeartbeatInterval is how often the Controller asks the agent of
+	// each running Job for its status. If zero, no heartbeats are sent.
+	HeartbeatInterval time.Duration
+
+	// HeartbeatTimeout is how long the agent of a running Job may stay
+	// silent before the Job fails. It has no effect unless
+	// HeartbeatInterval is set.
+	HeartbeatTimeout time.Duration
+}
+
+// Harness is an in-process Controller together with its fake Agents.
+type Harness struct {
+	// Controller is the Controller under test.
+	Controller *controller.Controller
+
+	// Client is a gRPC client for the Controller's service.
+	Client pbc.ControllerClient
+
+	// VolPrefix is the Controller's volume prefix.
+	VolPrefix string
+
+	// m guards agents
+	m sync.Mutex
+	// agents maps each FakeAgent's address to the agent
+	agents map[string]*FakeAgent
+
+	tempDir string
+	lis     *bufconn.Listener
+	server  *grpc.Server
+	conn    *grpc.ClientConn
+}
+
+// New creates and initializes a Controller, and serves its gRPC service
+// over an in-memory listener. The Controller is not started until Start
+// is called, and at least one agent must be added first.
+func New(opts Options) (*Harness, error) {
+	h := &Harness{
+		VolPrefix: opts.VolPrefix,
+		agents:    map[string]*FakeAgent{},
+	}
+
+	if h.VolPrefix == "" {
+		dir, err := ioutil.TempDir("", "peridot-testharness-")
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create temporary directory: %v", err)
+		}
+		h.tempDir = dir
+		h.VolPrefix = dir
+	}
+
+	maxJobsRunning := opts.MaxJobsRunning
+	if maxJobsRunning == 0 {
+		maxJobsRunning = 10
+	}
+
+	h.Controller = &controller.Controller{}
+	err := h.Controller.Init(&controller.Config{
+		VolPrefix:         h.VolPrefix,
+		MaxJobsRunning:    maxJobsRunning,
+		ResourceClasses:   opts.ResourceClasses,
+		AgentDialOptions:  []grpc.DialOption{grpc.WithContextDialer(h.dialAgent)},
+		HeartbeatInterval: opts.HeartbeatInterval,
+		HeartbeatTimeout:  opts.HeartbeatTimeout,
+	})
+	if err != nil {
+		h.removeTempDir()
+		return nil, err
+	}
+
+	// serve the Controller's gRPC service, and connect a client to it
+	h.lis = bufconn.Listen(bufSize)
+	h.server = grpc.NewServer()
+	pbc.RegisterControllerServer(h.server, &controllerrpc.CServer{C: h.Controller})
+	go h.server.Serve(h.lis)
+
+	dialer := func(ctx context.Context, address string) (net.Conn, error) {
+		return h.lis.DialContext(ctx)
+	}
+	h.conn, err = grpc.Dial("controller", grpc.WithInsecure(), grpc.WithContextDialer(dialer))
+	if err != nil {
+		h.server.Stop()
+		h.removeTempDir()
+		return nil, fmt.Errorf("couldn't connect to controller: %v", err)
+	}
+	h.Client = pbc.NewControllerClient(h.conn)
+
+	return h, nil
+}
+
+// dialAgent connects to the FakeAgent at the given address.
+func (h *Harness) dialAgent(ctx context.Context, address string) (net.Conn, error) {
+	h.m.Lock()
+	fa, ok := h.agents[address]
+	h.m.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no fake agent at %s", address)
+	}
+	return fa.lis.DialContext(ctx)
+}
+
+// AddAgent starts a FakeAgent with the given name and Behavior, and
+// registers it with the Controller with the agent type "fake".
+func (h *Harness) AddAgent(name string, b Behavior) (*FakeAgent, error) {
+	return h.AddAgentOfType(name, "fake", b)
+}
+
+// AddAgentOfType is like AddAgent, but registers the FakeAgent with the
+// given agent type, e.g. so that it can be picked by steps that name an
+// agent type.
+func (h *Harness) AddAgentOfType(name string, agentType string, b Behavior) (*FakeAgent, error) {
+	fa := newFakeAgent(name, agentType, b)
+	address := fmt.Sprintf("%s:%d", name, fakeAgentPort)
+
+	h.m.Lock()
+	if _, ok := h.agents[address]; ok {
+		h.m.Unlock()
+		fa.stop()
+		return nil, fmt.Errorf("fake agent %s already exists", name)
+	}
+	h.agents[address] = fa
+	h.m.Unlock()
+
+	err := h.Controller.AddAgent(&pbc.AgentConfig{
+		Name: name,
+		Url:  name,
+		Port: fakeAgentPort,
+		Type: agentType,
+	})
+	if err != nil {
+		h.m.Lock()
+		delete(h.agents, address)
+		h.m.Unlock()
+		fa.stop()
+		return nil, err
+	}
+	return fa, nil
+}
+
+// Agent returns the FakeAgent with the given name, or nil if none exists.
+func (h *Harness) Agent(name string) *FakeAgent {
+	h.m.Lock()
+	defer h.m.Unlock()
+	return h.agents[fmt.Sprintf("%s:%d", name, fakeAgentPort)]
+}
+
+// AddTemplate registers a JobSetTemplate with the Controller.
+func (h *Harness) AddTemplate(name string, steps []*controller.StepTemplate) error {
+	return h.Controller.AddJobSetTemplate(name, steps)
+}
+
+// AddTemplatesYAML registers the JobSetTemplates defined in YAML, using the
+// controller configuration file format. Only the templates section is used,
+// and templates are added in the order they appear.
+func (h *Harness) AddTemplatesYAML(y string) error {
+	cfg, err := controller.ParseConfig([]byte(y))
+	if err != nil {
+		return err
+	}
+	for _, jst := range cfg.JobSetTemplates {
+		err = h.Controller.AddJobSetTemplate(jst.Name, jst.Steps)
+		if err != nil {
+			return fmt.Errorf("couldn't add template %s: %v", jst.Name, err)
+		}
+	}
+	return nil
+}
+
+// Start starts the Controller.
+func (h *Harness) Start() error {
+	return h.Controller.Start()
+}
+
+// StartJobSet starts a new JobSet from the named template, with the given
+// configuration key-value pairs and the default priority, and returns its
+// ID.
+func (h *Harness) StartJobSet(jstName string, cfgs map[string]string) (uint64, error) {
+	return h.StartJobSetWithPriority(jstName, cfgs, 0)
+}
+
+// StartJobSetWithPriority is like StartJobSet, but starts the JobSet with
+// the given scheduling priority.
+func (h *Harness) StartJobSetWithPriority(jstName string, cfgs map[string]string, priority int32) (uint64, error) {
+	keys := []string{}
+	for k := range cfgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	jscs := []*pbc.JobSetConfig{}
+	for _, k := range keys {
+		jscs = append(jscs, &pbc.JobSetConfig{Key: k, Value: cfgs[k]})
+	}
+	return h.Controller.StartJobSet(jstName, jscs, priority, "")
+}
+
+// WaitForJobSet waits until the JobSet with the given ID has stopped, and
+// returns it. It returns an error if the JobSet is unknown, or if it has
+// not stopped within the timeout.
+func (h *Harness) WaitForJobSet(jobSetID uint64, timeout time.Duration) (*controller.JobSet, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		// the JobSet may not exist yet if its request is still queued
+		js, err := h.Controller.GetJobSet(jobSetID)
+		if err == nil && js.RunStatus == pbs.Status_STOPPED {
+			return js, nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return nil, err
+			}
+			return js, fmt.Errorf("jobSet %d still %s after %v", jobSetID, js.RunStatus, timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// WaitForJob waits until the Job with the given ID has stopped, and
+// returns it. It returns an error if the Job is unknown, or if it has not
+// stopped within the timeout.
+func (h *Harness) WaitForJob(jobID uint64, timeout time.Duration) (*controller.Job, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		job, err := h.Controller.GetJob(jobID)
+		if err == nil && job.Status.RunStatus == agent.JobRunStatus_STOPPED {
+			return job, nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return nil, err
+			}
+			return job, fmt.Errorf("job %d still %s after %v", jobID, job.Status.RunStatus, timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Close stops the Controller, its gRPC service and all fake Agents, and
+// removes the temporary volume directory if one was created.
+func (h *Harness) Close() {
+	h.Controller.Stop()
+	h.conn.Close()
+	h.server.Stop()
+
+	h.m.Lock()
+	for _, fa := range h.agents {
+		fa.stop()
+	}
+	h.m.Unlock()
+
+	h.removeTempDir()
+}
+
+// removeTempDir removes the temporary volume directory, if any.
+func (h *Harness) removeTempDir() {
+	if h.tempDir != "" {
+		os.RemoveAll(h.tempDir)
+	}
+}
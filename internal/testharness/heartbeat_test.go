@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatSilenceFailsJob(t *testing.T) {
+	h := newHarness(t, Options{HeartbeatInterval: 50 * time.Millisecond, HeartbeatTimeout: 300 * time.Millisecond})
+	// keeps the stream open, but never answers
+	addAgent(t, h, "wedged", Behavior{Disconnect: true, Delay: 10 * time.Second})
+	// runs for longer than the timeout, but answers each heartbeat
+	addAgent(t, h, "busy", Behavior{Delay: 700 * time.Millisecond})
+	addTemplates(t, h, `
+templates:
+  - name: wedged
+    steps:
+      - agent: wedged
+  - name: busy
+    steps:
+      - agent: busy
+`)
+	start(t, h)
+
+	wedged := startJobSet(t, h, "wedged")
+	busy := startJobSet(t, h, "busy")
+
+	js := waitForJobSet(t, h, wedged, "ERROR")
+	job, err := h.WaitForJob(js.Steps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.ConnectionError || !strings.Contains(job.Status.ErrorMessages, "no heartbeat from wedged") {
+		t.Errorf("expected no heartbeat connection error, got %v %q", job.ConnectionError, job.Status.ErrorMessages)
+	}
+
+	js = waitForJobSet(t, h, busy, "OK")
+	job, err = h.WaitForJob(js.Steps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.LastSeen.IsZero() {
+		t.Error("expected busy job to have a last-seen time")
+	}
+}
+
+func TestHeartbeatsOffByDefault(t *testing.T) {
+	h := newHarness(t, Options{HeartbeatTimeout: 100 * time.Millisecond})
+	addAgent(t, h, "quiet", Behavior{Disconnect: true, Delay: 300 * time.Millisecond})
+	addTemplates(t, h, `
+templates:
+  - name: quiet
+    steps:
+      - agent: quiet
+`)
+	start(t, h)
+
+	// without an interval, silence alone never fails the job; it only
+	// fails once the agent drops its stream
+	id := startJobSet(t, h, "quiet")
+	js := waitForJobSet(t, h, id, "ERROR")
+	job, err := h.WaitForJob(js.Steps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(job.Status.ErrorMessages, "no heartbeat") {
+		t.Errorf("expected no heartbeat failure, got %q", job.Status.ErrorMessages)
+	}
+}
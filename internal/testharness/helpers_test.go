@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/pkg/agent"
+	"github.com/swinslow/peridot-core/pkg/agentsdk"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+// waitTimeout is how long the tests wait for a JobSet or Job to stop.
+const waitTimeout = 5 * time.Second
+
+// newHarness creates a Harness, which is closed when the test finishes.
+func newHarness(t *testing.T, opts Options) *Harness {
+	t.Helper()
+	h, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Close)
+	return h
+}
+
+// addAgent adds a FakeAgent with the given name and Behavior.
+func addAgent(t *testing.T, h *Harness, name string, b Behavior) *FakeAgent {
+	t.Helper()
+	fa, err := h.AddAgent(name, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fa
+}
+
+// addAgentOfType adds a FakeAgent with the given name, agent type and
+// Behavior.
+func addAgentOfType(t *testing.T, h *Harness, name string, agentType string, b Behavior) *FakeAgent {
+	t.Helper()
+	fa, err := h.AddAgentOfType(name, agentType, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fa
+}
+
+// heldBehavior returns a Behavior whose Jobs don't finish until release
+// is closed, so that a test can queue up further JobSets behind them.
+func heldBehavior(release <-chan struct{}) Behavior {
+	return Behavior{Run: func(ctx context.Context, cfg *agent.JobConfig, r agentsdk.Reporter) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}}
+}
+
+// updateAgent changes the configuration of the FakeAgent named in cfg,
+// keeping its address. Its agent type is "fake" unless cfg gives one.
+func updateAgent(t *testing.T, h *Harness, cfg *pbc.AgentConfig) {
+	t.Helper()
+	cfg.Url = cfg.Name
+	cfg.Port = fakeAgentPort
+	if cfg.Type == "" {
+		cfg.Type = "fake"
+	}
+	if err := h.Controller.UpdateAgent(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addTemplates adds the JobSetTemplates defined in YAML.
+func addTemplates(t *testing.T, h *Harness, y string) {
+	t.Helper()
+	if err := h.AddTemplatesYAML(y); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// start starts the Harness's Controller.
+func start(t *testing.T, h *Harness) {
+	t.Helper()
+	if err := h.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startJobSet starts a JobSet from the named template, and returns its ID.
+func startJobSet(t *testing.T, h *Harness, jstName string) uint64 {
+	t.Helper()
+	id, err := h.StartJobSet(jstName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// waitForJobSet waits for the JobSet with the given ID to stop, and
+// checks that it finished with the expected health.
+func waitForJobSet(t *testing.T, h *Harness, jobSetID uint64, health string) *controller.JobSet {
+	t.Helper()
+	js, err := h.WaitForJobSet(jobSetID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if js.HealthStatus.String() != health {
+		t.Errorf("expected jobSet %d to finish with %s, got %s; errors: %q", jobSetID, health, js.HealthStatus, js.ErrorMessages)
+	}
+	return js
+}
+
+// waitForRunningJob waits until the JobSet with the given ID has a Job
+// that its agent is running, and returns the Job's ID.
+func waitForRunningJob(t *testing.T, h *Harness, jobSetID uint64) uint64 {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		for _, job := range h.Controller.GetAllJobsForJobSet(jobSetID) {
+			if job.Status.RunStatus == agent.JobRunStatus_RUNNING {
+				return job.JobID
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+	t.Fatalf("jobSet %d has no running job after %v", jobSetID, waitTimeout)
+	return 0
+}
+
+// waitForJobSetState polls the JobSet with the given ID until ok returns true for
+// it, and returns it then.
+func waitForJobSetState(t *testing.T, h *Harness, jobSetID uint64, ok func(js *controller.JobSet) bool) *controller.JobSet {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		js, err := h.Controller.GetJobSet(jobSetID)
+		if err == nil && ok(js) {
+			return js
+		}
+		time.Sleep(pollInterval)
+	}
+	t.Fatalf("jobSet %d didn't reach the expected state after %v", jobSetID, waitTimeout)
+	return nil
+}
+
+// jobsForJobSet returns the Jobs of the JobSet with the given ID, in the
+// order they were created.
+func jobsForJobSet(h *Harness, jobSetID uint64) []*controller.Job {
+	jobs := h.Controller.GetAllJobsForJobSet(jobSetID)
+	for i := 1; i < len(jobs); i++ {
+		for j := i; j > 0 && jobs[j].JobID < jobs[j-1].JobID; j-- {
+			jobs[j], jobs[j-1] = jobs[j-1], jobs[j]
+		}
+	}
+	return jobs
+}
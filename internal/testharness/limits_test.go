@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/pkg/agent"
+	"github.com/swinslow/peridot-core/pkg/agentsdk"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// concurrencyTracker records the most Jobs that were running at once.
+type concurrencyTracker struct {
+	running int32
+	max     int32
+}
+
+// behavior returns a Behavior whose Jobs each run for delay, and are
+// counted by the tracker while they run.
+func (ct *concurrencyTracker) behavior(delay time.Duration) Behavior {
+	return Behavior{Run: func(ctx context.Context, cfg *agent.JobConfig, r agentsdk.Reporter) error {
+		n := atomic.AddInt32(&ct.running, 1)
+		defer atomic.AddInt32(&ct.running, -1)
+		for {
+			max := atomic.LoadInt32(&ct.max)
+			if n <= max || atomic.CompareAndSwapInt32(&ct.max, max, n) {
+				break
+			}
+		}
+
+		select {
+		case <-time.After(delay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}}
+}
+
+func TestAgentConcurrencyLimit(t *testing.T) {
+	h := newHarness(t, Options{})
+	var ct concurrencyTracker
+	addAgent(t, h, "limited", ct.behavior(150*time.Millisecond))
+	updateAgent(t, h, &pbc.AgentConfig{Name: "limited", MaxConcurrentJobs: 1})
+	addAgent(t, h, "free", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: limit
+    steps:
+      - concurrent:
+          - agent: limited
+          - agent: limited
+          - agent: limited
+          - agent: free
+`)
+	start(t, h)
+
+	// the step for the other agent isn't held back by the limited ones
+	id := startJobSet(t, h, "limit")
+	js := waitForJobSetState(t, h, id, func(js *controller.JobSet) bool {
+		return js.Steps[0].ConcurrentSteps[3].RunStatus == pbs.Status_STOPPED
+	})
+	waiting := 0
+	for _, step := range js.Steps[0].ConcurrentSteps[:3] {
+		if step.RunStatus != pbs.Status_STARTUP {
+			continue
+		}
+		waiting++
+		if !strings.Contains(step.WaitingReason, "waiting for agent limited, which is running 1 of at most 1 jobs") {
+			t.Errorf("expected step %d to wait for the agent, got %q", step.StepID, step.WaitingReason)
+		}
+	}
+	if waiting != 2 {
+		t.Errorf("expected two steps to wait for the limited agent, got %d", waiting)
+	}
+
+	waitForJobSet(t, h, id, "OK")
+	if n := len(h.Agent("limited").Jobs()); n != 3 {
+		t.Errorf("expected three jobs on the limited agent, got %d", n)
+	}
+	if max := atomic.LoadInt32(&ct.max); max != 1 {
+		t.Errorf("expected at most one job at a time on the limited agent, got %d", max)
+	}
+}
+
+func TestAgentQueuePositions(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "limited", Behavior{Delay: 300 * time.Millisecond})
+	updateAgent(t, h, &pbc.AgentConfig{Name: "limited", MaxConcurrentJobs: 1})
+	addTemplates(t, h, `
+templates:
+  - name: one
+    steps:
+      - agent: limited
+`)
+	start(t, h)
+
+	first := startJobSet(t, h, "one")
+	waitForRunningJob(t, h, first)
+	second := startJobSet(t, h, "one")
+	third := startJobSet(t, h, "one")
+
+	// each waiting step shows its place in the agent's queue
+	for i, id := range []uint64{second, third} {
+		want := []string{"(position 1 in its queue)", "(position 2 in its queue)"}[i]
+		js := waitForJobSetState(t, h, id, func(js *controller.JobSet) bool {
+			return len(js.Steps) > 0 && js.Steps[0].WaitingReason != ""
+		})
+		if !strings.HasSuffix(js.Steps[0].WaitingReason, want) {
+			t.Errorf("expected jobSet %d to be %s, got %q", id, want, js.Steps[0].WaitingReason)
+		}
+	}
+	for _, id := range []uint64{first, second, third} {
+		waitForJobSet(t, h, id, "OK")
+	}
+}
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// jobAgents returns the names of the agents that ran the JobSet's Jobs, in
+// the order they were created.
+func jobAgents(h *Harness, jobSetID uint64) []string {
+	names := []string{}
+	for _, job := range jobsForJobSet(h, jobSetID) {
+		names = append(names, job.AgentName)
+	}
+	return names
+}
+
+func TestPoolLeastLoaded(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgentOfType(t, h, "pool-a", "pool", Behavior{Delay: 100 * time.Millisecond})
+	addAgentOfType(t, h, "pool-b", "pool", Behavior{Delay: 100 * time.Millisecond})
+	addTemplates(t, h, `
+templates:
+  - name: pool
+    steps:
+      - concurrent:
+          - agentType: pool
+          - agentType: pool
+          - agentType: pool
+          - agentType: pool
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "pool")
+	js := waitForJobSet(t, h, id, "OK")
+	if a, b := len(h.Agent("pool-a").Jobs()), len(h.Agent("pool-b").Jobs()); a != 2 || b != 2 {
+		t.Errorf("expected jobs to be split evenly, got %d and %d", a, b)
+	}
+
+	// each step records the agent that was picked for it
+	for _, step := range js.Steps[0].ConcurrentSteps {
+		job, err := h.Controller.GetJob(step.AgentJobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if step.AgentName == "" || step.AgentName != job.AgentName {
+			t.Errorf("expected step %d to record its job's agent %s, got %q", step.StepID, job.AgentName, step.AgentName)
+		}
+	}
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgentOfType(t, h, "pool-a", "pool", Behavior{})
+	addAgentOfType(t, h, "pool-b", "pool", Behavior{})
+	addAgentOfType(t, h, "pool-c", "pool", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: pool
+    steps:
+      - agentType: pool
+        strategy: round-robin
+      - agentType: pool
+        strategy: round-robin
+      - agentType: pool
+        strategy: round-robin
+      - agentType: pool
+        strategy: round-robin
+`)
+	start(t, h)
+
+	// idle agents would all be least loaded, but each is taken in turn
+	id := startJobSet(t, h, "pool")
+	waitForJobSet(t, h, id, "OK")
+	if got := strings.Join(jobAgents(h, id), ","); got != "pool-a,pool-b,pool-c,pool-a" {
+		t.Errorf("expected agents to be taken in turn, got %s", got)
+	}
+}
+
+func TestPoolSkipsUnhealthyAgent(t *testing.T) {
+	h := newHarness(t, Options{})
+	// pool-a would be picked first, but drops its connection
+	addAgentOfType(t, h, "pool-a", "pool", Behavior{Disconnect: true})
+	addAgentOfType(t, h, "pool-b", "pool", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: pool
+    steps:
+      - agentType: pool
+        retry:
+          maxAttempts: 2
+          backoff: 10ms
+      - agentType: pool
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "pool")
+	waitForJobSet(t, h, id, "OK")
+	if got := strings.Join(jobAgents(h, id), ","); got != "pool-a,pool-b,pool-b" {
+		t.Errorf("expected the unhealthy agent to be skipped after it failed, got %s", got)
+	}
+}
+
+func TestPoolWaitsForAgentOfType(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgentOfType(t, h, "pool-a", "pool", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: pool
+    steps:
+      - agentType: pool
+`)
+	start(t, h)
+	if err := h.Controller.RemoveAgent("pool-a", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// with no agents of its type left, the step waits for one
+	id := startJobSet(t, h, "pool")
+	waitForJobSetState(t, h, id, func(js *controller.JobSet) bool {
+		return len(js.Steps) > 0 && js.Steps[0].WaitingReason == "waiting for an agent of type pool to be registered"
+	})
+	addAgentOfType(t, h, "pool-b", "pool", Behavior{})
+	js := waitForJobSet(t, h, id, "OK")
+	if js.Steps[0].RunStatus != pbs.Status_STOPPED || js.Steps[0].AgentName != "pool-b" {
+		t.Errorf("expected step to run on pool-b, got %s on %q", js.Steps[0].RunStatus, js.Steps[0].AgentName)
+	}
+}
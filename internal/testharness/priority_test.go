@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/pkg/agentsdk"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+// jobKVOrder returns the value of the given config key for each Job that
+// the agent has received, in order.
+func jobKVOrder(fa *FakeAgent, key string) string {
+	vals := []string{}
+	for _, cfg := range fa.Jobs() {
+		v, _ := agentsdk.GetJobKV(cfg, key)
+		vals = append(vals, v)
+	}
+	return strings.Join(vals, ",")
+}
+
+func TestPriorityOrder(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "limited", Behavior{Delay: 100 * time.Millisecond})
+	updateAgent(t, h, &pbc.AgentConfig{Name: "limited", MaxConcurrentJobs: 1})
+	addTemplates(t, h, `
+templates:
+  - name: one
+    steps:
+      - agent: limited
+`)
+	start(t, h)
+
+	// the first jobSet takes the agent, and the rest queue behind it
+	ids := []uint64{}
+	for i, priority := range []int32{0, 0, 0, 5, 0} {
+		id, err := h.StartJobSetWithPriority("one", map[string]string{"n": fmt.Sprint(i)}, priority)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+		if i == 0 {
+			waitForRunningJob(t, h, id)
+		}
+	}
+
+	// raise one of the queued jobSets above the rest
+	resp, err := h.Client.SetJobSetPriority(context.Background(), &pbc.SetJobSetPriorityReq{JobSetID: ids[2], Priority: 10})
+	if err != nil || !resp.Success {
+		t.Fatalf("expected priority to be set, got %v %q", err, resp.GetErrorMsg())
+	}
+
+	for _, id := range ids {
+		waitForJobSet(t, h, id, "OK")
+	}
+	// then highest priority first, and oldest first within a priority
+	if got := jobKVOrder(h.Agent("limited"), "n"); got != "0,2,3,1,4" {
+		t.Errorf("expected jobs in order 0,2,3,1,4, got %s", got)
+	}
+
+	// a stopped jobSet's priority can't be changed
+	resp, err = h.Client.SetJobSetPriority(context.Background(), &pbc.SetJobSetPriorityReq{JobSetID: ids[0], Priority: 1})
+	if err != nil || resp.Success {
+		t.Errorf("expected failure for a stopped jobSet, got %v %v", err, resp.GetSuccess())
+	}
+}
+
+func TestPriorityInheritedBySubJobSets(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "slow", Behavior{Delay: 200 * time.Millisecond})
+	addAgent(t, h, "quick", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: sub
+    steps:
+      - agent: quick
+  - name: parent
+    steps:
+      - agent: slow
+      - jobset: sub
+      - jobset: sub
+`)
+	start(t, h)
+
+	id, err := h.StartJobSetWithPriority("parent", nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := waitForJobSet(t, h, id, "OK")
+	sub, err := h.Controller.GetJobSet(js.Steps[1].SubJobSetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Priority != 3 {
+		t.Errorf("expected sub-jobSet to inherit priority 3, got %d", sub.Priority)
+	}
+
+	// a changed priority applies to sub-jobSets created afterwards too
+	id, err = h.StartJobSetWithPriority("parent", nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForRunningJob(t, h, id)
+	if err := h.Controller.SetJobSetPriority(id, 7); err != nil {
+		t.Fatal(err)
+	}
+	js = waitForJobSet(t, h, id, "OK")
+	for _, step := range js.Steps[1:] {
+		sub, err := h.Controller.GetJobSet(step.SubJobSetID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.Priority != 7 {
+			t.Errorf("expected sub-jobSet %d to have priority 7, got %d", sub.JobSetID, sub.Priority)
+		}
+	}
+}
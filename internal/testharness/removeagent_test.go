@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+func TestForceRemovedAgentFailsWaitingSteps(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "slow", Behavior{Delay: 300 * time.Millisecond})
+	if err := h.Controller.UpdateAgent(&pbc.AgentConfig{Name: "slow", Url: "slow", Port: fakeAgentPort, Type: "fake", MaxConcurrentJobs: 1}); err != nil {
+		t.Fatal(err)
+	}
+	addTemplates(t, h, `
+templates:
+  - name: slow
+    steps:
+      - agent: slow
+        retry:
+          maxAttempts: 3
+          backoff: 10ms
+          on: [connection, agent]
+`)
+	start(t, h)
+
+	running := startJobSet(t, h, "slow")
+	waitForRunningJob(t, h, running)
+	waiting := startJobSet(t, h, "slow")
+
+	if err := h.Controller.RemoveAgent("slow", false); err == nil {
+		t.Fatal("expected removal to be refused while the agent has an active job")
+	}
+	if err := h.Controller.RemoveAgent("slow", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// the running job is left to finish
+	waitForJobSet(t, h, running, "OK")
+
+	// but the waiting step fails at once, without being retried
+	js := waitForJobSet(t, h, waiting, "ERROR")
+	if !strings.Contains(js.ErrorMessages, "agent slow was removed") {
+		t.Errorf("expected agent removed error, got %q", js.ErrorMessages)
+	}
+	if jobs := jobsForJobSet(h, waiting); len(jobs) != 0 {
+		t.Errorf("expected no jobs for the waiting step, got %d", len(jobs))
+	}
+
+	// as do jobSets started later from the template
+	later := startJobSet(t, h, "slow")
+	js = waitForJobSet(t, h, later, "ERROR")
+	if !strings.Contains(js.ErrorMessages, "agent slow was removed") {
+		t.Errorf("expected agent removed error, got %q", js.ErrorMessages)
+	}
+}
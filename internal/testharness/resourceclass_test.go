@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+// runningJobsByClass returns how many of the given Jobs are running in
+// each resource class, with "" for those with none.
+func runningJobsByClass(jobs []*controller.Job) map[string]int {
+	running := map[string]int{}
+	for _, job := range jobs {
+		if job.Status.RunStatus == agent.JobRunStatus_RUNNING {
+			running[job.ResourceClass]++
+		}
+	}
+	return running
+}
+
+// waitForJobs polls the Controller's Jobs until ok returns true for them.
+func waitForJobs(t *testing.T, h *Harness, ok func(jobs []*controller.Job) bool) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		if ok(h.Controller.GetAllJobs()) {
+			return
+		}
+		time.Sleep(pollInterval)
+	}
+	t.Fatalf("jobs didn't reach the expected state after %v", waitTimeout)
+}
+
+func TestResourceClasses(t *testing.T) {
+	h := newHarness(t, Options{MaxJobsRunning: 1, ResourceClasses: map[string]int{"net": 2, "cpu": 1}})
+	release := make(chan struct{})
+	addAgent(t, h, "fetch", heldBehavior(release))
+	addAgent(t, h, "fetch2", heldBehavior(release))
+	addAgent(t, h, "scan", heldBehavior(release))
+
+	// agents and steps can only name known classes
+	err := h.Controller.UpdateAgent(&pbc.AgentConfig{Name: "fetch2", Url: "fetch2", Port: fakeAgentPort, Type: "fake", ResourceClass: "bogus"})
+	if err == nil || !strings.Contains(err.Error(), "unknown resource class bogus") {
+		t.Errorf("expected agent with unknown class to be rejected, got %v", err)
+	}
+	err = h.AddTemplatesYAML(`
+templates:
+  - name: bad
+    steps:
+      - agent: fetch
+        resourceClass: nope
+`)
+	if err == nil || !strings.Contains(err.Error(), "unknown resource class nope") {
+		t.Errorf("expected step with unknown class to be rejected, got %v", err)
+	}
+
+	// fetch's Jobs are in the net class by their step, and fetch2's by
+	// their agent, unless their step says otherwise
+	updateAgent(t, h, &pbc.AgentConfig{Name: "fetch2", ResourceClass: "net"})
+	addTemplates(t, h, `
+templates:
+  - name: fetch
+    steps:
+      - agent: fetch
+        resourceClass: net
+  - name: fetch2
+    steps:
+      - agent: fetch2
+  - name: fetch2-cpu
+    steps:
+      - agent: fetch2
+        resourceClass: cpu
+  - name: scan
+    steps:
+      - agent: scan
+`)
+	start(t, h)
+
+	ids := []uint64{}
+	for _, name := range []string{"fetch", "fetch2", "fetch", "fetch2", "scan", "scan"} {
+		ids = append(ids, startJobSet(t, h, name))
+	}
+
+	// each class's Jobs count only against its own capacity, so the net
+	// Jobs don't hold up the scan, which has maxJobsRunning to itself
+	waitForJobs(t, h, func(jobs []*controller.Job) bool {
+		running := runningJobsByClass(jobs)
+		return running["net"] == 2 && running[""] == 1
+	})
+	for _, id := range ids[2:4] {
+		waitUntilQueued(t, h, id)
+	}
+	waitUntilQueued(t, h, ids[5])
+	if running := runningJobsByClass(h.Controller.GetAllJobs()); running["net"] != 2 || running[""] != 1 || len(running) != 2 {
+		t.Errorf("expected only 2 net jobs and 1 other job running, got %v", running)
+	}
+	js, err := h.Controller.GetJobSet(ids[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := js.Steps[0].WaitingReason; !strings.Contains(reason, "resource class net, which is running 2 of at most 2 jobs") {
+		t.Errorf("expected step to be waiting for the net class, got %q", reason)
+	}
+	js, err = h.Controller.GetJobSet(ids[5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := js.Steps[0].WaitingReason; strings.Contains(reason, "resource class") {
+		t.Errorf("expected step to be waiting for a job slot, got %q", reason)
+	}
+
+	close(release)
+	for _, id := range ids {
+		waitForJobSet(t, h, id, "OK")
+	}
+	js, err = h.Controller.GetJobSet(ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := h.Controller.GetJob(js.Steps[0].AgentJobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ResourceClass != "net" {
+		t.Errorf("expected job to take its agent's class, got %q", job.ResourceClass)
+	}
+
+	id := startJobSet(t, h, "fetch2-cpu")
+	js = waitForJobSet(t, h, id, "OK")
+	job, err = h.Controller.GetJob(js.Steps[0].AgentJobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ResourceClass != "cpu" {
+		t.Errorf("expected step's class to take precedence, got %q", job.ResourceClass)
+	}
+}
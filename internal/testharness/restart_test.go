@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// checkRunStatus checks the Controller's run status.
+func checkRunStatus(t *testing.T, h *Harness, want pbs.Status) {
+	t.Helper()
+	if runStatus, _, _, _ := h.Controller.GetStatus(); runStatus != want {
+		t.Errorf("expected controller to be %s, got %s", want, runStatus)
+	}
+}
+
+func TestRestartWhenIdle(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "quick", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: quick
+    steps:
+      - agent: quick
+`)
+	checkRunStatus(t, h, pbs.Status_STARTUP)
+	start(t, h)
+	checkRunStatus(t, h, pbs.Status_RUNNING)
+	first := waitForJobSet(t, h, startJobSet(t, h, "quick"), "OK")
+
+	h.Controller.Stop()
+	checkRunStatus(t, h, pbs.Status_STOPPED)
+	if _, err := h.StartJobSet("quick", nil); err == nil {
+		t.Error("expected error starting a JobSet while stopped")
+	}
+
+	// once restarted, it carries on from where it left off
+	start(t, h)
+	checkRunStatus(t, h, pbs.Status_RUNNING)
+	if err := h.Controller.Start(); err == nil {
+		t.Error("expected error starting a running controller")
+	}
+	second := waitForJobSet(t, h, startJobSet(t, h, "quick"), "OK")
+	if second.JobSetID <= first.JobSetID || second.Steps[0].AgentJobID <= first.Steps[0].AgentJobID {
+		t.Errorf("expected new IDs after restart, got JobSet %d and Job %d after JobSet %d and Job %d", second.JobSetID, second.Steps[0].AgentJobID, first.JobSetID, first.Steps[0].AgentJobID)
+	}
+	if js, err := h.Controller.GetJobSet(first.JobSetID); err != nil || js.HealthStatus != pbs.Health_OK {
+		t.Errorf("expected the earlier JobSet to be kept, got %v", err)
+	}
+}
+
+func TestRestartWithRunningJob(t *testing.T) {
+	h := newHarness(t, Options{})
+	release := make(chan struct{})
+	addAgent(t, h, "held", heldBehavior(release))
+	addTemplates(t, h, `
+templates:
+  - name: held
+    steps:
+      - agent: held
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "held")
+	jobID := waitForRunningJob(t, h, id)
+	h.Controller.Stop()
+	checkRunStatus(t, h, pbs.Status_STOPPED)
+
+	// the Job lost its stream when the controller stopped, so once it is
+	// restarted, the Job and its JobSet fail
+	start(t, h)
+	waitForJobSet(t, h, id, "ERROR")
+	job, err := h.Controller.GetJob(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status.RunStatus != agent.JobRunStatus_STOPPED || !strings.Contains(job.Status.ErrorMessages, "agent stream lost") {
+		t.Errorf("expected job to fail with its stream lost, got %s with %q", job.Status.RunStatus, job.Status.ErrorMessages)
+	}
+
+	// and new JobSets run as usual
+	close(release)
+	next := waitForJobSet(t, h, startJobSet(t, h, "held"), "OK")
+	if next.Steps[0].AgentJobID <= jobID {
+		t.Errorf("expected a new job ID after %d, got %d", jobID, next.Steps[0].AgentJobID)
+	}
+	if runStatus, health, _, _ := h.Controller.GetStatus(); runStatus != pbs.Status_RUNNING || health != pbs.Health_OK {
+		t.Errorf("expected controller to be RUNNING and OK, got %s and %s", runStatus, health)
+	}
+}
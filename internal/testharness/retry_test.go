@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	"github.com/swinslow/peridot-core/pkg/agentsdk"
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+// failFirst returns a Behavior whose first n Jobs fail with an agent error,
+// and whose later Jobs succeed.
+func failFirst(n int32) Behavior {
+	var count int32
+	return Behavior{Run: func(ctx context.Context, cfg *agent.JobConfig, r agentsdk.Reporter) error {
+		if atomic.AddInt32(&count, 1) <= n {
+			return fmt.Errorf("transient failure")
+		}
+		return nil
+	}}
+}
+
+func TestRetryAgentErrors(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "failtwice", failFirst(2))
+	addTemplates(t, h, `
+templates:
+  - name: retry
+    steps:
+      - agent: failtwice
+        retry:
+          maxAttempts: 3
+          backoff: 10ms
+          on: [agent]
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "retry")
+	js := waitForJobSet(t, h, id, "OK")
+	jobs := jobsForJobSet(h, id)
+	if len(jobs) != 3 {
+		t.Fatalf("expected three attempts, got %d", len(jobs))
+	}
+	for i, job := range jobs {
+		if job.Attempt != uint32(i+1) {
+			t.Errorf("expected job %d to be attempt %d, got %d", job.JobID, i+1, job.Attempt)
+		}
+	}
+	if jobs[2].Status.HealthStatus != agent.JobHealthStatus_OK || js.Steps[0].AgentJobID != jobs[2].JobID {
+		t.Errorf("expected step to finish with its last job, got job %d", js.Steps[0].AgentJobID)
+	}
+}
+
+func TestRetryConnectionErrors(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "flaky", Behavior{Disconnect: true})
+	addTemplates(t, h, `
+templates:
+  - name: retry
+    steps:
+      - agent: flaky
+        retry:
+          maxAttempts: 2
+          backoff: 10ms
+  - name: agentonly
+    steps:
+      - agent: flaky
+        retry:
+          maxAttempts: 2
+          backoff: 10ms
+          on: [agent]
+`)
+	start(t, h)
+
+	// connection errors are retried by default
+	id := startJobSet(t, h, "retry")
+	waitForJobSet(t, h, id, "ERROR")
+	jobs := jobsForJobSet(h, id)
+	if len(jobs) != 2 {
+		t.Fatalf("expected two attempts, got %d", len(jobs))
+	}
+	for _, job := range jobs {
+		if !job.ConnectionError {
+			t.Errorf("expected job %d to fail with a connection error", job.JobID)
+		}
+	}
+
+	// but not if only agent errors are to be retried
+	id = startJobSet(t, h, "agentonly")
+	waitForJobSet(t, h, id, "ERROR")
+	if n := len(jobsForJobSet(h, id)); n != 1 {
+		t.Errorf("expected a single attempt, got %d", n)
+	}
+}
+
+func TestRetryTimeouts(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "slow", Behavior{Delay: 10 * time.Second})
+	addTemplates(t, h, `
+templates:
+  - name: retry
+    steps:
+      - agent: slow
+        timeout: 50ms
+        retry:
+          maxAttempts: 2
+          backoff: 10ms
+          on: [timeout]
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "retry")
+	waitForJobSet(t, h, id, "ERROR")
+	jobs := jobsForJobSet(h, id)
+	if len(jobs) != 2 {
+		t.Fatalf("expected two attempts, got %d", len(jobs))
+	}
+	for _, job := range jobs {
+		if !job.TimedOut || job.ConnectionError {
+			t.Errorf("expected job %d to time out, got timed out %v, connection error %v", job.JobID, job.TimedOut, job.ConnectionError)
+		}
+	}
+}
+
+func TestRetryBackoffDoublesUntilMaxAttempts(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "bad", Behavior{Health: agent.JobHealthStatus_ERROR})
+	addTemplates(t, h, `
+templates:
+  - name: retry
+    steps:
+      - agent: bad
+        retry:
+          maxAttempts: 4
+          backoff: 20ms
+          on: [agent]
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "retry")
+	js := waitForJobSet(t, h, id, "ERROR")
+
+	// the step gives up after its last attempt
+	if n := len(jobsForJobSet(h, id)); n != 4 {
+		t.Errorf("expected four attempts, got %d", n)
+	}
+	for i, backoff := range []string{"20ms", "40ms", "80ms"} {
+		msg := fmt.Sprintf("(attempt %d of 4); retrying in %s", i+1, backoff)
+		if !strings.Contains(js.OutputMessages, msg) {
+			t.Errorf("expected %q in output, got %q", msg, js.OutputMessages)
+		}
+	}
+	if n := strings.Count(js.OutputMessages, "retrying in"); n != 3 {
+		t.Errorf("expected three retries, got %d", n)
+	}
+}
+
+func TestRetryPolicyRejected(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "a", Behavior{})
+
+	// templates added through the API are checked as a config file's are
+	tests := []struct {
+		name  string
+		retry *pbc.RetryPolicy
+		err   string
+	}{
+		{"no attempts", &pbc.RetryPolicy{RetryAgentErrors: true}, "step 1: retry must set maxAttempts"},
+		{"negative backoff", &pbc.RetryPolicy{MaxAttempts: 2, BackoffMillis: -1000}, "step 1: retry backoff must not be negative"},
+	}
+	for _, tc := range tests {
+		step := &pbc.StepTemplate{S: &pbc.StepTemplate_Agent{Agent: &pbc.StepAgentTemplate{Name: "a", Retry: tc.retry}}}
+		req := &pbc.AddJobSetTemplateReq{Jst: &pbc.JobSetTemplate{Name: "bad", Steps: []*pbc.StepTemplate{step}}}
+		resp, err := h.Client.AddJobSetTemplate(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Success || !strings.Contains(resp.ErrorMsg, tc.err) {
+			t.Errorf("%s: expected error containing %q, got %q", tc.name, tc.err, resp.ErrorMsg)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"testing"
+	"time"
+
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+// waitForSchedules polls the Controller's schedules, by name, until ok
+// returns true for them, and returns them then.
+func waitForSchedules(t *testing.T, h *Harness, ok func(sds map[string]*pbc.ScheduleDetails) bool) map[string]*pbc.ScheduleDetails {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		sds := map[string]*pbc.ScheduleDetails{}
+		for _, sd := range h.Controller.GetAllSchedules() {
+			sds[sd.Cfg.Name] = sd
+		}
+		if ok(sds) {
+			return sds
+		}
+		time.Sleep(pollInterval)
+	}
+	t.Fatalf("schedules didn't reach the expected state after %v", waitTimeout)
+	return nil
+}
+
+func TestScheduleOverlapPolicies(t *testing.T) {
+	h := newHarness(t, Options{})
+	release := make(chan struct{})
+	addAgent(t, h, "held", heldBehavior(release))
+	addTemplates(t, h, `
+templates:
+  - name: held
+    steps:
+      - agent: held
+`)
+	start(t, h)
+
+	policies := map[string]pbc.OverlapPolicy{
+		"skip":  pbc.OverlapPolicy_OVERLAP_SKIP,
+		"queue": pbc.OverlapPolicy_OVERLAP_QUEUE,
+		"allow": pbc.OverlapPolicy_OVERLAP_ALLOW,
+	}
+	for name, p := range policies {
+		err := h.Controller.AddSchedule(&pbc.ScheduleConfig{Name: name, Cron: "@every 1s", JstName: "held", Overlap: p})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// each schedule is due again while its first run is held
+	sds := waitForSchedules(t, h, func(sds map[string]*pbc.ScheduleDetails) bool {
+		return len(sds["allow"].ActiveJobSetIDs) >= 2 && sds["queue"].Queued
+	})
+	if n := len(sds["skip"].ActiveJobSetIDs); n != 1 || sds["skip"].Queued {
+		t.Errorf("expected skip schedule to drop its later runs, got %d JobSets and queued %v", n, sds["skip"].Queued)
+	}
+	if n := len(sds["queue"].ActiveJobSetIDs); n != 1 {
+		t.Errorf("expected queue schedule to hold back its later runs, got %d JobSets", n)
+	}
+	js, err := h.Controller.GetJobSet(sds["skip"].LastJobSetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if js.ScheduleName != "skip" {
+		t.Errorf("expected JobSet to record its schedule, got %q", js.ScheduleName)
+	}
+
+	// once the held runs finish, the queued run starts
+	first := sds["queue"].ActiveJobSetIDs[0]
+	close(release)
+	waitForJobSet(t, h, first, "OK")
+	sds = waitForSchedules(t, h, func(sds map[string]*pbc.ScheduleDetails) bool {
+		return !sds["queue"].Queued && sds["queue"].LastJobSetID != first
+	})
+	waitForJobSet(t, h, sds["queue"].LastJobSetID, "OK")
+}
+
+func TestSchedulePauseAndDelete(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "quick", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: quick
+    steps:
+      - agent: quick
+`)
+	start(t, h)
+
+	// invalid schedules are rejected
+	for _, cfg := range []*pbc.ScheduleConfig{
+		{Name: "never", Cron: "0 0 30 2 *", JstName: "quick"},
+		{Name: "invalid", Cron: "* * *", JstName: "quick"},
+		{Name: "unknown", Cron: "@daily", JstName: "nope"},
+		{Cron: "@daily", JstName: "quick"},
+	} {
+		if err := h.Controller.AddSchedule(cfg); err == nil {
+			t.Errorf("expected schedule %q with spec %q to be rejected", cfg.Name, cfg.Cron)
+		}
+	}
+
+	if err := h.Controller.AddSchedule(&pbc.ScheduleConfig{Name: "every", Cron: "@every 1s", JstName: "quick"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Controller.AddSchedule(&pbc.ScheduleConfig{Name: "every", Cron: "@daily", JstName: "quick"}); err == nil {
+		t.Error("expected schedule with a duplicate name to be rejected")
+	}
+	sds := waitForSchedules(t, h, func(sds map[string]*pbc.ScheduleDetails) bool {
+		return sds["every"].LastJobSetID != 0
+	})
+	waitForJobSet(t, h, sds["every"].LastJobSetID, "OK")
+
+	// a paused schedule starts nothing, until it is resumed
+	if err := h.Controller.PauseSchedule("every", true); err != nil {
+		t.Fatal(err)
+	}
+	sds = waitForSchedules(t, h, func(sds map[string]*pbc.ScheduleDetails) bool { return sds["every"].Paused })
+	paused := sds["every"].LastJobSetID
+	time.Sleep(1500 * time.Millisecond)
+	if sd := h.Controller.GetAllSchedules()[0]; sd.LastJobSetID != paused || sd.NextRun != 0 {
+		t.Errorf("expected paused schedule not to run, got JobSet %d and next run %d", sd.LastJobSetID, sd.NextRun)
+	}
+	if err := h.Controller.PauseSchedule("every", false); err != nil {
+		t.Fatal(err)
+	}
+	sds = waitForSchedules(t, h, func(sds map[string]*pbc.ScheduleDetails) bool {
+		return sds["every"].LastJobSetID != paused
+	})
+
+	// deleting the schedule leaves its JobSet to finish
+	last := sds["every"].LastJobSetID
+	if err := h.Controller.DeleteSchedule("every"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Controller.DeleteSchedule("every"); err == nil {
+		t.Error("expected deleting an unknown schedule to fail")
+	}
+	waitForJobSet(t, h, last, "OK")
+	if n := len(h.Controller.GetAllSchedules()); n != 0 {
+		t.Errorf("expected no schedules, got %d", n)
+	}
+}
@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controller"
+	"github.com/swinslow/peridot-core/pkg/agent"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// checkStepsStopped checks that each of the given steps, and each of their
+// concurrent sub-steps, has stopped with the expected health.
+func checkStepsStopped(t *testing.T, steps []*controller.Step, health pbs.Health) {
+	t.Helper()
+	for _, step := range steps {
+		if step.RunStatus != pbs.Status_STOPPED || step.HealthStatus != health {
+			t.Errorf("expected step %d to stop with %s, got %s %s", step.StepID, health, step.RunStatus, step.HealthStatus)
+		}
+		checkStepsStopped(t, step.ConcurrentSteps, health)
+	}
+}
+
+func TestSequentialTemplate(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "getter", Behavior{Delay: 50 * time.Millisecond, CodeFiles: map[string]string{"main.c": "int main() {}"}})
+	addAgent(t, h, "scanner", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: scan
+    steps:
+      - agent: getter
+      - agent: scanner
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "scan")
+	js := waitForJobSet(t, h, id, "OK")
+	checkStepsStopped(t, js.Steps, pbs.Health_OK)
+
+	jobs := jobsForJobSet(h, id)
+	if len(jobs) != 2 || jobs[0].AgentName != "getter" || jobs[1].AgentName != "scanner" {
+		t.Fatalf("expected jobs on getter then scanner, got %v", jobs)
+	}
+	for _, job := range jobs {
+		if job.Status.RunStatus != agent.JobRunStatus_STOPPED || job.Status.HealthStatus != agent.JobHealthStatus_OK {
+			t.Errorf("expected job %d to stop with OK, got %s %s", job.JobID, job.Status.RunStatus, job.Status.HealthStatus)
+		}
+	}
+
+	// the scanner is given the code that the getter wrote
+	cfgs := h.Agent("scanner").Jobs()
+	if len(cfgs) != 1 || len(cfgs[0].CodeInputs) != 1 {
+		t.Fatalf("expected scanner to get one code input, got %v", cfgs)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(cfgs[0].CodeInputs[0].Paths[0], "main.c"))
+	if err != nil || string(b) != "int main() {}" {
+		t.Errorf("expected scanner's code input to hold getter's file, got %q %v", b, err)
+	}
+}
+
+func TestConcurrentTemplate(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "getter", Behavior{})
+	addAgent(t, h, "scan1", Behavior{})
+	addAgent(t, h, "scan2", Behavior{})
+	addAgent(t, h, "scan3", Behavior{})
+	addAgent(t, h, "report", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: sub
+    steps:
+      - agent: scan1
+  - name: scan
+    steps:
+      - agent: getter
+      - concurrent:
+          - agent: scan1
+          - agent: scan2
+          - agent: scan3
+          - agent: scan1
+          - agent: scan2
+          - agent: scan3
+          - jobset: sub
+      - agent: report
+`)
+	start(t, h)
+
+	// agents that answer at once, while the Controller is still starting
+	// the other concurrent jobs, used to deadlock it with the JobController
+	ids := []uint64{}
+	for i := 0; i < 5; i++ {
+		ids = append(ids, startJobSet(t, h, "scan"))
+	}
+	for _, id := range ids {
+		js := waitForJobSet(t, h, id, "OK")
+
+		// each concurrent sub-step must be stopped for the next step to run
+		checkStepsStopped(t, js.Steps, pbs.Health_OK)
+		if sub := js.Steps[1].ConcurrentSteps[6].SubJobSetID; sub != 0 {
+			waitForJobSet(t, h, sub, "OK")
+		} else {
+			t.Errorf("expected jobSet %d to start a sub-jobSet", id)
+		}
+	}
+	if n := len(h.Agent("report").Jobs()); n != len(ids) {
+		t.Errorf("expected %d report jobs, got %d", len(ids), n)
+	}
+}
+
+func TestDegradedAgent(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "getter", Behavior{})
+	addAgent(t, h, "scanner", Behavior{Health: agent.JobHealthStatus_DEGRADED})
+	addAgent(t, h, "report", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: scan
+    steps:
+      - agent: getter
+      - agent: scanner
+      - agent: report
+`)
+	start(t, h)
+
+	// a degraded step doesn't stop the steps after it
+	id := startJobSet(t, h, "scan")
+	js := waitForJobSet(t, h, id, "DEGRADED")
+	if js.Steps[1].HealthStatus != pbs.Health_DEGRADED || js.Steps[2].HealthStatus != pbs.Health_OK {
+		t.Errorf("expected only the scanner step to be DEGRADED, got %s and %s", js.Steps[1].HealthStatus, js.Steps[2].HealthStatus)
+	}
+	jobs := jobsForJobSet(h, id)
+	if len(jobs) != 3 || jobs[1].Status.HealthStatus != agent.JobHealthStatus_DEGRADED {
+		t.Errorf("expected three jobs with the second DEGRADED, got %v", jobs)
+	}
+}
+
+func TestErrorAgent(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "getter", Behavior{})
+	addAgent(t, h, "bad", Behavior{Health: agent.JobHealthStatus_ERROR})
+	addAgent(t, h, "report", Behavior{})
+	addTemplates(t, h, `
+templates:
+  - name: scan
+    steps:
+      - agent: getter
+      - agent: bad
+      - agent: report
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "scan")
+	js := waitForJobSet(t, h, id, "ERROR")
+	if js.Steps[1].RunStatus != pbs.Status_STOPPED || js.Steps[1].HealthStatus != pbs.Health_ERROR {
+		t.Errorf("expected failed step to stop with ERROR, got %s %s", js.Steps[1].RunStatus, js.Steps[1].HealthStatus)
+	}
+	if n := len(h.Agent("report").Jobs()); n != 0 {
+		t.Errorf("expected no steps to run after the failed one, got %d report jobs", n)
+	}
+
+	jobs := jobsForJobSet(h, id)
+	if len(jobs) != 2 {
+		t.Fatalf("expected two jobs, got %d", len(jobs))
+	}
+	job, err := h.WaitForJob(jobs[1].JobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status.HealthStatus != agent.JobHealthStatus_ERROR || job.ConnectionError {
+		t.Errorf("expected agent error, got %s with connection error %v", job.Status.HealthStatus, job.ConnectionError)
+	}
+}
+
+func TestDisconnectingAgent(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "flaky", Behavior{Delay: 20 * time.Millisecond, Disconnect: true})
+	addTemplates(t, h, `
+templates:
+  - name: drop
+    steps:
+      - agent: flaky
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "drop")
+	js := waitForJobSet(t, h, id, "ERROR")
+	job, err := h.WaitForJob(js.Steps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status.HealthStatus != agent.JobHealthStatus_ERROR || !job.ConnectionError {
+		t.Errorf("expected connection error, got %s with connection error %v", job.Status.HealthStatus, job.ConnectionError)
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package testharness
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pbc "github.com/swinslow/peridot-core/pkg/controller"
+)
+
+func TestStepTimeout(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "slow", Behavior{Delay: 10 * time.Second})
+	addTemplates(t, h, `
+templates:
+  - name: slow
+    steps:
+      - agent: slow
+        timeout: 100ms
+`)
+	start(t, h)
+
+	id := startJobSet(t, h, "slow")
+	js := waitForJobSet(t, h, id, "ERROR")
+	job, err := h.WaitForJob(js.Steps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.TimedOut || job.ConnectionError || job.Timeout != 100*time.Millisecond {
+		t.Errorf("expected job to time out after 100ms, got timed out %v, connection error %v, timeout %v", job.TimedOut, job.ConnectionError, job.Timeout)
+	}
+
+	// and the timeout is reported through the API
+	resp, err := h.Client.GetJob(context.Background(), &pbc.GetJobReq{JobID: job.JobID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Job.TimedOut || resp.Job.TimeoutMillis != 100 {
+		t.Errorf("expected API to report a 100ms timeout, got %v and %d", resp.Job.TimedOut, resp.Job.TimeoutMillis)
+	}
+}
+
+func TestAgentTimeout(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "slow", Behavior{Delay: 300 * time.Millisecond})
+	updateAgent(t, h, &pbc.AgentConfig{Name: "slow", JobTimeoutMillis: 100})
+	addTemplates(t, h, `
+templates:
+  - name: agent
+    steps:
+      - agent: slow
+  - name: step
+    steps:
+      - agent: slow
+        timeout: 2s
+`)
+	start(t, h)
+
+	// the agent's timeout applies to steps that don't set their own
+	id := startJobSet(t, h, "agent")
+	js := waitForJobSet(t, h, id, "ERROR")
+	job, err := h.WaitForJob(js.Steps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.TimedOut || job.Timeout != 100*time.Millisecond {
+		t.Errorf("expected job to time out after the agent's 100ms, got %v after %v", job.TimedOut, job.Timeout)
+	}
+
+	// but a step's own timeout takes precedence
+	id = startJobSet(t, h, "step")
+	js = waitForJobSet(t, h, id, "OK")
+	job, err = h.WaitForJob(js.Steps[0].AgentJobID, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.TimedOut || job.Timeout != 2*time.Second {
+		t.Errorf("expected job to finish within the step's 2s, got %v after %v", job.TimedOut, job.Timeout)
+	}
+}
+
+func TestTimeoutRejected(t *testing.T) {
+	h := newHarness(t, Options{})
+	addAgent(t, h, "a", Behavior{})
+
+	// a template added through the API can't have a negative timeout, any
+	// more than a config file can
+	step := &pbc.StepTemplate{S: &pbc.StepTemplate_Agent{Agent: &pbc.StepAgentTemplate{Name: "a", TimeoutMillis: -100}}}
+	req := &pbc.AddJobSetTemplateReq{Jst: &pbc.JobSetTemplate{Name: "bad", Steps: []*pbc.StepTemplate{step}}}
+	resp, err := h.Client.AddJobSetTemplate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success || !strings.Contains(resp.ErrorMsg, "step 1: timeout must not be negative") {
+		t.Errorf("expected negative step timeout to be rejected, got %q", resp.ErrorMsg)
+	}
+
+	// nor can an agent
+	err = h.Controller.UpdateAgent(&pbc.AgentConfig{Name: "a", Url: "a", Port: fakeAgentPort, Type: "fake", JobTimeoutMillis: -100})
+	if err == nil || !strings.Contains(err.Error(), "job timeout must not be negative") {
+		t.Errorf("expected negative agent timeout to be rejected, got %v", err)
+	}
+}
@@ -1,24 +1,170 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/swinslow/peridot-core/internal/controller"
 	"github.com/swinslow/peridot-core/internal/controllerrpc"
+	"github.com/swinslow/peridot-core/internal/logging"
+	pbs "github.com/swinslow/peridot-core/pkg/status"
+)
+
+// default values for settings that aren't otherwise configured
+const (
+	defaultVolPrefix         = "/tmp/peridot/"
+	defaultMaxJobsRunning    = 10
+	defaultHeartbeatInterval = 0
+	defaultHeartbeatTimeout  = time.Minute
+	defaultLogLevel          = "info"
 )
 
+// defineFlags defines the command-line flags in the given FlagSet. Each
+// setting can be given as a command-line flag or as an environment
+// variable. Flags take precedence over environment variables, which take
+// precedence over values from the configuration file.
+func defineFlags(fs *flag.FlagSet) {
+	fs.String("config", "", "path to YAML configuration file (env PERIDOT_CONFIG)")
+	fs.String("listen", controllerrpc.DefaultAddress, "address for the gRPC server to listen on (env PERIDOT_LISTEN)")
+	fs.String("vol-prefix", defaultVolPrefix, "volume prefix for code and SPDX files (env PERIDOT_VOL_PREFIX)")
+	fs.String("store", "", "path to file for persisting controller state; defaults to a file under vol-prefix (env PERIDOT_STORE)")
+	fs.Int("max-jobs-running", defaultMaxJobsRunning, "maximum number of Jobs that can run at once (env PERIDOT_MAX_JOBS_RUNNING)")
+	fs.Duration("heartbeat-interval", defaultHeartbeatInterval, "how often to ask each running Job's agent for its status; 0, the default, turns heartbeats off (env PERIDOT_HEARTBEAT_INTERVAL)")
+	fs.Duration("heartbeat-timeout", defaultHeartbeatTimeout, "how long a running Job's agent may stay silent before the Job fails (env PERIDOT_HEARTBEAT_TIMEOUT)")
+	fs.String("log-level", defaultLogLevel, "log level: debug, info or error (env PERIDOT_LOG_LEVEL)")
+	fs.Bool("auto-start", false, "start the controller immediately, without waiting for a Start request (env PERIDOT_AUTO_START)")
+}
+
+// envNames maps each flag's name to its corresponding environment variable.
+var envNames = map[string]string{
+	"config":             "PERIDOT_CONFIG",
+	"listen":             "PERIDOT_LISTEN",
+	"vol-prefix":         "PERIDOT_VOL_PREFIX",
+	"store":              "PERIDOT_STORE",
+	"max-jobs-running":   "PERIDOT_MAX_JOBS_RUNNING",
+	"heartbeat-interval": "PERIDOT_HEARTBEAT_INTERVAL",
+	"heartbeat-timeout":  "PERIDOT_HEARTBEAT_TIMEOUT",
+	"log-level":          "PERIDOT_LOG_LEVEL",
+	"auto-start":         "PERIDOT_AUTO_START",
+}
+
+// getSetting returns the value for the named setting from the given
+// parsed FlagSet, and whether it was set explicitly via either a flag or
+// an environment variable.
+func getSetting(fs *flag.FlagSet, name string) (string, bool) {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	if set {
+		return fs.Lookup(name).Value.String(), true
+	}
+	if v, ok := os.LookupEnv(envNames[name]); ok {
+		return v, true
+	}
+	return fs.Lookup(name).Value.String(), false
+}
+
+// buildConfig creates the Controller's configuration from the config file
+// (if any), then applies any settings given by the flags in the given
+// parsed FlagSet or by environment variables, and finally fills in
+// defaults for anything still unset.
+func buildConfig(fs *flag.FlagSet) (*controller.Config, error) {
+	cfg := &controller.Config{}
+	if v, ok := getSetting(fs, "config"); ok && v != "" {
+		var err error
+		cfg, err = controller.LoadConfigFile(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := getSetting(fs, "vol-prefix"); ok || cfg.VolPrefix == "" {
+		cfg.VolPrefix = v
+	}
+	if v, ok := getSetting(fs, "store"); ok {
+		cfg.StorePath = v
+	}
+	if v, ok := getSetting(fs, "max-jobs-running"); ok || cfg.MaxJobsRunning == 0 {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid max-jobs-running value %q; must be a positive integer", v)
+		}
+		cfg.MaxJobsRunning = n
+	}
+	if v, ok := getSetting(fs, "heartbeat-interval"); ok || cfg.HeartbeatInterval == 0 {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid heartbeat-interval value %q; must be a duration such as 15s, or 0", v)
+		}
+		cfg.HeartbeatInterval = d
+	}
+	if v, ok := getSetting(fs, "heartbeat-timeout"); ok || cfg.HeartbeatTimeout == 0 {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid heartbeat-timeout value %q; must be a positive duration such as 1m", v)
+		}
+		cfg.HeartbeatTimeout = d
+	}
+	if cfg.HeartbeatInterval > 0 && cfg.HeartbeatTimeout <= cfg.HeartbeatInterval {
+		return nil, fmt.Errorf("heartbeat-timeout (%v) must be longer than heartbeat-interval (%v)", cfg.HeartbeatTimeout, cfg.HeartbeatInterval)
+	}
+
+	return cfg, nil
+}
+
 func main() {
+	fs := flag.CommandLine
+	defineFlags(fs)
+	flag.Parse()
+
+	// set up logging first, so that everything after this respects it
+	levelName, _ := getSetting(fs, "log-level")
+	level, err := logging.ParseLevel(levelName)
+	if err != nil {
+		log.Fatalf("couldn't configure logging: %v", err)
+	}
+	logging.SetLevel(level)
+
 	// set up Controller configuration
-	cfg := &controller.Config{
-		VolPrefix:      "/tmp/peridot/",
-		MaxJobsRunning: 10,
+	cfg, err := buildConfig(fs)
+	if err != nil {
+		log.Fatalf("couldn't load configuration: %v", err)
 	}
 
 	// create and initialize Controller
 	controller := &controller.Controller{}
-	controller.Init(cfg)
+	err = controller.Init(cfg)
+	if err != nil {
+		log.Fatalf("couldn't initialize controller: %v", err)
+	}
+
+	// start right away if requested
+	autoStartStr, _ := getSetting(fs, "auto-start")
+	shouldStart, err := strconv.ParseBool(autoStartStr)
+	if err != nil {
+		log.Fatalf("invalid auto-start value %q: %v", autoStartStr, err)
+	}
+	if shouldStart {
+		if runStatus, _, _, _ := controller.GetStatus(); runStatus != pbs.Status_RUNNING {
+			err = controller.Start()
+			if err != nil {
+				log.Fatalf("couldn't start controller: %v", err)
+			}
+		}
+	}
 
 	// create the gRPC server object
 	cs := &controllerrpc.CServer{C: controller}
 
 	// run the gRPC server until it's done
-	controllerrpc.RunGRPCServer(cs)
+	address, _ := getSetting(fs, "listen")
+	logging.Infof("peridot controller listening on %s", address)
+	controllerrpc.RunGRPCServer(cs, address)
 }
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/swinslow/peridot-core/internal/controllerrpc"
+)
+
+// parseFlags defines the flags in a new FlagSet, and parses the given
+// arguments with it.
+func parseFlags(t *testing.T, args ...string) *flag.FlagSet {
+	t.Helper()
+	fs := flag.NewFlagSet("peridot-core", flag.ContinueOnError)
+	defineFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+// clearEnv unsets the environment variable for each setting until the
+// test finishes.
+func clearEnv(t *testing.T) {
+	for _, name := range envNames {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+// setConfigFile writes a config file with the given contents, and points
+// the config setting's environment variable at it.
+func setConfigFile(t *testing.T, contents string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PERIDOT_CONFIG", path)
+}
+
+func TestSettingPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		file    string
+		env     string
+		flag    string
+		want    string
+	}{
+		{"vol-prefix default", "vol-prefix", "", "", "", defaultVolPrefix},
+		{"vol-prefix from file", "vol-prefix", "volPrefix: /file/\n", "", "", "/file/"},
+		{"vol-prefix env over file", "vol-prefix", "volPrefix: /file/\n", "/env/", "", "/env/"},
+		{"vol-prefix flag over env", "vol-prefix", "volPrefix: /file/\n", "/env/", "/flag/", "/flag/"},
+		{"max-jobs-running default", "max-jobs-running", "", "", "", strconv.Itoa(defaultMaxJobsRunning)},
+		{"max-jobs-running from file", "max-jobs-running", "maxJobsRunning: 3\n", "", "", "3"},
+		{"max-jobs-running env over file", "max-jobs-running", "maxJobsRunning: 3\n", "5", "", "5"},
+		{"max-jobs-running flag over env", "max-jobs-running", "maxJobsRunning: 3\n", "5", "7", "7"},
+		// the listen address can't be set in the config file
+		{"listen default", "listen", "", "", "", controllerrpc.DefaultAddress},
+		{"listen from env", "listen", "", ":9000", "", ":9000"},
+		{"listen flag over env", "listen", "", ":9000", ":9100", ":9100"},
+	}
+	for _, tc := range tests {
+		clearEnv(t)
+		if tc.file != "" {
+			setConfigFile(t, tc.file)
+		}
+		if tc.env != "" {
+			t.Setenv(envNames[tc.setting], tc.env)
+		}
+		args := []string{}
+		if tc.flag != "" {
+			args = append(args, "-"+tc.setting, tc.flag)
+		}
+		fs := parseFlags(t, args...)
+
+		cfg, err := buildConfig(fs)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+			continue
+		}
+		var got string
+		switch tc.setting {
+		case "vol-prefix":
+			got = cfg.VolPrefix
+		case "max-jobs-running":
+			got = strconv.Itoa(cfg.MaxJobsRunning)
+		case "listen":
+			got, _ = getSetting(fs, "listen")
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %s to be %q, got %q", tc.name, tc.setting, tc.want, got)
+		}
+	}
+}
+
+func TestBuildConfigHeartbeats(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		env      string
+		want     time.Duration
+		wantFail bool
+	}{
+		{name: "off by default", want: 0},
+		{name: "set in file", file: "heartbeatInterval: 15s\n", want: 15 * time.Second},
+		{name: "turned off in file", file: "heartbeatInterval: 0\n", want: 0},
+		{name: "turned off by env", file: "heartbeatInterval: 15s\n", env: "0", want: 0},
+		{name: "set by env", env: "20s", want: 20 * time.Second},
+		{name: "negative in file", file: "heartbeatInterval: -5s\n", wantFail: true},
+		{name: "not below timeout", file: "heartbeatInterval: 2m\n", wantFail: true},
+	}
+	for i, tc := range tests {
+		clearEnv(t)
+		if tc.file != "" {
+			setConfigFile(t, tc.file)
+		}
+		if tc.env != "" {
+			t.Setenv("PERIDOT_HEARTBEAT_INTERVAL", tc.env)
+		}
+
+		cfg, err := buildConfig(parseFlags(t))
+		if tc.wantFail {
+			if err == nil {
+				t.Errorf("%d (%s): expected error, got nil", i, tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d (%s): expected no error, got %v", i, tc.name, err)
+			continue
+		}
+		if cfg.HeartbeatInterval != tc.want {
+			t.Errorf("%d (%s): expected heartbeat interval %v, got %v", i, tc.name, tc.want, cfg.HeartbeatInterval)
+		}
+	}
+}
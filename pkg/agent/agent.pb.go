@@ -102,6 +102,7 @@ type ControllerMsg struct {
 	//	*ControllerMsg_Describe
 	//	*ControllerMsg_Start
 	//	*ControllerMsg_Status
+	//	*ControllerMsg_Cancel
 	Cm                   isControllerMsg_Cm `protobuf_oneof:"cm"`
 	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
 	XXX_unrecognized     []byte             `json:"-"`
@@ -149,12 +150,18 @@ type ControllerMsg_Status struct {
 	Status *StatusReq `protobuf:"bytes,3,opt,name=status,proto3,oneof"`
 }
 
+type ControllerMsg_Cancel struct {
+	Cancel *CancelReq `protobuf:"bytes,4,opt,name=cancel,proto3,oneof"`
+}
+
 func (*ControllerMsg_Describe) isControllerMsg_Cm() {}
 
 func (*ControllerMsg_Start) isControllerMsg_Cm() {}
 
 func (*ControllerMsg_Status) isControllerMsg_Cm() {}
 
+func (*ControllerMsg_Cancel) isControllerMsg_Cm() {}
+
 func (m *ControllerMsg) GetCm() isControllerMsg_Cm {
 	if m != nil {
 		return m.Cm
@@ -183,12 +190,20 @@ func (m *ControllerMsg) GetStatus() *StatusReq {
 	return nil
 }
 
+func (m *ControllerMsg) GetCancel() *CancelReq {
+	if x, ok := m.GetCm().(*ControllerMsg_Cancel); ok {
+		return x.Cancel
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*ControllerMsg) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*ControllerMsg_Describe)(nil),
 		(*ControllerMsg_Start)(nil),
 		(*ControllerMsg_Status)(nil),
+		(*ControllerMsg_Cancel)(nil),
 	}
 }
 
@@ -617,6 +632,40 @@ func (m *StatusReq) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_StatusReq proto.InternalMessageInfo
 
+// CancelReq requests that the Agent stop the Job for this connection. An
+// Agent should respond to this by stopping the Job promptly and sending a
+// StatusReport with runStatus STOPPED.
+type CancelReq struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelReq) Reset()         { *m = CancelReq{} }
+func (m *CancelReq) String() string { return proto.CompactTextString(m) }
+func (*CancelReq) ProtoMessage()    {}
+func (*CancelReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a60391ce6a1a9c17, []int{6}
+}
+
+func (m *CancelReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelReq.Unmarshal(m, b)
+}
+func (m *CancelReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelReq.Marshal(b, m, deterministic)
+}
+func (m *CancelReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelReq.Merge(m, src)
+}
+func (m *CancelReq) XXX_Size() int {
+	return xxx_messageInfo_CancelReq.Size(m)
+}
+func (m *CancelReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelReq proto.InternalMessageInfo
+
 // DescribeReport provides information about the Agent instance over its
 // lifetime (and not information specific to this Job).
 type DescribeReport struct {
@@ -640,7 +689,7 @@ func (m *DescribeReport) Reset()         { *m = DescribeReport{} }
 func (m *DescribeReport) String() string { return proto.CompactTextString(m) }
 func (*DescribeReport) ProtoMessage()    {}
 func (*DescribeReport) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a60391ce6a1a9c17, []int{6}
+	return fileDescriptor_a60391ce6a1a9c17, []int{7}
 }
 
 func (m *DescribeReport) XXX_Unmarshal(b []byte) error {
@@ -709,7 +758,7 @@ func (m *DescribeReport_KVMeaning) Reset()         { *m = DescribeReport_KVMeani
 func (m *DescribeReport_KVMeaning) String() string { return proto.CompactTextString(m) }
 func (*DescribeReport_KVMeaning) ProtoMessage()    {}
 func (*DescribeReport_KVMeaning) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a60391ce6a1a9c17, []int{6, 0}
+	return fileDescriptor_a60391ce6a1a9c17, []int{7, 0}
 }
 
 func (m *DescribeReport_KVMeaning) XXX_Unmarshal(b []byte) error {
@@ -771,7 +820,7 @@ func (m *StatusReport) Reset()         { *m = StatusReport{} }
 func (m *StatusReport) String() string { return proto.CompactTextString(m) }
 func (*StatusReport) ProtoMessage()    {}
 func (*StatusReport) Descriptor() ([]byte, []int) {
-	return fileDescriptor_a60391ce6a1a9c17, []int{7}
+	return fileDescriptor_a60391ce6a1a9c17, []int{8}
 }
 
 func (m *StatusReport) XXX_Unmarshal(b []byte) error {
@@ -846,6 +895,7 @@ func init() {
 	proto.RegisterType((*JobConfig_JobKV)(nil), "agent.JobConfig.JobKV")
 	proto.RegisterType((*StartReq)(nil), "agent.StartReq")
 	proto.RegisterType((*StatusReq)(nil), "agent.StatusReq")
+	proto.RegisterType((*CancelReq)(nil), "agent.CancelReq")
 	proto.RegisterType((*DescribeReport)(nil), "agent.DescribeReport")
 	proto.RegisterType((*DescribeReport_KVMeaning)(nil), "agent.DescribeReport.KVMeaning")
 	proto.RegisterType((*StatusReport)(nil), "agent.StatusReport")
@@ -854,55 +904,56 @@ func init() {
 func init() { proto.RegisterFile("pkg/agent/agent.proto", fileDescriptor_a60391ce6a1a9c17) }
 
 var fileDescriptor_a60391ce6a1a9c17 = []byte{
-	// 762 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0xcf, 0x6e, 0xfa, 0x46,
-	0x10, 0x8e, 0x31, 0x10, 0x3c, 0x10, 0x62, 0x6d, 0x93, 0x08, 0x71, 0x69, 0x64, 0x55, 0x2d, 0x42,
-	0x0d, 0xa4, 0x49, 0xab, 0xaa, 0xa9, 0x54, 0x89, 0x00, 0x09, 0x21, 0x05, 0xa2, 0x85, 0xe4, 0xd0,
-	0x4b, 0x65, 0x9b, 0x2d, 0xb8, 0x80, 0xd7, 0xf5, 0xae, 0x43, 0xf3, 0x18, 0xbd, 0xf7, 0xd4, 0x67,
-	0xec, 0x03, 0x54, 0xbb, 0xfe, 0x83, 0x4d, 0xd2, 0xc3, 0xef, 0x82, 0x3c, 0xdf, 0x7c, 0x33, 0xdf,
-	0xcc, 0xec, 0xec, 0x02, 0xa7, 0xde, 0x6a, 0xd1, 0x36, 0x17, 0xc4, 0xe5, 0xe1, 0x6f, 0xcb, 0xf3,
-	0x29, 0xa7, 0xa8, 0x20, 0x0d, 0xe3, 0x6f, 0x05, 0x8e, 0xba, 0xd4, 0xe5, 0x3e, 0x5d, 0xaf, 0x89,
-	0x3f, 0x62, 0x0b, 0x74, 0x09, 0xa5, 0x39, 0x61, 0xb6, 0xef, 0x58, 0xa4, 0xa6, 0x9c, 0x2b, 0x8d,
-	0xf2, 0x15, 0x6a, 0x85, 0x81, 0xbd, 0x08, 0xc6, 0xe4, 0x8f, 0xc1, 0x01, 0x4e, 0x58, 0xe8, 0x2b,
-	0x28, 0x30, 0x6e, 0xfa, 0xbc, 0x96, 0x93, 0xf4, 0xe3, 0x88, 0x3e, 0x15, 0x58, 0xc8, 0x0d, 0xfd,
-	0xa8, 0x09, 0x45, 0xc6, 0x4d, 0x1e, 0xb0, 0x9a, 0x2a, 0x99, 0xfa, 0x8e, 0xc9, 0x03, 0x16, 0x52,
-	0x23, 0xc6, 0x6d, 0x1e, 0x72, 0xf6, 0xc6, 0xe0, 0x50, 0xea, 0x08, 0x8a, 0x28, 0xec, 0xfa, 0x5d,
-	0x61, 0xa7, 0xef, 0x0a, 0xf3, 0xa8, 0xcf, 0x33, 0xb5, 0x5d, 0x24, 0x92, 0x61, 0x71, 0x9f, 0xed,
-	0x49, 0x46, 0x01, 0x29, 0x55, 0x73, 0x63, 0x1c, 0x41, 0x39, 0xd5, 0xab, 0xf1, 0x8f, 0x0a, 0xda,
-	0x90, 0x5a, 0x5d, 0xea, 0xfe, 0xe6, 0x2c, 0xd0, 0x0d, 0x80, 0x4d, 0xe7, 0xe4, 0xc1, 0xf5, 0x02,
-	0xce, 0x6a, 0xca, 0xb9, 0xda, 0x28, 0x5f, 0xd5, 0xa3, 0xac, 0x09, 0xab, 0xd5, 0x8d, 0x29, 0x38,
-	0xc5, 0x46, 0x5f, 0xc0, 0x91, 0xb0, 0x26, 0x01, 0xf7, 0x02, 0xde, 0x73, 0x7c, 0x59, 0x94, 0x86,
-	0xb3, 0xa0, 0x50, 0x60, 0xde, 0xfc, 0xcf, 0x48, 0x41, 0xfd, 0x1f, 0x85, 0x69, 0x4c, 0xc1, 0x29,
-	0xb6, 0x50, 0x10, 0xd6, 0x4e, 0x21, 0x1f, 0x2a, 0x64, 0x40, 0xd4, 0x84, 0xfc, 0xef, 0xab, 0x57,
-	0x56, 0x2b, 0xc8, 0xdc, 0x67, 0xef, 0x72, 0x0f, 0xa9, 0xf5, 0xf8, 0x82, 0x25, 0xa7, 0xfe, 0x03,
-	0x68, 0x49, 0x33, 0xe8, 0x0c, 0x8a, 0x8c, 0x06, 0xbe, 0x1d, 0x9e, 0x80, 0x86, 0x23, 0x0b, 0x9d,
-	0x40, 0xc1, 0x33, 0xf9, 0x52, 0x4c, 0x59, 0x6d, 0x68, 0x38, 0x34, 0x44, 0x68, 0x52, 0xe5, 0x27,
-	0x86, 0xb6, 0xa1, 0x20, 0x8b, 0x40, 0x3a, 0xa8, 0x2b, 0xf2, 0x16, 0xc5, 0x88, 0x4f, 0x11, 0xf0,
-	0x6a, 0xae, 0x03, 0x12, 0x0d, 0x2f, 0x34, 0x8c, 0x6f, 0xa1, 0x14, 0x2f, 0x1c, 0x6a, 0x40, 0xd1,
-	0x96, 0x8d, 0x44, 0x7b, 0xa2, 0xef, 0x37, 0x88, 0x23, 0xbf, 0x51, 0x06, 0x2d, 0x59, 0x3e, 0xe3,
-	0x5f, 0x05, 0xaa, 0xd9, 0x55, 0x42, 0x08, 0xf2, 0xae, 0xb9, 0x89, 0x4b, 0x96, 0xdf, 0x02, 0xe3,
-	0x6f, 0x5e, 0x2c, 0x2f, 0xbf, 0xd1, 0x39, 0x94, 0xa5, 0x44, 0x98, 0x5e, 0xae, 0xb7, 0x86, 0xd3,
-	0x10, 0x32, 0xa0, 0x62, 0x9b, 0x9e, 0x69, 0x39, 0x6b, 0x87, 0x3b, 0x84, 0xd5, 0xf2, 0xb2, 0xdb,
-	0x0c, 0x86, 0x7e, 0x84, 0xd2, 0xca, 0xa5, 0x5b, 0x77, 0x77, 0x34, 0x9f, 0x7f, 0xb8, 0xe1, 0xad,
-	0xc7, 0x97, 0x11, 0x31, 0x5d, 0xc7, 0x5d, 0xe0, 0x24, 0xa0, 0xfe, 0x3d, 0x68, 0x09, 0xfc, 0xc1,
-	0xd4, 0x6a, 0x70, 0xb8, 0x09, 0x9d, 0x51, 0xe1, 0xb1, 0x69, 0xfc, 0x95, 0x83, 0x4a, 0xfa, 0x3a,
-	0xa0, 0x6f, 0x40, 0xf3, 0x03, 0x37, 0x84, 0x64, 0x8a, 0x6a, 0x72, 0x6d, 0x86, 0xd4, 0xc2, 0xb1,
-	0x0b, 0xef, 0x58, 0xe8, 0x06, 0x2a, 0x4b, 0x62, 0xae, 0xf9, 0x72, 0xba, 0xbb, 0x6c, 0xd5, 0xf4,
-	0x62, 0x0d, 0x52, 0x5e, 0x9c, 0xe1, 0x8a, 0xd9, 0x71, 0x67, 0x43, 0xe4, 0xe9, 0x91, 0xb9, 0x9c,
-	0x9d, 0x8a, 0xd3, 0x90, 0x98, 0x9d, 0x30, 0xef, 0x1c, 0xd7, 0x61, 0x4b, 0x32, 0x97, 0x3b, 0xad,
-	0xe2, 0x0c, 0x86, 0xbe, 0x84, 0x2a, 0x95, 0xfb, 0x3d, 0x22, 0x8c, 0x99, 0x0b, 0x22, 0x26, 0x28,
-	0xda, 0xdc, 0x43, 0xc5, 0x05, 0x21, 0xbe, 0x4f, 0xfd, 0x84, 0x56, 0x0c, 0x2f, 0x48, 0x06, 0x6c,
-	0xde, 0x41, 0x25, 0xdd, 0x2a, 0x3a, 0x86, 0xf2, 0x74, 0xd6, 0x99, 0x3d, 0x4f, 0x7f, 0x9d, 0x76,
-	0x46, 0x7d, 0xfd, 0x00, 0x95, 0xe1, 0x70, 0x3a, 0xeb, 0xe0, 0xd9, 0xf3, 0x93, 0xae, 0x08, 0x03,
-	0x3f, 0x8f, 0xc7, 0x0f, 0xe3, 0x7b, 0x3d, 0x17, 0x7a, 0x26, 0x4f, 0x4f, 0xfd, 0x9e, 0xae, 0x36,
-	0xbb, 0x70, 0xbc, 0xd7, 0xbc, 0x48, 0x35, 0xe8, 0x77, 0x7e, 0x9e, 0x0d, 0xe2, 0x54, 0x45, 0xc8,
-	0x4d, 0x1e, 0x75, 0x05, 0x55, 0xa0, 0xd4, 0xeb, 0xdf, 0xe3, 0x4e, 0xaf, 0xdf, 0xd3, 0x73, 0x48,
-	0x83, 0x42, 0x1f, 0xe3, 0x09, 0xd6, 0xd5, 0xab, 0x9f, 0xa0, 0x20, 0x1f, 0x41, 0xf4, 0x1d, 0x14,
-	0xc7, 0x64, 0x3b, 0xa4, 0x16, 0x3a, 0x89, 0x26, 0x9b, 0x79, 0xba, 0xeb, 0xf1, 0xcb, 0x1b, 0x3f,
-	0x99, 0xc6, 0x41, 0x43, 0xb9, 0x54, 0x6e, 0xbf, 0xfe, 0xa5, 0xb9, 0x70, 0xf8, 0x32, 0xb0, 0x5a,
-	0x36, 0xdd, 0xb4, 0xd9, 0xd6, 0x71, 0xd9, 0x9a, 0x6e, 0xdb, 0x1e, 0xf1, 0x9d, 0x39, 0xe5, 0x17,
-	0x36, 0xf5, 0x49, 0x3b, 0xf9, 0x93, 0xb0, 0x8a, 0xf2, 0xff, 0xe1, 0xfa, 0xbf, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0x53, 0xd4, 0x67, 0xe2, 0x38, 0x06, 0x00, 0x00,
+	// 783 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0xdd, 0x6e, 0xe2, 0x46,
+	0x14, 0x8e, 0x31, 0xb0, 0xf8, 0x40, 0x88, 0x35, 0xdd, 0x5d, 0x59, 0xdc, 0x34, 0xb2, 0xaa, 0x16,
+	0xa1, 0x2e, 0x6c, 0xb3, 0xad, 0xaa, 0x6e, 0xa5, 0x4a, 0x2c, 0xb0, 0x21, 0xa4, 0x40, 0x34, 0x90,
+	0x5c, 0xf4, 0xa6, 0xb2, 0xcd, 0x14, 0x5c, 0xc0, 0xe3, 0x7a, 0xc6, 0xa1, 0x79, 0x8c, 0xbe, 0x42,
+	0x9f, 0xa6, 0x0f, 0xd4, 0x07, 0xa8, 0x66, 0xc6, 0x36, 0x76, 0x92, 0x5e, 0xec, 0x0d, 0xf2, 0xf9,
+	0xe6, 0x3b, 0x3f, 0xdf, 0x99, 0x73, 0x06, 0x78, 0x15, 0x6e, 0xd7, 0x3d, 0x67, 0x4d, 0x02, 0xae,
+	0x7e, 0xbb, 0x61, 0x44, 0x39, 0x45, 0x15, 0x69, 0xd8, 0xff, 0x68, 0x70, 0x3a, 0xa0, 0x01, 0x8f,
+	0xe8, 0x6e, 0x47, 0xa2, 0x29, 0x5b, 0xa3, 0xb7, 0x50, 0x5b, 0x11, 0xe6, 0x45, 0xbe, 0x4b, 0x2c,
+	0xed, 0x5c, 0x6b, 0xd7, 0x2f, 0x50, 0x57, 0x39, 0x0e, 0x13, 0x18, 0x93, 0x3f, 0xc6, 0x27, 0x38,
+	0x63, 0xa1, 0xaf, 0xa0, 0xc2, 0xb8, 0x13, 0x71, 0xab, 0x24, 0xe9, 0x67, 0x09, 0x7d, 0x21, 0x30,
+	0xc5, 0x55, 0xe7, 0xa8, 0x03, 0x55, 0xc6, 0x1d, 0x1e, 0x33, 0x4b, 0x97, 0x4c, 0xf3, 0xc8, 0xe4,
+	0x31, 0x53, 0xd4, 0x84, 0x21, 0xb8, 0x9e, 0x13, 0x78, 0x64, 0x67, 0x95, 0x0b, 0xdc, 0x81, 0x04,
+	0x13, 0xae, 0x62, 0x7c, 0x28, 0x43, 0xc9, 0xdb, 0xdb, 0x1c, 0x6a, 0x7d, 0x41, 0x11, 0x22, 0xde,
+	0x3d, 0x11, 0xf1, 0xea, 0x89, 0x88, 0x90, 0x46, 0xbc, 0xa0, 0xe3, 0x4d, 0x56, 0x9e, 0x12, 0xf2,
+	0xd9, 0xa3, 0xf2, 0x12, 0x87, 0x84, 0x24, 0xb2, 0x3a, 0x7b, 0xfb, 0x14, 0xea, 0xb9, 0xbe, 0xd8,
+	0x7f, 0xeb, 0x60, 0x4c, 0xa8, 0x3b, 0xa0, 0xc1, 0x6f, 0xfe, 0x1a, 0xbd, 0x07, 0xf0, 0xe8, 0x8a,
+	0x5c, 0x05, 0x61, 0xcc, 0x99, 0xa5, 0x9d, 0xeb, 0xed, 0xfa, 0x45, 0x2b, 0x89, 0x9a, 0xb1, 0xba,
+	0x83, 0x94, 0x82, 0x73, 0x6c, 0xf4, 0x05, 0x9c, 0x0a, 0x6b, 0x1e, 0xf3, 0x30, 0xe6, 0x43, 0x3f,
+	0x92, 0x45, 0x19, 0xb8, 0x08, 0x8a, 0x0c, 0x2c, 0x5c, 0xfd, 0x99, 0x64, 0xd0, 0xff, 0x27, 0xc3,
+	0x22, 0xa5, 0xe0, 0x1c, 0x5b, 0x64, 0x10, 0xd6, 0x31, 0x43, 0x59, 0x65, 0x28, 0x80, 0xa8, 0x03,
+	0xe5, 0xdf, 0xb7, 0xf7, 0xcc, 0xaa, 0xc8, 0xd8, 0xaf, 0x9f, 0xc4, 0x9e, 0x50, 0xf7, 0xfa, 0x0e,
+	0x4b, 0x4e, 0xeb, 0x07, 0x30, 0x32, 0x31, 0xe8, 0x35, 0x54, 0x19, 0x8d, 0x23, 0x4f, 0xdd, 0x80,
+	0x81, 0x13, 0x0b, 0xbd, 0x84, 0x4a, 0xe8, 0xf0, 0x8d, 0xe8, 0xb2, 0xde, 0x36, 0xb0, 0x32, 0x84,
+	0x6b, 0x56, 0xe5, 0x27, 0xba, 0xf6, 0xa0, 0x22, 0x8b, 0x40, 0x26, 0xe8, 0x5b, 0xf2, 0x90, 0xf8,
+	0x88, 0x4f, 0xe1, 0x70, 0xef, 0xec, 0x62, 0x92, 0x34, 0x4f, 0x19, 0xf6, 0xb7, 0x50, 0x4b, 0x87,
+	0x13, 0xb5, 0xa1, 0xea, 0x49, 0x21, 0x96, 0x56, 0x98, 0xb3, 0x4c, 0x20, 0x4e, 0xce, 0xed, 0x3a,
+	0x18, 0xd9, 0xa0, 0x0a, 0x23, 0x9b, 0x44, 0xfb, 0x5f, 0x0d, 0x9a, 0xc5, 0xb9, 0x42, 0x08, 0xca,
+	0x81, 0xb3, 0x4f, 0xeb, 0x97, 0xdf, 0x02, 0xe3, 0x0f, 0x61, 0x5a, 0x8b, 0xfc, 0x46, 0xe7, 0x50,
+	0x97, 0xf9, 0x54, 0x2e, 0xb9, 0x17, 0x06, 0xce, 0x43, 0xc8, 0x86, 0x86, 0xe7, 0x84, 0x8e, 0xeb,
+	0xef, 0x7c, 0xee, 0x13, 0x66, 0x95, 0xa5, 0xf4, 0x02, 0x86, 0x7e, 0x84, 0xda, 0x36, 0xa0, 0x87,
+	0xe0, 0x78, 0x4f, 0x9f, 0x3f, 0x3b, 0xee, 0xdd, 0xeb, 0xbb, 0x29, 0x71, 0x02, 0x3f, 0x58, 0xe3,
+	0xcc, 0xa1, 0xf5, 0x3d, 0x18, 0x19, 0xfc, 0x4c, 0x0b, 0x2d, 0x78, 0xb1, 0x57, 0x87, 0x49, 0xe1,
+	0xa9, 0x69, 0xff, 0x55, 0x82, 0x46, 0x7e, 0x37, 0xd0, 0x37, 0x60, 0x44, 0x71, 0xa0, 0x20, 0x19,
+	0xa2, 0x99, 0xed, 0xd0, 0x84, 0xba, 0x38, 0x3d, 0xc2, 0x47, 0x16, 0x7a, 0x0f, 0x8d, 0x0d, 0x71,
+	0x76, 0x7c, 0xb3, 0x38, 0x6e, 0x5e, 0x33, 0x3f, 0x65, 0xe3, 0xdc, 0x29, 0x2e, 0x70, 0x45, 0xef,
+	0xb8, 0xbf, 0x27, 0xf2, 0x2a, 0xc9, 0x4a, 0xf6, 0x4e, 0xc7, 0x79, 0x48, 0xf4, 0x4e, 0x98, 0x1f,
+	0xfd, 0xc0, 0x67, 0x1b, 0xb2, 0x92, 0x03, 0xae, 0xe3, 0x02, 0x86, 0xbe, 0x84, 0x26, 0x95, 0xc3,
+	0x3e, 0x25, 0x8c, 0x39, 0x6b, 0x22, 0x3a, 0x28, 0x64, 0x3e, 0x42, 0xc5, 0xb6, 0x90, 0x28, 0xa2,
+	0x51, 0x46, 0xab, 0xaa, 0x6d, 0x29, 0x80, 0x9d, 0x8f, 0xd0, 0xc8, 0x4b, 0x45, 0x67, 0x50, 0x5f,
+	0x2c, 0xfb, 0xcb, 0xdb, 0xc5, 0xaf, 0x8b, 0xfe, 0x74, 0x64, 0x9e, 0xa0, 0x3a, 0xbc, 0x58, 0x2c,
+	0xfb, 0x78, 0x79, 0x7b, 0x63, 0x6a, 0xc2, 0xc0, 0xb7, 0xb3, 0xd9, 0xd5, 0xec, 0xd2, 0x2c, 0xa9,
+	0x93, 0xf9, 0xcd, 0xcd, 0x68, 0x68, 0xea, 0x9d, 0x01, 0x9c, 0x3d, 0x12, 0x2f, 0x42, 0x8d, 0x47,
+	0xfd, 0x9f, 0x97, 0xe3, 0x34, 0x54, 0x15, 0x4a, 0xf3, 0x6b, 0x53, 0x43, 0x0d, 0xa8, 0x0d, 0x47,
+	0x97, 0xb8, 0x3f, 0x1c, 0x0d, 0xcd, 0x12, 0x32, 0xa0, 0x32, 0xc2, 0x78, 0x8e, 0x4d, 0xfd, 0xe2,
+	0x27, 0xa8, 0xc8, 0x17, 0x11, 0x7d, 0x07, 0xd5, 0x19, 0x39, 0x4c, 0xa8, 0x8b, 0x5e, 0xa6, 0xcf,
+	0x68, 0xfe, 0xcd, 0x6f, 0xa5, 0x4f, 0x76, 0xfa, 0x7e, 0xda, 0x27, 0x6d, 0xed, 0xad, 0xf6, 0xe1,
+	0xeb, 0x5f, 0x3a, 0x6b, 0x9f, 0x6f, 0x62, 0xb7, 0xeb, 0xd1, 0x7d, 0x8f, 0x1d, 0xfc, 0x80, 0xed,
+	0xe8, 0xa1, 0x17, 0x92, 0xc8, 0x5f, 0x51, 0xfe, 0xc6, 0xa3, 0x11, 0xe9, 0x65, 0xff, 0x2e, 0x6e,
+	0x55, 0xfe, 0xb1, 0xbc, 0xfb, 0x6f, 0x00, 0xcf, 0x9e, 0x8d, 0xf0, 0x71, 0x06, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
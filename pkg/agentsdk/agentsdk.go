@@ -0,0 +1,125 @@
+// Package agentsdk provides a ready-made gRPC server for writing peridot
+// Agents in Go. An Agent author supplies a Description of the Agent and a
+// RunJobFunc which does the actual work for a Job; the SDK handles the
+// Agent.NewJob stream, including answering DescribeReq and StatusReq
+// messages, tracking the Job's status and timestamps, cancelling the Job
+// on a CancelReq or if the controller goes away, and reporting failures
+// as ERROR.
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+package agentsdk
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	"google.golang.org/grpc"
+)
+
+// Description describes an Agent instance over its lifetime, and is
+// returned to the controller in response to a DescribeReq.
+type Description struct {
+	// Name is the name of this agent instance, e.g. its unique name
+	// within the peridot group.
+	Name string
+
+	// Type is the type of this agent, which does not need to be unique
+	// within the peridot group.
+	Type string
+
+	// AgentConfig is this agent's configuration on startup, as a YAML
+	// string. It is not any particular Job's configuration.
+	AgentConfig string
+
+	// Capabilities lists this agent's capabilities, e.g. "codereader"
+	// or "spdxwriter".
+	Capabilities []string
+
+	// KnownKVs lists the Job config keys that this agent recognizes,
+	// and their meanings.
+	KnownKVs []KnownKV
+}
+
+// KnownKV is a Job config key that an Agent recognizes.
+type KnownKV struct {
+	Key     string
+	Meaning string
+}
+
+// Reporter is passed to a RunJobFunc so that it can report on its
+// progress. Each call sends an updated StatusReport to the controller.
+type Reporter interface {
+	// Outputf records an output message, with arguments handled as for
+	// fmt.Printf. Messages should be short; anything lengthy should be
+	// separately logged or reported elsewhere.
+	Outputf(format string, v ...interface{})
+
+	// Errorf records an error message, with arguments handled as for
+	// fmt.Printf, and marks the Job as DEGRADED. It is for problems that
+	// the Job can continue past; for unrecoverable problems, the
+	// RunJobFunc should instead return an error.
+	Errorf(format string, v ...interface{})
+}
+
+// RunJobFunc does the work for a single Job with the given configuration.
+// The Job is treated as finished when it returns. If it returns an error,
+// or if it panics, the Job is reported as STOPPED with ERROR health.
+//
+// ctx is cancelled if the controller goes away or asks for the Job to be
+// cancelled; the RunJobFunc should then stop promptly and return.
+type RunJobFunc func(ctx context.Context, cfg *agent.JobConfig, r Reporter) error
+
+// Server is a gRPC server for an Agent. It implements agent.AgentServer.
+type Server struct {
+	desc Description
+	run  RunJobFunc
+}
+
+// NewServer creates a Server for an Agent with the given Description,
+// which runs each Job by calling run.
+func NewServer(desc Description, run RunJobFunc) *Server {
+	return &Server{desc: desc, run: run}
+}
+
+// Serve runs a gRPC server for the Agent on the given listener, until it
+// fails or is stopped.
+func (s *Server) Serve(lis net.Listener) error {
+	server := grpc.NewServer()
+	agent.RegisterAgentServer(server, s)
+	return server.Serve(lis)
+}
+
+// ListenAndServe listens on the given TCP address and then calls Serve.
+func (s *Server) ListenAndServe(address string) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("couldn't open port %v: %v", address, err)
+	}
+	return s.Serve(lis)
+}
+
+// describeReport returns the DescribeReport for the Agent's Description.
+func (s *Server) describeReport() *agent.DescribeReport {
+	dr := &agent.DescribeReport{
+		Name:         s.desc.Name,
+		Type:         s.desc.Type,
+		AgentConfig:  s.desc.AgentConfig,
+		Capabilities: s.desc.Capabilities,
+	}
+	for _, kv := range s.desc.KnownKVs {
+		dr.Knownkvs = append(dr.Knownkvs, &agent.DescribeReport_KVMeaning{Key: kv.Key, Meaning: kv.Meaning})
+	}
+	return dr
+}
+
+// GetJobKV returns the value for the given key in the Job's configuration,
+// and whether the key was present.
+func GetJobKV(cfg *agent.JobConfig, key string) (string, bool) {
+	for _, kv := range cfg.GetJkvs() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return "", false
+}
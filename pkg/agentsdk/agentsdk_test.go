@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package agentsdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testDesc = Description{
+	Name:         "test-1",
+	Type:         "test",
+	Capabilities: []string{"codereader"},
+	KnownKVs:     []KnownKV{{Key: "repo", Meaning: "repo to scan"}},
+}
+
+// newJobStream serves an Agent which runs each Job by calling run, and
+// opens a NewJob stream to it.
+func newJobStream(t *testing.T, run RunJobFunc) agent.Agent_NewJobClient {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	go NewServer(testDesc, run).Serve(lis)
+	t.Cleanup(func() { lis.Close() })
+
+	dialer := func(ctx context.Context, address string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}
+	conn, err := grpc.Dial("agent", grpc.WithInsecure(), grpc.WithContextDialer(dialer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	stream, err := agent.NewAgentClient(conn).NewJob(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+// send sends a message to the Agent.
+func send(t *testing.T, stream agent.Agent_NewJobClient, cm *agent.ControllerMsg) {
+	t.Helper()
+	if err := stream.Send(cm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var (
+	describeMsg = &agent.ControllerMsg{Cm: &agent.ControllerMsg_Describe{Describe: &agent.DescribeReq{}}}
+	statusMsg   = &agent.ControllerMsg{Cm: &agent.ControllerMsg_Status{Status: &agent.StatusReq{}}}
+	cancelMsg   = &agent.ControllerMsg{Cm: &agent.ControllerMsg_Cancel{Cancel: &agent.CancelReq{}}}
+	startMsg    = &agent.ControllerMsg{Cm: &agent.ControllerMsg_Start{Start: &agent.StartReq{
+		Config: &agent.JobConfig{Jkvs: []*agent.JobConfig_JobKV{{Key: "repo", Value: "a"}}},
+	}}}
+)
+
+// recvStatus receives the next message from the Agent, which must be a
+// StatusReport.
+func recvStatus(t *testing.T, stream agent.Agent_NewJobClient) *agent.StatusReport {
+	t.Helper()
+	am, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := am.GetStatus()
+	if st == nil {
+		t.Fatalf("expected a status report, got %v", am)
+	}
+	return st
+}
+
+// recvFinalStatus receives StatusReports from the Agent until the Job has
+// stopped, and checks that the stream then ends.
+func recvFinalStatus(t *testing.T, stream agent.Agent_NewJobClient) *agent.StatusReport {
+	t.Helper()
+	for {
+		st := recvStatus(t, stream)
+		if st.RunStatus != agent.JobRunStatus_STOPPED {
+			continue
+		}
+		if _, err := stream.Recv(); err != io.EOF {
+			t.Errorf("expected stream to end after the final status, got %v", err)
+		}
+		return st
+	}
+}
+
+func TestDescribeAndStatus(t *testing.T) {
+	release := make(chan struct{})
+	stream := newJobStream(t, func(ctx context.Context, cfg *agent.JobConfig, r Reporter) error {
+		<-release
+		repo, _ := GetJobKV(cfg, "repo")
+		r.Outputf("scanned %s", repo)
+		return nil
+	})
+
+	send(t, stream, describeMsg)
+	am, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr := am.GetDescribe()
+	if dr == nil || dr.Name != "test-1" || dr.Type != "test" || len(dr.Capabilities) != 1 || len(dr.Knownkvs) != 1 || dr.Knownkvs[0].Key != "repo" {
+		t.Fatalf("expected the agent's description, got %v", am)
+	}
+
+	send(t, stream, statusMsg)
+	if st := recvStatus(t, stream); st.RunStatus != agent.JobRunStatus_STARTUP || st.HealthStatus != agent.JobHealthStatus_OK {
+		t.Errorf("expected STARTUP and OK before the job starts, got %s and %s", st.RunStatus, st.HealthStatus)
+	}
+
+	send(t, stream, startMsg)
+	st := recvStatus(t, stream)
+	if st.RunStatus != agent.JobRunStatus_RUNNING || st.TimeStarted == 0 {
+		t.Errorf("expected RUNNING with a start time once started, got %s at %d", st.RunStatus, st.TimeStarted)
+	}
+	send(t, stream, statusMsg)
+	if st := recvStatus(t, stream); st.RunStatus != agent.JobRunStatus_RUNNING {
+		t.Errorf("expected RUNNING while the job runs, got %s", st.RunStatus)
+	}
+
+	// a second StartReq is ignored, without affecting the job's health
+	send(t, stream, startMsg)
+	st = recvStatus(t, stream)
+	if st.HealthStatus != agent.JobHealthStatus_OK || !strings.Contains(st.OutputMessages, "ignoring StartReq") {
+		t.Errorf("expected repeated StartReq to be noted but leave health OK, got %s and %q", st.HealthStatus, st.OutputMessages)
+	}
+
+	close(release)
+	st = recvFinalStatus(t, stream)
+	if st.HealthStatus != agent.JobHealthStatus_OK || !strings.Contains(st.OutputMessages, "scanned a") || st.TimeFinished == 0 {
+		t.Errorf("expected job to finish OK with its output, got %s and %q", st.HealthStatus, st.OutputMessages)
+	}
+}
+
+func TestJobHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		run    RunJobFunc
+		health agent.JobHealthStatus
+		msg    string
+	}{
+		{"errorf", func(ctx context.Context, cfg *agent.JobConfig, r Reporter) error {
+			r.Errorf("couldn't read %d files", 2)
+			return nil
+		}, agent.JobHealthStatus_DEGRADED, "couldn't read 2 files"},
+		{"returned error", func(ctx context.Context, cfg *agent.JobConfig, r Reporter) error {
+			return fmt.Errorf("scan failed")
+		}, agent.JobHealthStatus_ERROR, "scan failed"},
+		{"panic", func(ctx context.Context, cfg *agent.JobConfig, r Reporter) error {
+			panic("oops")
+		}, agent.JobHealthStatus_ERROR, "job panicked: oops"},
+	}
+	for _, tc := range tests {
+		stream := newJobStream(t, tc.run)
+		send(t, stream, startMsg)
+		st := recvFinalStatus(t, stream)
+		if st.HealthStatus != tc.health || !strings.Contains(st.ErrorMessages, tc.msg) {
+			t.Errorf("%s: expected %s with %q, got %s with %q", tc.name, tc.health, tc.msg, st.HealthStatus, st.ErrorMessages)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	stopped := make(chan struct{})
+	stream := newJobStream(t, func(ctx context.Context, cfg *agent.JobConfig, r Reporter) error {
+		<-ctx.Done()
+		close(stopped)
+		return nil
+	})
+
+	// a running job sees its context cancelled, and is then reported as
+	// failed
+	send(t, stream, startMsg)
+	recvStatus(t, stream)
+	send(t, stream, cancelMsg)
+	st := recvFinalStatus(t, stream)
+	<-stopped
+	if st.HealthStatus != agent.JobHealthStatus_ERROR || !strings.Contains(st.ErrorMessages, "job cancelled") {
+		t.Errorf("expected cancelled job to fail, got %s with %q", st.HealthStatus, st.ErrorMessages)
+	}
+
+	// and a job that hasn't started stops right away
+	stream = newJobStream(t, func(ctx context.Context, cfg *agent.JobConfig, r Reporter) error {
+		t.Error("expected cancelled job not to run")
+		return nil
+	})
+	send(t, stream, cancelMsg)
+	st = recvFinalStatus(t, stream)
+	if st.HealthStatus != agent.JobHealthStatus_ERROR || st.TimeStarted != 0 {
+		t.Errorf("expected job cancelled before starting to fail without starting, got %s and start time %d", st.HealthStatus, st.TimeStarted)
+	}
+}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+package agentsdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swinslow/peridot-core/pkg/agent"
+)
+
+// job tracks the status of a single Job, and serializes messages sent on
+// its stream. It implements Reporter.
+type job struct {
+	// m guards st, and also serializes calls to stream.Send, since a gRPC
+	// stream does not allow concurrent sends
+	m sync.Mutex
+
+	stream agent.Agent_NewJobServer
+	st     agent.StatusReport
+}
+
+// NewJob implements the Agent.NewJob endpoint. It handles messages from
+// the controller for the lifetime of one Job, and returns once the Job
+// has stopped and its final StatusReport has been sent.
+func (s *Server) NewJob(stream agent.Agent_NewJobServer) error {
+	j := &job{
+		stream: stream,
+		st: agent.StatusReport{
+			RunStatus:    agent.JobRunStatus_STARTUP,
+			HealthStatus: agent.JobHealthStatus_OK,
+		},
+	}
+
+	// the Job's context is cancelled if the controller sends a CancelReq,
+	// if the stream fails (for instance, because the controller went
+	// away) or when NewJob returns
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	// read messages from the controller in a separate goroutine, so
+	// that we can respond to them while the Job is running
+	recvc := make(chan *agent.ControllerMsg)
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case recvc <- in:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// donec stays nil until the Job has started
+	var donec chan error
+
+	for {
+		select {
+		case in := <-recvc:
+			switch x := in.Cm.(type) {
+			case *agent.ControllerMsg_Describe:
+				j.send(&agent.AgentMsg{Am: &agent.AgentMsg_Describe{Describe: s.describeReport()}})
+			case *agent.ControllerMsg_Status:
+				j.sendStatus()
+			case *agent.ControllerMsg_Cancel:
+				if donec == nil {
+					// nothing has started yet, so we can stop right away
+					cancel()
+					j.finish(ctx, nil)
+					return j.sendStatus()
+				}
+				// the Job will see its context cancelled, and we'll
+				// report once it has returned
+				cancel()
+			case *agent.ControllerMsg_Start:
+				if donec != nil {
+					// note it, but it's the controller's mistake, not
+					// the Job's, so leave the Job's health alone
+					j.Outputf("ignoring StartReq; job already started")
+					continue
+				}
+				j.start()
+				donec = make(chan error, 1)
+				go func(cfg *agent.JobConfig) {
+					donec <- s.runJob(ctx, cfg, j)
+				}(x.Start.Config)
+			}
+
+		case err := <-errc:
+			// stop listening for further messages either way
+			errc = nil
+			if err == io.EOF {
+				// the controller won't send anything further, but may
+				// still be waiting for the Job's results
+				if donec == nil {
+					return nil
+				}
+				continue
+			}
+			// the stream failed, so cancel the Job and wait for it to
+			// wrap up; its final status can't be delivered
+			cancel()
+			if donec == nil {
+				return err
+			}
+
+		case err := <-donec:
+			j.finish(ctx, err)
+			return j.sendStatus()
+		}
+	}
+}
+
+// runJob calls the Agent's RunJobFunc, converting a panic into an error.
+func (s *Server) runJob(ctx context.Context, cfg *agent.JobConfig, r Reporter) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	if cfg == nil {
+		cfg = &agent.JobConfig{}
+	}
+	return s.run(ctx, cfg, r)
+}
+
+// start marks the Job as RUNNING and reports this to the controller.
+func (j *job) start() {
+	j.m.Lock()
+	defer j.m.Unlock()
+	j.st.RunStatus = agent.JobRunStatus_RUNNING
+	j.st.TimeStarted = time.Now().Unix()
+	j.sendStatusLocked()
+}
+
+// finish marks the Job as STOPPED, with ERROR health if it returned an
+// error or was cancelled.
+func (j *job) finish(ctx context.Context, err error) {
+	j.m.Lock()
+	defer j.m.Unlock()
+	j.st.RunStatus = agent.JobRunStatus_STOPPED
+	j.st.TimeFinished = time.Now().Unix()
+	if err == nil && ctx.Err() != nil {
+		err = fmt.Errorf("job cancelled")
+	}
+	if err != nil {
+		j.st.HealthStatus = agent.JobHealthStatus_ERROR
+		j.st.ErrorMessages = appendMessage(j.st.ErrorMessages, err.Error())
+	}
+}
+
+// Outputf implements Reporter.
+func (j *job) Outputf(format string, v ...interface{}) {
+	j.m.Lock()
+	defer j.m.Unlock()
+	j.st.OutputMessages = appendMessage(j.st.OutputMessages, fmt.Sprintf(format, v...))
+	j.sendStatusLocked()
+}
+
+// Errorf implements Reporter.
+func (j *job) Errorf(format string, v ...interface{}) {
+	j.m.Lock()
+	defer j.m.Unlock()
+	j.st.ErrorMessages = appendMessage(j.st.ErrorMessages, fmt.Sprintf(format, v...))
+	if j.st.HealthStatus == agent.JobHealthStatus_OK {
+		j.st.HealthStatus = agent.JobHealthStatus_DEGRADED
+	}
+	j.sendStatusLocked()
+}
+
+// sendStatus sends the Job's current StatusReport to the controller.
+func (j *job) sendStatus() error {
+	j.m.Lock()
+	defer j.m.Unlock()
+	return j.sendStatusLocked()
+}
+
+// sendStatusLocked sends the Job's current StatusReport to the controller.
+// The caller must hold j.m.
+func (j *job) sendStatusLocked() error {
+	st := j.st
+	return j.stream.Send(&agent.AgentMsg{Am: &agent.AgentMsg_Status{Status: &st}})
+}
+
+// send sends a message to the controller.
+func (j *job) send(am *agent.AgentMsg) error {
+	j.m.Lock()
+	defer j.m.Unlock()
+	return j.stream.Send(am)
+}
+
+// appendMessage adds a message to a newline-separated list of messages.
+func appendMessage(msgs string, msg string) string {
+	msg = strings.TrimRight(msg, "\n")
+	if msgs == "" {
+		return msg
+	}
+	return msgs + "\n" + msg
+}
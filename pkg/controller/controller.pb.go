@@ -24,6 +24,108 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// PoolStrategy is how a step that names an agent type picks one of the
+// agents of that type to run its Job. Agents that recently couldn't be
+// reached, or that are already running as many Jobs as they can, are
+// skipped.
+type PoolStrategy int32
+
+const (
+	// pick the agent running the fewest Jobs
+	PoolStrategy_LEAST_LOADED PoolStrategy = 0
+	// pick each agent of the type in turn
+	PoolStrategy_ROUND_ROBIN PoolStrategy = 1
+)
+
+var PoolStrategy_name = map[int32]string{
+	0: "LEAST_LOADED",
+	1: "ROUND_ROBIN",
+}
+
+var PoolStrategy_value = map[string]int32{
+	"LEAST_LOADED": 0,
+	"ROUND_ROBIN":  1,
+}
+
+func (x PoolStrategy) String() string {
+	return proto.EnumName(PoolStrategy_name, int32(x))
+}
+
+func (PoolStrategy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{0}
+}
+
+// StepOutcome is how a step finished, for checking StepConditions.
+type StepOutcome int32
+
+const (
+	// finished with OK health
+	StepOutcome_STEP_OK StepOutcome = 0
+	// finished with DEGRADED health
+	StepOutcome_STEP_DEGRADED StepOutcome = 1
+	// finished with ERROR health
+	StepOutcome_STEP_ERROR StepOutcome = 2
+	// skipped because its own condition wasn't met, or because its Job
+	// was cancelled with skipStep
+	StepOutcome_STEP_SKIPPED StepOutcome = 3
+)
+
+var StepOutcome_name = map[int32]string{
+	0: "STEP_OK",
+	1: "STEP_DEGRADED",
+	2: "STEP_ERROR",
+	3: "STEP_SKIPPED",
+}
+
+var StepOutcome_value = map[string]int32{
+	"STEP_OK":       0,
+	"STEP_DEGRADED": 1,
+	"STEP_ERROR":    2,
+	"STEP_SKIPPED":  3,
+}
+
+func (x StepOutcome) String() string {
+	return proto.EnumName(StepOutcome_name, int32(x))
+}
+
+func (StepOutcome) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{1}
+}
+
+// OverlapPolicy says what a schedule does when it is due while a JobSet
+// that it started earlier is still running.
+type OverlapPolicy int32
+
+const (
+	// don't start a new JobSet this time
+	OverlapPolicy_OVERLAP_SKIP OverlapPolicy = 0
+	// start a new JobSet once the earlier ones have stopped. at most one
+	// run is queued at a time.
+	OverlapPolicy_OVERLAP_QUEUE OverlapPolicy = 1
+	// start a new JobSet anyway
+	OverlapPolicy_OVERLAP_ALLOW OverlapPolicy = 2
+)
+
+var OverlapPolicy_name = map[int32]string{
+	0: "OVERLAP_SKIP",
+	1: "OVERLAP_QUEUE",
+	2: "OVERLAP_ALLOW",
+}
+
+var OverlapPolicy_value = map[string]int32{
+	"OVERLAP_SKIP":  0,
+	"OVERLAP_QUEUE": 1,
+	"OVERLAP_ALLOW": 2,
+}
+
+func (x OverlapPolicy) String() string {
+	return proto.EnumName(OverlapPolicy_name, int32(x))
+}
+
+func (OverlapPolicy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{2}
+}
+
 // StartReq requests that the Controller start running.
 type StartReq struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -208,6 +310,13 @@ func (m *GetStatusResp) GetErrorMsg() string {
 
 // StopReq requests that the Controller stop running.
 type StopReq struct {
+	// should the Controller drain before stopping? if so, it will stop
+	// accepting new JobSets and will not start any new Jobs, but will
+	// let active Jobs finish before stopping.
+	Drain bool `protobuf:"varint,1,opt,name=drain,proto3" json:"drain,omitempty"`
+	// if draining, the maximum number of seconds to wait for active Jobs
+	// to finish before cancelling whatever remains
+	DrainTimeoutSeconds  int64    `protobuf:"varint,2,opt,name=drainTimeoutSeconds,proto3" json:"drainTimeoutSeconds,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -238,8 +347,28 @@ func (m *StopReq) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_StopReq proto.InternalMessageInfo
 
+func (m *StopReq) GetDrain() bool {
+	if m != nil {
+		return m.Drain
+	}
+	return false
+}
+
+func (m *StopReq) GetDrainTimeoutSeconds() int64 {
+	if m != nil {
+		return m.DrainTimeoutSeconds
+	}
+	return 0
+}
+
 // StopResp tells whether the Controller could try to stop.
 type StopResp struct {
+	// if draining, the IDs of Jobs that were still running at the
+	// deadline and were cancelled
+	InterruptedJobIDs []uint64 `protobuf:"varint,1,rep,packed,name=interruptedJobIDs,proto3" json:"interruptedJobIDs,omitempty"`
+	// if draining, the IDs of the JobSets that those Jobs belonged to.
+	// other JobSets that had not finished are resumed on the next Start
+	InterruptedJobSetIDs []uint64 `protobuf:"varint,2,rep,packed,name=interruptedJobSetIDs,proto3" json:"interruptedJobSetIDs,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -270,6 +399,20 @@ func (m *StopResp) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_StopResp proto.InternalMessageInfo
 
+func (m *StopResp) GetInterruptedJobIDs() []uint64 {
+	if m != nil {
+		return m.InterruptedJobIDs
+	}
+	return nil
+}
+
+func (m *StopResp) GetInterruptedJobSetIDs() []uint64 {
+	if m != nil {
+		return m.InterruptedJobSetIDs
+	}
+	return nil
+}
+
 // AgentConfig defines an Agent instance's configuration.
 type AgentConfig struct {
 	// name for this agent instance. must be unique across the controller.
@@ -281,11 +424,23 @@ type AgentConfig struct {
 	// agent type; need not be unique across instances (e.g., can have multiple
 	// idsearcher instances with 'idsearcher' type and different configs, as
 	// long as they have different names).
-	Type                 string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
-	Kvs                  []*AgentConfig_AgentKV `protobuf:"bytes,5,rep,name=kvs,proto3" json:"kvs,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
-	XXX_unrecognized     []byte                 `json:"-"`
-	XXX_sizecache        int32                  `json:"-"`
+	Type string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Kvs  []*AgentConfig_AgentKV `protobuf:"bytes,5,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	// maximum number of Jobs that this agent may run at once. 0 means no
+	// limit, other than the controller's overall maximum.
+	MaxConcurrentJobs uint32 `protobuf:"varint,6,opt,name=maxConcurrentJobs,proto3" json:"maxConcurrentJobs,omitempty"`
+	// how long each of this agent's Jobs may run, in milliseconds, before
+	// it is stopped and marked as timed out, unless its step sets its own
+	// timeout. 0 means no limit.
+	JobTimeoutMillis int64 `protobuf:"varint,7,opt,name=jobTimeoutMillis,proto3" json:"jobTimeoutMillis,omitempty"`
+	// resource class of this agent's Jobs, unless their step sets its own.
+	// the Jobs of each resource class that the controller is configured
+	// with share that class's capacity, rather than the controller's
+	// overall maximum. must be one of those classes, or empty.
+	ResourceClass        string   `protobuf:"bytes,8,opt,name=resourceClass,proto3" json:"resourceClass,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *AgentConfig) Reset()         { *m = AgentConfig{} }
@@ -348,6 +503,27 @@ func (m *AgentConfig) GetKvs() []*AgentConfig_AgentKV {
 	return nil
 }
 
+func (m *AgentConfig) GetMaxConcurrentJobs() uint32 {
+	if m != nil {
+		return m.MaxConcurrentJobs
+	}
+	return 0
+}
+
+func (m *AgentConfig) GetJobTimeoutMillis() int64 {
+	if m != nil {
+		return m.JobTimeoutMillis
+	}
+	return 0
+}
+
+func (m *AgentConfig) GetResourceClass() string {
+	if m != nil {
+		return m.ResourceClass
+	}
+	return ""
+}
+
 // agent-specific key-value pairs
 type AgentConfig_AgentKV struct {
 	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -486,6 +662,198 @@ func (m *AddAgentResp) GetErrorMsg() string {
 	return ""
 }
 
+// UpdateAgentReq requests that an existing Agent's configuration be
+// replaced. The Agent is identified by cfg.name.
+type UpdateAgentReq struct {
+	Cfg                  *AgentConfig `protobuf:"bytes,1,opt,name=cfg,proto3" json:"cfg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *UpdateAgentReq) Reset()         { *m = UpdateAgentReq{} }
+func (m *UpdateAgentReq) String() string { return proto.CompactTextString(m) }
+func (*UpdateAgentReq) ProtoMessage()    {}
+func (*UpdateAgentReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{9}
+}
+
+func (m *UpdateAgentReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateAgentReq.Unmarshal(m, b)
+}
+func (m *UpdateAgentReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateAgentReq.Marshal(b, m, deterministic)
+}
+func (m *UpdateAgentReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateAgentReq.Merge(m, src)
+}
+func (m *UpdateAgentReq) XXX_Size() int {
+	return xxx_messageInfo_UpdateAgentReq.Size(m)
+}
+func (m *UpdateAgentReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateAgentReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateAgentReq proto.InternalMessageInfo
+
+func (m *UpdateAgentReq) GetCfg() *AgentConfig {
+	if m != nil {
+		return m.Cfg
+	}
+	return nil
+}
+
+// UpdateAgentResp tells whether the agent could be updated.
+type UpdateAgentResp struct {
+	// was the agent successfully updated?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateAgentResp) Reset()         { *m = UpdateAgentResp{} }
+func (m *UpdateAgentResp) String() string { return proto.CompactTextString(m) }
+func (*UpdateAgentResp) ProtoMessage()    {}
+func (*UpdateAgentResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{10}
+}
+
+func (m *UpdateAgentResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateAgentResp.Unmarshal(m, b)
+}
+func (m *UpdateAgentResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateAgentResp.Marshal(b, m, deterministic)
+}
+func (m *UpdateAgentResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateAgentResp.Merge(m, src)
+}
+func (m *UpdateAgentResp) XXX_Size() int {
+	return xxx_messageInfo_UpdateAgentResp.Size(m)
+}
+func (m *UpdateAgentResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateAgentResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateAgentResp proto.InternalMessageInfo
+
+func (m *UpdateAgentResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *UpdateAgentResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+// RemoveAgentReq requests that an existing Agent be removed.
+type RemoveAgentReq struct {
+	// the agent's name
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// remove the agent even if it has active Jobs? if so, those Jobs
+	// will be left to finish.
+	Force                bool     `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveAgentReq) Reset()         { *m = RemoveAgentReq{} }
+func (m *RemoveAgentReq) String() string { return proto.CompactTextString(m) }
+func (*RemoveAgentReq) ProtoMessage()    {}
+func (*RemoveAgentReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{11}
+}
+
+func (m *RemoveAgentReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveAgentReq.Unmarshal(m, b)
+}
+func (m *RemoveAgentReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveAgentReq.Marshal(b, m, deterministic)
+}
+func (m *RemoveAgentReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveAgentReq.Merge(m, src)
+}
+func (m *RemoveAgentReq) XXX_Size() int {
+	return xxx_messageInfo_RemoveAgentReq.Size(m)
+}
+func (m *RemoveAgentReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveAgentReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveAgentReq proto.InternalMessageInfo
+
+func (m *RemoveAgentReq) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *RemoveAgentReq) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
+// RemoveAgentResp tells whether the agent could be removed.
+type RemoveAgentResp struct {
+	// was the agent successfully removed?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveAgentResp) Reset()         { *m = RemoveAgentResp{} }
+func (m *RemoveAgentResp) String() string { return proto.CompactTextString(m) }
+func (*RemoveAgentResp) ProtoMessage()    {}
+func (*RemoveAgentResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{12}
+}
+
+func (m *RemoveAgentResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveAgentResp.Unmarshal(m, b)
+}
+func (m *RemoveAgentResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveAgentResp.Marshal(b, m, deterministic)
+}
+func (m *RemoveAgentResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveAgentResp.Merge(m, src)
+}
+func (m *RemoveAgentResp) XXX_Size() int {
+	return xxx_messageInfo_RemoveAgentResp.Size(m)
+}
+func (m *RemoveAgentResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveAgentResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveAgentResp proto.InternalMessageInfo
+
+func (m *RemoveAgentResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *RemoveAgentResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
 // GetAgentReq requests info on the Agent with the given name.
 type GetAgentReq struct {
 	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -498,7 +866,7 @@ func (m *GetAgentReq) Reset()         { *m = GetAgentReq{} }
 func (m *GetAgentReq) String() string { return proto.CompactTextString(m) }
 func (*GetAgentReq) ProtoMessage()    {}
 func (*GetAgentReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{9}
+	return fileDescriptor_d329ddaa36318286, []int{13}
 }
 
 func (m *GetAgentReq) XXX_Unmarshal(b []byte) error {
@@ -544,7 +912,7 @@ func (m *GetAgentResp) Reset()         { *m = GetAgentResp{} }
 func (m *GetAgentResp) String() string { return proto.CompactTextString(m) }
 func (*GetAgentResp) ProtoMessage()    {}
 func (*GetAgentResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{10}
+	return fileDescriptor_d329ddaa36318286, []int{14}
 }
 
 func (m *GetAgentResp) XXX_Unmarshal(b []byte) error {
@@ -597,7 +965,7 @@ func (m *GetAllAgentsReq) Reset()         { *m = GetAllAgentsReq{} }
 func (m *GetAllAgentsReq) String() string { return proto.CompactTextString(m) }
 func (*GetAllAgentsReq) ProtoMessage()    {}
 func (*GetAllAgentsReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{11}
+	return fileDescriptor_d329ddaa36318286, []int{15}
 }
 
 func (m *GetAllAgentsReq) XXX_Unmarshal(b []byte) error {
@@ -630,7 +998,7 @@ func (m *GetAllAgentsResp) Reset()         { *m = GetAllAgentsResp{} }
 func (m *GetAllAgentsResp) String() string { return proto.CompactTextString(m) }
 func (*GetAllAgentsResp) ProtoMessage()    {}
 func (*GetAllAgentsResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{12}
+	return fileDescriptor_d329ddaa36318286, []int{16}
 }
 
 func (m *GetAllAgentsResp) XXX_Unmarshal(b []byte) error {
@@ -658,10 +1026,25 @@ func (m *GetAllAgentsResp) GetCfgs() []*AgentConfig {
 	return nil
 }
 
-// StepAgentTemplate is a JobSetTemplate step for a single Agent.
+// StepAgentTemplate is a JobSetTemplate step for a single Agent. Exactly
+// one of name or agentType should be set.
 type StepAgentTemplate struct {
 	// the agent's name
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// an agent type, if the step's Job may run on any agent of that type.
+	// the agent is picked when the Job is started, using the strategy.
+	AgentType string       `protobuf:"bytes,3,opt,name=agentType,proto3" json:"agentType,omitempty"`
+	Strategy  PoolStrategy `protobuf:"varint,4,opt,name=strategy,proto3,enum=controller.PoolStrategy" json:"strategy,omitempty"`
+	// whether and how to retry the step's Job if it fails. if not set,
+	// the step is not retried.
+	Retry *RetryPolicy `protobuf:"bytes,2,opt,name=retry,proto3" json:"retry,omitempty"`
+	// how long the step's Job may run, in milliseconds, before it is
+	// stopped and marked as timed out. 0 means that the agent's
+	// jobTimeoutMillis applies.
+	TimeoutMillis int64 `protobuf:"varint,5,opt,name=timeoutMillis,proto3" json:"timeoutMillis,omitempty"`
+	// the resource class of the step's Job. if empty, the agent's own
+	// resource class applies.
+	ResourceClass        string   `protobuf:"bytes,6,opt,name=resourceClass,proto3" json:"resourceClass,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -671,7 +1054,7 @@ func (m *StepAgentTemplate) Reset()         { *m = StepAgentTemplate{} }
 func (m *StepAgentTemplate) String() string { return proto.CompactTextString(m) }
 func (*StepAgentTemplate) ProtoMessage()    {}
 func (*StepAgentTemplate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{13}
+	return fileDescriptor_d329ddaa36318286, []int{17}
 }
 
 func (m *StepAgentTemplate) XXX_Unmarshal(b []byte) error {
@@ -699,61 +1082,177 @@ func (m *StepAgentTemplate) GetName() string {
 	return ""
 }
 
-// StepJobSetTemplate is a JobSetTemplate step for a separate JobSet.
-type StepJobSetTemplate struct {
-	// the JobSetTemplate's name
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+func (m *StepAgentTemplate) GetAgentType() string {
+	if m != nil {
+		return m.AgentType
+	}
+	return ""
+}
+
+func (m *StepAgentTemplate) GetStrategy() PoolStrategy {
+	if m != nil {
+		return m.Strategy
+	}
+	return PoolStrategy_LEAST_LOADED
+}
+
+func (m *StepAgentTemplate) GetRetry() *RetryPolicy {
+	if m != nil {
+		return m.Retry
+	}
+	return nil
+}
+
+func (m *StepAgentTemplate) GetTimeoutMillis() int64 {
+	if m != nil {
+		return m.TimeoutMillis
+	}
+	return 0
+}
+
+func (m *StepAgentTemplate) GetResourceClass() string {
+	if m != nil {
+		return m.ResourceClass
+	}
+	return ""
+}
+
+// RetryPolicy says whether and how an agent step's Job is retried if it
+// fails. Each attempt is run as a separate Job for the same step.
+type RetryPolicy struct {
+	// the maximum number of Jobs to run for the step, including the
+	// first; 0 or 1 means that the step is not retried
+	MaxAttempts uint32 `protobuf:"varint,1,opt,name=maxAttempts,proto3" json:"maxAttempts,omitempty"`
+	// how long to wait before the first retry, in milliseconds. the wait
+	// doubles for each retry after that.
+	BackoffMillis int64 `protobuf:"varint,2,opt,name=backoffMillis,proto3" json:"backoffMillis,omitempty"`
+	// retry if the Job failed because its agent couldn't be reached, or
+	// because the stream to the agent failed
+	RetryConnectionErrors bool `protobuf:"varint,3,opt,name=retryConnectionErrors,proto3" json:"retryConnectionErrors,omitempty"`
+	// retry if the agent itself reported that the Job ended with ERROR
+	RetryAgentErrors bool `protobuf:"varint,4,opt,name=retryAgentErrors,proto3" json:"retryAgentErrors,omitempty"`
+	// retry if the Job was stopped because it ran past its timeout
+	RetryTimeouts        bool     `protobuf:"varint,5,opt,name=retryTimeouts,proto3" json:"retryTimeouts,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *StepJobSetTemplate) Reset()         { *m = StepJobSetTemplate{} }
-func (m *StepJobSetTemplate) String() string { return proto.CompactTextString(m) }
-func (*StepJobSetTemplate) ProtoMessage()    {}
-func (*StepJobSetTemplate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{14}
+func (m *RetryPolicy) Reset()         { *m = RetryPolicy{} }
+func (m *RetryPolicy) String() string { return proto.CompactTextString(m) }
+func (*RetryPolicy) ProtoMessage()    {}
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{18}
 }
 
-func (m *StepJobSetTemplate) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_StepJobSetTemplate.Unmarshal(m, b)
+func (m *RetryPolicy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RetryPolicy.Unmarshal(m, b)
 }
-func (m *StepJobSetTemplate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_StepJobSetTemplate.Marshal(b, m, deterministic)
+func (m *RetryPolicy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RetryPolicy.Marshal(b, m, deterministic)
 }
-func (m *StepJobSetTemplate) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_StepJobSetTemplate.Merge(m, src)
+func (m *RetryPolicy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RetryPolicy.Merge(m, src)
 }
-func (m *StepJobSetTemplate) XXX_Size() int {
-	return xxx_messageInfo_StepJobSetTemplate.Size(m)
+func (m *RetryPolicy) XXX_Size() int {
+	return xxx_messageInfo_RetryPolicy.Size(m)
 }
-func (m *StepJobSetTemplate) XXX_DiscardUnknown() {
-	xxx_messageInfo_StepJobSetTemplate.DiscardUnknown(m)
+func (m *RetryPolicy) XXX_DiscardUnknown() {
+	xxx_messageInfo_RetryPolicy.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_StepJobSetTemplate proto.InternalMessageInfo
+var xxx_messageInfo_RetryPolicy proto.InternalMessageInfo
 
-func (m *StepJobSetTemplate) GetName() string {
+func (m *RetryPolicy) GetMaxAttempts() uint32 {
 	if m != nil {
-		return m.Name
+		return m.MaxAttempts
 	}
-	return ""
+	return 0
 }
 
-// StepConcurrentTemplate represents a collection of steps that can run concurrently.
-type StepConcurrentTemplate struct {
-	// the set of concurrent steps
-	Steps                []*StepTemplate `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+func (m *RetryPolicy) GetBackoffMillis() int64 {
+	if m != nil {
+		return m.BackoffMillis
+	}
+	return 0
 }
 
-func (m *StepConcurrentTemplate) Reset()         { *m = StepConcurrentTemplate{} }
-func (m *StepConcurrentTemplate) String() string { return proto.CompactTextString(m) }
+func (m *RetryPolicy) GetRetryConnectionErrors() bool {
+	if m != nil {
+		return m.RetryConnectionErrors
+	}
+	return false
+}
+
+func (m *RetryPolicy) GetRetryAgentErrors() bool {
+	if m != nil {
+		return m.RetryAgentErrors
+	}
+	return false
+}
+
+func (m *RetryPolicy) GetRetryTimeouts() bool {
+	if m != nil {
+		return m.RetryTimeouts
+	}
+	return false
+}
+
+// StepJobSetTemplate is a JobSetTemplate step for a separate JobSet.
+type StepJobSetTemplate struct {
+	// the JobSetTemplate's name
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StepJobSetTemplate) Reset()         { *m = StepJobSetTemplate{} }
+func (m *StepJobSetTemplate) String() string { return proto.CompactTextString(m) }
+func (*StepJobSetTemplate) ProtoMessage()    {}
+func (*StepJobSetTemplate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{19}
+}
+
+func (m *StepJobSetTemplate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StepJobSetTemplate.Unmarshal(m, b)
+}
+func (m *StepJobSetTemplate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StepJobSetTemplate.Marshal(b, m, deterministic)
+}
+func (m *StepJobSetTemplate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StepJobSetTemplate.Merge(m, src)
+}
+func (m *StepJobSetTemplate) XXX_Size() int {
+	return xxx_messageInfo_StepJobSetTemplate.Size(m)
+}
+func (m *StepJobSetTemplate) XXX_DiscardUnknown() {
+	xxx_messageInfo_StepJobSetTemplate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StepJobSetTemplate proto.InternalMessageInfo
+
+func (m *StepJobSetTemplate) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// StepConcurrentTemplate represents a collection of steps that can run concurrently.
+type StepConcurrentTemplate struct {
+	// the set of concurrent steps
+	Steps                []*StepTemplate `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *StepConcurrentTemplate) Reset()         { *m = StepConcurrentTemplate{} }
+func (m *StepConcurrentTemplate) String() string { return proto.CompactTextString(m) }
 func (*StepConcurrentTemplate) ProtoMessage()    {}
 func (*StepConcurrentTemplate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{15}
+	return fileDescriptor_d329ddaa36318286, []int{20}
 }
 
 func (m *StepConcurrentTemplate) XXX_Unmarshal(b []byte) error {
@@ -781,23 +1280,111 @@ func (m *StepConcurrentTemplate) GetSteps() []*StepTemplate {
 	return nil
 }
 
+// StepForEachTemplate is a step that runs a separate JobSet for each item
+// in a list, concurrently with one another.
+type StepForEachTemplate struct {
+	// the JobSetTemplate's name
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// the JobSet config key whose value is a comma-separated list of items
+	ConfigKey string `protobuf:"bytes,2,opt,name=configKey,proto3" json:"configKey,omitempty"`
+	// the config key that is set to the item, for each item's JobSet
+	ItemKey string `protobuf:"bytes,3,opt,name=itemKey,proto3" json:"itemKey,omitempty"`
+	// how many of the items' JobSets may run at once. 0 means no limit.
+	MaxParallel          uint32   `protobuf:"varint,4,opt,name=maxParallel,proto3" json:"maxParallel,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StepForEachTemplate) Reset()         { *m = StepForEachTemplate{} }
+func (m *StepForEachTemplate) String() string { return proto.CompactTextString(m) }
+func (*StepForEachTemplate) ProtoMessage()    {}
+func (*StepForEachTemplate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{21}
+}
+
+func (m *StepForEachTemplate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StepForEachTemplate.Unmarshal(m, b)
+}
+func (m *StepForEachTemplate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StepForEachTemplate.Marshal(b, m, deterministic)
+}
+func (m *StepForEachTemplate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StepForEachTemplate.Merge(m, src)
+}
+func (m *StepForEachTemplate) XXX_Size() int {
+	return xxx_messageInfo_StepForEachTemplate.Size(m)
+}
+func (m *StepForEachTemplate) XXX_DiscardUnknown() {
+	xxx_messageInfo_StepForEachTemplate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StepForEachTemplate proto.InternalMessageInfo
+
+func (m *StepForEachTemplate) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *StepForEachTemplate) GetConfigKey() string {
+	if m != nil {
+		return m.ConfigKey
+	}
+	return ""
+}
+
+func (m *StepForEachTemplate) GetItemKey() string {
+	if m != nil {
+		return m.ItemKey
+	}
+	return ""
+}
+
+func (m *StepForEachTemplate) GetMaxParallel() uint32 {
+	if m != nil {
+		return m.MaxParallel
+	}
+	return 0
+}
+
 // Step represents the union of step types for templates.
 type StepTemplate struct {
 	// Types that are valid to be assigned to S:
 	//	*StepTemplate_Agent
 	//	*StepTemplate_Jobset
 	//	*StepTemplate_Concurrent
-	S                    isStepTemplate_S `protobuf_oneof:"s"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	//	*StepTemplate_Foreach
+	S isStepTemplate_S `protobuf_oneof:"s"`
+	// when the step should run. if not set, it always runs; otherwise,
+	// it is skipped if the condition isn't met when the step is reached.
+	Condition *StepCondition `protobuf:"bytes,4,opt,name=condition,proto3" json:"condition,omitempty"`
+	// an optional name, unique within the template, by which other steps'
+	// conditions can refer to this one, and for top-level steps, by which
+	// other steps can depend on it
+	Name string `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	// for "agent", "jobset" and "foreach" steps only: if true, the step
+	// failing with ERROR health doesn't stop the JobSet. the JobSet is
+	// instead marked DEGRADED and carries on with its later steps. for a
+	// "foreach" step, this applies to each item's JobSet too.
+	AllowFailure bool `protobuf:"varint,6,opt,name=allowFailure,proto3" json:"allowFailure,omitempty"`
+	// for top-level steps only: the names of the top-level steps that
+	// must finish before this one is reached. if any step in a template
+	// lists dependencies, each of its top-level steps is reached as soon
+	// as its own dependencies have finished, rather than after the step
+	// before it; steps that list none are reached right away.
+	DependsOn            []string `protobuf:"bytes,8,rep,name=dependsOn,proto3" json:"dependsOn,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *StepTemplate) Reset()         { *m = StepTemplate{} }
 func (m *StepTemplate) String() string { return proto.CompactTextString(m) }
 func (*StepTemplate) ProtoMessage()    {}
 func (*StepTemplate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{16}
+	return fileDescriptor_d329ddaa36318286, []int{22}
 }
 
 func (m *StepTemplate) XXX_Unmarshal(b []byte) error {
@@ -834,12 +1421,18 @@ type StepTemplate_Concurrent struct {
 	Concurrent *StepConcurrentTemplate `protobuf:"bytes,3,opt,name=concurrent,proto3,oneof"`
 }
 
+type StepTemplate_Foreach struct {
+	Foreach *StepForEachTemplate `protobuf:"bytes,7,opt,name=foreach,proto3,oneof"`
+}
+
 func (*StepTemplate_Agent) isStepTemplate_S() {}
 
 func (*StepTemplate_Jobset) isStepTemplate_S() {}
 
 func (*StepTemplate_Concurrent) isStepTemplate_S() {}
 
+func (*StepTemplate_Foreach) isStepTemplate_S() {}
+
 func (m *StepTemplate) GetS() isStepTemplate_S {
 	if m != nil {
 		return m.S
@@ -868,13 +1461,119 @@ func (m *StepTemplate) GetConcurrent() *StepConcurrentTemplate {
 	return nil
 }
 
+func (m *StepTemplate) GetForeach() *StepForEachTemplate {
+	if x, ok := m.GetS().(*StepTemplate_Foreach); ok {
+		return x.Foreach
+	}
+	return nil
+}
+
+func (m *StepTemplate) GetCondition() *StepCondition {
+	if m != nil {
+		return m.Condition
+	}
+	return nil
+}
+
+func (m *StepTemplate) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *StepTemplate) GetAllowFailure() bool {
+	if m != nil {
+		return m.AllowFailure
+	}
+	return false
+}
+
+func (m *StepTemplate) GetDependsOn() []string {
+	if m != nil {
+		return m.DependsOn
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*StepTemplate) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*StepTemplate_Agent)(nil),
 		(*StepTemplate_Jobset)(nil),
 		(*StepTemplate_Concurrent)(nil),
+		(*StepTemplate_Foreach)(nil),
+	}
+}
+
+// StepCondition says when a step should run. Every part of it that is set
+// must be met.
+type StepCondition struct {
+	// a JobSet config key that must have a non-empty value, or the value
+	// configValue if that is also set
+	ConfigKey   string `protobuf:"bytes,1,opt,name=configKey,proto3" json:"configKey,omitempty"`
+	ConfigValue string `protobuf:"bytes,2,opt,name=configValue,proto3" json:"configValue,omitempty"`
+	// the name of an earlier step, in an earlier top-level step, that must
+	// have finished with one of the given outcomes. if no outcomes are
+	// given, it must have finished with OK or DEGRADED health.
+	StepName             string        `protobuf:"bytes,3,opt,name=stepName,proto3" json:"stepName,omitempty"`
+	StepOutcomes         []StepOutcome `protobuf:"varint,4,rep,packed,name=stepOutcomes,proto3,enum=controller.StepOutcome" json:"stepOutcomes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *StepCondition) Reset()         { *m = StepCondition{} }
+func (m *StepCondition) String() string { return proto.CompactTextString(m) }
+func (*StepCondition) ProtoMessage()    {}
+func (*StepCondition) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{23}
+}
+
+func (m *StepCondition) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StepCondition.Unmarshal(m, b)
+}
+func (m *StepCondition) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StepCondition.Marshal(b, m, deterministic)
+}
+func (m *StepCondition) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StepCondition.Merge(m, src)
+}
+func (m *StepCondition) XXX_Size() int {
+	return xxx_messageInfo_StepCondition.Size(m)
+}
+func (m *StepCondition) XXX_DiscardUnknown() {
+	xxx_messageInfo_StepCondition.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StepCondition proto.InternalMessageInfo
+
+func (m *StepCondition) GetConfigKey() string {
+	if m != nil {
+		return m.ConfigKey
+	}
+	return ""
+}
+
+func (m *StepCondition) GetConfigValue() string {
+	if m != nil {
+		return m.ConfigValue
+	}
+	return ""
+}
+
+func (m *StepCondition) GetStepName() string {
+	if m != nil {
+		return m.StepName
+	}
+	return ""
+}
+
+func (m *StepCondition) GetStepOutcomes() []StepOutcome {
+	if m != nil {
+		return m.StepOutcomes
 	}
+	return nil
 }
 
 // JobSetTemplate defines a template for new JobSets.
@@ -892,7 +1591,7 @@ func (m *JobSetTemplate) Reset()         { *m = JobSetTemplate{} }
 func (m *JobSetTemplate) String() string { return proto.CompactTextString(m) }
 func (*JobSetTemplate) ProtoMessage()    {}
 func (*JobSetTemplate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{17}
+	return fileDescriptor_d329ddaa36318286, []int{24}
 }
 
 func (m *JobSetTemplate) XXX_Unmarshal(b []byte) error {
@@ -940,7 +1639,7 @@ func (m *AddJobSetTemplateReq) Reset()         { *m = AddJobSetTemplateReq{} }
 func (m *AddJobSetTemplateReq) String() string { return proto.CompactTextString(m) }
 func (*AddJobSetTemplateReq) ProtoMessage()    {}
 func (*AddJobSetTemplateReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{18}
+	return fileDescriptor_d329ddaa36318286, []int{25}
 }
 
 func (m *AddJobSetTemplateReq) XXX_Unmarshal(b []byte) error {
@@ -986,7 +1685,7 @@ func (m *AddJobSetTemplateResp) Reset()         { *m = AddJobSetTemplateResp{} }
 func (m *AddJobSetTemplateResp) String() string { return proto.CompactTextString(m) }
 func (*AddJobSetTemplateResp) ProtoMessage()    {}
 func (*AddJobSetTemplateResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{19}
+	return fileDescriptor_d329ddaa36318286, []int{26}
 }
 
 func (m *AddJobSetTemplateResp) XXX_Unmarshal(b []byte) error {
@@ -1040,7 +1739,7 @@ func (m *GetJobSetTemplateReq) Reset()         { *m = GetJobSetTemplateReq{} }
 func (m *GetJobSetTemplateReq) String() string { return proto.CompactTextString(m) }
 func (*GetJobSetTemplateReq) ProtoMessage()    {}
 func (*GetJobSetTemplateReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{20}
+	return fileDescriptor_d329ddaa36318286, []int{27}
 }
 
 func (m *GetJobSetTemplateReq) XXX_Unmarshal(b []byte) error {
@@ -1086,7 +1785,7 @@ func (m *GetJobSetTemplateResp) Reset()         { *m = GetJobSetTemplateResp{} }
 func (m *GetJobSetTemplateResp) String() string { return proto.CompactTextString(m) }
 func (*GetJobSetTemplateResp) ProtoMessage()    {}
 func (*GetJobSetTemplateResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{21}
+	return fileDescriptor_d329ddaa36318286, []int{28}
 }
 
 func (m *GetJobSetTemplateResp) XXX_Unmarshal(b []byte) error {
@@ -1139,7 +1838,7 @@ func (m *GetAllJobSetTemplatesReq) Reset()         { *m = GetAllJobSetTemplatesR
 func (m *GetAllJobSetTemplatesReq) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobSetTemplatesReq) ProtoMessage()    {}
 func (*GetAllJobSetTemplatesReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{22}
+	return fileDescriptor_d329ddaa36318286, []int{29}
 }
 
 func (m *GetAllJobSetTemplatesReq) XXX_Unmarshal(b []byte) error {
@@ -1172,7 +1871,7 @@ func (m *GetAllJobSetTemplatesResp) Reset()         { *m = GetAllJobSetTemplates
 func (m *GetAllJobSetTemplatesResp) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobSetTemplatesResp) ProtoMessage()    {}
 func (*GetAllJobSetTemplatesResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{23}
+	return fileDescriptor_d329ddaa36318286, []int{30}
 }
 
 func (m *GetAllJobSetTemplatesResp) XXX_Unmarshal(b []byte) error {
@@ -1212,7 +1911,7 @@ func (m *GetJobReq) Reset()         { *m = GetJobReq{} }
 func (m *GetJobReq) String() string { return proto.CompactTextString(m) }
 func (*GetJobReq) ProtoMessage()    {}
 func (*GetJobReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{24}
+	return fileDescriptor_d329ddaa36318286, []int{31}
 }
 
 func (m *GetJobReq) XXX_Unmarshal(b []byte) error {
@@ -1254,17 +1953,38 @@ type JobDetails struct {
 	// configuration for this job
 	Cfg *agent.JobConfig `protobuf:"bytes,6,opt,name=cfg,proto3" json:"cfg,omitempty"`
 	// status of this job
-	St                   *agent.StatusReport `protobuf:"bytes,7,opt,name=st,proto3" json:"st,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
-	XXX_unrecognized     []byte              `json:"-"`
-	XXX_sizecache        int32               `json:"-"`
+	St *agent.StatusReport `protobuf:"bytes,7,opt,name=st,proto3" json:"st,omitempty"`
+	// was this job cancelled?
+	Cancelled bool `protobuf:"varint,8,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	// if cancelled, was its step skipped (rather than failed)?
+	StepSkipped bool `protobuf:"varint,9,opt,name=stepSkipped,proto3" json:"stepSkipped,omitempty"`
+	// which attempt at its step this job is, starting from 1
+	Attempt uint32 `protobuf:"varint,10,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	// did this job fail because its agent couldn't be reached, or because
+	// the stream to the agent failed, rather than because the agent
+	// reported an error?
+	ConnectionError bool `protobuf:"varint,11,opt,name=connectionError,proto3" json:"connectionError,omitempty"`
+	// how long this job could run, in milliseconds, before being stopped;
+	// 0 if there was no limit
+	TimeoutMillis int64 `protobuf:"varint,12,opt,name=timeoutMillis,proto3" json:"timeoutMillis,omitempty"`
+	// was this job stopped because it ran past its timeout?
+	TimedOut bool `protobuf:"varint,13,opt,name=timedOut,proto3" json:"timedOut,omitempty"`
+	// when the job's agent last sent anything about it, as a Unix time,
+	// including replies to the controller's heartbeat status requests, or
+	// 0 if it hasn't sent anything yet
+	LastSeen int64 `protobuf:"varint,14,opt,name=lastSeen,proto3" json:"lastSeen,omitempty"`
+	// the job's resource class, if any, whose capacity it counts against
+	ResourceClass        string   `protobuf:"bytes,15,opt,name=resourceClass,proto3" json:"resourceClass,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *JobDetails) Reset()         { *m = JobDetails{} }
 func (m *JobDetails) String() string { return proto.CompactTextString(m) }
 func (*JobDetails) ProtoMessage()    {}
 func (*JobDetails) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{25}
+	return fileDescriptor_d329ddaa36318286, []int{32}
 }
 
 func (m *JobDetails) XXX_Unmarshal(b []byte) error {
@@ -1334,6 +2054,62 @@ func (m *JobDetails) GetSt() *agent.StatusReport {
 	return nil
 }
 
+func (m *JobDetails) GetCancelled() bool {
+	if m != nil {
+		return m.Cancelled
+	}
+	return false
+}
+
+func (m *JobDetails) GetStepSkipped() bool {
+	if m != nil {
+		return m.StepSkipped
+	}
+	return false
+}
+
+func (m *JobDetails) GetAttempt() uint32 {
+	if m != nil {
+		return m.Attempt
+	}
+	return 0
+}
+
+func (m *JobDetails) GetConnectionError() bool {
+	if m != nil {
+		return m.ConnectionError
+	}
+	return false
+}
+
+func (m *JobDetails) GetTimeoutMillis() int64 {
+	if m != nil {
+		return m.TimeoutMillis
+	}
+	return 0
+}
+
+func (m *JobDetails) GetTimedOut() bool {
+	if m != nil {
+		return m.TimedOut
+	}
+	return false
+}
+
+func (m *JobDetails) GetLastSeen() int64 {
+	if m != nil {
+		return m.LastSeen
+	}
+	return 0
+}
+
+func (m *JobDetails) GetResourceClass() string {
+	if m != nil {
+		return m.ResourceClass
+	}
+	return ""
+}
+
 // GetJobResp returns information on the specified Job's status.
 type GetJobResp struct {
 	// was a job found with the given ID?
@@ -1351,7 +2127,7 @@ func (m *GetJobResp) Reset()         { *m = GetJobResp{} }
 func (m *GetJobResp) String() string { return proto.CompactTextString(m) }
 func (*GetJobResp) ProtoMessage()    {}
 func (*GetJobResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{26}
+	return fileDescriptor_d329ddaa36318286, []int{33}
 }
 
 func (m *GetJobResp) XXX_Unmarshal(b []byte) error {
@@ -1406,7 +2182,7 @@ func (m *GetAllJobsForJobSetReq) Reset()         { *m = GetAllJobsForJobSetReq{}
 func (m *GetAllJobsForJobSetReq) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobsForJobSetReq) ProtoMessage()    {}
 func (*GetAllJobsForJobSetReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{27}
+	return fileDescriptor_d329ddaa36318286, []int{34}
 }
 
 func (m *GetAllJobsForJobSetReq) XXX_Unmarshal(b []byte) error {
@@ -1447,7 +2223,7 @@ func (m *GetAllJobsForJobSetResp) Reset()         { *m = GetAllJobsForJobSetResp
 func (m *GetAllJobsForJobSetResp) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobsForJobSetResp) ProtoMessage()    {}
 func (*GetAllJobsForJobSetResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{28}
+	return fileDescriptor_d329ddaa36318286, []int{35}
 }
 
 func (m *GetAllJobsForJobSetResp) XXX_Unmarshal(b []byte) error {
@@ -1486,7 +2262,7 @@ func (m *GetAllJobsReq) Reset()         { *m = GetAllJobsReq{} }
 func (m *GetAllJobsReq) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobsReq) ProtoMessage()    {}
 func (*GetAllJobsReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{29}
+	return fileDescriptor_d329ddaa36318286, []int{36}
 }
 
 func (m *GetAllJobsReq) XXX_Unmarshal(b []byte) error {
@@ -1519,7 +2295,7 @@ func (m *GetAllJobsResp) Reset()         { *m = GetAllJobsResp{} }
 func (m *GetAllJobsResp) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobsResp) ProtoMessage()    {}
 func (*GetAllJobsResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{30}
+	return fileDescriptor_d329ddaa36318286, []int{37}
 }
 
 func (m *GetAllJobsResp) XXX_Unmarshal(b []byte) error {
@@ -1547,20 +2323,121 @@ func (m *GetAllJobsResp) GetJobs() []*JobDetails {
 	return nil
 }
 
-// JobSet-specific key-value pairs; will be passed along to all Agents
-type JobSetConfig struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+// CancelJobReq requests that the specified Job be cancelled.
+type CancelJobReq struct {
+	JobID uint64 `protobuf:"varint,1,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	// if false, the Job's step is treated as failed, and its JobSet stops
+	// with ERROR health. if true, the step is treated as skipped, and the
+	// JobSet's later steps proceed as though it had succeeded.
+	SkipStep             bool     `protobuf:"varint,2,opt,name=skipStep,proto3" json:"skipStep,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *JobSetConfig) Reset()         { *m = JobSetConfig{} }
-func (m *JobSetConfig) String() string { return proto.CompactTextString(m) }
-func (*JobSetConfig) ProtoMessage()    {}
-func (*JobSetConfig) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{31}
+func (m *CancelJobReq) Reset()         { *m = CancelJobReq{} }
+func (m *CancelJobReq) String() string { return proto.CompactTextString(m) }
+func (*CancelJobReq) ProtoMessage()    {}
+func (*CancelJobReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{38}
+}
+
+func (m *CancelJobReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobReq.Unmarshal(m, b)
+}
+func (m *CancelJobReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobReq.Marshal(b, m, deterministic)
+}
+func (m *CancelJobReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobReq.Merge(m, src)
+}
+func (m *CancelJobReq) XXX_Size() int {
+	return xxx_messageInfo_CancelJobReq.Size(m)
+}
+func (m *CancelJobReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobReq proto.InternalMessageInfo
+
+func (m *CancelJobReq) GetJobID() uint64 {
+	if m != nil {
+		return m.JobID
+	}
+	return 0
+}
+
+func (m *CancelJobReq) GetSkipStep() bool {
+	if m != nil {
+		return m.SkipStep
+	}
+	return false
+}
+
+// CancelJobResp tells whether the Job was cancelled.
+type CancelJobResp struct {
+	// was the Job successfully cancelled?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelJobResp) Reset()         { *m = CancelJobResp{} }
+func (m *CancelJobResp) String() string { return proto.CompactTextString(m) }
+func (*CancelJobResp) ProtoMessage()    {}
+func (*CancelJobResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{39}
+}
+
+func (m *CancelJobResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobResp.Unmarshal(m, b)
+}
+func (m *CancelJobResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobResp.Marshal(b, m, deterministic)
+}
+func (m *CancelJobResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobResp.Merge(m, src)
+}
+func (m *CancelJobResp) XXX_Size() int {
+	return xxx_messageInfo_CancelJobResp.Size(m)
+}
+func (m *CancelJobResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobResp proto.InternalMessageInfo
+
+func (m *CancelJobResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *CancelJobResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+// JobSet-specific key-value pairs; will be passed along to all Agents
+type JobSetConfig struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *JobSetConfig) Reset()         { *m = JobSetConfig{} }
+func (m *JobSetConfig) String() string { return proto.CompactTextString(m) }
+func (*JobSetConfig) ProtoMessage()    {}
+func (*JobSetConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{40}
 }
 
 func (m *JobSetConfig) XXX_Unmarshal(b []byte) error {
@@ -1600,17 +2477,27 @@ type StartJobSetReq struct {
 	// name of the JobSetTemplate to run as a new JobSet
 	JstName string `protobuf:"bytes,1,opt,name=jstName,proto3" json:"jstName,omitempty"`
 	// configuration for this JobSet
-	Cfgs                 []*JobSetConfig `protobuf:"bytes,2,rep,name=cfgs,proto3" json:"cfgs,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	Cfgs []*JobSetConfig `protobuf:"bytes,2,rep,name=cfgs,proto3" json:"cfgs,omitempty"`
+	// scheduling priority for this JobSet and its sub-JobSets. when there
+	// isn't capacity to start every ready step, a tenant's steps from
+	// JobSets with a higher priority are started first, and JobSets with
+	// the same priority are started in the order they were submitted.
+	// defaults to 0.
+	Priority int32 `protobuf:"varint,3,opt,name=priority,proto3" json:"priority,omitempty"`
+	// tenant (e.g. team or project) that owns this JobSet and its
+	// sub-JobSets. running Job slots are shared fairly between tenants,
+	// and priority only orders steps within a tenant. defaults to "".
+	Tenant               string   `protobuf:"bytes,4,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *StartJobSetReq) Reset()         { *m = StartJobSetReq{} }
 func (m *StartJobSetReq) String() string { return proto.CompactTextString(m) }
 func (*StartJobSetReq) ProtoMessage()    {}
 func (*StartJobSetReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{32}
+	return fileDescriptor_d329ddaa36318286, []int{41}
 }
 
 func (m *StartJobSetReq) XXX_Unmarshal(b []byte) error {
@@ -1645,6 +2532,20 @@ func (m *StartJobSetReq) GetCfgs() []*JobSetConfig {
 	return nil
 }
 
+func (m *StartJobSetReq) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *StartJobSetReq) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
 // StartJobSetResp tells whether the JobSet was started successfully.
 type StartJobSetResp struct {
 	// was the JobSet successfully started?
@@ -1662,7 +2563,7 @@ func (m *StartJobSetResp) Reset()         { *m = StartJobSetResp{} }
 func (m *StartJobSetResp) String() string { return proto.CompactTextString(m) }
 func (*StartJobSetResp) ProtoMessage()    {}
 func (*StartJobSetResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{33}
+	return fileDescriptor_d329ddaa36318286, []int{42}
 }
 
 func (m *StartJobSetResp) XXX_Unmarshal(b []byte) error {
@@ -1716,7 +2617,7 @@ func (m *GetJobSetReq) Reset()         { *m = GetJobSetReq{} }
 func (m *GetJobSetReq) String() string { return proto.CompactTextString(m) }
 func (*GetJobSetReq) ProtoMessage()    {}
 func (*GetJobSetReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{34}
+	return fileDescriptor_d329ddaa36318286, []int{43}
 }
 
 func (m *GetJobSetReq) XXX_Unmarshal(b []byte) error {
@@ -1748,8 +2649,18 @@ func (m *GetJobSetReq) GetJobSetID() uint64 {
 type StepAgent struct {
 	// the agent's name
 	AgentName string `protobuf:"bytes,1,opt,name=agentName,proto3" json:"agentName,omitempty"`
-	// the actual Job's ID
-	JobID                uint64   `protobuf:"varint,2,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	// the actual Job's ID. if the step has been retried, this is the
+	// latest attempt's Job.
+	JobID uint64 `protobuf:"varint,2,opt,name=jobID,proto3" json:"jobID,omitempty"`
+	// how many Jobs have been started for this step so far
+	Attempts uint32 `protobuf:"varint,3,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	// if the agent is picked from a pool, the agent type. agentName is
+	// empty until an agent has been picked.
+	AgentType string `protobuf:"bytes,4,opt,name=agentType,proto3" json:"agentType,omitempty"`
+	// the step's own timeout, in milliseconds, if any
+	TimeoutMillis int64 `protobuf:"varint,5,opt,name=timeoutMillis,proto3" json:"timeoutMillis,omitempty"`
+	// the step's own resource class, if any
+	ResourceClass        string   `protobuf:"bytes,6,opt,name=resourceClass,proto3" json:"resourceClass,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1759,7 +2670,7 @@ func (m *StepAgent) Reset()         { *m = StepAgent{} }
 func (m *StepAgent) String() string { return proto.CompactTextString(m) }
 func (*StepAgent) ProtoMessage()    {}
 func (*StepAgent) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{35}
+	return fileDescriptor_d329ddaa36318286, []int{44}
 }
 
 func (m *StepAgent) XXX_Unmarshal(b []byte) error {
@@ -1794,22 +2705,53 @@ func (m *StepAgent) GetJobID() uint64 {
 	return 0
 }
 
+func (m *StepAgent) GetAttempts() uint32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *StepAgent) GetAgentType() string {
+	if m != nil {
+		return m.AgentType
+	}
+	return ""
+}
+
+func (m *StepAgent) GetTimeoutMillis() int64 {
+	if m != nil {
+		return m.TimeoutMillis
+	}
+	return 0
+}
+
+func (m *StepAgent) GetResourceClass() string {
+	if m != nil {
+		return m.ResourceClass
+	}
+	return ""
+}
+
 // StepJobSet is a JobSet step for a separate JobSet.
 type StepJobSet struct {
 	// the JobSet's template name
 	TemplateName string `protobuf:"bytes,1,opt,name=templateName,proto3" json:"templateName,omitempty"`
 	// the actual JobSet's ID
-	JobSetID             uint64   `protobuf:"varint,2,opt,name=jobSetID,proto3" json:"jobSetID,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	JobSetID uint64 `protobuf:"varint,2,opt,name=jobSetID,proto3" json:"jobSetID,omitempty"`
+	// config values for the JobSet, in addition to those of its parent.
+	// set for the items of a foreach step.
+	Cfgs                 []*JobSetConfig `protobuf:"bytes,3,rep,name=cfgs,proto3" json:"cfgs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
 func (m *StepJobSet) Reset()         { *m = StepJobSet{} }
 func (m *StepJobSet) String() string { return proto.CompactTextString(m) }
 func (*StepJobSet) ProtoMessage()    {}
 func (*StepJobSet) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{36}
+	return fileDescriptor_d329ddaa36318286, []int{45}
 }
 
 func (m *StepJobSet) XXX_Unmarshal(b []byte) error {
@@ -1844,6 +2786,13 @@ func (m *StepJobSet) GetJobSetID() uint64 {
 	return 0
 }
 
+func (m *StepJobSet) GetCfgs() []*JobSetConfig {
+	if m != nil {
+		return m.Cfgs
+	}
+	return nil
+}
+
 // StepConcurrent represents a collection of steps that can run concurrently.
 type StepConcurrent struct {
 	// the set of concurrent steps
@@ -1857,7 +2806,7 @@ func (m *StepConcurrent) Reset()         { *m = StepConcurrent{} }
 func (m *StepConcurrent) String() string { return proto.CompactTextString(m) }
 func (*StepConcurrent) ProtoMessage()    {}
 func (*StepConcurrent) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{37}
+	return fileDescriptor_d329ddaa36318286, []int{46}
 }
 
 func (m *StepConcurrent) XXX_Unmarshal(b []byte) error {
@@ -1885,30 +2834,118 @@ func (m *StepConcurrent) GetSteps() []*Step {
 	return nil
 }
 
+// StepForEach is a JobSet step that runs a separate JobSet for each item
+// in a list.
+type StepForEach struct {
+	// the JobSetTemplate's name
+	TemplateName string `protobuf:"bytes,1,opt,name=templateName,proto3" json:"templateName,omitempty"`
+	// the JobSet config key that lists the items
+	ConfigKey string `protobuf:"bytes,2,opt,name=configKey,proto3" json:"configKey,omitempty"`
+	// the config key that is set to the item, for each item's JobSet
+	ItemKey string `protobuf:"bytes,3,opt,name=itemKey,proto3" json:"itemKey,omitempty"`
+	// how many of the items' JobSets may run at once. 0 means no limit.
+	MaxParallel uint32 `protobuf:"varint,4,opt,name=maxParallel,proto3" json:"maxParallel,omitempty"`
+	// a jobset step for each item. empty until the step is reached.
+	Steps                []*Step  `protobuf:"bytes,5,rep,name=steps,proto3" json:"steps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StepForEach) Reset()         { *m = StepForEach{} }
+func (m *StepForEach) String() string { return proto.CompactTextString(m) }
+func (*StepForEach) ProtoMessage()    {}
+func (*StepForEach) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{47}
+}
+
+func (m *StepForEach) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StepForEach.Unmarshal(m, b)
+}
+func (m *StepForEach) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StepForEach.Marshal(b, m, deterministic)
+}
+func (m *StepForEach) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StepForEach.Merge(m, src)
+}
+func (m *StepForEach) XXX_Size() int {
+	return xxx_messageInfo_StepForEach.Size(m)
+}
+func (m *StepForEach) XXX_DiscardUnknown() {
+	xxx_messageInfo_StepForEach.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StepForEach proto.InternalMessageInfo
+
+func (m *StepForEach) GetTemplateName() string {
+	if m != nil {
+		return m.TemplateName
+	}
+	return ""
+}
+
+func (m *StepForEach) GetConfigKey() string {
+	if m != nil {
+		return m.ConfigKey
+	}
+	return ""
+}
+
+func (m *StepForEach) GetItemKey() string {
+	if m != nil {
+		return m.ItemKey
+	}
+	return ""
+}
+
+func (m *StepForEach) GetMaxParallel() uint32 {
+	if m != nil {
+		return m.MaxParallel
+	}
+	return 0
+}
+
+func (m *StepForEach) GetSteps() []*Step {
+	if m != nil {
+		return m.Steps
+	}
+	return nil
+}
+
 // Step represents the union of step types for s.
 type Step struct {
 	// Types that are valid to be assigned to S:
 	//	*Step_Agent
 	//	*Step_Jobset
 	//	*Step_Concurrent
+	//	*Step_Foreach
 	S isStep_S `protobuf_oneof:"s"`
 	// unique ID of step within JobSet
 	StepID uint64 `protobuf:"varint,4,opt,name=stepID,proto3" json:"stepID,omitempty"`
 	// ordering of step within jobSet
 	StepOrder uint64 `protobuf:"varint,5,opt,name=stepOrder,proto3" json:"stepOrder,omitempty"`
 	// step's overall status and health
-	RunStatus            status.Status `protobuf:"varint,6,opt,name=runStatus,proto3,enum=status.Status" json:"runStatus,omitempty"`
-	HealthStatus         status.Health `protobuf:"varint,7,opt,name=healthStatus,proto3,enum=status.Health" json:"healthStatus,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	RunStatus    status.Status `protobuf:"varint,6,opt,name=runStatus,proto3,enum=status.Status" json:"runStatus,omitempty"`
+	HealthStatus status.Health `protobuf:"varint,7,opt,name=healthStatus,proto3,enum=status.Health" json:"healthStatus,omitempty"`
+	// if the step is ready to run but is being held back, the reason why
+	WaitingReason string `protobuf:"bytes,8,opt,name=waitingReason,proto3" json:"waitingReason,omitempty"`
+	// the step's name, if any
+	Name string `protobuf:"bytes,9,opt,name=name,proto3" json:"name,omitempty"`
+	// if true, this step failing doesn't stop its JobSet
+	AllowFailure bool `protobuf:"varint,10,opt,name=allowFailure,proto3" json:"allowFailure,omitempty"`
+	// top-level steps only: the IDs of the steps that must finish before
+	// this one is reached, if its JobSet's steps form a DAG
+	DependsOn            []uint64 `protobuf:"varint,12,rep,packed,name=dependsOn,proto3" json:"dependsOn,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Step) Reset()         { *m = Step{} }
 func (m *Step) String() string { return proto.CompactTextString(m) }
 func (*Step) ProtoMessage()    {}
 func (*Step) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{38}
+	return fileDescriptor_d329ddaa36318286, []int{48}
 }
 
 func (m *Step) XXX_Unmarshal(b []byte) error {
@@ -1945,12 +2982,18 @@ type Step_Concurrent struct {
 	Concurrent *StepConcurrent `protobuf:"bytes,3,opt,name=concurrent,proto3,oneof"`
 }
 
+type Step_Foreach struct {
+	Foreach *StepForEach `protobuf:"bytes,11,opt,name=foreach,proto3,oneof"`
+}
+
 func (*Step_Agent) isStep_S() {}
 
 func (*Step_Jobset) isStep_S() {}
 
 func (*Step_Concurrent) isStep_S() {}
 
+func (*Step_Foreach) isStep_S() {}
+
 func (m *Step) GetS() isStep_S {
 	if m != nil {
 		return m.S
@@ -1979,6 +3022,13 @@ func (m *Step) GetConcurrent() *StepConcurrent {
 	return nil
 }
 
+func (m *Step) GetForeach() *StepForEach {
+	if x, ok := m.GetS().(*Step_Foreach); ok {
+		return x.Foreach
+	}
+	return nil
+}
+
 func (m *Step) GetStepID() uint64 {
 	if m != nil {
 		return m.StepID
@@ -2007,12 +3057,41 @@ func (m *Step) GetHealthStatus() status.Health {
 	return status.Health_HEALTH_SAME
 }
 
+func (m *Step) GetWaitingReason() string {
+	if m != nil {
+		return m.WaitingReason
+	}
+	return ""
+}
+
+func (m *Step) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Step) GetAllowFailure() bool {
+	if m != nil {
+		return m.AllowFailure
+	}
+	return false
+}
+
+func (m *Step) GetDependsOn() []uint64 {
+	if m != nil {
+		return m.DependsOn
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Step) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*Step_Agent)(nil),
 		(*Step_Jobset)(nil),
 		(*Step_Concurrent)(nil),
+		(*Step_Foreach)(nil),
 	}
 }
 
@@ -2029,7 +3108,10 @@ type JobSetStatusReport struct {
 	// lengthy should be separately logged or reported elsewhere
 	OutputMessages string `protobuf:"bytes,5,opt,name=outputMessages,proto3" json:"outputMessages,omitempty"`
 	// logged errors, if any
-	ErrorMessages        string   `protobuf:"bytes,6,opt,name=errorMessages,proto3" json:"errorMessages,omitempty"`
+	ErrorMessages string `protobuf:"bytes,6,opt,name=errorMessages,proto3" json:"errorMessages,omitempty"`
+	// was the JobSet cancelled? if so, runStatus will be STOPPED, and
+	// healthStatus reflects the steps that ran before it was cancelled
+	Cancelled            bool     `protobuf:"varint,7,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -2039,7 +3121,7 @@ func (m *JobSetStatusReport) Reset()         { *m = JobSetStatusReport{} }
 func (m *JobSetStatusReport) String() string { return proto.CompactTextString(m) }
 func (*JobSetStatusReport) ProtoMessage()    {}
 func (*JobSetStatusReport) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{39}
+	return fileDescriptor_d329ddaa36318286, []int{49}
 }
 
 func (m *JobSetStatusReport) XXX_Unmarshal(b []byte) error {
@@ -2102,6 +3184,13 @@ func (m *JobSetStatusReport) GetErrorMessages() string {
 	return ""
 }
 
+func (m *JobSetStatusReport) GetCancelled() bool {
+	if m != nil {
+		return m.Cancelled
+	}
+	return false
+}
+
 type JobSetDetails struct {
 	// JobSet ID
 	JobSetID uint64 `protobuf:"varint,1,opt,name=jobSetID,proto3" json:"jobSetID,omitempty"`
@@ -2110,7 +3199,13 @@ type JobSetDetails struct {
 	// overall status of this JobSet
 	St *JobSetStatusReport `protobuf:"bytes,3,opt,name=st,proto3" json:"st,omitempty"`
 	// steps
-	Steps                []*Step  `protobuf:"bytes,4,rep,name=steps,proto3" json:"steps,omitempty"`
+	Steps []*Step `protobuf:"bytes,4,rep,name=steps,proto3" json:"steps,omitempty"`
+	// scheduling priority
+	Priority int32 `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	// tenant that owns this JobSet
+	Tenant string `protobuf:"bytes,6,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// name of the schedule that started this JobSet, if any
+	Schedule             string   `protobuf:"bytes,7,opt,name=schedule,proto3" json:"schedule,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -2120,7 +3215,7 @@ func (m *JobSetDetails) Reset()         { *m = JobSetDetails{} }
 func (m *JobSetDetails) String() string { return proto.CompactTextString(m) }
 func (*JobSetDetails) ProtoMessage()    {}
 func (*JobSetDetails) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{40}
+	return fileDescriptor_d329ddaa36318286, []int{50}
 }
 
 func (m *JobSetDetails) XXX_Unmarshal(b []byte) error {
@@ -2169,6 +3264,27 @@ func (m *JobSetDetails) GetSteps() []*Step {
 	return nil
 }
 
+func (m *JobSetDetails) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *JobSetDetails) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
+func (m *JobSetDetails) GetSchedule() string {
+	if m != nil {
+		return m.Schedule
+	}
+	return ""
+}
+
 // GetJobSetResp returns information on the specified JobSet's status.
 type GetJobSetResp struct {
 	// was a JobSet found with the given ID?
@@ -2186,7 +3302,7 @@ func (m *GetJobSetResp) Reset()         { *m = GetJobSetResp{} }
 func (m *GetJobSetResp) String() string { return proto.CompactTextString(m) }
 func (*GetJobSetResp) ProtoMessage()    {}
 func (*GetJobSetResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{41}
+	return fileDescriptor_d329ddaa36318286, []int{51}
 }
 
 func (m *GetJobSetResp) XXX_Unmarshal(b []byte) error {
@@ -2239,7 +3355,7 @@ func (m *GetAllJobSetsReq) Reset()         { *m = GetAllJobSetsReq{} }
 func (m *GetAllJobSetsReq) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobSetsReq) ProtoMessage()    {}
 func (*GetAllJobSetsReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{42}
+	return fileDescriptor_d329ddaa36318286, []int{52}
 }
 
 func (m *GetAllJobSetsReq) XXX_Unmarshal(b []byte) error {
@@ -2272,7 +3388,7 @@ func (m *GetAllJobSetsResp) Reset()         { *m = GetAllJobSetsResp{} }
 func (m *GetAllJobSetsResp) String() string { return proto.CompactTextString(m) }
 func (*GetAllJobSetsResp) ProtoMessage()    {}
 func (*GetAllJobSetsResp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d329ddaa36318286, []int{43}
+	return fileDescriptor_d329ddaa36318286, []int{53}
 }
 
 func (m *GetAllJobSetsResp) XXX_Unmarshal(b []byte) error {
@@ -2300,39 +3416,1080 @@ func (m *GetAllJobSetsResp) GetJobSets() []*JobSetDetails {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*StartReq)(nil), "controller.StartReq")
-	proto.RegisterType((*StartResp)(nil), "controller.StartResp")
-	proto.RegisterType((*GetStatusReq)(nil), "controller.GetStatusReq")
-	proto.RegisterType((*GetStatusResp)(nil), "controller.GetStatusResp")
-	proto.RegisterType((*StopReq)(nil), "controller.StopReq")
-	proto.RegisterType((*StopResp)(nil), "controller.StopResp")
-	proto.RegisterType((*AgentConfig)(nil), "controller.AgentConfig")
-	proto.RegisterType((*AgentConfig_AgentKV)(nil), "controller.AgentConfig.AgentKV")
-	proto.RegisterType((*AddAgentReq)(nil), "controller.AddAgentReq")
-	proto.RegisterType((*AddAgentResp)(nil), "controller.AddAgentResp")
-	proto.RegisterType((*GetAgentReq)(nil), "controller.GetAgentReq")
-	proto.RegisterType((*GetAgentResp)(nil), "controller.GetAgentResp")
-	proto.RegisterType((*GetAllAgentsReq)(nil), "controller.GetAllAgentsReq")
-	proto.RegisterType((*GetAllAgentsResp)(nil), "controller.GetAllAgentsResp")
-	proto.RegisterType((*StepAgentTemplate)(nil), "controller.StepAgentTemplate")
-	proto.RegisterType((*StepJobSetTemplate)(nil), "controller.StepJobSetTemplate")
-	proto.RegisterType((*StepConcurrentTemplate)(nil), "controller.StepConcurrentTemplate")
-	proto.RegisterType((*StepTemplate)(nil), "controller.StepTemplate")
-	proto.RegisterType((*JobSetTemplate)(nil), "controller.JobSetTemplate")
-	proto.RegisterType((*AddJobSetTemplateReq)(nil), "controller.AddJobSetTemplateReq")
-	proto.RegisterType((*AddJobSetTemplateResp)(nil), "controller.AddJobSetTemplateResp")
-	proto.RegisterType((*GetJobSetTemplateReq)(nil), "controller.GetJobSetTemplateReq")
-	proto.RegisterType((*GetJobSetTemplateResp)(nil), "controller.GetJobSetTemplateResp")
-	proto.RegisterType((*GetAllJobSetTemplatesReq)(nil), "controller.GetAllJobSetTemplatesReq")
-	proto.RegisterType((*GetAllJobSetTemplatesResp)(nil), "controller.GetAllJobSetTemplatesResp")
-	proto.RegisterType((*GetJobReq)(nil), "controller.GetJobReq")
-	proto.RegisterType((*JobDetails)(nil), "controller.JobDetails")
-	proto.RegisterType((*GetJobResp)(nil), "controller.GetJobResp")
-	proto.RegisterType((*GetAllJobsForJobSetReq)(nil), "controller.GetAllJobsForJobSetReq")
+// CancelJobSetReq requests that the specified JobSet be cancelled.
+type CancelJobSetReq struct {
+	JobSetID             uint64   `protobuf:"varint,1,opt,name=jobSetID,proto3" json:"jobSetID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelJobSetReq) Reset()         { *m = CancelJobSetReq{} }
+func (m *CancelJobSetReq) String() string { return proto.CompactTextString(m) }
+func (*CancelJobSetReq) ProtoMessage()    {}
+func (*CancelJobSetReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{54}
+}
+
+func (m *CancelJobSetReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobSetReq.Unmarshal(m, b)
+}
+func (m *CancelJobSetReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobSetReq.Marshal(b, m, deterministic)
+}
+func (m *CancelJobSetReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobSetReq.Merge(m, src)
+}
+func (m *CancelJobSetReq) XXX_Size() int {
+	return xxx_messageInfo_CancelJobSetReq.Size(m)
+}
+func (m *CancelJobSetReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobSetReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobSetReq proto.InternalMessageInfo
+
+func (m *CancelJobSetReq) GetJobSetID() uint64 {
+	if m != nil {
+		return m.JobSetID
+	}
+	return 0
+}
+
+// CancelJobSetResp tells whether the JobSet was cancelled.
+type CancelJobSetResp struct {
+	// was the JobSet successfully cancelled?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelJobSetResp) Reset()         { *m = CancelJobSetResp{} }
+func (m *CancelJobSetResp) String() string { return proto.CompactTextString(m) }
+func (*CancelJobSetResp) ProtoMessage()    {}
+func (*CancelJobSetResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{55}
+}
+
+func (m *CancelJobSetResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelJobSetResp.Unmarshal(m, b)
+}
+func (m *CancelJobSetResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelJobSetResp.Marshal(b, m, deterministic)
+}
+func (m *CancelJobSetResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelJobSetResp.Merge(m, src)
+}
+func (m *CancelJobSetResp) XXX_Size() int {
+	return xxx_messageInfo_CancelJobSetResp.Size(m)
+}
+func (m *CancelJobSetResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelJobSetResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelJobSetResp proto.InternalMessageInfo
+
+func (m *CancelJobSetResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *CancelJobSetResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+// SetJobSetPriorityReq requests that the specified JobSet's priority be
+// changed.
+type SetJobSetPriorityReq struct {
+	JobSetID uint64 `protobuf:"varint,1,opt,name=jobSetID,proto3" json:"jobSetID,omitempty"`
+	// the new priority
+	Priority             int32    `protobuf:"varint,2,opt,name=priority,proto3" json:"priority,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetJobSetPriorityReq) Reset()         { *m = SetJobSetPriorityReq{} }
+func (m *SetJobSetPriorityReq) String() string { return proto.CompactTextString(m) }
+func (*SetJobSetPriorityReq) ProtoMessage()    {}
+func (*SetJobSetPriorityReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{56}
+}
+
+func (m *SetJobSetPriorityReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetJobSetPriorityReq.Unmarshal(m, b)
+}
+func (m *SetJobSetPriorityReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetJobSetPriorityReq.Marshal(b, m, deterministic)
+}
+func (m *SetJobSetPriorityReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetJobSetPriorityReq.Merge(m, src)
+}
+func (m *SetJobSetPriorityReq) XXX_Size() int {
+	return xxx_messageInfo_SetJobSetPriorityReq.Size(m)
+}
+func (m *SetJobSetPriorityReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetJobSetPriorityReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetJobSetPriorityReq proto.InternalMessageInfo
+
+func (m *SetJobSetPriorityReq) GetJobSetID() uint64 {
+	if m != nil {
+		return m.JobSetID
+	}
+	return 0
+}
+
+func (m *SetJobSetPriorityReq) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+// SetJobSetPriorityResp tells whether the JobSet's priority was changed.
+type SetJobSetPriorityResp struct {
+	// was the priority successfully changed?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetJobSetPriorityResp) Reset()         { *m = SetJobSetPriorityResp{} }
+func (m *SetJobSetPriorityResp) String() string { return proto.CompactTextString(m) }
+func (*SetJobSetPriorityResp) ProtoMessage()    {}
+func (*SetJobSetPriorityResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{57}
+}
+
+func (m *SetJobSetPriorityResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetJobSetPriorityResp.Unmarshal(m, b)
+}
+func (m *SetJobSetPriorityResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetJobSetPriorityResp.Marshal(b, m, deterministic)
+}
+func (m *SetJobSetPriorityResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetJobSetPriorityResp.Merge(m, src)
+}
+func (m *SetJobSetPriorityResp) XXX_Size() int {
+	return xxx_messageInfo_SetJobSetPriorityResp.Size(m)
+}
+func (m *SetJobSetPriorityResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetJobSetPriorityResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetJobSetPriorityResp proto.InternalMessageInfo
+
+func (m *SetJobSetPriorityResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *SetJobSetPriorityResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+// TenantConfig configures a tenant's share of the running Job slots.
+type TenantConfig struct {
+	// tenant name, as given in StartJobSetReq
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// relative share of the running Job slots. when slots are contended,
+	// each tenant with ready steps gets slots in proportion to its weight.
+	// 0 is treated as 1, which is also the weight of unconfigured tenants.
+	Weight uint32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	// number of running Jobs guaranteed to this tenant. while it is
+	// running fewer Jobs than this, its ready steps are started before
+	// any other tenant's. running Jobs are never stopped to make room.
+	MinJobs              uint32   `protobuf:"varint,3,opt,name=minJobs,proto3" json:"minJobs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TenantConfig) Reset()         { *m = TenantConfig{} }
+func (m *TenantConfig) String() string { return proto.CompactTextString(m) }
+func (*TenantConfig) ProtoMessage()    {}
+func (*TenantConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{58}
+}
+
+func (m *TenantConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TenantConfig.Unmarshal(m, b)
+}
+func (m *TenantConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TenantConfig.Marshal(b, m, deterministic)
+}
+func (m *TenantConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantConfig.Merge(m, src)
+}
+func (m *TenantConfig) XXX_Size() int {
+	return xxx_messageInfo_TenantConfig.Size(m)
+}
+func (m *TenantConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantConfig proto.InternalMessageInfo
+
+func (m *TenantConfig) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TenantConfig) GetWeight() uint32 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *TenantConfig) GetMinJobs() uint32 {
+	if m != nil {
+		return m.MinJobs
+	}
+	return 0
+}
+
+// SetTenantReq requests that a tenant's configuration be added or
+// replaced.
+type SetTenantReq struct {
+	Cfg                  *TenantConfig `protobuf:"bytes,1,opt,name=cfg,proto3" json:"cfg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *SetTenantReq) Reset()         { *m = SetTenantReq{} }
+func (m *SetTenantReq) String() string { return proto.CompactTextString(m) }
+func (*SetTenantReq) ProtoMessage()    {}
+func (*SetTenantReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{59}
+}
+
+func (m *SetTenantReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetTenantReq.Unmarshal(m, b)
+}
+func (m *SetTenantReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetTenantReq.Marshal(b, m, deterministic)
+}
+func (m *SetTenantReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetTenantReq.Merge(m, src)
+}
+func (m *SetTenantReq) XXX_Size() int {
+	return xxx_messageInfo_SetTenantReq.Size(m)
+}
+func (m *SetTenantReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetTenantReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetTenantReq proto.InternalMessageInfo
+
+func (m *SetTenantReq) GetCfg() *TenantConfig {
+	if m != nil {
+		return m.Cfg
+	}
+	return nil
+}
+
+// SetTenantResp tells whether the tenant's configuration was set.
+type SetTenantResp struct {
+	// was the configuration successfully set?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetTenantResp) Reset()         { *m = SetTenantResp{} }
+func (m *SetTenantResp) String() string { return proto.CompactTextString(m) }
+func (*SetTenantResp) ProtoMessage()    {}
+func (*SetTenantResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{60}
+}
+
+func (m *SetTenantResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetTenantResp.Unmarshal(m, b)
+}
+func (m *SetTenantResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetTenantResp.Marshal(b, m, deterministic)
+}
+func (m *SetTenantResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetTenantResp.Merge(m, src)
+}
+func (m *SetTenantResp) XXX_Size() int {
+	return xxx_messageInfo_SetTenantResp.Size(m)
+}
+func (m *SetTenantResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetTenantResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetTenantResp proto.InternalMessageInfo
+
+func (m *SetTenantResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *SetTenantResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+// GetAllTenantsReq requests information on all tenants.
+type GetAllTenantsReq struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAllTenantsReq) Reset()         { *m = GetAllTenantsReq{} }
+func (m *GetAllTenantsReq) String() string { return proto.CompactTextString(m) }
+func (*GetAllTenantsReq) ProtoMessage()    {}
+func (*GetAllTenantsReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{61}
+}
+
+func (m *GetAllTenantsReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAllTenantsReq.Unmarshal(m, b)
+}
+func (m *GetAllTenantsReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAllTenantsReq.Marshal(b, m, deterministic)
+}
+func (m *GetAllTenantsReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAllTenantsReq.Merge(m, src)
+}
+func (m *GetAllTenantsReq) XXX_Size() int {
+	return xxx_messageInfo_GetAllTenantsReq.Size(m)
+}
+func (m *GetAllTenantsReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAllTenantsReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAllTenantsReq proto.InternalMessageInfo
+
+// TenantDetails describes a tenant's configuration and current load.
+type TenantDetails struct {
+	// configuration; weight is filled in as 1 for unconfigured tenants
+	Cfg *TenantConfig `protobuf:"bytes,1,opt,name=cfg,proto3" json:"cfg,omitempty"`
+	// number of this tenant's Jobs that are currently running
+	RunningJobs uint32 `protobuf:"varint,2,opt,name=runningJobs,proto3" json:"runningJobs,omitempty"`
+	// number of this tenant's steps that are ready to run but are
+	// waiting for a free Job slot or agent, as of the last time the
+	// scheduler ran
+	QueuedSteps uint32 `protobuf:"varint,3,opt,name=queuedSteps,proto3" json:"queuedSteps,omitempty"`
+	// number of this tenant's JobSets that have not yet stopped
+	ActiveJobSets        uint32   `protobuf:"varint,4,opt,name=activeJobSets,proto3" json:"activeJobSets,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TenantDetails) Reset()         { *m = TenantDetails{} }
+func (m *TenantDetails) String() string { return proto.CompactTextString(m) }
+func (*TenantDetails) ProtoMessage()    {}
+func (*TenantDetails) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{62}
+}
+
+func (m *TenantDetails) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TenantDetails.Unmarshal(m, b)
+}
+func (m *TenantDetails) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TenantDetails.Marshal(b, m, deterministic)
+}
+func (m *TenantDetails) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantDetails.Merge(m, src)
+}
+func (m *TenantDetails) XXX_Size() int {
+	return xxx_messageInfo_TenantDetails.Size(m)
+}
+func (m *TenantDetails) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantDetails.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantDetails proto.InternalMessageInfo
+
+func (m *TenantDetails) GetCfg() *TenantConfig {
+	if m != nil {
+		return m.Cfg
+	}
+	return nil
+}
+
+func (m *TenantDetails) GetRunningJobs() uint32 {
+	if m != nil {
+		return m.RunningJobs
+	}
+	return 0
+}
+
+func (m *TenantDetails) GetQueuedSteps() uint32 {
+	if m != nil {
+		return m.QueuedSteps
+	}
+	return 0
+}
+
+func (m *TenantDetails) GetActiveJobSets() uint32 {
+	if m != nil {
+		return m.ActiveJobSets
+	}
+	return 0
+}
+
+// GetAllTenantsResp returns information on all tenants.
+type GetAllTenantsResp struct {
+	Tenants              []*TenantDetails `protobuf:"bytes,1,rep,name=tenants,proto3" json:"tenants,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *GetAllTenantsResp) Reset()         { *m = GetAllTenantsResp{} }
+func (m *GetAllTenantsResp) String() string { return proto.CompactTextString(m) }
+func (*GetAllTenantsResp) ProtoMessage()    {}
+func (*GetAllTenantsResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{63}
+}
+
+func (m *GetAllTenantsResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAllTenantsResp.Unmarshal(m, b)
+}
+func (m *GetAllTenantsResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAllTenantsResp.Marshal(b, m, deterministic)
+}
+func (m *GetAllTenantsResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAllTenantsResp.Merge(m, src)
+}
+func (m *GetAllTenantsResp) XXX_Size() int {
+	return xxx_messageInfo_GetAllTenantsResp.Size(m)
+}
+func (m *GetAllTenantsResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAllTenantsResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAllTenantsResp proto.InternalMessageInfo
+
+func (m *GetAllTenantsResp) GetTenants() []*TenantDetails {
+	if m != nil {
+		return m.Tenants
+	}
+	return nil
+}
+
+// ScheduleConfig configures a schedule.
+type ScheduleConfig struct {
+	// unique name of the schedule
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// when to start JobSets, as a cron spec with five fields (minute,
+	// hour, day of month, month and day of week), in the controller's
+	// local time zone. "@hourly", "@daily", "@weekly", "@monthly",
+	// "@yearly" and "@every DURATION" are also accepted.
+	Cron string `protobuf:"bytes,2,opt,name=cron,proto3" json:"cron,omitempty"`
+	// name of the JobSetTemplate to start
+	JstName string `protobuf:"bytes,3,opt,name=jstName,proto3" json:"jstName,omitempty"`
+	// configuration for each JobSet
+	Cfgs []*JobSetConfig `protobuf:"bytes,4,rep,name=cfgs,proto3" json:"cfgs,omitempty"`
+	// scheduling priority for each JobSet, as in StartJobSetReq
+	Priority int32 `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	// tenant that owns each JobSet, as in StartJobSetReq
+	Tenant string `protobuf:"bytes,6,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// what to do if a JobSet from an earlier run is still running
+	Overlap              OverlapPolicy `protobuf:"varint,7,opt,name=overlap,proto3,enum=controller.OverlapPolicy" json:"overlap,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ScheduleConfig) Reset()         { *m = ScheduleConfig{} }
+func (m *ScheduleConfig) String() string { return proto.CompactTextString(m) }
+func (*ScheduleConfig) ProtoMessage()    {}
+func (*ScheduleConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{64}
+}
+
+func (m *ScheduleConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScheduleConfig.Unmarshal(m, b)
+}
+func (m *ScheduleConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScheduleConfig.Marshal(b, m, deterministic)
+}
+func (m *ScheduleConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScheduleConfig.Merge(m, src)
+}
+func (m *ScheduleConfig) XXX_Size() int {
+	return xxx_messageInfo_ScheduleConfig.Size(m)
+}
+func (m *ScheduleConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScheduleConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScheduleConfig proto.InternalMessageInfo
+
+func (m *ScheduleConfig) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ScheduleConfig) GetCron() string {
+	if m != nil {
+		return m.Cron
+	}
+	return ""
+}
+
+func (m *ScheduleConfig) GetJstName() string {
+	if m != nil {
+		return m.JstName
+	}
+	return ""
+}
+
+func (m *ScheduleConfig) GetCfgs() []*JobSetConfig {
+	if m != nil {
+		return m.Cfgs
+	}
+	return nil
+}
+
+func (m *ScheduleConfig) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
+func (m *ScheduleConfig) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
+func (m *ScheduleConfig) GetOverlap() OverlapPolicy {
+	if m != nil {
+		return m.Overlap
+	}
+	return OverlapPolicy_OVERLAP_SKIP
+}
+
+// AddScheduleReq requests that a new schedule be added.
+type AddScheduleReq struct {
+	Cfg                  *ScheduleConfig `protobuf:"bytes,1,opt,name=cfg,proto3" json:"cfg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *AddScheduleReq) Reset()         { *m = AddScheduleReq{} }
+func (m *AddScheduleReq) String() string { return proto.CompactTextString(m) }
+func (*AddScheduleReq) ProtoMessage()    {}
+func (*AddScheduleReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{65}
+}
+
+func (m *AddScheduleReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddScheduleReq.Unmarshal(m, b)
+}
+func (m *AddScheduleReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddScheduleReq.Marshal(b, m, deterministic)
+}
+func (m *AddScheduleReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddScheduleReq.Merge(m, src)
+}
+func (m *AddScheduleReq) XXX_Size() int {
+	return xxx_messageInfo_AddScheduleReq.Size(m)
+}
+func (m *AddScheduleReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddScheduleReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddScheduleReq proto.InternalMessageInfo
+
+func (m *AddScheduleReq) GetCfg() *ScheduleConfig {
+	if m != nil {
+		return m.Cfg
+	}
+	return nil
+}
+
+// AddScheduleResp tells whether the schedule was added.
+type AddScheduleResp struct {
+	// was the schedule successfully added?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddScheduleResp) Reset()         { *m = AddScheduleResp{} }
+func (m *AddScheduleResp) String() string { return proto.CompactTextString(m) }
+func (*AddScheduleResp) ProtoMessage()    {}
+func (*AddScheduleResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{66}
+}
+
+func (m *AddScheduleResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddScheduleResp.Unmarshal(m, b)
+}
+func (m *AddScheduleResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddScheduleResp.Marshal(b, m, deterministic)
+}
+func (m *AddScheduleResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddScheduleResp.Merge(m, src)
+}
+func (m *AddScheduleResp) XXX_Size() int {
+	return xxx_messageInfo_AddScheduleResp.Size(m)
+}
+func (m *AddScheduleResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddScheduleResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddScheduleResp proto.InternalMessageInfo
+
+func (m *AddScheduleResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *AddScheduleResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+// GetAllSchedulesReq requests information on all schedules.
+type GetAllSchedulesReq struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAllSchedulesReq) Reset()         { *m = GetAllSchedulesReq{} }
+func (m *GetAllSchedulesReq) String() string { return proto.CompactTextString(m) }
+func (*GetAllSchedulesReq) ProtoMessage()    {}
+func (*GetAllSchedulesReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{67}
+}
+
+func (m *GetAllSchedulesReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAllSchedulesReq.Unmarshal(m, b)
+}
+func (m *GetAllSchedulesReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAllSchedulesReq.Marshal(b, m, deterministic)
+}
+func (m *GetAllSchedulesReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAllSchedulesReq.Merge(m, src)
+}
+func (m *GetAllSchedulesReq) XXX_Size() int {
+	return xxx_messageInfo_GetAllSchedulesReq.Size(m)
+}
+func (m *GetAllSchedulesReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAllSchedulesReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAllSchedulesReq proto.InternalMessageInfo
+
+// ScheduleDetails describes a schedule's configuration and state.
+type ScheduleDetails struct {
+	Cfg *ScheduleConfig `protobuf:"bytes,1,opt,name=cfg,proto3" json:"cfg,omitempty"`
+	// is the schedule paused?
+	Paused bool `protobuf:"varint,2,opt,name=paused,proto3" json:"paused,omitempty"`
+	// when the schedule is next due, as Unix time. 0 if it is paused.
+	NextRun int64 `protobuf:"varint,3,opt,name=nextRun,proto3" json:"nextRun,omitempty"`
+	// when the schedule last started a JobSet, as Unix time, and that
+	// JobSet's ID. 0 if it never has.
+	LastRun      int64  `protobuf:"varint,4,opt,name=lastRun,proto3" json:"lastRun,omitempty"`
+	LastJobSetID uint64 `protobuf:"varint,5,opt,name=lastJobSetID,proto3" json:"lastJobSetID,omitempty"`
+	// is a run queued, waiting for earlier JobSets to stop?
+	Queued bool `protobuf:"varint,6,opt,name=queued,proto3" json:"queued,omitempty"`
+	// IDs of the JobSets started by this schedule that haven't stopped
+	ActiveJobSetIDs      []uint64 `protobuf:"varint,7,rep,packed,name=activeJobSetIDs,proto3" json:"activeJobSetIDs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScheduleDetails) Reset()         { *m = ScheduleDetails{} }
+func (m *ScheduleDetails) String() string { return proto.CompactTextString(m) }
+func (*ScheduleDetails) ProtoMessage()    {}
+func (*ScheduleDetails) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{68}
+}
+
+func (m *ScheduleDetails) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScheduleDetails.Unmarshal(m, b)
+}
+func (m *ScheduleDetails) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScheduleDetails.Marshal(b, m, deterministic)
+}
+func (m *ScheduleDetails) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScheduleDetails.Merge(m, src)
+}
+func (m *ScheduleDetails) XXX_Size() int {
+	return xxx_messageInfo_ScheduleDetails.Size(m)
+}
+func (m *ScheduleDetails) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScheduleDetails.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScheduleDetails proto.InternalMessageInfo
+
+func (m *ScheduleDetails) GetCfg() *ScheduleConfig {
+	if m != nil {
+		return m.Cfg
+	}
+	return nil
+}
+
+func (m *ScheduleDetails) GetPaused() bool {
+	if m != nil {
+		return m.Paused
+	}
+	return false
+}
+
+func (m *ScheduleDetails) GetNextRun() int64 {
+	if m != nil {
+		return m.NextRun
+	}
+	return 0
+}
+
+func (m *ScheduleDetails) GetLastRun() int64 {
+	if m != nil {
+		return m.LastRun
+	}
+	return 0
+}
+
+func (m *ScheduleDetails) GetLastJobSetID() uint64 {
+	if m != nil {
+		return m.LastJobSetID
+	}
+	return 0
+}
+
+func (m *ScheduleDetails) GetQueued() bool {
+	if m != nil {
+		return m.Queued
+	}
+	return false
+}
+
+func (m *ScheduleDetails) GetActiveJobSetIDs() []uint64 {
+	if m != nil {
+		return m.ActiveJobSetIDs
+	}
+	return nil
+}
+
+// GetAllSchedulesResp returns information on all schedules.
+type GetAllSchedulesResp struct {
+	Schedules            []*ScheduleDetails `protobuf:"bytes,1,rep,name=schedules,proto3" json:"schedules,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetAllSchedulesResp) Reset()         { *m = GetAllSchedulesResp{} }
+func (m *GetAllSchedulesResp) String() string { return proto.CompactTextString(m) }
+func (*GetAllSchedulesResp) ProtoMessage()    {}
+func (*GetAllSchedulesResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{69}
+}
+
+func (m *GetAllSchedulesResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAllSchedulesResp.Unmarshal(m, b)
+}
+func (m *GetAllSchedulesResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAllSchedulesResp.Marshal(b, m, deterministic)
+}
+func (m *GetAllSchedulesResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAllSchedulesResp.Merge(m, src)
+}
+func (m *GetAllSchedulesResp) XXX_Size() int {
+	return xxx_messageInfo_GetAllSchedulesResp.Size(m)
+}
+func (m *GetAllSchedulesResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAllSchedulesResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAllSchedulesResp proto.InternalMessageInfo
+
+func (m *GetAllSchedulesResp) GetSchedules() []*ScheduleDetails {
+	if m != nil {
+		return m.Schedules
+	}
+	return nil
+}
+
+// PauseScheduleReq requests that a schedule be paused or resumed.
+type PauseScheduleReq struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// true to pause the schedule, false to resume it
+	Paused               bool     `protobuf:"varint,2,opt,name=paused,proto3" json:"paused,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PauseScheduleReq) Reset()         { *m = PauseScheduleReq{} }
+func (m *PauseScheduleReq) String() string { return proto.CompactTextString(m) }
+func (*PauseScheduleReq) ProtoMessage()    {}
+func (*PauseScheduleReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{70}
+}
+
+func (m *PauseScheduleReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PauseScheduleReq.Unmarshal(m, b)
+}
+func (m *PauseScheduleReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PauseScheduleReq.Marshal(b, m, deterministic)
+}
+func (m *PauseScheduleReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PauseScheduleReq.Merge(m, src)
+}
+func (m *PauseScheduleReq) XXX_Size() int {
+	return xxx_messageInfo_PauseScheduleReq.Size(m)
+}
+func (m *PauseScheduleReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PauseScheduleReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PauseScheduleReq proto.InternalMessageInfo
+
+func (m *PauseScheduleReq) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PauseScheduleReq) GetPaused() bool {
+	if m != nil {
+		return m.Paused
+	}
+	return false
+}
+
+// PauseScheduleResp tells whether the schedule was paused or resumed.
+type PauseScheduleResp struct {
+	// was the schedule successfully paused or resumed?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PauseScheduleResp) Reset()         { *m = PauseScheduleResp{} }
+func (m *PauseScheduleResp) String() string { return proto.CompactTextString(m) }
+func (*PauseScheduleResp) ProtoMessage()    {}
+func (*PauseScheduleResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{71}
+}
+
+func (m *PauseScheduleResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PauseScheduleResp.Unmarshal(m, b)
+}
+func (m *PauseScheduleResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PauseScheduleResp.Marshal(b, m, deterministic)
+}
+func (m *PauseScheduleResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PauseScheduleResp.Merge(m, src)
+}
+func (m *PauseScheduleResp) XXX_Size() int {
+	return xxx_messageInfo_PauseScheduleResp.Size(m)
+}
+func (m *PauseScheduleResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_PauseScheduleResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PauseScheduleResp proto.InternalMessageInfo
+
+func (m *PauseScheduleResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *PauseScheduleResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+// DeleteScheduleReq requests that a schedule be removed.
+type DeleteScheduleReq struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteScheduleReq) Reset()         { *m = DeleteScheduleReq{} }
+func (m *DeleteScheduleReq) String() string { return proto.CompactTextString(m) }
+func (*DeleteScheduleReq) ProtoMessage()    {}
+func (*DeleteScheduleReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{72}
+}
+
+func (m *DeleteScheduleReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteScheduleReq.Unmarshal(m, b)
+}
+func (m *DeleteScheduleReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteScheduleReq.Marshal(b, m, deterministic)
+}
+func (m *DeleteScheduleReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteScheduleReq.Merge(m, src)
+}
+func (m *DeleteScheduleReq) XXX_Size() int {
+	return xxx_messageInfo_DeleteScheduleReq.Size(m)
+}
+func (m *DeleteScheduleReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteScheduleReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteScheduleReq proto.InternalMessageInfo
+
+func (m *DeleteScheduleReq) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// DeleteScheduleResp tells whether the schedule was removed.
+type DeleteScheduleResp struct {
+	// was the schedule successfully removed?
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// any error messages; should only be set if success == false
+	ErrorMsg             string   `protobuf:"bytes,2,opt,name=errorMsg,proto3" json:"errorMsg,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteScheduleResp) Reset()         { *m = DeleteScheduleResp{} }
+func (m *DeleteScheduleResp) String() string { return proto.CompactTextString(m) }
+func (*DeleteScheduleResp) ProtoMessage()    {}
+func (*DeleteScheduleResp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d329ddaa36318286, []int{73}
+}
+
+func (m *DeleteScheduleResp) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteScheduleResp.Unmarshal(m, b)
+}
+func (m *DeleteScheduleResp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteScheduleResp.Marshal(b, m, deterministic)
+}
+func (m *DeleteScheduleResp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteScheduleResp.Merge(m, src)
+}
+func (m *DeleteScheduleResp) XXX_Size() int {
+	return xxx_messageInfo_DeleteScheduleResp.Size(m)
+}
+func (m *DeleteScheduleResp) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteScheduleResp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteScheduleResp proto.InternalMessageInfo
+
+func (m *DeleteScheduleResp) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *DeleteScheduleResp) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("controller.PoolStrategy", PoolStrategy_name, PoolStrategy_value)
+	proto.RegisterEnum("controller.StepOutcome", StepOutcome_name, StepOutcome_value)
+	proto.RegisterEnum("controller.OverlapPolicy", OverlapPolicy_name, OverlapPolicy_value)
+	proto.RegisterType((*StartReq)(nil), "controller.StartReq")
+	proto.RegisterType((*StartResp)(nil), "controller.StartResp")
+	proto.RegisterType((*GetStatusReq)(nil), "controller.GetStatusReq")
+	proto.RegisterType((*GetStatusResp)(nil), "controller.GetStatusResp")
+	proto.RegisterType((*StopReq)(nil), "controller.StopReq")
+	proto.RegisterType((*StopResp)(nil), "controller.StopResp")
+	proto.RegisterType((*AgentConfig)(nil), "controller.AgentConfig")
+	proto.RegisterType((*AgentConfig_AgentKV)(nil), "controller.AgentConfig.AgentKV")
+	proto.RegisterType((*AddAgentReq)(nil), "controller.AddAgentReq")
+	proto.RegisterType((*AddAgentResp)(nil), "controller.AddAgentResp")
+	proto.RegisterType((*UpdateAgentReq)(nil), "controller.UpdateAgentReq")
+	proto.RegisterType((*UpdateAgentResp)(nil), "controller.UpdateAgentResp")
+	proto.RegisterType((*RemoveAgentReq)(nil), "controller.RemoveAgentReq")
+	proto.RegisterType((*RemoveAgentResp)(nil), "controller.RemoveAgentResp")
+	proto.RegisterType((*GetAgentReq)(nil), "controller.GetAgentReq")
+	proto.RegisterType((*GetAgentResp)(nil), "controller.GetAgentResp")
+	proto.RegisterType((*GetAllAgentsReq)(nil), "controller.GetAllAgentsReq")
+	proto.RegisterType((*GetAllAgentsResp)(nil), "controller.GetAllAgentsResp")
+	proto.RegisterType((*StepAgentTemplate)(nil), "controller.StepAgentTemplate")
+	proto.RegisterType((*RetryPolicy)(nil), "controller.RetryPolicy")
+	proto.RegisterType((*StepJobSetTemplate)(nil), "controller.StepJobSetTemplate")
+	proto.RegisterType((*StepConcurrentTemplate)(nil), "controller.StepConcurrentTemplate")
+	proto.RegisterType((*StepForEachTemplate)(nil), "controller.StepForEachTemplate")
+	proto.RegisterType((*StepTemplate)(nil), "controller.StepTemplate")
+	proto.RegisterType((*StepCondition)(nil), "controller.StepCondition")
+	proto.RegisterType((*JobSetTemplate)(nil), "controller.JobSetTemplate")
+	proto.RegisterType((*AddJobSetTemplateReq)(nil), "controller.AddJobSetTemplateReq")
+	proto.RegisterType((*AddJobSetTemplateResp)(nil), "controller.AddJobSetTemplateResp")
+	proto.RegisterType((*GetJobSetTemplateReq)(nil), "controller.GetJobSetTemplateReq")
+	proto.RegisterType((*GetJobSetTemplateResp)(nil), "controller.GetJobSetTemplateResp")
+	proto.RegisterType((*GetAllJobSetTemplatesReq)(nil), "controller.GetAllJobSetTemplatesReq")
+	proto.RegisterType((*GetAllJobSetTemplatesResp)(nil), "controller.GetAllJobSetTemplatesResp")
+	proto.RegisterType((*GetJobReq)(nil), "controller.GetJobReq")
+	proto.RegisterType((*JobDetails)(nil), "controller.JobDetails")
+	proto.RegisterType((*GetJobResp)(nil), "controller.GetJobResp")
+	proto.RegisterType((*GetAllJobsForJobSetReq)(nil), "controller.GetAllJobsForJobSetReq")
 	proto.RegisterType((*GetAllJobsForJobSetResp)(nil), "controller.GetAllJobsForJobSetResp")
 	proto.RegisterType((*GetAllJobsReq)(nil), "controller.GetAllJobsReq")
 	proto.RegisterType((*GetAllJobsResp)(nil), "controller.GetAllJobsResp")
+	proto.RegisterType((*CancelJobReq)(nil), "controller.CancelJobReq")
+	proto.RegisterType((*CancelJobResp)(nil), "controller.CancelJobResp")
 	proto.RegisterType((*JobSetConfig)(nil), "controller.JobSetConfig")
 	proto.RegisterType((*StartJobSetReq)(nil), "controller.StartJobSetReq")
 	proto.RegisterType((*StartJobSetResp)(nil), "controller.StartJobSetResp")
@@ -2340,111 +4497,223 @@ func init() {
 	proto.RegisterType((*StepAgent)(nil), "controller.StepAgent")
 	proto.RegisterType((*StepJobSet)(nil), "controller.StepJobSet")
 	proto.RegisterType((*StepConcurrent)(nil), "controller.StepConcurrent")
+	proto.RegisterType((*StepForEach)(nil), "controller.StepForEach")
 	proto.RegisterType((*Step)(nil), "controller.Step")
 	proto.RegisterType((*JobSetStatusReport)(nil), "controller.JobSetStatusReport")
 	proto.RegisterType((*JobSetDetails)(nil), "controller.JobSetDetails")
 	proto.RegisterType((*GetJobSetResp)(nil), "controller.GetJobSetResp")
 	proto.RegisterType((*GetAllJobSetsReq)(nil), "controller.GetAllJobSetsReq")
 	proto.RegisterType((*GetAllJobSetsResp)(nil), "controller.GetAllJobSetsResp")
+	proto.RegisterType((*CancelJobSetReq)(nil), "controller.CancelJobSetReq")
+	proto.RegisterType((*CancelJobSetResp)(nil), "controller.CancelJobSetResp")
+	proto.RegisterType((*SetJobSetPriorityReq)(nil), "controller.SetJobSetPriorityReq")
+	proto.RegisterType((*SetJobSetPriorityResp)(nil), "controller.SetJobSetPriorityResp")
+	proto.RegisterType((*TenantConfig)(nil), "controller.TenantConfig")
+	proto.RegisterType((*SetTenantReq)(nil), "controller.SetTenantReq")
+	proto.RegisterType((*SetTenantResp)(nil), "controller.SetTenantResp")
+	proto.RegisterType((*GetAllTenantsReq)(nil), "controller.GetAllTenantsReq")
+	proto.RegisterType((*TenantDetails)(nil), "controller.TenantDetails")
+	proto.RegisterType((*GetAllTenantsResp)(nil), "controller.GetAllTenantsResp")
+	proto.RegisterType((*ScheduleConfig)(nil), "controller.ScheduleConfig")
+	proto.RegisterType((*AddScheduleReq)(nil), "controller.AddScheduleReq")
+	proto.RegisterType((*AddScheduleResp)(nil), "controller.AddScheduleResp")
+	proto.RegisterType((*GetAllSchedulesReq)(nil), "controller.GetAllSchedulesReq")
+	proto.RegisterType((*ScheduleDetails)(nil), "controller.ScheduleDetails")
+	proto.RegisterType((*GetAllSchedulesResp)(nil), "controller.GetAllSchedulesResp")
+	proto.RegisterType((*PauseScheduleReq)(nil), "controller.PauseScheduleReq")
+	proto.RegisterType((*PauseScheduleResp)(nil), "controller.PauseScheduleResp")
+	proto.RegisterType((*DeleteScheduleReq)(nil), "controller.DeleteScheduleReq")
+	proto.RegisterType((*DeleteScheduleResp)(nil), "controller.DeleteScheduleResp")
 }
 
 func init() { proto.RegisterFile("pkg/controller/controller.proto", fileDescriptor_d329ddaa36318286) }
 
 var fileDescriptor_d329ddaa36318286 = []byte{
-	// 1486 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcd, 0x72, 0x13, 0xc7,
-	0x13, 0xb7, 0xb4, 0xfa, 0xb0, 0x5a, 0xb2, 0x6c, 0x0f, 0xb6, 0x91, 0x17, 0xf8, 0x63, 0x16, 0xfe,
-	0xc4, 0x21, 0x20, 0xc7, 0x26, 0xa1, 0x48, 0x15, 0x55, 0x14, 0x58, 0x80, 0x30, 0x09, 0xa9, 0x5a,
-	0x53, 0xa9, 0x14, 0xa7, 0xe8, 0x63, 0x2c, 0x4b, 0x96, 0xb5, 0xcb, 0xce, 0x08, 0x42, 0xe5, 0x0d,
-	0xf2, 0x22, 0x39, 0xe4, 0x29, 0x92, 0x63, 0x1e, 0x26, 0x97, 0xbc, 0x40, 0x6a, 0x7a, 0x66, 0xb5,
-	0x33, 0xbb, 0xab, 0x95, 0xb9, 0xe4, 0x62, 0xef, 0xf4, 0xfc, 0xba, 0xa7, 0xbf, 0xa6, 0xbb, 0x47,
-	0x70, 0xdd, 0x3f, 0x1b, 0xec, 0xf5, 0xbc, 0x09, 0x0f, 0xbc, 0xf1, 0x98, 0x06, 0xda, 0x67, 0xd3,
-	0x0f, 0x3c, 0xee, 0x11, 0x88, 0x28, 0xf6, 0x65, 0x01, 0x66, 0xbc, 0xc3, 0xa7, 0x4c, 0xfd, 0x93,
-	0x20, 0x7b, 0x53, 0x6c, 0x74, 0x06, 0x74, 0xc2, 0xe5, 0x5f, 0x49, 0x76, 0x00, 0x96, 0x8f, 0x79,
-	0x27, 0xe0, 0x2e, 0x7d, 0xe7, 0x1c, 0x42, 0x45, 0x7d, 0x33, 0x9f, 0xd8, 0xb0, 0xcc, 0xc4, 0x62,
-	0x38, 0x19, 0x34, 0x72, 0x3b, 0xb9, 0xdd, 0x65, 0x77, 0xb6, 0x16, 0x7b, 0x34, 0x08, 0xbc, 0xe0,
-	0x3b, 0x36, 0x68, 0xe4, 0x77, 0x72, 0xbb, 0x15, 0x77, 0xb6, 0x76, 0xea, 0x50, 0x7b, 0x41, 0xf9,
-	0x31, 0x1e, 0x2d, 0x84, 0xfe, 0x9e, 0x83, 0x15, 0x8d, 0xc0, 0x7c, 0x72, 0x17, 0x2a, 0xc1, 0x74,
-	0x22, 0x09, 0x28, 0xba, 0x7e, 0x50, 0x6f, 0x2a, 0x5d, 0x15, 0x2c, 0x02, 0x90, 0x03, 0xa8, 0x9d,
-	0xd2, 0xce, 0x98, 0x9f, 0x2a, 0x86, 0xbc, 0xc9, 0xd0, 0xc6, 0x3d, 0xd7, 0xc0, 0x90, 0xab, 0x50,
-	0xf1, 0xa6, 0xdc, 0x9f, 0x72, 0xa1, 0xa0, 0x85, 0x0a, 0x46, 0x04, 0x43, 0xfb, 0x42, 0x4c, 0xfb,
-	0x0a, 0x94, 0x8f, 0xb9, 0xe7, 0x0b, 0xc5, 0xd1, 0x33, 0xe2, 0x93, 0xf9, 0xce, 0x1f, 0x39, 0xa8,
-	0x3e, 0x11, 0x5e, 0x3b, 0xf4, 0x26, 0x27, 0xc3, 0x01, 0x21, 0x50, 0x98, 0x74, 0xce, 0x29, 0x6a,
-	0x5f, 0x71, 0xf1, 0x9b, 0xac, 0x81, 0x35, 0x0d, 0xc6, 0xca, 0x1f, 0xe2, 0x53, 0xa0, 0x7c, 0x2f,
-	0xe0, 0xa8, 0xc1, 0x8a, 0x8b, 0xdf, 0x82, 0xc6, 0x3f, 0xfa, 0x54, 0x1d, 0x8c, 0xdf, 0x64, 0x1f,
-	0xac, 0xb3, 0xf7, 0xac, 0x51, 0xdc, 0xb1, 0x76, 0xab, 0x07, 0xd7, 0x9b, 0x5a, 0x7c, 0xb5, 0x33,
-	0xe5, 0xf7, 0xab, 0x1f, 0x5c, 0x81, 0xb5, 0xf7, 0xa1, 0xac, 0xd6, 0xe2, 0xdc, 0x33, 0xfa, 0x51,
-	0xa9, 0x22, 0x3e, 0xc9, 0x06, 0x14, 0xdf, 0x77, 0xc6, 0x53, 0xaa, 0x74, 0x91, 0x0b, 0xe7, 0x21,
-	0x54, 0x9f, 0xf4, 0xfb, 0xc8, 0xe5, 0xd2, 0x77, 0xe4, 0x73, 0xb0, 0x7a, 0x27, 0x32, 0xb4, 0xd5,
-	0x83, 0xcb, 0x73, 0x0e, 0x75, 0x05, 0xc6, 0x69, 0x41, 0x2d, 0xe2, 0x64, 0x3e, 0x69, 0x40, 0x99,
-	0x4d, 0x7b, 0x3d, 0xca, 0x98, 0xca, 0x8c, 0x70, 0x99, 0x99, 0x18, 0x37, 0xa0, 0xfa, 0x82, 0xf2,
-	0xd9, 0xf9, 0x29, 0x2e, 0x74, 0x3c, 0xcc, 0x9d, 0x8b, 0x1c, 0xa4, 0xb4, 0xcf, 0x2f, 0xd6, 0xde,
-	0xd0, 0xc9, 0x8a, 0xe9, 0xb4, 0x0e, 0xab, 0xe2, 0xc0, 0xf1, 0x18, 0xb9, 0x30, 0x5f, 0x1f, 0xc3,
-	0x9a, 0x49, 0x62, 0x3e, 0xf9, 0x02, 0x0a, 0xbd, 0x93, 0x81, 0x50, 0xc2, 0xca, 0x3a, 0x0e, 0x41,
-	0xce, 0x67, 0xb0, 0x7e, 0xcc, 0xa9, 0x8f, 0x1b, 0x6f, 0xe8, 0xb9, 0x3f, 0xee, 0x70, 0x9a, 0x6a,
-	0xed, 0x2e, 0x10, 0x01, 0x3c, 0xf2, 0xba, 0xc7, 0x34, 0x1b, 0xd9, 0x86, 0x2d, 0x81, 0x3c, 0xf4,
-	0x26, 0xbd, 0x69, 0x10, 0xe8, 0x72, 0x9b, 0x50, 0x64, 0x9c, 0xfa, 0xa1, 0x6a, 0x0d, 0x5d, 0x35,
-	0xc1, 0x12, 0x02, 0x5d, 0x09, 0x73, 0xfe, 0xca, 0x41, 0x4d, 0xa7, 0x93, 0xaf, 0xa1, 0x88, 0xe5,
-	0x40, 0x25, 0xc2, 0xb5, 0xb8, 0x00, 0xc3, 0x8c, 0xf6, 0x92, 0x2b, 0xd1, 0xe4, 0x21, 0x94, 0x46,
-	0x5e, 0x97, 0x51, 0xae, 0x42, 0xf0, 0xbf, 0x38, 0x9f, 0x69, 0x55, 0x7b, 0xc9, 0x55, 0x78, 0xd2,
-	0x02, 0x51, 0xae, 0x94, 0x1d, 0x18, 0x90, 0xea, 0x81, 0x13, 0xe7, 0x4e, 0x5a, 0xda, 0x5e, 0x72,
-	0x35, 0xbe, 0xa7, 0x16, 0xe4, 0x98, 0xf3, 0x06, 0xea, 0x8b, 0x9d, 0x17, 0xb9, 0x28, 0x7f, 0x31,
-	0x17, 0xb5, 0x60, 0xe3, 0x49, 0xbf, 0x6f, 0x0a, 0x16, 0x09, 0x7b, 0x17, 0xac, 0x11, 0x0b, 0xfd,
-	0x64, 0xeb, 0x52, 0x62, 0x58, 0x01, 0x73, 0xce, 0x60, 0x33, 0x45, 0x4a, 0x66, 0x4e, 0x1b, 0x55,
-	0x2b, 0x9f, 0x55, 0xb5, 0xe2, 0x69, 0x7c, 0x07, 0x36, 0x5e, 0x50, 0x9e, 0x54, 0x39, 0x2d, 0x97,
-	0x7e, 0x81, 0xcd, 0x14, 0x6c, 0xa6, 0x62, 0xca, 0xf2, 0xfc, 0x85, 0x2c, 0xcf, 0x54, 0xd4, 0x86,
-	0x86, 0xbc, 0x5c, 0x26, 0x23, 0x5e, 0xbc, 0x57, 0xb0, 0x3d, 0x67, 0x8f, 0xf9, 0xa4, 0x09, 0x85,
-	0x11, 0xe3, 0x61, 0x9a, 0x67, 0xe9, 0x80, 0x38, 0xe7, 0x06, 0x54, 0xa4, 0x95, 0xc2, 0x0d, 0x1b,
-	0x50, 0x1c, 0x79, 0xdd, 0x97, 0x2d, 0xb4, 0xab, 0xe0, 0xca, 0x85, 0xf3, 0x4f, 0x0e, 0xe0, 0xc8,
-	0xeb, 0xb6, 0x28, 0xef, 0x0c, 0xc7, 0x2c, 0x1d, 0x24, 0x8c, 0x19, 0xa1, 0xfc, 0x97, 0x2d, 0xb4,
-	0xbf, 0xe0, 0xce, 0xd6, 0xc4, 0x81, 0x9a, 0xfc, 0x16, 0x59, 0xf4, 0xb2, 0x85, 0xc6, 0x16, 0x5c,
-	0x83, 0x46, 0x76, 0x61, 0x35, 0x5a, 0x7f, 0x1f, 0xf4, 0x69, 0x80, 0x95, 0xbf, 0xe0, 0xc6, 0xc9,
-	0x22, 0xfa, 0x78, 0xb5, 0x5e, 0x8b, 0x80, 0x15, 0x65, 0xf4, 0x67, 0x04, 0xe2, 0xc8, 0x7a, 0x57,
-	0xc2, 0x10, 0xac, 0x35, 0x65, 0x07, 0x3f, 0xf2, 0xba, 0x7a, 0xa1, 0xbb, 0x09, 0x79, 0xc6, 0x1b,
-	0x65, 0x84, 0x5c, 0x52, 0x90, 0xb0, 0xed, 0x8a, 0xde, 0xe3, 0xe6, 0x19, 0x77, 0xc6, 0x00, 0xa1,
-	0x63, 0x32, 0x63, 0xbe, 0x0b, 0xd6, 0xc8, 0xeb, 0xaa, 0x98, 0x6f, 0xc5, 0xfc, 0xad, 0x7c, 0xe6,
-	0x0a, 0x48, 0x66, 0xbc, 0xbf, 0x82, 0xad, 0x59, 0x4c, 0xd9, 0x73, 0x2f, 0x90, 0xb1, 0x12, 0x31,
-	0xd1, 0x1d, 0x9b, 0x33, 0x1d, 0xeb, 0x3c, 0x83, 0xcb, 0xa9, 0x5c, 0xcc, 0x27, 0x77, 0xa0, 0x20,
-	0xea, 0x88, 0xca, 0x83, 0x79, 0x7a, 0x21, 0xc6, 0x59, 0xc5, 0xc1, 0x43, 0x89, 0x11, 0x19, 0xf6,
-	0x08, 0xea, 0x3a, 0xe1, 0x13, 0xc5, 0x3d, 0x80, 0x9a, 0x54, 0x44, 0xcd, 0x00, 0x17, 0xed, 0xbb,
-	0x3f, 0x42, 0x1d, 0xa7, 0xaa, 0xc8, 0xf6, 0x06, 0x94, 0x47, 0x4c, 0x06, 0x5a, 0x72, 0x87, 0x4b,
-	0x72, 0x57, 0x35, 0x9a, 0x94, 0x52, 0xa5, 0x9f, 0xad, 0x3a, 0x4d, 0x0f, 0x56, 0x0d, 0xc9, 0x8b,
-	0x5a, 0xf3, 0xdc, 0x4c, 0xce, 0xae, 0x2d, 0xb5, 0x59, 0xbd, 0x58, 0x14, 0xb8, 0xc7, 0x62, 0x80,
-	0x54, 0x3d, 0xc3, 0x4c, 0xe8, 0x5c, 0x3c, 0xa1, 0x67, 0xd7, 0x2d, 0xaf, 0xdf, 0xc9, 0x6f, 0x01,
-	0xa2, 0xe6, 0x21, 0x2e, 0x18, 0x57, 0xd7, 0x5a, 0x13, 0x62, 0xd0, 0xb2, 0xcc, 0x72, 0x1e, 0x0a,
-	0xcf, 0xeb, 0xcd, 0x84, 0xdc, 0x36, 0xdb, 0xe5, 0x5a, 0xbc, 0x17, 0x84, 0x3d, 0xe0, 0xcf, 0x3c,
-	0x14, 0xc4, 0x9a, 0xdc, 0x33, 0xdb, 0xe3, 0x66, 0x6a, 0x7b, 0x8c, 0xda, 0xe2, 0x97, 0xb1, 0xb6,
-	0xb8, 0x95, 0xde, 0x16, 0xb5, 0x76, 0xf8, 0x28, 0xa5, 0x1d, 0xda, 0xf3, 0xdb, 0xa1, 0xd9, 0x06,
-	0xc9, 0x16, 0x94, 0x98, 0x2c, 0x3e, 0xb2, 0xaa, 0xa8, 0x95, 0xf0, 0x3d, 0x9b, 0x15, 0x9c, 0x22,
-	0x6e, 0x45, 0x04, 0x73, 0x00, 0x2f, 0x7d, 0xea, 0x00, 0x5e, 0x5e, 0x3c, 0x80, 0xcb, 0xf6, 0xfc,
-	0x6b, 0x1e, 0xc8, 0x91, 0xaa, 0x72, 0x51, 0x15, 0xfa, 0x0f, 0xc6, 0xff, 0x1d, 0xa8, 0xf2, 0xe1,
-	0x39, 0xc5, 0xbb, 0x41, 0xfb, 0xe8, 0x54, 0xcb, 0xd5, 0x49, 0x98, 0x59, 0xc3, 0x73, 0xfa, 0x7c,
-	0x38, 0x19, 0xb2, 0x53, 0xda, 0x47, 0xef, 0x59, 0xae, 0x41, 0x23, 0xb7, 0xa1, 0xae, 0xba, 0x2f,
-	0x65, 0xac, 0x33, 0xa0, 0x4c, 0x55, 0xe5, 0x18, 0x95, 0xdc, 0x82, 0x15, 0x79, 0x59, 0x42, 0x58,
-	0x09, 0x61, 0x26, 0xd1, 0xf9, 0x2d, 0x07, 0x2b, 0xd2, 0x19, 0x61, 0xc3, 0xc9, 0xb8, 0x48, 0x89,
-	0xcc, 0xcf, 0xa7, 0x64, 0x7e, 0x13, 0xcb, 0xbd, 0x95, 0x1c, 0xbf, 0x92, 0x3e, 0x17, 0x95, 0x3f,
-	0xca, 0xfd, 0x42, 0x76, 0xee, 0xff, 0x8c, 0x65, 0xf3, 0x42, 0x35, 0x65, 0x1f, 0xd3, 0xfd, 0x78,
-	0x96, 0xee, 0xdb, 0x49, 0x35, 0xc2, 0x1a, 0xaa, 0x80, 0x99, 0xa5, 0x86, 0x84, 0xa3, 0xb7, 0x64,
-	0xc5, 0x9a, 0xdd, 0x86, 0xf5, 0x18, 0x8d, 0xf9, 0xe4, 0x3e, 0x94, 0xa5, 0xb8, 0xf0, 0x22, 0x67,
-	0x1c, 0x1c, 0x22, 0x0f, 0xfe, 0x5e, 0x06, 0x38, 0x9c, 0xa1, 0xc8, 0x03, 0x28, 0x62, 0x36, 0x90,
-	0x0d, 0xd3, 0x11, 0xf2, 0x2d, 0x6c, 0x6f, 0xa6, 0x50, 0x99, 0xef, 0x2c, 0x91, 0xa7, 0x38, 0x59,
-	0xa8, 0x4c, 0x33, 0x2a, 0xb4, 0xfe, 0xec, 0xb5, 0xb7, 0xe7, 0xec, 0xa0, 0x8c, 0xfb, 0xa2, 0xba,
-	0x78, 0x3e, 0xb9, 0x64, 0x1e, 0x82, 0xef, 0x4e, 0x7b, 0x23, 0x49, 0x44, 0xa6, 0xc7, 0xb0, 0x1c,
-	0xbe, 0xc2, 0x88, 0xf9, 0x04, 0x89, 0x5e, 0x75, 0x76, 0x23, 0x7d, 0x23, 0x14, 0x10, 0xbe, 0xae,
-	0x4c, 0x01, 0xda, 0xb3, 0xcc, 0x6e, 0xa4, 0x6f, 0xa0, 0x80, 0x57, 0xf2, 0x79, 0x16, 0x3e, 0x8d,
-	0xc8, 0x95, 0x38, 0x56, 0x7b, 0x47, 0xd9, 0x57, 0xe7, 0x6f, 0xa2, 0xb0, 0xb7, 0xb0, 0x9e, 0x18,
-	0x90, 0xc9, 0x4e, 0x4c, 0xfd, 0xc4, 0x48, 0x6b, 0xdf, 0x58, 0x80, 0x08, 0x65, 0x27, 0x66, 0x5c,
-	0x53, 0x76, 0xda, 0xb8, 0x6c, 0xca, 0x4e, 0x1d, 0x92, 0x9d, 0x25, 0x72, 0x82, 0xf3, 0x73, 0x72,
-	0x4c, 0x25, 0xb7, 0x92, 0x06, 0x27, 0xa7, 0x5c, 0xfb, 0xff, 0x17, 0x40, 0xe1, 0x39, 0xdf, 0x40,
-	0x49, 0xaa, 0x40, 0x36, 0x93, 0x6a, 0x09, 0x49, 0x5b, 0x69, 0x64, 0x64, 0xfd, 0x09, 0x2e, 0xa5,
-	0xcc, 0x4f, 0xc4, 0x49, 0x3d, 0xda, 0x18, 0xcb, 0xec, 0x9b, 0x0b, 0x31, 0x78, 0xc2, 0x33, 0x9c,
-	0x22, 0xd5, 0x26, 0xd9, 0x4e, 0x67, 0x12, 0xf2, 0xec, 0x79, 0x5b, 0x28, 0xa6, 0x0d, 0x55, 0x6d,
-	0x80, 0x21, 0x76, 0xe2, 0xce, 0x45, 0x8a, 0x5d, 0x99, 0xbb, 0xa7, 0xdd, 0x4a, 0x25, 0xa7, 0x91,
-	0x1a, 0xc7, 0xb4, 0x5b, 0x69, 0xc8, 0x78, 0xad, 0xcd, 0x8b, 0xa2, 0x62, 0x90, 0xab, 0xf3, 0x62,
-	0x85, 0xa6, 0x5d, 0xcb, 0xd8, 0x15, 0xf2, 0x9e, 0xee, 0xbf, 0xdd, 0x1b, 0x0c, 0xf9, 0xe9, 0xb4,
-	0xdb, 0xec, 0x79, 0xe7, 0x7b, 0xec, 0xc3, 0x70, 0xc2, 0xc6, 0xde, 0x87, 0x3d, 0x9f, 0x06, 0xc3,
-	0xbe, 0xc7, 0xef, 0xf5, 0xbc, 0x80, 0xee, 0x99, 0x3f, 0xed, 0x75, 0x4b, 0xf8, 0xa3, 0xdc, 0xfd,
-	0x7f, 0x03, 0x00, 0x00, 0xff, 0xff, 0xa8, 0x18, 0x6e, 0x7e, 0xf3, 0x13, 0x00, 0x00,
+	// 2932 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x5a, 0x4b, 0x53, 0x24, 0xc7,
+	0x11, 0x66, 0x1e, 0xc0, 0x90, 0xf3, 0x60, 0xa8, 0x05, 0x34, 0xf4, 0xee, 0x4a, 0x6c, 0x4b, 0x96,
+	0x31, 0x96, 0x58, 0x2d, 0x2b, 0xcb, 0x7a, 0x85, 0x64, 0x16, 0x66, 0x61, 0x1f, 0xda, 0x41, 0x3d,
+	0xac, 0x1c, 0xa1, 0x8b, 0xdc, 0xf4, 0x14, 0xd0, 0xd0, 0x74, 0xb7, 0xba, 0x6b, 0x76, 0x17, 0xfb,
+	0xe8, 0xab, 0xcf, 0xfe, 0x07, 0x8e, 0x70, 0x84, 0x8f, 0xfe, 0x05, 0x3e, 0xd8, 0xff, 0xc2, 0x3f,
+	0xc0, 0x47, 0x87, 0x23, 0xec, 0x83, 0x2f, 0x8e, 0x7a, 0x75, 0x57, 0x75, 0xf7, 0x34, 0x2c, 0x61,
+	0xfb, 0x02, 0x5d, 0x99, 0x59, 0x55, 0x99, 0x59, 0x99, 0x59, 0x5f, 0x55, 0x0d, 0xbc, 0x15, 0x9e,
+	0x1d, 0xdf, 0x75, 0x02, 0x9f, 0x44, 0x81, 0xe7, 0xe1, 0x48, 0xf9, 0xdc, 0x08, 0xa3, 0x80, 0x04,
+	0x08, 0x52, 0x8a, 0xf1, 0x06, 0x15, 0x8e, 0x89, 0x4d, 0xc6, 0xb1, 0xf8, 0xc7, 0x85, 0x8c, 0x25,
+	0xca, 0xb0, 0x8f, 0xb1, 0x4f, 0xf8, 0x5f, 0x4e, 0x36, 0x01, 0x1a, 0x43, 0x62, 0x47, 0xc4, 0xc2,
+	0xdf, 0x9b, 0xdb, 0x30, 0x27, 0xbe, 0xe3, 0x10, 0x19, 0xd0, 0x88, 0x69, 0xc3, 0xf5, 0x8f, 0x7b,
+	0x95, 0xd5, 0xca, 0x5a, 0xc3, 0x4a, 0xda, 0x94, 0x87, 0xa3, 0x28, 0x88, 0xbe, 0x8a, 0x8f, 0x7b,
+	0xd5, 0xd5, 0xca, 0xda, 0x9c, 0x95, 0xb4, 0xcd, 0x0e, 0xb4, 0x76, 0x31, 0x19, 0xb2, 0xa9, 0xe9,
+	0xa0, 0x7f, 0xa8, 0x40, 0x5b, 0x21, 0xc4, 0x21, 0x7a, 0x0f, 0xe6, 0xa2, 0xb1, 0xcf, 0x09, 0x6c,
+	0xe8, 0xce, 0x66, 0x67, 0x43, 0xe8, 0x2a, 0xc4, 0x52, 0x01, 0xb4, 0x09, 0xad, 0x13, 0x6c, 0x7b,
+	0xe4, 0x44, 0x74, 0xa8, 0xea, 0x1d, 0xf6, 0x18, 0xcf, 0xd2, 0x64, 0xd0, 0x2d, 0x98, 0x0b, 0xc6,
+	0x24, 0x1c, 0x13, 0xaa, 0x60, 0x8d, 0x29, 0x98, 0x12, 0x34, 0xed, 0xeb, 0x19, 0xed, 0xbf, 0x86,
+	0xd9, 0x21, 0x09, 0x42, 0x0b, 0x7f, 0x8f, 0x16, 0x61, 0x7a, 0x14, 0xd9, 0xae, 0x2f, 0xac, 0xe7,
+	0x0d, 0xf4, 0x01, 0xdc, 0x60, 0x1f, 0x07, 0xee, 0x39, 0x0e, 0xc6, 0x64, 0x88, 0x9d, 0xc0, 0x1f,
+	0x71, 0xad, 0x6a, 0x56, 0x11, 0xcb, 0xf4, 0xa0, 0xc1, 0x87, 0x64, 0xa6, 0x2f, 0xb8, 0x3e, 0xc1,
+	0x51, 0x34, 0x0e, 0x09, 0x1e, 0x3d, 0x0e, 0x0e, 0x1f, 0xed, 0x50, 0x17, 0xd4, 0xd6, 0xea, 0x56,
+	0x9e, 0x81, 0x36, 0x61, 0x51, 0x27, 0x0e, 0x31, 0xa1, 0x1d, 0xaa, 0xac, 0x43, 0x21, 0xcf, 0xfc,
+	0x53, 0x15, 0x9a, 0x5b, 0x74, 0x7d, 0xb7, 0x03, 0xff, 0xc8, 0x3d, 0x46, 0x08, 0xea, 0xbe, 0x7d,
+	0x8e, 0x99, 0x11, 0x73, 0x16, 0xfb, 0x46, 0x5d, 0xa8, 0x8d, 0x23, 0x4f, 0xac, 0x1c, 0xfd, 0xa4,
+	0x52, 0x61, 0x10, 0x11, 0xe6, 0xab, 0xb6, 0xc5, 0xbe, 0x29, 0x8d, 0x5c, 0x84, 0x58, 0xb8, 0x88,
+	0x7d, 0xa3, 0x7b, 0x50, 0x3b, 0x7b, 0x11, 0xf7, 0xa6, 0x57, 0x6b, 0x6b, 0xcd, 0xcd, 0xb7, 0x36,
+	0x94, 0x48, 0x54, 0xe6, 0xe4, 0xdf, 0x4f, 0xbe, 0xb1, 0xa8, 0x2c, 0x35, 0xf9, 0xdc, 0x7e, 0xb5,
+	0x1d, 0xf8, 0xce, 0x38, 0x8a, 0xb0, 0x4f, 0x1e, 0x07, 0x87, 0x71, 0x6f, 0x86, 0xcd, 0x93, 0x67,
+	0xa0, 0x75, 0xe8, 0x9e, 0x06, 0x87, 0xc2, 0x83, 0x5f, 0xb9, 0x9e, 0xe7, 0xc6, 0xbd, 0x59, 0xe6,
+	0xdb, 0x1c, 0x1d, 0xbd, 0x03, 0xed, 0x08, 0xc7, 0xc1, 0x38, 0x72, 0xf0, 0xb6, 0x67, 0xc7, 0x71,
+	0xaf, 0xc1, 0x34, 0xd5, 0x89, 0xc6, 0x3d, 0x98, 0x15, 0xfa, 0x50, 0xbb, 0xcf, 0xf0, 0x85, 0x70,
+	0x05, 0xfd, 0xa4, 0x6b, 0xfc, 0xc2, 0xf6, 0xc6, 0x58, 0xf8, 0x82, 0x37, 0xcc, 0x8f, 0xa1, 0xb9,
+	0x35, 0x1a, 0xb1, 0x5e, 0x34, 0x10, 0x7e, 0x04, 0x35, 0xe7, 0x88, 0x27, 0x41, 0x73, 0xf3, 0x8d,
+	0x09, 0x46, 0x5b, 0x54, 0xc6, 0xdc, 0x81, 0x56, 0xda, 0x33, 0x0e, 0x51, 0x0f, 0x66, 0xe3, 0xb1,
+	0xe3, 0xe0, 0x38, 0x16, 0x51, 0x24, 0x9b, 0xa5, 0x29, 0xf4, 0x19, 0x74, 0x9e, 0x87, 0x23, 0x9b,
+	0xe0, 0xeb, 0xa8, 0xb0, 0x0b, 0xf3, 0x5a, 0xe7, 0x6b, 0x6b, 0xf1, 0x29, 0x74, 0x2c, 0x7c, 0x1e,
+	0xbc, 0x48, 0xb5, 0x28, 0x8a, 0xa5, 0x45, 0x98, 0x3e, 0x0a, 0x22, 0x87, 0x7b, 0xb0, 0x61, 0xf1,
+	0x06, 0x55, 0x42, 0xeb, 0x7b, 0x6d, 0x25, 0xee, 0x40, 0x73, 0x17, 0x93, 0x32, 0x0d, 0xcc, 0x00,
+	0x5a, 0xa9, 0x48, 0xe9, 0x44, 0xc2, 0x8b, 0xd5, 0xcb, 0xbd, 0xa8, 0xe9, 0x54, 0xcb, 0xe8, 0xb4,
+	0x00, 0xf3, 0x74, 0x42, 0xcf, 0x63, 0xbd, 0x58, 0x91, 0xfb, 0x12, 0xba, 0x3a, 0x29, 0x0e, 0xd1,
+	0x8f, 0xa1, 0xee, 0x1c, 0x1d, 0xf3, 0xf4, 0x2e, 0x99, 0x8e, 0x09, 0x99, 0xff, 0xac, 0xc0, 0xc2,
+	0x90, 0xe0, 0x90, 0x71, 0x0e, 0xf0, 0x79, 0xe8, 0xd9, 0x04, 0x17, 0x3a, 0xfc, 0x16, 0xcc, 0xb1,
+	0xfa, 0x7d, 0x40, 0x73, 0x53, 0xd4, 0xb6, 0x84, 0x80, 0x3e, 0xa4, 0x55, 0x3b, 0xb2, 0x09, 0x3e,
+	0xbe, 0x60, 0x89, 0xdb, 0xd9, 0xec, 0xa9, 0x13, 0xef, 0x07, 0x81, 0x37, 0x14, 0x7c, 0x2b, 0x91,
+	0x44, 0xef, 0xc3, 0x74, 0x84, 0x49, 0x74, 0x51, 0xe4, 0x1a, 0x8b, 0x32, 0xf6, 0x03, 0xcf, 0x75,
+	0x2e, 0x2c, 0x2e, 0x45, 0x13, 0x8f, 0x68, 0x19, 0x3a, 0xcd, 0x32, 0x54, 0x27, 0xe6, 0xd3, 0x73,
+	0xa6, 0x20, 0x3d, 0xcd, 0xbf, 0x56, 0xa0, 0xa9, 0x4c, 0x81, 0x56, 0xa1, 0x79, 0x6e, 0xbf, 0xda,
+	0x22, 0x04, 0x9f, 0x87, 0x84, 0xaf, 0x60, 0xdb, 0x52, 0x49, 0x74, 0xdc, 0x43, 0xdb, 0x39, 0x0b,
+	0x8e, 0x8e, 0xc4, 0xec, 0xbc, 0xf6, 0xea, 0x44, 0xf4, 0x21, 0x2c, 0x31, 0x65, 0xb7, 0x03, 0xdf,
+	0xc7, 0x0e, 0x71, 0x03, 0xbf, 0x4f, 0xd7, 0x2f, 0x66, 0x2e, 0x6b, 0x58, 0xc5, 0x4c, 0x5a, 0x7e,
+	0x18, 0x83, 0x2d, 0x83, 0xe8, 0x50, 0x67, 0x1d, 0x72, 0x74, 0x6e, 0x1f, 0x89, 0x2e, 0x44, 0x51,
+	0xe2, 0x5e, 0x68, 0x58, 0x3a, 0xd1, 0x5c, 0x03, 0x44, 0xd7, 0x95, 0x17, 0xe8, 0xb2, 0x85, 0x35,
+	0xf7, 0x60, 0x99, 0x4a, 0xa6, 0x05, 0x31, 0x91, 0xde, 0x80, 0xe9, 0x98, 0xe0, 0x50, 0x86, 0x92,
+	0xb6, 0xa2, 0xb4, 0x8b, 0x14, 0xb4, 0xb8, 0x98, 0xf9, 0xeb, 0x0a, 0xdc, 0xa0, 0xf4, 0x87, 0x41,
+	0xd4, 0xb7, 0x9d, 0x93, 0xcb, 0xc2, 0xc9, 0x61, 0x81, 0xf8, 0x04, 0x5f, 0x88, 0xec, 0x4b, 0x09,
+	0x34, 0x97, 0x5c, 0x82, 0xcf, 0x29, 0x8f, 0x87, 0x9a, 0x6c, 0x8a, 0x75, 0xda, 0xb7, 0x23, 0xdb,
+	0xf3, 0xb0, 0xd7, 0xab, 0x27, 0xeb, 0x24, 0x49, 0xe6, 0x6f, 0x6b, 0xd0, 0x52, 0xb5, 0x43, 0x3f,
+	0x81, 0x69, 0x16, 0xa8, 0xa2, 0x8c, 0xdd, 0xce, 0x9a, 0xa1, 0xc5, 0xfe, 0xde, 0x94, 0xc5, 0xa5,
+	0xd1, 0xc7, 0x30, 0x73, 0x1a, 0x1c, 0xc6, 0x98, 0x88, 0xe8, 0x7c, 0x33, 0xdb, 0x4f, 0xf7, 0xed,
+	0xde, 0x94, 0x25, 0xe4, 0xd1, 0x0e, 0x80, 0x93, 0x78, 0x93, 0x19, 0xd0, 0xdc, 0x34, 0xb3, 0xbd,
+	0xf3, 0xfe, 0xde, 0x9b, 0xb2, 0x94, 0x7e, 0xe8, 0x33, 0x98, 0x3d, 0x0a, 0x22, 0x6c, 0x3b, 0x27,
+	0x6c, 0x27, 0xca, 0xec, 0x7b, 0x05, 0x7e, 0xde, 0x9b, 0xb2, 0x64, 0x0f, 0xf4, 0x53, 0xe6, 0xde,
+	0x91, 0x4b, 0x63, 0x8c, 0x39, 0xa9, 0xb9, 0xb9, 0x52, 0xa0, 0x01, 0x17, 0xb0, 0x52, 0xd9, 0x64,
+	0xad, 0xa6, 0x95, 0xb5, 0x32, 0xa1, 0x65, 0x7b, 0x5e, 0xf0, 0xf2, 0xa1, 0xed, 0x7a, 0xe3, 0x08,
+	0xb3, 0x84, 0x6a, 0x58, 0x1a, 0x8d, 0xae, 0xe7, 0x08, 0x87, 0xd8, 0x1f, 0xc5, 0x03, 0xbf, 0xd7,
+	0x58, 0xad, 0xd1, 0xf5, 0x4c, 0x08, 0x0f, 0x6a, 0x50, 0x89, 0xcd, 0xdf, 0x57, 0xa0, 0xad, 0xcd,
+	0xab, 0x07, 0x41, 0x25, 0x1b, 0x04, 0xab, 0xd0, 0xe4, 0x8d, 0x6f, 0x94, 0xad, 0x52, 0x25, 0x71,
+	0xac, 0x88, 0xc3, 0x67, 0x54, 0x61, 0x51, 0x2d, 0x65, 0x1b, 0x7d, 0x06, 0x2d, 0xfa, 0x3d, 0x18,
+	0x13, 0x27, 0x38, 0xc7, 0x34, 0x9d, 0x6a, 0x6b, 0x1d, 0xbd, 0xc4, 0x0c, 0x53, 0xbe, 0xa5, 0x09,
+	0x9b, 0x07, 0xd0, 0xb9, 0x3c, 0x73, 0xd2, 0xfc, 0xa8, 0x5e, 0x2d, 0x3f, 0x76, 0x60, 0x71, 0x6b,
+	0x34, 0xd2, 0x07, 0xa6, 0xbb, 0xcb, 0x7b, 0x50, 0x3b, 0x8d, 0x65, 0x78, 0x1a, 0xea, 0x28, 0x19,
+	0x59, 0x2a, 0x66, 0x9e, 0xc1, 0x52, 0xc1, 0x28, 0xa5, 0x1b, 0x90, 0x86, 0x4b, 0xab, 0x65, 0xb8,
+	0x34, 0xbb, 0xe7, 0xac, 0xc3, 0xe2, 0x2e, 0x26, 0x79, 0x95, 0x8b, 0x0a, 0xc9, 0xaf, 0x60, 0xa9,
+	0x40, 0xb6, 0x54, 0x31, 0x61, 0x79, 0xf5, 0x4a, 0x96, 0x97, 0x2a, 0x6a, 0x40, 0x8f, 0xef, 0x84,
+	0x7a, 0x47, 0xb6, 0x4b, 0x3e, 0x81, 0x95, 0x09, 0xbc, 0x38, 0x44, 0x1b, 0x50, 0x3f, 0x8d, 0x89,
+	0xac, 0x71, 0x65, 0x3a, 0x30, 0x39, 0xf3, 0x0e, 0xcc, 0x71, 0x2b, 0x05, 0x56, 0x3f, 0xa5, 0x98,
+	0x99, 0xd9, 0x55, 0xb7, 0x78, 0xc3, 0xfc, 0x77, 0x0d, 0xe0, 0x71, 0x70, 0xb8, 0x83, 0x89, 0xed,
+	0x7a, 0x71, 0xb1, 0x10, 0x35, 0xe6, 0x54, 0xa0, 0x67, 0x66, 0x7f, 0xdd, 0x4a, 0xda, 0x34, 0xe1,
+	0xf8, 0x37, 0x8d, 0xa2, 0x47, 0x3b, 0xcc, 0xd8, 0xba, 0xa5, 0xd1, 0xd0, 0x1a, 0xcc, 0xa7, 0xed,
+	0x41, 0x34, 0xc2, 0x11, 0xcb, 0xf3, 0xba, 0x95, 0x25, 0x27, 0x3b, 0xf7, 0xb3, 0x34, 0xaf, 0x53,
+	0x02, 0x32, 0x39, 0x38, 0x99, 0x61, 0x4b, 0xd0, 0xdd, 0x60, 0x0c, 0x6a, 0xb9, 0x8a, 0x4a, 0xde,
+	0x86, 0x6a, 0x4c, 0x44, 0x15, 0xba, 0x21, 0x44, 0xe4, 0xc1, 0x8a, 0x62, 0x76, 0xab, 0x1a, 0x13,
+	0x96, 0xcc, 0xb6, 0xef, 0x60, 0xcf, 0xc3, 0x23, 0x06, 0x89, 0x1b, 0x56, 0x4a, 0xa0, 0xc9, 0x4c,
+	0x93, 0x60, 0x78, 0xe6, 0x86, 0x21, 0x1e, 0xf5, 0xe6, 0x18, 0x5f, 0x25, 0xd1, 0x28, 0xb1, 0xf9,
+	0x5e, 0xdb, 0x03, 0x56, 0xd5, 0x65, 0x93, 0x9a, 0xea, 0xe8, 0x3b, 0x66, 0xaf, 0xc9, 0xfa, 0x67,
+	0xc9, 0x79, 0x84, 0xd0, 0x2a, 0x42, 0x08, 0x06, 0x34, 0x28, 0x61, 0x34, 0x18, 0x93, 0x5e, 0x9b,
+	0x1f, 0x31, 0x65, 0x9b, 0xf2, 0x3c, 0x3b, 0x26, 0x43, 0x8c, 0xfd, 0x5e, 0x87, 0x75, 0x4e, 0xda,
+	0x79, 0x64, 0x31, 0x5f, 0x84, 0x2c, 0x3c, 0x00, 0x19, 0x20, 0xa5, 0xb1, 0xbf, 0x06, 0xb5, 0xd3,
+	0xe0, 0x50, 0xc4, 0xfe, 0x72, 0x26, 0xee, 0x44, 0xec, 0x58, 0x54, 0xa4, 0x34, 0xee, 0x3f, 0x84,
+	0xe5, 0x24, 0xb6, 0xe3, 0x87, 0x41, 0xc4, 0x63, 0x96, 0xc6, 0xa6, 0x1a, 0x60, 0x15, 0x3d, 0xc0,
+	0xcc, 0x3e, 0xbc, 0x51, 0xd8, 0x2b, 0x0e, 0xd1, 0x3a, 0xd4, 0xe9, 0x36, 0x26, 0xf2, 0x61, 0x92,
+	0x5e, 0x4c, 0xc6, 0x9c, 0x87, 0x76, 0x3a, 0x0c, 0xcd, 0xb4, 0xcf, 0xa1, 0xa3, 0x12, 0x5e, 0x73,
+	0xb8, 0x9f, 0x41, 0x6b, 0x9b, 0x05, 0x4c, 0x59, 0x76, 0xb1, 0xa2, 0x7f, 0xe6, 0x86, 0x34, 0xbe,
+	0x05, 0xf8, 0x4f, 0xda, 0x66, 0x1f, 0xda, 0xca, 0x08, 0xd7, 0x46, 0xff, 0x1f, 0x41, 0x8b, 0x7b,
+	0x44, 0x1c, 0x66, 0xaf, 0x7a, 0x80, 0xfb, 0x4d, 0x05, 0x3a, 0xec, 0x26, 0x23, 0x5d, 0x85, 0x1e,
+	0xcc, 0x9e, 0xc6, 0x3c, 0xf5, 0x78, 0x77, 0xd9, 0x44, 0xef, 0x09, 0x9c, 0x5e, 0xb0, 0x79, 0xa8,
+	0x93, 0x73, 0xa0, 0x4e, 0xd5, 0x0d, 0x23, 0x37, 0x88, 0x5c, 0xc2, 0x21, 0xd1, 0xb4, 0x95, 0xb4,
+	0xd1, 0x32, 0xcc, 0x10, 0xec, 0xdb, 0x3e, 0x11, 0x67, 0x66, 0xd1, 0x32, 0x1d, 0x98, 0xd7, 0xb4,
+	0xb9, 0xcc, 0x1f, 0x13, 0xeb, 0x51, 0xf9, 0x0e, 0xd1, 0xda, 0xc5, 0x8a, 0xc1, 0x65, 0x61, 0xf7,
+	0xe7, 0x0a, 0xcc, 0x25, 0x88, 0x4b, 0xaf, 0x4b, 0x95, 0x6c, 0x5d, 0x4a, 0x16, 0xbf, 0x9a, 0x59,
+	0x7c, 0x5b, 0x62, 0x74, 0x7e, 0x69, 0x90, 0xb4, 0xf5, 0x13, 0x4a, 0x3d, 0x7b, 0x42, 0xf9, 0x6f,
+	0x1e, 0x1e, 0x7e, 0x09, 0x90, 0x02, 0x40, 0x5a, 0xad, 0x89, 0xd8, 0x23, 0x14, 0x53, 0x34, 0x5a,
+	0xa9, 0x77, 0x65, 0x20, 0xd4, 0xae, 0x12, 0x08, 0xe6, 0xc7, 0xd0, 0x11, 0x20, 0x4a, 0x02, 0xc5,
+	0x77, 0x75, 0x98, 0xde, 0xcd, 0xc2, 0x10, 0x09, 0x3f, 0xfe, 0x58, 0x81, 0xa6, 0x02, 0x1b, 0xaf,
+	0xa4, 0xf7, 0xff, 0x0c, 0xa6, 0xa7, 0x5a, 0x4f, 0x97, 0x6b, 0xfd, 0x8f, 0x1a, 0xd4, 0x69, 0x9b,
+	0x1e, 0x16, 0x55, 0x18, 0xbf, 0x54, 0x08, 0xe3, 0x53, 0xf8, 0xfe, 0x41, 0x06, 0xbe, 0x2f, 0x17,
+	0xc3, 0x77, 0x05, 0xb6, 0x7f, 0x5e, 0x00, 0xdb, 0x8d, 0xc9, 0xb0, 0x3d, 0x03, 0xd7, 0xef, 0xa7,
+	0x70, 0xbd, 0x99, 0x3f, 0xcd, 0x2a, 0x7e, 0x57, 0x61, 0xfa, 0x32, 0xcc, 0xc4, 0x7c, 0x8b, 0xe7,
+	0x7b, 0xb7, 0x68, 0x51, 0xb7, 0xc7, 0xc9, 0xb6, 0x3e, 0xcd, 0x58, 0x29, 0x41, 0xbf, 0xc8, 0x9c,
+	0x79, 0xdd, 0x8b, 0xcc, 0xd9, 0x2b, 0x5c, 0x64, 0xbe, 0x03, 0xed, 0x97, 0xb6, 0x4b, 0xef, 0x5c,
+	0x2d, 0x6c, 0xc7, 0x81, 0x2f, 0xaf, 0xb8, 0x34, 0x62, 0x02, 0x02, 0xe7, 0x4a, 0xce, 0x0a, 0x70,
+	0xd9, 0x59, 0xa1, 0xc5, 0x2e, 0x15, 0x73, 0x67, 0x85, 0x2a, 0xa0, 0xc7, 0x02, 0xc7, 0xa4, 0x38,
+	0xe3, 0xff, 0x70, 0x85, 0xbb, 0x0a, 0x4d, 0x5a, 0x11, 0x58, 0xdd, 0xc4, 0x23, 0x16, 0x05, 0x35,
+	0x4b, 0x25, 0xb1, 0xb4, 0x71, 0xcf, 0xf1, 0x43, 0xd7, 0x77, 0xe3, 0x13, 0x3c, 0x62, 0x2b, 0x57,
+	0xb3, 0x34, 0x1a, 0x7a, 0x17, 0x3a, 0x02, 0x5f, 0xe3, 0x38, 0xb6, 0x8f, 0x71, 0x2c, 0x70, 0x57,
+	0x86, 0x4a, 0xfd, 0xcc, 0x0b, 0xa9, 0x14, 0x13, 0xe5, 0x46, 0x23, 0xea, 0xc8, 0x6a, 0x36, 0x83,
+	0xac, 0xcc, 0x7f, 0x55, 0xa0, 0xcd, 0x5d, 0x25, 0x01, 0x67, 0x49, 0x09, 0xce, 0x25, 0x7d, 0xb5,
+	0x20, 0xe9, 0x37, 0x18, 0xdc, 0xab, 0xe5, 0x4f, 0xbd, 0xf9, 0x15, 0x61, 0xc8, 0x2f, 0x49, 0xe5,
+	0x7a, 0x69, 0x2a, 0x6b, 0x7b, 0xd8, 0xf4, 0xc4, 0x3d, 0x6c, 0x46, 0xdd, 0xc3, 0x68, 0x9f, 0xd8,
+	0x39, 0xc1, 0xa3, 0xb1, 0x87, 0x7b, 0xb3, 0xe2, 0x88, 0x27, 0xda, 0xe6, 0x2b, 0x06, 0x3f, 0xae,
+	0xb4, 0xbb, 0xdd, 0x63, 0xd5, 0x60, 0x98, 0x54, 0x83, 0x95, 0xbc, 0x59, 0x12, 0x8b, 0x08, 0xc1,
+	0xd2, 0x4d, 0x0f, 0xc9, 0x7b, 0x37, 0xde, 0x95, 0x61, 0x9f, 0x3d, 0x58, 0xc8, 0xd0, 0xe2, 0x90,
+	0x56, 0x05, 0x3e, 0x9c, 0xac, 0xce, 0x25, 0x13, 0x4b, 0x49, 0xf3, 0x7d, 0x98, 0x4f, 0x50, 0xcc,
+	0x15, 0x76, 0xd5, 0x3d, 0xe8, 0xea, 0xe2, 0xd7, 0xc6, 0x3d, 0xcf, 0x60, 0x71, 0x28, 0x1d, 0xba,
+	0x2f, 0x56, 0xe6, 0x92, 0xd9, 0xb5, 0x45, 0xad, 0xea, 0x8b, 0x6a, 0x7e, 0x05, 0x4b, 0x05, 0xe3,
+	0x5d, 0x5b, 0xbd, 0x03, 0x68, 0x1d, 0xb0, 0xa8, 0x28, 0x79, 0x63, 0x58, 0x86, 0x99, 0x97, 0xd8,
+	0x3d, 0x3e, 0xe1, 0x0b, 0xdd, 0xb6, 0x44, 0x8b, 0xce, 0x78, 0xee, 0xfa, 0xec, 0x11, 0x80, 0xe3,
+	0x06, 0xd9, 0x34, 0x3f, 0x85, 0x16, 0x3b, 0xe5, 0xd1, 0x81, 0xa9, 0xb1, 0xeb, 0xea, 0x9d, 0xb7,
+	0xb6, 0x1b, 0xab, 0x93, 0xf3, 0x4b, 0xef, 0x3e, 0xb4, 0x95, 0xbe, 0xd7, 0x36, 0x2c, 0x09, 0x27,
+	0x3e, 0x12, 0x0b, 0xa7, 0xdf, 0x55, 0xa0, 0xcd, 0x9b, 0x32, 0xad, 0x5f, 0x43, 0x31, 0x5a, 0xc6,
+	0xa2, 0xb1, 0xef, 0xbb, 0xfe, 0x31, 0x33, 0x99, 0xfb, 0x42, 0x25, 0x51, 0x89, 0xef, 0xc7, 0x78,
+	0x8c, 0x47, 0x43, 0x96, 0xba, 0xdc, 0x29, 0x2a, 0x89, 0x16, 0x27, 0xdb, 0x21, 0xee, 0x0b, 0x2c,
+	0x02, 0x5a, 0xec, 0xe2, 0x3a, 0x31, 0x0d, 0xfb, 0x44, 0x77, 0x1e, 0xf6, 0x3c, 0x7f, 0x0b, 0xc3,
+	0x5e, 0x33, 0xcb, 0x92, 0x92, 0xe6, 0xdf, 0x28, 0x7a, 0x16, 0xb9, 0x5d, 0xb2, 0xc2, 0x08, 0xea,
+	0x4e, 0x14, 0xf8, 0xc2, 0x89, 0xec, 0x5b, 0x45, 0xd9, 0xb5, 0x62, 0x94, 0x5d, 0x7f, 0x6d, 0x94,
+	0x7d, 0xd5, 0x0a, 0x75, 0x1f, 0x66, 0x83, 0x17, 0x38, 0xf2, 0xec, 0x50, 0x6c, 0xad, 0x9a, 0xad,
+	0x03, 0xce, 0x12, 0x17, 0xd9, 0x52, 0xd2, 0xfc, 0x02, 0x3a, 0x5b, 0xa3, 0x91, 0xb4, 0x56, 0x5c,
+	0x02, 0xa5, 0xab, 0xab, 0xc3, 0x0e, 0xcd, 0x27, 0xc9, 0x6b, 0x8b, 0xd6, 0xff, 0xda, 0xa1, 0xb7,
+	0x08, 0x88, 0x2f, 0x9f, 0x1c, 0x8b, 0x05, 0xdf, 0xdf, 0x2b, 0x30, 0x2f, 0x09, 0x32, 0xfc, 0x5e,
+	0x4b, 0x41, 0xea, 0xad, 0xd0, 0x1e, 0xc7, 0x78, 0x24, 0xce, 0x68, 0xa2, 0x45, 0xb5, 0xf4, 0xf1,
+	0x2b, 0x62, 0x8d, 0x7d, 0xb1, 0xb7, 0xca, 0x26, 0xe5, 0xd0, 0x93, 0x36, 0xe5, 0xf0, 0x2d, 0x55,
+	0x36, 0xe9, 0x9e, 0x45, 0x3f, 0xe5, 0x63, 0xa3, 0x00, 0x44, 0x1a, 0x8d, 0xce, 0xc7, 0x63, 0x57,
+	0xdc, 0x4e, 0x8a, 0x16, 0xbd, 0x3b, 0x50, 0xe3, 0x95, 0x3e, 0x63, 0xce, 0x32, 0xc4, 0x91, 0x25,
+	0x9b, 0xfb, 0x70, 0x23, 0xe7, 0x89, 0x38, 0x44, 0x9f, 0xc0, 0x9c, 0xdc, 0x70, 0x64, 0x30, 0xdf,
+	0x2c, 0x32, 0x5e, 0x86, 0x73, 0x2a, 0x6d, 0x7e, 0x01, 0xdd, 0x7d, 0x6a, 0xb5, 0xba, 0xcc, 0x13,
+	0x6a, 0x56, 0x91, 0xaf, 0xcc, 0x47, 0xb0, 0x90, 0xe9, 0x7f, 0xed, 0x65, 0xfe, 0x21, 0x2c, 0xec,
+	0x60, 0x0f, 0x93, 0xcb, 0x74, 0x31, 0x1f, 0x03, 0xca, 0x0a, 0x5e, 0x77, 0xd2, 0xf5, 0x7b, 0xd0,
+	0x52, 0x1f, 0x7e, 0x50, 0x17, 0x5a, 0x4f, 0xfb, 0x5b, 0xc3, 0x83, 0xef, 0x9e, 0x0e, 0xb6, 0x76,
+	0xfa, 0x3b, 0xdd, 0x29, 0x34, 0x0f, 0x4d, 0x6b, 0xf0, 0xfc, 0xd9, 0xce, 0x77, 0xd6, 0xe0, 0xc1,
+	0xa3, 0x67, 0xdd, 0xca, 0xfa, 0x80, 0x1f, 0x51, 0xc4, 0x45, 0x2c, 0x6a, 0xc2, 0xec, 0xf0, 0xa0,
+	0xbf, 0xff, 0xdd, 0xe0, 0x49, 0x77, 0x0a, 0x2d, 0x40, 0x9b, 0x35, 0x76, 0xfa, 0xbb, 0x16, 0xeb,
+	0x5f, 0x41, 0x1d, 0x00, 0x46, 0xea, 0x5b, 0xd6, 0xc0, 0xea, 0x56, 0xe9, 0x0c, 0xac, 0x3d, 0x7c,
+	0xf2, 0x68, 0x7f, 0xbf, 0xbf, 0xd3, 0xad, 0xad, 0xef, 0x42, 0x5b, 0x4b, 0x41, 0x2a, 0x32, 0xf8,
+	0xa6, 0x6f, 0x3d, 0xdd, 0xe2, 0x52, 0x7c, 0x5c, 0x49, 0xf9, 0xfa, 0x79, 0xff, 0x79, 0xbf, 0x5b,
+	0x51, 0x49, 0x5b, 0x4f, 0x9f, 0x0e, 0x7e, 0xde, 0xad, 0x6e, 0xfe, 0x65, 0x1e, 0x60, 0x3b, 0x59,
+	0x76, 0xf4, 0x11, 0x4c, 0x33, 0x40, 0x88, 0x16, 0x75, 0xb4, 0xc3, 0x7f, 0xd2, 0x60, 0x2c, 0x15,
+	0x50, 0xe3, 0xd0, 0x9c, 0x42, 0x0f, 0xd8, 0xf5, 0xa1, 0x00, 0x9b, 0x5a, 0x39, 0x52, 0x7f, 0xbd,
+	0x60, 0xac, 0x4c, 0xe0, 0xb0, 0x31, 0xee, 0xd3, 0x13, 0x51, 0x10, 0xa2, 0x1b, 0xfa, 0x24, 0xec,
+	0xe7, 0x03, 0xc6, 0x62, 0x9e, 0xc8, 0x3a, 0x7d, 0x09, 0x0d, 0xf9, 0x44, 0x8c, 0xf4, 0x47, 0xc1,
+	0xf4, 0xc9, 0xd9, 0xe8, 0x15, 0x33, 0xd8, 0x00, 0x7b, 0xd0, 0x54, 0x1e, 0x78, 0x91, 0x56, 0x01,
+	0xf4, 0x67, 0x63, 0xe3, 0xe6, 0x44, 0x9e, 0x1c, 0x49, 0x79, 0xa5, 0xd5, 0x47, 0xd2, 0x9f, 0x7e,
+	0x8d, 0x9b, 0x13, 0x79, 0xd2, 0x28, 0xf9, 0x06, 0xab, 0x1b, 0xa5, 0x3c, 0xde, 0x1a, 0xbd, 0x62,
+	0x06, 0x1b, 0xe0, 0x09, 0xb4, 0xd4, 0x07, 0x54, 0x74, 0x33, 0x2b, 0xab, 0xbc, 0xb6, 0x1a, 0xb7,
+	0x26, 0x33, 0xd9, 0x60, 0xdf, 0xc2, 0x42, 0xee, 0x66, 0x1e, 0xad, 0x66, 0x5c, 0x9a, 0xbb, 0x4b,
+	0x37, 0xee, 0x5c, 0x22, 0x21, 0xc7, 0xce, 0x5d, 0xae, 0xeb, 0x63, 0x17, 0xdd, 0xd3, 0x1b, 0x77,
+	0x2e, 0x91, 0x60, 0x63, 0x1f, 0xc1, 0x92, 0x8a, 0x5c, 0x25, 0x37, 0x46, 0xef, 0xe4, 0x0d, 0xce,
+	0x5f, 0xaf, 0x1b, 0x3f, 0xb8, 0x82, 0x14, 0x9b, 0xe7, 0x13, 0x98, 0xe1, 0x2a, 0xa0, 0xa5, 0xbc,
+	0x5a, 0x74, 0xa4, 0xe5, 0x22, 0x32, 0xeb, 0xfa, 0x0b, 0x59, 0x9c, 0xb5, 0x0b, 0x4b, 0x64, 0x16,
+	0x4e, 0xad, 0xdd, 0x83, 0x1a, 0x6f, 0x5f, 0x2a, 0xc3, 0x66, 0xe8, 0x03, 0xa4, 0x4c, 0xb4, 0x52,
+	0xdc, 0x89, 0x8e, 0x67, 0x4c, 0x62, 0xc9, 0xfc, 0x4e, 0xc0, 0xb8, 0x9e, 0xdf, 0xea, 0xd5, 0xa6,
+	0xb1, 0x32, 0x81, 0x23, 0xf3, 0x43, 0xb9, 0xb7, 0x43, 0x46, 0xae, 0x96, 0xa4, 0xc6, 0xdd, 0x9c,
+	0xc8, 0x53, 0xaa, 0x8d, 0x18, 0xa7, 0x57, 0x18, 0x0b, 0x45, 0xd5, 0x46, 0x1b, 0xe3, 0x99, 0x72,
+	0xc9, 0x4b, 0x11, 0x1f, 0xba, 0x35, 0x69, 0xbd, 0x99, 0x7b, 0x6e, 0x97, 0x70, 0x65, 0xca, 0xa9,
+	0xc7, 0x15, 0x3d, 0xe5, 0x32, 0xe7, 0x1e, 0xe3, 0xd6, 0x64, 0xa6, 0x4c, 0x8b, 0xdc, 0x09, 0x43,
+	0x4f, 0x8b, 0xa2, 0x03, 0x8d, 0x71, 0xe7, 0x12, 0x09, 0xe9, 0xbc, 0x04, 0xdc, 0xeb, 0xce, 0x53,
+	0xcf, 0x0b, 0xc6, 0xca, 0x04, 0x8e, 0xee, 0x3c, 0x4e, 0x2d, 0x74, 0x5e, 0x0a, 0xfa, 0x8d, 0xdb,
+	0x25, 0x5c, 0x19, 0x1a, 0x0a, 0xee, 0xd3, 0x43, 0x43, 0x07, 0x94, 0xc6, 0xcd, 0x89, 0x3c, 0x36,
+	0xd2, 0x81, 0xfc, 0x35, 0x89, 0xa4, 0xc7, 0xe8, 0xcd, 0xfc, 0xec, 0x2a, 0x2a, 0x34, 0xde, 0x2a,
+	0xe5, 0x4b, 0x7b, 0x35, 0xc8, 0xa2, 0xdb, 0x9b, 0x45, 0x43, 0xc6, 0xed, 0x12, 0x2e, 0x1b, 0xef,
+	0x6b, 0xe8, 0xe8, 0x70, 0x04, 0x69, 0x5d, 0x72, 0x98, 0xc6, 0x78, 0xb3, 0x8c, 0x4d, 0x87, 0x7c,
+	0x70, 0xef, 0xdb, 0xbb, 0xc7, 0x2e, 0x39, 0x19, 0x1f, 0x6e, 0x38, 0xc1, 0xf9, 0xdd, 0xf8, 0xa5,
+	0xeb, 0xc7, 0x5e, 0xf0, 0xf2, 0x6e, 0x88, 0x23, 0x77, 0x14, 0x90, 0xf7, 0x9d, 0x20, 0xc2, 0x77,
+	0xf5, 0x5f, 0x3e, 0x1e, 0xce, 0xb0, 0xdf, 0x2c, 0xde, 0xff, 0xcf, 0x00, 0x7f, 0x10, 0x6c, 0xaf,
+	0x12, 0x29, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -2460,18 +4729,30 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type ControllerClient interface {
 	// Start the Controller. Should only be called after all agents have
-	// been added via AddAgent.
+	// been added via AddAgent. Can also be called after Stop, to restart
+	// the Controller with its existing history.
 	Start(ctx context.Context, in *StartReq, opts ...grpc.CallOption) (*StartResp, error)
 	// Get Controller overall status.
 	GetStatus(ctx context.Context, in *GetStatusReq, opts ...grpc.CallOption) (*GetStatusResp, error)
-	// Shut down the Controller and all agents.
+	// Shut down the Controller and all agents. If requested, the
+	// Controller will first drain: it stops accepting new JobSets and
+	// lets active Jobs finish, up to the requested deadline.
 	Stop(ctx context.Context, in *StopReq, opts ...grpc.CallOption) (*StopResp, error)
 	// AddAgent configures the controller to know about a new
-	// Agent that is available for new Jobs and JobSets. It will
-	// return a failure message if called after the Controller has
-	// started (e.g., after Start has been called) or if an Agent
-	// already exists with the given Name.
+	// Agent that is available for new Jobs and JobSets. It can be
+	// called before or after the Controller has started. It will
+	// return a failure message if an Agent already exists with the
+	// given Name.
 	AddAgent(ctx context.Context, in *AddAgentReq, opts ...grpc.CallOption) (*AddAgentResp, error)
+	// UpdateAgent replaces the configuration of an existing Agent.
+	// The new configuration applies to new Jobs; Jobs that are already
+	// running on the Agent are not affected.
+	UpdateAgent(ctx context.Context, in *UpdateAgentReq, opts ...grpc.CallOption) (*UpdateAgentResp, error)
+	// RemoveAgent removes an existing Agent, so that no new Jobs will
+	// be started on it. It will return a failure message if the Agent
+	// has active Jobs, unless force is set. Steps that name the Agent
+	// and have not started yet will fail, and are not retried.
+	RemoveAgent(ctx context.Context, in *RemoveAgentReq, opts ...grpc.CallOption) (*RemoveAgentResp, error)
 	// GetAgent requests configuration information about the Agent with
 	// the given name.
 	GetAgent(ctx context.Context, in *GetAgentReq, opts ...grpc.CallOption) (*GetAgentResp, error)
@@ -2494,6 +4775,10 @@ type ControllerClient interface {
 	GetAllJobsForJobSet(ctx context.Context, in *GetAllJobsForJobSetReq, opts ...grpc.CallOption) (*GetAllJobsForJobSetResp, error)
 	// GetAllJobs requests information on all known Jobs.
 	GetAllJobs(ctx context.Context, in *GetAllJobsReq, opts ...grpc.CallOption) (*GetAllJobsResp, error)
+	// CancelJob requests that a single running Job be cancelled. The caller
+	// chooses whether the Job's step is then treated as failed, which stops
+	// its JobSet, or as skipped, so that later steps still proceed.
+	CancelJob(ctx context.Context, in *CancelJobReq, opts ...grpc.CallOption) (*CancelJobResp, error)
 	// StartJobSet requests that the Controller begin a new JobSet, with the
 	// specified configuration.
 	StartJobSet(ctx context.Context, in *StartJobSetReq, opts ...grpc.CallOption) (*StartJobSetResp, error)
@@ -2501,6 +4786,31 @@ type ControllerClient interface {
 	GetJobSet(ctx context.Context, in *GetJobSetReq, opts ...grpc.CallOption) (*GetJobSetResp, error)
 	// GetAllJobSets requests information on all known JobSets.
 	GetAllJobSets(ctx context.Context, in *GetAllJobSetsReq, opts ...grpc.CallOption) (*GetAllJobSetsResp, error)
+	// CancelJobSet requests that a running JobSet be cancelled, together
+	// with any sub-JobSets started from its steps. No further steps will
+	// be started, and any running Jobs will be asked to stop.
+	CancelJobSet(ctx context.Context, in *CancelJobSetReq, opts ...grpc.CallOption) (*CancelJobSetResp, error)
+	// SetJobSetPriority changes the priority of a JobSet that has not yet
+	// stopped, together with any sub-JobSets started from its steps. It
+	// affects which of their steps are started first from then on.
+	SetJobSetPriority(ctx context.Context, in *SetJobSetPriorityReq, opts ...grpc.CallOption) (*SetJobSetPriorityResp, error)
+	// SetTenant adds or replaces the configuration for a tenant, which
+	// determines its fair share of the running Job slots.
+	SetTenant(ctx context.Context, in *SetTenantReq, opts ...grpc.CallOption) (*SetTenantResp, error)
+	// GetAllTenants requests information on all configured tenants and on
+	// any other tenants with active JobSets, including their queue depths.
+	GetAllTenants(ctx context.Context, in *GetAllTenantsReq, opts ...grpc.CallOption) (*GetAllTenantsResp, error)
+	// AddSchedule adds a schedule, which starts a new JobSet from a
+	// JobSetTemplate each time its cron-style spec is due.
+	AddSchedule(ctx context.Context, in *AddScheduleReq, opts ...grpc.CallOption) (*AddScheduleResp, error)
+	// GetAllSchedules requests information on all schedules.
+	GetAllSchedules(ctx context.Context, in *GetAllSchedulesReq, opts ...grpc.CallOption) (*GetAllSchedulesResp, error)
+	// PauseSchedule pauses or resumes a schedule. A paused schedule
+	// doesn't start any JobSets until it is resumed.
+	PauseSchedule(ctx context.Context, in *PauseScheduleReq, opts ...grpc.CallOption) (*PauseScheduleResp, error)
+	// DeleteSchedule removes a schedule. JobSets that it has already
+	// started are not affected.
+	DeleteSchedule(ctx context.Context, in *DeleteScheduleReq, opts ...grpc.CallOption) (*DeleteScheduleResp, error)
 }
 
 type controllerClient struct {
@@ -2547,6 +4857,24 @@ func (c *controllerClient) AddAgent(ctx context.Context, in *AddAgentReq, opts .
 	return out, nil
 }
 
+func (c *controllerClient) UpdateAgent(ctx context.Context, in *UpdateAgentReq, opts ...grpc.CallOption) (*UpdateAgentResp, error) {
+	out := new(UpdateAgentResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/UpdateAgent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) RemoveAgent(ctx context.Context, in *RemoveAgentReq, opts ...grpc.CallOption) (*RemoveAgentResp, error) {
+	out := new(RemoveAgentResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/RemoveAgent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *controllerClient) GetAgent(ctx context.Context, in *GetAgentReq, opts ...grpc.CallOption) (*GetAgentResp, error) {
 	out := new(GetAgentResp)
 	err := c.cc.Invoke(ctx, "/controller.Controller/GetAgent", in, out, opts...)
@@ -2619,6 +4947,15 @@ func (c *controllerClient) GetAllJobs(ctx context.Context, in *GetAllJobsReq, op
 	return out, nil
 }
 
+func (c *controllerClient) CancelJob(ctx context.Context, in *CancelJobReq, opts ...grpc.CallOption) (*CancelJobResp, error) {
+	out := new(CancelJobResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/CancelJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *controllerClient) StartJobSet(ctx context.Context, in *StartJobSetReq, opts ...grpc.CallOption) (*StartJobSetResp, error) {
 	out := new(StartJobSetResp)
 	err := c.cc.Invoke(ctx, "/controller.Controller/StartJobSet", in, out, opts...)
@@ -2646,21 +4983,105 @@ func (c *controllerClient) GetAllJobSets(ctx context.Context, in *GetAllJobSetsR
 	return out, nil
 }
 
+func (c *controllerClient) CancelJobSet(ctx context.Context, in *CancelJobSetReq, opts ...grpc.CallOption) (*CancelJobSetResp, error) {
+	out := new(CancelJobSetResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/CancelJobSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) SetJobSetPriority(ctx context.Context, in *SetJobSetPriorityReq, opts ...grpc.CallOption) (*SetJobSetPriorityResp, error) {
+	out := new(SetJobSetPriorityResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/SetJobSetPriority", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) SetTenant(ctx context.Context, in *SetTenantReq, opts ...grpc.CallOption) (*SetTenantResp, error) {
+	out := new(SetTenantResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/SetTenant", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) GetAllTenants(ctx context.Context, in *GetAllTenantsReq, opts ...grpc.CallOption) (*GetAllTenantsResp, error) {
+	out := new(GetAllTenantsResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/GetAllTenants", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) AddSchedule(ctx context.Context, in *AddScheduleReq, opts ...grpc.CallOption) (*AddScheduleResp, error) {
+	out := new(AddScheduleResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/AddSchedule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) GetAllSchedules(ctx context.Context, in *GetAllSchedulesReq, opts ...grpc.CallOption) (*GetAllSchedulesResp, error) {
+	out := new(GetAllSchedulesResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/GetAllSchedules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) PauseSchedule(ctx context.Context, in *PauseScheduleReq, opts ...grpc.CallOption) (*PauseScheduleResp, error) {
+	out := new(PauseScheduleResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/PauseSchedule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) DeleteSchedule(ctx context.Context, in *DeleteScheduleReq, opts ...grpc.CallOption) (*DeleteScheduleResp, error) {
+	out := new(DeleteScheduleResp)
+	err := c.cc.Invoke(ctx, "/controller.Controller/DeleteSchedule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ControllerServer is the server API for Controller service.
 type ControllerServer interface {
 	// Start the Controller. Should only be called after all agents have
-	// been added via AddAgent.
+	// been added via AddAgent. Can also be called after Stop, to restart
+	// the Controller with its existing history.
 	Start(context.Context, *StartReq) (*StartResp, error)
 	// Get Controller overall status.
 	GetStatus(context.Context, *GetStatusReq) (*GetStatusResp, error)
-	// Shut down the Controller and all agents.
+	// Shut down the Controller and all agents. If requested, the
+	// Controller will first drain: it stops accepting new JobSets and
+	// lets active Jobs finish, up to the requested deadline.
 	Stop(context.Context, *StopReq) (*StopResp, error)
 	// AddAgent configures the controller to know about a new
-	// Agent that is available for new Jobs and JobSets. It will
-	// return a failure message if called after the Controller has
-	// started (e.g., after Start has been called) or if an Agent
-	// already exists with the given Name.
+	// Agent that is available for new Jobs and JobSets. It can be
+	// called before or after the Controller has started. It will
+	// return a failure message if an Agent already exists with the
+	// given Name.
 	AddAgent(context.Context, *AddAgentReq) (*AddAgentResp, error)
+	// UpdateAgent replaces the configuration of an existing Agent.
+	// The new configuration applies to new Jobs; Jobs that are already
+	// running on the Agent are not affected.
+	UpdateAgent(context.Context, *UpdateAgentReq) (*UpdateAgentResp, error)
+	// RemoveAgent removes an existing Agent, so that no new Jobs will
+	// be started on it. It will return a failure message if the Agent
+	// has active Jobs, unless force is set. Steps that name the Agent
+	// and have not started yet will fail, and are not retried.
+	RemoveAgent(context.Context, *RemoveAgentReq) (*RemoveAgentResp, error)
 	// GetAgent requests configuration information about the Agent with
 	// the given name.
 	GetAgent(context.Context, *GetAgentReq) (*GetAgentResp, error)
@@ -2683,6 +5104,10 @@ type ControllerServer interface {
 	GetAllJobsForJobSet(context.Context, *GetAllJobsForJobSetReq) (*GetAllJobsForJobSetResp, error)
 	// GetAllJobs requests information on all known Jobs.
 	GetAllJobs(context.Context, *GetAllJobsReq) (*GetAllJobsResp, error)
+	// CancelJob requests that a single running Job be cancelled. The caller
+	// chooses whether the Job's step is then treated as failed, which stops
+	// its JobSet, or as skipped, so that later steps still proceed.
+	CancelJob(context.Context, *CancelJobReq) (*CancelJobResp, error)
 	// StartJobSet requests that the Controller begin a new JobSet, with the
 	// specified configuration.
 	StartJobSet(context.Context, *StartJobSetReq) (*StartJobSetResp, error)
@@ -2690,6 +5115,31 @@ type ControllerServer interface {
 	GetJobSet(context.Context, *GetJobSetReq) (*GetJobSetResp, error)
 	// GetAllJobSets requests information on all known JobSets.
 	GetAllJobSets(context.Context, *GetAllJobSetsReq) (*GetAllJobSetsResp, error)
+	// CancelJobSet requests that a running JobSet be cancelled, together
+	// with any sub-JobSets started from its steps. No further steps will
+	// be started, and any running Jobs will be asked to stop.
+	CancelJobSet(context.Context, *CancelJobSetReq) (*CancelJobSetResp, error)
+	// SetJobSetPriority changes the priority of a JobSet that has not yet
+	// stopped, together with any sub-JobSets started from its steps. It
+	// affects which of their steps are started first from then on.
+	SetJobSetPriority(context.Context, *SetJobSetPriorityReq) (*SetJobSetPriorityResp, error)
+	// SetTenant adds or replaces the configuration for a tenant, which
+	// determines its fair share of the running Job slots.
+	SetTenant(context.Context, *SetTenantReq) (*SetTenantResp, error)
+	// GetAllTenants requests information on all configured tenants and on
+	// any other tenants with active JobSets, including their queue depths.
+	GetAllTenants(context.Context, *GetAllTenantsReq) (*GetAllTenantsResp, error)
+	// AddSchedule adds a schedule, which starts a new JobSet from a
+	// JobSetTemplate each time its cron-style spec is due.
+	AddSchedule(context.Context, *AddScheduleReq) (*AddScheduleResp, error)
+	// GetAllSchedules requests information on all schedules.
+	GetAllSchedules(context.Context, *GetAllSchedulesReq) (*GetAllSchedulesResp, error)
+	// PauseSchedule pauses or resumes a schedule. A paused schedule
+	// doesn't start any JobSets until it is resumed.
+	PauseSchedule(context.Context, *PauseScheduleReq) (*PauseScheduleResp, error)
+	// DeleteSchedule removes a schedule. JobSets that it has already
+	// started are not affected.
+	DeleteSchedule(context.Context, *DeleteScheduleReq) (*DeleteScheduleResp, error)
 }
 
 func RegisterControllerServer(s *grpc.Server, srv ControllerServer) {
@@ -2768,6 +5218,42 @@ func _Controller_AddAgent_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Controller_UpdateAgent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAgentReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).UpdateAgent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/UpdateAgent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).UpdateAgent(ctx, req.(*UpdateAgentReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_RemoveAgent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveAgentReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).RemoveAgent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/RemoveAgent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).RemoveAgent(ctx, req.(*RemoveAgentReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Controller_GetAgent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetAgentReq)
 	if err := dec(in); err != nil {
@@ -2912,6 +5398,24 @@ func _Controller_GetAllJobs_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Controller_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/CancelJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).CancelJob(ctx, req.(*CancelJobReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Controller_StartJobSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(StartJobSetReq)
 	if err := dec(in); err != nil {
@@ -2966,6 +5470,150 @@ func _Controller_GetAllJobSets_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Controller_CancelJobSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobSetReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).CancelJobSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/CancelJobSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).CancelJobSet(ctx, req.(*CancelJobSetReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_SetJobSetPriority_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetJobSetPriorityReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).SetJobSetPriority(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/SetJobSetPriority",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).SetJobSetPriority(ctx, req.(*SetJobSetPriorityReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_SetTenant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTenantReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).SetTenant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/SetTenant",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).SetTenant(ctx, req.(*SetTenantReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_GetAllTenants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAllTenantsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).GetAllTenants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/GetAllTenants",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).GetAllTenants(ctx, req.(*GetAllTenantsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_AddSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddScheduleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).AddSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/AddSchedule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).AddSchedule(ctx, req.(*AddScheduleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_GetAllSchedules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAllSchedulesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).GetAllSchedules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/GetAllSchedules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).GetAllSchedules(ctx, req.(*GetAllSchedulesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_PauseSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseScheduleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).PauseSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/PauseSchedule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).PauseSchedule(ctx, req.(*PauseScheduleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_DeleteSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteScheduleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).DeleteSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/controller.Controller/DeleteSchedule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).DeleteSchedule(ctx, req.(*DeleteScheduleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Controller_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "controller.Controller",
 	HandlerType: (*ControllerServer)(nil),
@@ -2986,6 +5634,14 @@ var _Controller_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AddAgent",
 			Handler:    _Controller_AddAgent_Handler,
 		},
+		{
+			MethodName: "UpdateAgent",
+			Handler:    _Controller_UpdateAgent_Handler,
+		},
+		{
+			MethodName: "RemoveAgent",
+			Handler:    _Controller_RemoveAgent_Handler,
+		},
 		{
 			MethodName: "GetAgent",
 			Handler:    _Controller_GetAgent_Handler,
@@ -3018,6 +5674,10 @@ var _Controller_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetAllJobs",
 			Handler:    _Controller_GetAllJobs_Handler,
 		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _Controller_CancelJob_Handler,
+		},
 		{
 			MethodName: "StartJobSet",
 			Handler:    _Controller_StartJobSet_Handler,
@@ -3030,6 +5690,38 @@ var _Controller_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetAllJobSets",
 			Handler:    _Controller_GetAllJobSets_Handler,
 		},
+		{
+			MethodName: "CancelJobSet",
+			Handler:    _Controller_CancelJobSet_Handler,
+		},
+		{
+			MethodName: "SetJobSetPriority",
+			Handler:    _Controller_SetJobSetPriority_Handler,
+		},
+		{
+			MethodName: "SetTenant",
+			Handler:    _Controller_SetTenant_Handler,
+		},
+		{
+			MethodName: "GetAllTenants",
+			Handler:    _Controller_GetAllTenants_Handler,
+		},
+		{
+			MethodName: "AddSchedule",
+			Handler:    _Controller_AddSchedule_Handler,
+		},
+		{
+			MethodName: "GetAllSchedules",
+			Handler:    _Controller_GetAllSchedules_Handler,
+		},
+		{
+			MethodName: "PauseSchedule",
+			Handler:    _Controller_PauseSchedule_Handler,
+		},
+		{
+			MethodName: "DeleteSchedule",
+			Handler:    _Controller_DeleteSchedule_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/controller/controller.proto",
@@ -32,6 +32,8 @@ const (
 	Status_RUNNING Status = 2
 	// no longer running. may have encountered problems and/or been cancelled.
 	Status_STOPPED Status = 3
+	// never run, because its condition wasn't met. only used for steps.
+	Status_SKIPPED Status = 4
 )
 
 var Status_name = map[int32]string{
@@ -39,6 +41,7 @@ var Status_name = map[int32]string{
 	1: "STARTUP",
 	2: "RUNNING",
 	3: "STOPPED",
+	4: "SKIPPED",
 }
 
 var Status_value = map[string]int32{
@@ -46,6 +49,7 @@ var Status_value = map[string]int32{
 	"STARTUP":     1,
 	"RUNNING":     2,
 	"STOPPED":     3,
+	"SKIPPED":     4,
 }
 
 func (x Status) String() string {
@@ -102,18 +106,18 @@ func init() {
 func init() { proto.RegisterFile("pkg/status/status.proto", fileDescriptor_cc39aad052ac7064) }
 
 var fileDescriptor_cc39aad052ac7064 = []byte{
-	// 201 bytes of a gzipped FileDescriptorProto
+	// 204 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x2f, 0xc8, 0x4e, 0xd7,
 	0x2f, 0x2e, 0x49, 0x2c, 0x29, 0x2d, 0x86, 0x52, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0x6c,
-	0x10, 0x9e, 0x96, 0x03, 0x17, 0x5b, 0x30, 0x98, 0x25, 0xc4, 0xcf, 0xc5, 0x1d, 0x1c, 0xe2, 0x18,
+	0x10, 0x9e, 0x96, 0x2f, 0x17, 0x5b, 0x30, 0x98, 0x25, 0xc4, 0xcf, 0xc5, 0x1d, 0x1c, 0xe2, 0x18,
 	0x12, 0x1a, 0x1c, 0x1f, 0xec, 0xe8, 0xeb, 0x2a, 0xc0, 0x20, 0xc4, 0xcd, 0xc5, 0x1e, 0x1c, 0xe2,
 	0x18, 0x14, 0x12, 0x1a, 0x20, 0xc0, 0x08, 0xe2, 0x04, 0x85, 0xfa, 0xf9, 0x79, 0xfa, 0xb9, 0x0b,
-	0x30, 0x41, 0x64, 0xfc, 0x03, 0x02, 0x5c, 0x5d, 0x04, 0x98, 0xb5, 0xac, 0xb8, 0xd8, 0x3c, 0x52,
-	0x13, 0x73, 0x4a, 0x32, 0x40, 0x26, 0x78, 0xb8, 0x3a, 0xfa, 0x84, 0x78, 0xc0, 0x4c, 0x60, 0xe3,
-	0x62, 0xf2, 0xf7, 0x16, 0x60, 0x14, 0xe2, 0xe1, 0xe2, 0x70, 0x71, 0x75, 0x0f, 0x72, 0x74, 0x71,
-	0x75, 0x11, 0x60, 0x12, 0xe2, 0xe4, 0x62, 0x75, 0x0d, 0x0a, 0xf2, 0x0f, 0x12, 0x60, 0x76, 0xd2,
-	0x8d, 0xd2, 0x4e, 0xcf, 0x2c, 0xc9, 0x28, 0x4d, 0xd2, 0x4b, 0xce, 0xcf, 0xd5, 0x2f, 0x2e, 0xcf,
-	0xcc, 0x2b, 0xce, 0xc9, 0x2f, 0xd7, 0x2f, 0x48, 0x2d, 0xca, 0x4c, 0xc9, 0x2f, 0xd1, 0x4d, 0xce,
-	0x2f, 0x4a, 0xd5, 0x47, 0xf8, 0x20, 0x89, 0x0d, 0xec, 0x76, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff,
-	0xff, 0x64, 0x84, 0xc5, 0x91, 0xd6, 0x00, 0x00, 0x00,
+	0x30, 0x41, 0x64, 0xfc, 0x03, 0x02, 0x5c, 0x5d, 0x04, 0x98, 0xc1, 0x1c, 0x6f, 0x4f, 0x30, 0x87,
+	0x45, 0xcb, 0x8a, 0x8b, 0xcd, 0x23, 0x35, 0x31, 0xa7, 0x24, 0x03, 0x64, 0x9c, 0x87, 0xab, 0xa3,
+	0x4f, 0x88, 0x07, 0xcc, 0x38, 0x36, 0x2e, 0x26, 0x7f, 0x6f, 0x01, 0x46, 0x21, 0x1e, 0x2e, 0x0e,
+	0x17, 0x57, 0xf7, 0x20, 0x47, 0x17, 0x57, 0x17, 0x01, 0x26, 0x21, 0x4e, 0x2e, 0x56, 0xd7, 0xa0,
+	0x20, 0xff, 0x20, 0x01, 0x66, 0x27, 0xdd, 0x28, 0xed, 0xf4, 0xcc, 0x92, 0x8c, 0xd2, 0x24, 0xbd,
+	0xe4, 0xfc, 0x5c, 0xfd, 0xe2, 0xf2, 0xcc, 0xbc, 0xe2, 0x9c, 0xfc, 0x72, 0xfd, 0x82, 0xd4, 0xa2,
+	0xcc, 0x94, 0xfc, 0x12, 0xdd, 0xe4, 0xfc, 0xa2, 0x54, 0x7d, 0x84, 0x77, 0x92, 0xd8, 0xc0, 0x1e,
+	0x31, 0x06, 0x0c, 0x00, 0x11, 0xc4, 0xb5, 0x5c, 0xe3, 0x00, 0x00, 0x00,
 }